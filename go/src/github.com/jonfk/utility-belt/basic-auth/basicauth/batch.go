@@ -0,0 +1,110 @@
+package basicauth
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Credential is a row of the --from-file CSV.
+type Credential struct {
+	Username string
+	Password string
+	// Machine is used by the netrc format, from an optional machine column
+	Machine string
+}
+
+// ReadCredentials reads a CSV of usernames and passwords. With a header row
+// the username, password and machine columns are found by name and the other
+// columns ignored, like the bcrypt column of pass-gen bulk. Without one the
+// first two columns are the username and password.
+func ReadCredentials(r io.Reader) ([]Credential, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	username, password, machine := 0, 1, -1
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "username") {
+		password = -1
+		for i, column := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "password":
+				password = i
+			case "machine":
+				machine = i
+			}
+		}
+		if password < 0 {
+			return nil, fmt.Errorf("The CSV header has no password column")
+		}
+		records = records[1:]
+	}
+
+	credentials := []Credential{}
+	for i, record := range records {
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(record) <= password || len(record) <= username {
+			return nil, fmt.Errorf("Row %d: expected a username and a password", i+1)
+		}
+		credential := Credential{Username: strings.TrimSpace(record[username]), Password: record[password]}
+		if machine >= 0 && machine < len(record) {
+			credential.Machine = strings.TrimSpace(record[machine])
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, nil
+}
+
+// batchAction prints the basic auth of every credential of the --from-file
+// CSV, verifying them all before reporting the refused ones.
+func batchAction(c *cli.Context) error {
+	in := os.Stdin
+	if filename := c.String("from-file"); filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	credentials, err := ReadCredentials(in)
+	if err != nil {
+		return err
+	}
+
+	refused := 0
+	for _, credential := range credentials {
+		machine := credential.Machine
+		if machine == "" {
+			machine = c.String("machine")
+		}
+		out, err := FormatBasicAuth(c.String("format"), credential.Username, credential.Password, machine)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+
+		if verifyURL := c.String("verify"); verifyURL != "" {
+			header := AuthorizationHeader("Basic " + basicAuth(credential.Username, credential.Password))
+			if err := Verify("GET", verifyURL, []Header{header}, nil); err != nil {
+				if _, ok := err.(*cli.ExitError); !ok {
+					return err
+				}
+				refused++
+			}
+		}
+	}
+	if refused > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d credentials were not accepted", refused, len(credentials)), 1)
+	}
+	return nil
+}
@@ -0,0 +1,626 @@
+package basicauth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/encode/encode"
+	"github.com/jonfk/utility-belt/internal/httpx"
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var formatFlag = cli.StringFlag{
+	Name:  "format,f",
+	Usage: "Output format: token, header, curl, curlrc or httpie",
+	Value: HeaderFormat,
+}
+
+var basicFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "format,f",
+		Usage: "Output format: token, header, curl, curlrc, httpie or netrc",
+		Value: HeaderFormat,
+	},
+	cli.StringFlag{
+		Name:  "machine,m",
+		Usage: "Machine name for the netrc format, defaults to the netrc default entry",
+		Value: "",
+	},
+	cli.StringFlag{
+		Name:  "from-file",
+		Usage: "Print the auth of every username,password row of the CSV `FILE`, - for stdin",
+	},
+	verifyFlag,
+}
+
+// Command is the basic-auth command tree, run standalone as basic-auth or as ub auth.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "auth",
+		Usage:     "Constructs authentication headers for API debugging",
+		ArgsUsage: "username password",
+		Before: func(c *cli.Context) error {
+			var err error
+			httpClient, err = httpx.FromContext(c)
+			return err
+		},
+		// Without a subcommand basic-auth keeps behaving like `basic-auth basic`
+		Action: basicAction,
+		Flags:  append(append([]cli.Flag{}, basicFlags...), httpx.Flags...),
+		Subcommands: []cli.Command{
+			{
+				Name:      "basic",
+				Usage:     "Basic auth header from a username and password",
+				ArgsUsage: "username password",
+				Flags:     basicFlags,
+				Action:    basicAction,
+			},
+			{
+				Name:      "bearer",
+				Usage:     "Bearer auth header wrapping a token",
+				ArgsUsage: "token",
+				Flags:     []cli.Flag{formatFlag, verifyFlag},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("bearer takes a token")
+					}
+					token := c.Args().First()
+					out, err := FormatBearer(c.String("format"), token)
+					if err != nil {
+						return err
+					}
+					fmt.Println(out)
+
+					if verifyURL := c.String("verify"); verifyURL != "" {
+						return Verify("GET", verifyURL, []Header{AuthorizationHeader("Bearer " + token)}, nil)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "digest",
+				Usage:     "Digest auth header answering a WWW-Authenticate challenge",
+				ArgsUsage: "username password",
+				Flags: []cli.Flag{
+					formatFlag,
+					cli.StringFlag{
+						Name:  "verify",
+						Usage: "Send a request to `URL` and report the response status, the challenge is fetched from it when not given",
+					},
+					cli.StringFlag{
+						Name:  "challenge,c",
+						Usage: "The WWW-Authenticate header value returned by the server",
+					},
+					cli.StringFlag{Name: "realm", Usage: "Challenge realm, overrides --challenge"},
+					cli.StringFlag{Name: "nonce", Usage: "Challenge nonce, overrides --challenge"},
+					cli.StringFlag{Name: "opaque", Usage: "Challenge opaque value, overrides --challenge"},
+					cli.StringFlag{Name: "qop", Usage: "Challenge qop, overrides --challenge"},
+					cli.StringFlag{Name: "algorithm", Usage: "MD5, MD5-sess, SHA-256 or SHA-256-sess, overrides --challenge"},
+					cli.StringFlag{Name: "method,X", Usage: "HTTP method of the request", Value: "GET"},
+					cli.StringFlag{Name: "uri", Usage: "Request URI, path and query, defaults to the one of --verify or /"},
+					cli.IntFlag{Name: "nc", Usage: "Nonce count", Value: 1},
+					cli.StringFlag{Name: "cnonce", Usage: "Client nonce, random when empty"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 2 {
+						return fmt.Errorf("digest takes a username and password")
+					}
+					verifyURL := c.String("verify")
+					challengeHeader := c.String("challenge")
+					if challengeHeader == "" && !c.IsSet("nonce") && verifyURL != "" {
+						var err error
+						challengeHeader, err = FetchChallenge(c.String("method"), verifyURL)
+						if err != nil {
+							return err
+						}
+					}
+					challenge, err := ParseDigestChallenge(challengeHeader)
+					if err != nil {
+						return err
+					}
+					for name, field := range map[string]*string{
+						"realm":     &challenge.Realm,
+						"nonce":     &challenge.Nonce,
+						"opaque":    &challenge.Opaque,
+						"qop":       &challenge.Qop,
+						"algorithm": &challenge.Algorithm,
+					} {
+						if c.IsSet(name) {
+							*field = c.String(name)
+						}
+					}
+					if challenge.Nonce == "" {
+						return fmt.Errorf("A nonce is required, pass --challenge or --nonce")
+					}
+
+					uri := c.String("uri")
+					if uri == "" && verifyURL != "" {
+						u, err := url.Parse(verifyURL)
+						if err != nil {
+							return err
+						}
+						uri = u.RequestURI()
+					}
+					if uri == "" {
+						uri = "/"
+					}
+
+					value, err := DigestAuth(challenge, DigestRequest{
+						Username: c.Args().Get(0),
+						Password: c.Args().Get(1),
+						Method:   c.String("method"),
+						URI:      uri,
+						Nc:       c.Int("nc"),
+						Cnonce:   c.String("cnonce"),
+					})
+					if err != nil {
+						return err
+					}
+					headers := []Header{AuthorizationHeader(value)}
+					out, err := FormatHeaders(c.String("format"), headers)
+					if err != nil {
+						return err
+					}
+					fmt.Println(out)
+
+					if verifyURL != "" {
+						return Verify(c.String("method"), verifyURL, headers, nil)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "sigv4",
+				Usage:     "AWS Signature Version 4 headers for a request",
+				ArgsUsage: "url",
+				Flags: []cli.Flag{
+					formatFlag,
+					cli.StringFlag{Name: "access-key", Usage: "AWS access key id", EnvVar: "AWS_ACCESS_KEY_ID"},
+					cli.StringFlag{Name: "secret-key", Usage: "AWS secret access key", EnvVar: "AWS_SECRET_ACCESS_KEY"},
+					cli.StringFlag{Name: "session-token", Usage: "AWS session token", EnvVar: "AWS_SESSION_TOKEN"},
+					cli.StringFlag{Name: "region,r", Usage: "AWS region", EnvVar: "AWS_REGION"},
+					cli.StringFlag{Name: "service,s", Usage: "AWS service name, e.g. s3, execute-api"},
+					cli.StringFlag{Name: "method,X", Usage: "HTTP method of the request", Value: "GET"},
+					cli.StringFlag{Name: "body,d", Usage: "File containing the request body, - for stdin"},
+					cli.StringFlag{Name: "date", Usage: "Signing time as 20060102T150405Z, defaults to now"},
+					cli.BoolFlag{Name: "verify", Usage: "Send the signed request and report the response status"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("sigv4 takes the url of the request")
+					}
+					for _, name := range []string{"access-key", "secret-key", "region", "service"} {
+						if c.String(name) == "" {
+							return fmt.Errorf("--%s is required", name)
+						}
+					}
+
+					var body []byte
+					var err error
+					switch c.String("body") {
+					case "":
+					case "-":
+						body, err = ioutil.ReadAll(os.Stdin)
+					default:
+						body, err = ioutil.ReadFile(c.String("body"))
+					}
+					if err != nil {
+						return err
+					}
+
+					signingTime := time.Now()
+					if c.String("date") != "" {
+						signingTime, err = time.Parse(SigV4TimeFormat, c.String("date"))
+						if err != nil {
+							return err
+						}
+					}
+
+					headers, err := SignV4(SigV4Request{
+						AccessKey:    c.String("access-key"),
+						SecretKey:    c.String("secret-key"),
+						SessionToken: c.String("session-token"),
+						Region:       c.String("region"),
+						Service:      c.String("service"),
+						Method:       c.String("method"),
+						URL:          c.Args().First(),
+						Body:         body,
+						Time:         signingTime,
+					})
+					if err != nil {
+						return err
+					}
+					out, err := FormatHeaders(c.String("format"), headers)
+					if err != nil {
+						return err
+					}
+					fmt.Println(out)
+
+					if c.Bool("verify") {
+						return Verify(c.String("method"), c.Args().First(), headers, body)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "url",
+				Usage: "Embed credentials in URLs or extract them",
+				Subcommands: []cli.Command{
+					{
+						Name:      "embed",
+						Usage:     "Print the URL with the username and password percent-encoded in it, the password is read from stdin when omitted",
+						ArgsUsage: "url username [password]",
+						Action: func(c *cli.Context) error {
+							args := c.Args()
+							if len(args) < 2 {
+								return fmt.Errorf("url embed takes a url and a username")
+							}
+							password, err := readPassword(args, 2)
+							if err != nil {
+								return err
+							}
+							out, err := EmbedCredentials(args[0], args[1], password)
+							if err != nil {
+								return err
+							}
+							fmt.Println(out)
+							return nil
+						},
+					},
+					{
+						Name:      "extract",
+						Usage:     "Print the decoded username and password of a URL and the URL without them, the URL is read from stdin when omitted",
+						ArgsUsage: "[url]",
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "format,f",
+								Usage: "Print the credentials as basic auth in an output format: token, header, curl, curlrc, httpie or netrc, instead of as text",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							rawURL, err := readPassword(c.Args(), 0)
+							if err != nil {
+								return err
+							}
+							username, password, stripped, err := ExtractCredentials(strings.TrimSpace(rawURL))
+							if err != nil {
+								return err
+							}
+							if format := c.String("format"); format != "" {
+								machine := ""
+								if u, err := url.Parse(stripped); err == nil {
+									machine = u.Hostname()
+								}
+								out, err := FormatBasicAuth(format, username, password, machine)
+								if err != nil {
+									return err
+								}
+								fmt.Println(out)
+								return nil
+							}
+							fmt.Printf("username: %s\npassword: %s\nurl: %s\n", username, password, stripped)
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "htpasswd",
+				Usage: "Manage htpasswd files for nginx, traefik or apache basic auth",
+				Subcommands: []cli.Command{
+					{
+						Name:      "set",
+						Usage:     "Create or update the entry of a user, the password is read from stdin when omitted",
+						ArgsUsage: "file username [password]",
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "algorithm,a",
+								Usage: "Hash algorithm: bcrypt or apr1",
+								Value: BcryptAlgorithm,
+							},
+							cli.IntFlag{
+								Name:  "cost,c",
+								Usage: "bcrypt cost",
+								Value: bcrypt.DefaultCost,
+							},
+						},
+						Action: func(c *cli.Context) error {
+							args := c.Args()
+							if len(args) < 2 {
+								return fmt.Errorf("htpasswd set takes a file and a username")
+							}
+							password, err := readPassword(args, 2)
+							if err != nil {
+								return err
+							}
+							updated, err := SetHtpasswd(args[0], args[1], password, c.String("algorithm"), c.Int("cost"))
+							if err != nil {
+								return err
+							}
+							if updated {
+								fmt.Printf("Updated password for user %s\n", args[1])
+							} else {
+								fmt.Printf("Added user %s\n", args[1])
+							}
+							return nil
+						},
+					},
+					{
+						Name:      "delete",
+						Usage:     "Remove the entry of a user",
+						ArgsUsage: "file username",
+						Action: func(c *cli.Context) error {
+							if c.NArg() < 2 {
+								return fmt.Errorf("htpasswd delete takes a file and a username")
+							}
+							return DeleteHtpasswd(c.Args().Get(0), c.Args().Get(1))
+						},
+					},
+					{
+						Name:      "verify",
+						Usage:     "Check a password against the entry of a user, the password is read from stdin when omitted",
+						ArgsUsage: "file username [password]",
+						Action: func(c *cli.Context) error {
+							args := c.Args()
+							if len(args) < 2 {
+								return fmt.Errorf("htpasswd verify takes a file and a username")
+							}
+							password, err := readPassword(args, 2)
+							if err != nil {
+								return err
+							}
+							ok, err := VerifyHtpasswd(args[0], args[1], password)
+							if err != nil {
+								return err
+							}
+							if !ok {
+								return cli.NewExitError("Password incorrect", 1)
+							}
+							fmt.Println("Password correct")
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "netrc",
+				Usage: "Manage the machine entries of the .netrc file read by curl --netrc",
+				Flags: []cli.Flag{netrcFileFlag},
+				Subcommands: []cli.Command{
+					{
+						Name:      "set",
+						Usage:     "Create or update the login and password of a machine, the password is read from stdin when omitted",
+						ArgsUsage: "machine|default login [password]",
+						Flags:     []cli.Flag{netrcFileFlag},
+						Action: func(c *cli.Context) error {
+							args := c.Args()
+							if len(args) < 2 {
+								return fmt.Errorf("netrc set takes a machine and a login")
+							}
+							password, err := readPassword(args, 2)
+							if err != nil {
+								return err
+							}
+							filename, err := netrcFile(c)
+							if err != nil {
+								return err
+							}
+							updated, err := SetNetrc(filename, args[0], args[1], password)
+							if err != nil {
+								return err
+							}
+							if updated {
+								fmt.Printf("Updated %s in %s\n", args[0], filename)
+							} else {
+								fmt.Printf("Added %s to %s\n", args[0], filename)
+							}
+							return nil
+						},
+					},
+					{
+						Name:      "delete",
+						Usage:     "Remove the entry of a machine",
+						ArgsUsage: "machine|default",
+						Flags:     []cli.Flag{netrcFileFlag},
+						Action: func(c *cli.Context) error {
+							if c.NArg() < 1 {
+								return fmt.Errorf("netrc delete takes a machine")
+							}
+							filename, err := netrcFile(c)
+							if err != nil {
+								return err
+							}
+							return DeleteNetrc(filename, c.Args().First())
+						},
+					},
+				},
+			},
+			{
+				Name:      "hash",
+				Usage:     "Hash a password with bcrypt, argon2id or scrypt, e.g. to seed users into a database, the password is read from stdin when omitted",
+				ArgsUsage: "[password]",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "algorithm,a",
+						Usage: "Hash algorithm: bcrypt, argon2id or scrypt",
+						Value: BcryptAlgorithm,
+					},
+					cli.IntFlag{
+						Name:  "cost,c",
+						Usage: "bcrypt cost",
+						Value: DefaultHashParams[BcryptAlgorithm].Cost,
+					},
+					cli.IntFlag{
+						Name:  "memory,m",
+						Usage: "argon2id memory in `KIB`",
+						Value: int(DefaultHashParams[Argon2idAlgorithm].Memory),
+					},
+					cli.IntFlag{
+						Name:  "iterations,t",
+						Usage: "argon2id iterations",
+						Value: int(DefaultHashParams[Argon2idAlgorithm].Iterations),
+					},
+					cli.IntFlag{
+						Name:  "parallelism,p",
+						Usage: "argon2id threads or scrypt parallelization, defaults to 4 and 1",
+					},
+					cli.IntFlag{
+						Name:  "log-n",
+						Usage: "scrypt CPU/memory cost as a power of 2",
+						Value: DefaultHashParams[ScryptAlgorithm].LogN,
+					},
+					cli.IntFlag{
+						Name:  "block-size,r",
+						Usage: "scrypt block size",
+						Value: DefaultHashParams[ScryptAlgorithm].BlockSize,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					password, err := readPassword(c.Args(), 0)
+					if err != nil {
+						return err
+					}
+					algorithm := c.String("algorithm")
+					params := HashParams{
+						Cost:        c.Int("cost"),
+						Memory:      uint32(c.Int("memory")),
+						Iterations:  uint32(c.Int("iterations")),
+						Parallelism: DefaultHashParams[algorithm].Parallelism,
+						LogN:        c.Int("log-n"),
+						BlockSize:   c.Int("block-size"),
+					}
+					if c.IsSet("parallelism") {
+						params.Parallelism = c.Int("parallelism")
+					}
+					hash, err := HashPassword(password, algorithm, params)
+					if err != nil {
+						return err
+					}
+					fmt.Println(hash)
+					return nil
+				},
+			},
+			{
+				Name:      "verify",
+				Usage:     "Check a password against a bcrypt, argon2id, scrypt, apr1 or {SHA} hash, the password is read from stdin when omitted",
+				ArgsUsage: "hash [password]",
+				Action: func(c *cli.Context) error {
+					args := c.Args()
+					if len(args) < 1 {
+						return fmt.Errorf("verify takes a hash")
+					}
+					password, err := readPassword(args, 1)
+					if err != nil {
+						return err
+					}
+					ok, err := VerifyPassword(args[0], password)
+					if err != nil {
+						return err
+					}
+					if !ok {
+						return cli.NewExitError("Password incorrect", 1)
+					}
+					fmt.Println("Password correct")
+					return nil
+				},
+			},
+			{
+				Name:  "jwt",
+				Usage: "Inspect JSON Web Tokens",
+				Subcommands: []cli.Command{
+					{
+						Name:      "decode",
+						Usage:     "Decode the header and claims of a token, the token is read from stdin when omitted",
+						ArgsUsage: "[token]",
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "key,k",
+								Usage: "Verify the signature with the HS256/HS512 secret or RS256/RS512 PEM public key, prefix with @ to read it from a file",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							token, err := readPassword(c.Args(), 0)
+							if err != nil {
+								return err
+							}
+							jwt, err := ParseJWT(token)
+							if err != nil {
+								return err
+							}
+							out, err := FormatJWT(jwt, time.Now())
+							if err != nil {
+								return err
+							}
+							fmt.Println(out)
+
+							if c.String("key") == "" {
+								return nil
+							}
+							key, err := readKey(c.String("key"))
+							if err != nil {
+								return err
+							}
+							if err := jwt.VerifySignature(key); err != nil {
+								return cli.NewExitError(err.Error(), 1)
+							}
+							fmt.Printf("Valid %s signature\n", jwt.Algorithm())
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+var netrcFileFlag = cli.StringFlag{
+	Name:  "file",
+	Usage: "The netrc `FILE`, defaults to $NETRC or ~/.netrc",
+}
+
+// netrcFile returns the netrc file of the --file flag of the command or of
+// netrc, the default one otherwise.
+func netrcFile(c *cli.Context) (string, error) {
+	if c.String("file") != "" {
+		return c.String("file"), nil
+	}
+	if c.Parent() != nil && c.Parent().String("file") != "" {
+		return c.Parent().String("file"), nil
+	}
+	return DefaultNetrc()
+}
+
+func basicAction(c *cli.Context) error {
+	if c.String("from-file") != "" {
+		if c.NArg() > 0 {
+			return fmt.Errorf("Pass either a username and password or --from-file, not both")
+		}
+		return batchAction(c)
+	}
+	if c.NArg() < 2 {
+		return fmt.Errorf("basic-auth takes a username and password")
+	}
+	username, password := c.Args().Get(0), c.Args().Get(1)
+
+	out, err := FormatBasicAuth(c.String("format"), username, password, c.String("machine"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(out)
+
+	if verifyURL := c.String("verify"); verifyURL != "" {
+		header := AuthorizationHeader("Basic " + basicAuth(username, password))
+		return Verify("GET", verifyURL, []Header{header}, nil)
+	}
+	return nil
+}
+
+func basicAuth(username, password string) string {
+	auth := username + ":" + password
+	return encode.Base64([]byte(auth))
+}
@@ -0,0 +1,164 @@
+package basicauth
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+type DigestChallenge struct {
+	Realm     string
+	Nonce     string
+	Opaque    string
+	Qop       string
+	Algorithm string
+}
+
+type DigestRequest struct {
+	Username string
+	Password string
+	Method   string
+	URI      string
+	Nc       int
+	Cnonce   string
+}
+
+// ParseDigestChallenge parses the parameters of a WWW-Authenticate header
+// such as `Digest realm="x", nonce="y", qop="auth"`.
+func ParseDigestChallenge(header string) (DigestChallenge, error) {
+	scheme, params := ParseAuthParams(header)
+	if scheme != "" && !strings.EqualFold(scheme, "digest") {
+		return DigestChallenge{}, fmt.Errorf("Expected a Digest challenge, got %s", scheme)
+	}
+	return DigestChallenge{
+		Realm:     params["realm"],
+		Nonce:     params["nonce"],
+		Opaque:    params["opaque"],
+		Qop:       params["qop"],
+		Algorithm: params["algorithm"],
+	}, nil
+}
+
+// ParseAuthParams splits an authentication header value into its scheme and
+// parameters. Quoted parameter values are unquoted.
+func ParseAuthParams(header string) (string, map[string]string) {
+	params := map[string]string{}
+	header = strings.TrimSpace(header)
+
+	scheme := ""
+	if i := strings.IndexAny(header, " \t"); i > 0 && !strings.Contains(header[:i], "=") {
+		scheme, header = header[:i], header[i+1:]
+	}
+
+	for len(header) > 0 {
+		header = strings.TrimLeft(header, " \t,")
+		eq := strings.Index(header, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(header[:eq]))
+		header = strings.TrimLeft(header[eq+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(header, `"`) {
+			var buf strings.Builder
+			i := 1
+			for ; i < len(header) && header[i] != '"'; i++ {
+				if header[i] == '\\' && i+1 < len(header) {
+					i++
+				}
+				buf.WriteByte(header[i])
+			}
+			value = buf.String()
+			if i < len(header) {
+				i++
+			}
+			header = header[i:]
+		} else {
+			end := strings.Index(header, ",")
+			if end < 0 {
+				end = len(header)
+			}
+			value = strings.TrimSpace(header[:end])
+			header = header[end:]
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
+
+func DigestAuth(challenge DigestChallenge, req DigestRequest) (string, error) {
+	algorithm := challenge.Algorithm
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("Unsupported digest algorithm %s", algorithm)
+	}
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	qop := ""
+	if challenge.Qop != "" {
+		for _, q := range strings.Split(challenge.Qop, ",") {
+			if strings.TrimSpace(q) == "auth" {
+				qop = "auth"
+			}
+		}
+		if qop == "" {
+			return "", fmt.Errorf("Unsupported qop %q, only auth is supported", challenge.Qop)
+		}
+	}
+
+	cnonce := req.Cnonce
+	if cnonce == "" {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		cnonce = hex.EncodeToString(b)
+	}
+	nc := fmt.Sprintf("%08x", req.Nc)
+
+	ha1 := h(req.Username + ":" + challenge.Realm + ":" + req.Password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + challenge.Nonce + ":" + cnonce)
+	}
+	ha2 := h(req.Method + ":" + req.URI)
+
+	var response string
+	if qop == "" {
+		response = h(ha1 + ":" + challenge.Nonce + ":" + ha2)
+	} else {
+		response = h(strings.Join([]string{ha1, challenge.Nonce, nc, cnonce, qop, ha2}, ":"))
+	}
+
+	parts := []string{
+		fmt.Sprintf("username=%q", req.Username),
+		fmt.Sprintf("realm=%q", challenge.Realm),
+		fmt.Sprintf("nonce=%q", challenge.Nonce),
+		fmt.Sprintf("uri=%q", req.URI),
+		fmt.Sprintf("algorithm=%s", algorithm),
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+nc, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	parts = append(parts, fmt.Sprintf("response=%q", response))
+	if challenge.Opaque != "" {
+		parts = append(parts, fmt.Sprintf("opaque=%q", challenge.Opaque))
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
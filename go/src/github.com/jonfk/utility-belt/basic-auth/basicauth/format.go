@@ -0,0 +1,94 @@
+package basicauth
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	TokenFormat  = "token"
+	HeaderFormat = "header"
+	CurlFormat   = "curl"
+	// CurlrcFormat is a stanza of a curl --config file
+	CurlrcFormat = "curlrc"
+	HttpieFormat = "httpie"
+	NetrcFormat  = "netrc"
+)
+
+type Header struct {
+	Name  string
+	Value string
+}
+
+func (h Header) String() string {
+	return h.Name + ": " + h.Value
+}
+
+func AuthorizationHeader(value string) Header {
+	return Header{Name: "Authorization", Value: value}
+}
+
+// FormatHeaders renders headers in one of the generic formats. The first header is
+// expected to be the Authorization header, its credentials are used for the token format.
+func FormatHeaders(format string, headers []Header) (string, error) {
+	switch format {
+	case TokenFormat:
+		parts := strings.SplitN(headers[0].Value, " ", 2)
+		return parts[len(parts)-1], nil
+	case HeaderFormat, "":
+		lines := []string{}
+		for _, h := range headers {
+			lines = append(lines, h.String())
+		}
+		return strings.Join(lines, "\n"), nil
+	case CurlFormat:
+		args := []string{"curl"}
+		for _, h := range headers {
+			args = append(args, "-H", shellQuote(h.String()))
+		}
+		return strings.Join(args, " "), nil
+	case CurlrcFormat:
+		options := [][2]string{}
+		for _, h := range headers {
+			options = append(options, [2]string{"header", h.String()})
+		}
+		return FormatCurlConfig(options), nil
+	case HttpieFormat:
+		args := []string{"http"}
+		for _, h := range headers {
+			args = append(args, shellQuote(h.Name+":"+h.Value))
+		}
+		return strings.Join(args, " "), nil
+	default:
+		return "", fmt.Errorf("Unknown or unsupported format %q", format)
+	}
+}
+
+func FormatBasicAuth(format, username, password, machine string) (string, error) {
+	switch format {
+	case HttpieFormat:
+		return fmt.Sprintf("http -a %s", shellQuote(username+":"+password)), nil
+	case CurlrcFormat:
+		return FormatCurlConfig([][2]string{{"user", username + ":" + password}}), nil
+	case NetrcFormat:
+		if machine == "" {
+			return fmt.Sprintf("default login %s password %s", netrcQuote(username), netrcQuote(password)), nil
+		}
+		return fmt.Sprintf("machine %s login %s password %s", netrcQuote(machine), netrcQuote(username), netrcQuote(password)), nil
+	default:
+		header := AuthorizationHeader("Basic " + basicAuth(username, password))
+		return FormatHeaders(format, []Header{header})
+	}
+}
+
+func FormatBearer(format, token string) (string, error) {
+	if format == HttpieFormat {
+		return fmt.Sprintf("http -A bearer -a %s", shellQuote(token)), nil
+	}
+	return FormatHeaders(format, []Header{AuthorizationHeader("Bearer " + token)})
+}
+
+// shellQuote wraps s in single quotes so it can be pasted into a POSIX shell as is.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,187 @@
+package basicauth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	Argon2idAlgorithm = "argon2id"
+	ScryptAlgorithm   = "scrypt"
+)
+
+// saltSize and keySize are the bytes of the salts and derived keys of the
+// argon2id and scrypt hashes.
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// HashParams are the cost parameters of the password hashes, the defaults
+// follow the OWASP recommendations.
+type HashParams struct {
+	// Cost of bcrypt, the log2 of its rounds
+	Cost int
+	// Memory of argon2id in KiB
+	Memory uint32
+	// Iterations of argon2id
+	Iterations uint32
+	// Parallelism of argon2id and scrypt
+	Parallelism int
+	// LogN of scrypt, the log2 of its CPU/memory cost N
+	LogN int
+	// BlockSize of scrypt, its r
+	BlockSize int
+}
+
+var DefaultHashParams = map[string]HashParams{
+	BcryptAlgorithm:   {Cost: bcrypt.DefaultCost},
+	Argon2idAlgorithm: {Memory: 64 * 1024, Iterations: 3, Parallelism: 4},
+	ScryptAlgorithm:   {LogN: 15, BlockSize: 8, Parallelism: 1},
+}
+
+// HashPassword hashes password with algorithm, bcrypt hashes in their usual
+// $2a$ form, argon2id and scrypt ones in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=4$salt$hash, as read by most libraries.
+func HashPassword(password, algorithm string, params HashParams) (string, error) {
+	switch algorithm {
+	case BcryptAlgorithm:
+		if params.Cost < bcrypt.MinCost || params.Cost > bcrypt.MaxCost {
+			return "", fmt.Errorf("Invalid bcrypt cost %d, expected %d to %d", params.Cost, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		if len(password) > 72 {
+			return "", fmt.Errorf("bcrypt only uses the first 72 bytes of a password, this one has %d", len(password))
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), params.Cost)
+		return string(hash), err
+	case Argon2idAlgorithm:
+		if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 || params.Parallelism > 255 {
+			return "", fmt.Errorf("Invalid argon2id parameters, expected at least 1 iteration, 1 to 255 threads and 8 KiB of memory per thread")
+		}
+		salt, err := newSalt()
+		if err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, uint8(params.Parallelism), keySize)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+			phcBase64.EncodeToString(salt), phcBase64.EncodeToString(key)), nil
+	case ScryptAlgorithm:
+		if params.LogN < 1 || params.LogN > 30 || params.BlockSize < 1 || params.Parallelism < 1 {
+			return "", fmt.Errorf("Invalid scrypt parameters, expected a log2 N of 1 to 30 and a positive block size and parallelism")
+		}
+		salt, err := newSalt()
+		if err != nil {
+			return "", err
+		}
+		key, err := scrypt.Key([]byte(password), salt, 1<<uint(params.LogN), params.BlockSize, params.Parallelism, keySize)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", params.LogN, params.BlockSize, params.Parallelism,
+			phcBase64.EncodeToString(salt), phcBase64.EncodeToString(key)), nil
+	default:
+		return "", fmt.Errorf("Unknown algorithm %s, expected bcrypt, argon2id or scrypt", algorithm)
+	}
+}
+
+// VerifyPassword checks password against a bcrypt, argon2id, argon2i or
+// scrypt hash, or any hash of an htpasswd file.
+func VerifyPassword(hash, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"), strings.HasPrefix(hash, "$argon2i$"):
+		return verifyArgon2(hash, password)
+	case strings.HasPrefix(hash, "$scrypt$"):
+		return verifyScrypt(hash, password)
+	case strings.HasPrefix(hash, "$2"), strings.HasPrefix(hash, "$apr1$"), strings.HasPrefix(hash, "{SHA}"):
+		return VerifyHtpasswdHash(hash, password)
+	default:
+		return false, fmt.Errorf("Unsupported hash format, expected bcrypt, argon2id, scrypt, apr1 or {SHA}")
+	}
+}
+
+// phcBase64 is the unpadded base64 of the PHC string format.
+var phcBase64 = base64.RawStdEncoding
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	_, err := rand.Read(salt)
+	return salt, err
+}
+
+// parsePHC splits a PHC string into its algorithm, parameters, salt and hash,
+// the version of argon2 being kept with the parameters.
+func parsePHC(hash string) (string, map[string]int, []byte, []byte, error) {
+	invalid := fmt.Errorf("Invalid hash, expected $algorithm$parameters$salt$hash")
+	parts := strings.Split(hash, "$")
+	if len(parts) < 5 || parts[0] != "" {
+		return "", nil, nil, nil, invalid
+	}
+	fields := parts[2 : len(parts)-2]
+	params := map[string]int{}
+	for _, field := range fields {
+		for _, param := range strings.Split(field, ",") {
+			kv := strings.SplitN(param, "=", 2)
+			if len(kv) != 2 {
+				return "", nil, nil, nil, invalid
+			}
+			value, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return "", nil, nil, nil, fmt.Errorf("Invalid hash parameter %s", param)
+			}
+			params[kv[0]] = value
+		}
+	}
+	salt, err := phcBase64.DecodeString(parts[len(parts)-2])
+	if err != nil {
+		return "", nil, nil, nil, fmt.Errorf("Invalid salt: %v", err)
+	}
+	key, err := phcBase64.DecodeString(parts[len(parts)-1])
+	if err != nil || len(key) == 0 {
+		return "", nil, nil, nil, invalid
+	}
+	return parts[1], params, salt, key, nil
+}
+
+func verifyArgon2(hash, password string) (bool, error) {
+	algorithm, params, salt, key, err := parsePHC(hash)
+	if err != nil {
+		return false, err
+	}
+	if v, ok := params["v"]; ok && v != argon2.Version {
+		return false, fmt.Errorf("Unsupported argon2 version %d, expected %d", v, argon2.Version)
+	}
+	m, t, p := params["m"], params["t"], params["p"]
+	if m < 1 || t < 1 || p < 1 || p > 255 {
+		return false, fmt.Errorf("Invalid argon2 parameters m=%d,t=%d,p=%d", m, t, p)
+	}
+	derive := argon2.IDKey
+	if algorithm == "argon2i" {
+		derive = argon2.Key
+	}
+	derived := derive([]byte(password), salt, uint32(t), uint32(m), uint8(p), uint32(len(key)))
+	return subtle.ConstantTimeCompare(derived, key) == 1, nil
+}
+
+func verifyScrypt(hash, password string) (bool, error) {
+	_, params, salt, key, err := parsePHC(hash)
+	if err != nil {
+		return false, err
+	}
+	ln, r, p := params["ln"], params["r"], params["p"]
+	if ln < 1 || ln > 30 || r < 1 || p < 1 {
+		return false, fmt.Errorf("Invalid scrypt parameters ln=%d,r=%d,p=%d", ln, r, p)
+	}
+	derived, err := scrypt.Key([]byte(password), salt, 1<<uint(ln), r, p, len(key))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(derived, key) == 1, nil
+}
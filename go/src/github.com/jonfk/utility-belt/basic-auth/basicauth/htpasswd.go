@@ -0,0 +1,242 @@
+package basicauth
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/jonfk/utility-belt/encode/encode"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	BcryptAlgorithm = "bcrypt"
+	Apr1Algorithm   = "apr1"
+)
+
+type HtpasswdEntry struct {
+	Username string
+	Hash     string
+}
+
+func ReadHtpasswd(filename string) ([]HtpasswdEntry, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []HtpasswdEntry{}
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: malformed entry", filename, i+1)
+		}
+		entries = append(entries, HtpasswdEntry{Username: parts[0], Hash: parts[1]})
+	}
+	return entries, nil
+}
+
+func WriteHtpasswd(filename string, entries []HtpasswdEntry) error {
+	var buf strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&buf, "%s:%s\n", entry.Username, entry.Hash)
+	}
+	return ioutil.WriteFile(filename, []byte(buf.String()), 0640)
+}
+
+// SetHtpasswd creates or updates the entry of username in filename. The file is
+// created when it doesn't exist.
+func SetHtpasswd(filename, username, password, algorithm string, cost int) (bool, error) {
+	if strings.Contains(username, ":") {
+		return false, fmt.Errorf("Username cannot contain ':'")
+	}
+	entries, err := ReadHtpasswd(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	hash, err := HtpasswdHash(password, algorithm, cost)
+	if err != nil {
+		return false, err
+	}
+
+	updated := false
+	for i := range entries {
+		if entries[i].Username == username {
+			entries[i].Hash = hash
+			updated = true
+		}
+	}
+	if !updated {
+		entries = append(entries, HtpasswdEntry{Username: username, Hash: hash})
+	}
+	return updated, WriteHtpasswd(filename, entries)
+}
+
+func DeleteHtpasswd(filename, username string) error {
+	entries, err := ReadHtpasswd(filename)
+	if err != nil {
+		return err
+	}
+	kept := []HtpasswdEntry{}
+	for _, entry := range entries {
+		if entry.Username != username {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == len(entries) {
+		return fmt.Errorf("User %s not found in %s", username, filename)
+	}
+	return WriteHtpasswd(filename, kept)
+}
+
+func VerifyHtpasswd(filename, username, password string) (bool, error) {
+	entries, err := ReadHtpasswd(filename)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if entry.Username == username {
+			return VerifyHtpasswdHash(entry.Hash, password)
+		}
+	}
+	return false, fmt.Errorf("User %s not found in %s", username, filename)
+}
+
+func HtpasswdHash(password, algorithm string, cost int) (string, error) {
+	switch algorithm {
+	case BcryptAlgorithm, "":
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+		return string(hash), err
+	case Apr1Algorithm:
+		salt, err := apr1Salt()
+		if err != nil {
+			return "", err
+		}
+		return Apr1Crypt(password, salt), nil
+	default:
+		return "", fmt.Errorf("Unknown algorithm %s, expected bcrypt or apr1", algorithm)
+	}
+}
+
+func VerifyHtpasswdHash(hash, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	case strings.HasPrefix(hash, "$apr1$"):
+		salt := strings.SplitN(strings.TrimPrefix(hash, "$apr1$"), "$", 2)[0]
+		return subtle.ConstantTimeCompare([]byte(Apr1Crypt(password, salt)), []byte(hash)) == 1, nil
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum := sha1.Sum([]byte(password))
+		expected := "{SHA}" + encode.Base64(sum[:])
+		return subtle.ConstantTimeCompare([]byte(expected), []byte(hash)) == 1, nil
+	default:
+		return false, fmt.Errorf("Unsupported hash format, expected bcrypt, apr1 or {SHA}")
+	}
+}
+
+const apr1Alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+func apr1Salt() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = apr1Alphabet[int(b[i])%len(apr1Alphabet)]
+	}
+	return string(b), nil
+}
+
+// Apr1Crypt is Apache's variant of the MD5 based crypt algorithm.
+func Apr1Crypt(password, salt string) string {
+	const magic = "$apr1$"
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+
+	alt := md5.Sum([]byte(password + salt + password))
+
+	ctx := md5.New()
+	ctx.Write([]byte(password + magic + salt))
+	for i := len(password); i > 0; i -= 16 {
+		n := i
+		if n > 16 {
+			n = 16
+		}
+		ctx.Write(alt[:n])
+	}
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			ctx.Write([]byte{0})
+		} else {
+			ctx.Write([]byte{password[0]})
+		}
+	}
+	final := ctx.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		round := md5.New()
+		if i&1 == 1 {
+			round.Write([]byte(password))
+		} else {
+			round.Write(final)
+		}
+		if i%3 != 0 {
+			round.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			round.Write([]byte(password))
+		}
+		if i&1 == 1 {
+			round.Write(final)
+		} else {
+			round.Write([]byte(password))
+		}
+		final = round.Sum(nil)
+	}
+
+	var buf strings.Builder
+	encode64 := func(a, b, c byte, n int) {
+		v := uint(a)<<16 | uint(b)<<8 | uint(c)
+		for ; n > 0; n-- {
+			buf.WriteByte(apr1Alphabet[v&0x3f])
+			v >>= 6
+		}
+	}
+	encode64(final[0], final[6], final[12], 4)
+	encode64(final[1], final[7], final[13], 4)
+	encode64(final[2], final[8], final[14], 4)
+	encode64(final[3], final[9], final[15], 4)
+	encode64(final[4], final[10], final[5], 4)
+	encode64(0, 0, final[11], 2)
+
+	return magic + salt + "$" + buf.String()
+}
+
+// readPassword returns the argument at index i, reading a line from
+// stdin when it wasn't given.
+func readPassword(args []string, i int) (string, error) {
+	if len(args) > i {
+		return args[i], nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("No password given as argument or on stdin")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,193 @@
+package basicauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/encode/encode"
+)
+
+// JWTTimeClaims are the registered claims holding NumericDate values.
+var JWTTimeClaims = []string{"iat", "nbf", "exp"}
+
+type JWT struct {
+	Header    map[string]interface{}
+	Claims    map[string]interface{}
+	RawHeader []byte
+	RawClaims []byte
+	Signature []byte
+	// SigningInput is the `header.payload` part the signature was computed over
+	SigningInput string
+}
+
+// ParseJWT splits a compact serialized JWT and decodes its header and payload.
+// A leading `Bearer ` is ignored so Authorization header values can be pasted as is.
+func ParseJWT(token string) (*JWT, error) {
+	token = strings.TrimSpace(token)
+	if strings.HasPrefix(strings.ToLower(token), "bearer ") {
+		token = strings.TrimSpace(token[len("bearer "):])
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("A JWT has 3 parts separated by '.', got %d", len(parts))
+	}
+
+	jwt := &JWT{SigningInput: parts[0] + "." + parts[1]}
+	var err error
+	if jwt.RawHeader, err = encode.DecodeBase64URL(parts[0]); err != nil {
+		return nil, fmt.Errorf("Decoding header: %v", err)
+	}
+	if jwt.RawClaims, err = encode.DecodeBase64URL(parts[1]); err != nil {
+		return nil, fmt.Errorf("Decoding payload: %v", err)
+	}
+	if jwt.Signature, err = encode.DecodeBase64URL(parts[2]); err != nil {
+		return nil, fmt.Errorf("Decoding signature: %v", err)
+	}
+	if err := json.Unmarshal(jwt.RawHeader, &jwt.Header); err != nil {
+		return nil, fmt.Errorf("Parsing header: %v", err)
+	}
+	if err := json.Unmarshal(jwt.RawClaims, &jwt.Claims); err != nil {
+		return nil, fmt.Errorf("Parsing payload: %v", err)
+	}
+	return jwt, nil
+}
+
+func (t *JWT) Algorithm() string {
+	alg, _ := t.Header["alg"].(string)
+	return alg
+}
+
+// TimeClaim returns the NumericDate claim name as a time, false when it's
+// absent or not a number.
+func (t *JWT) TimeClaim(name string) (time.Time, bool) {
+	seconds, ok := t.Claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// VerifySignature checks the signature with key, the shared secret for HS256
+// and HS512 or a PEM encoded public key for RS256 and RS512.
+func (t *JWT) VerifySignature(key []byte) error {
+	alg := t.Algorithm()
+	switch alg {
+	case "HS256", "HS512":
+		h := sha256.New
+		if alg == "HS512" {
+			h = sha512.New
+		}
+		mac := hmac.New(h, key)
+		mac.Write([]byte(t.SigningInput))
+		if !hmac.Equal(mac.Sum(nil), t.Signature) {
+			return fmt.Errorf("Invalid %s signature", alg)
+		}
+		return nil
+	case "RS256", "RS512":
+		pub, err := parseRSAPublicKey(key)
+		if err != nil {
+			return err
+		}
+		var digest []byte
+		hash := crypto.SHA256
+		if alg == "RS512" {
+			sum := sha512.Sum512([]byte(t.SigningInput))
+			digest, hash = sum[:], crypto.SHA512
+		} else {
+			sum := sha256.Sum256([]byte(t.SigningInput))
+			digest = sum[:]
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, t.Signature); err != nil {
+			return fmt.Errorf("Invalid %s signature", alg)
+		}
+		return nil
+	default:
+		return fmt.Errorf("Unsupported signature algorithm %q, expected HS256, HS512, RS256 or RS512", alg)
+	}
+}
+
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("No PEM block found in public key")
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if pub, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return pub, nil
+		}
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if pub, ok := key.(*rsa.PublicKey); ok {
+			return pub, nil
+		}
+	}
+	return nil, fmt.Errorf("Public key is not an RSA key")
+}
+
+// FormatJWT pretty prints the header and claims followed by the time claims
+// as human dates relative to now.
+func FormatJWT(t *JWT, now time.Time) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("Header:\n")
+	if err := json.Indent(&buf, t.RawHeader, "", "  "); err != nil {
+		return "", err
+	}
+	buf.WriteString("\nPayload:\n")
+	if err := json.Indent(&buf, t.RawClaims, "", "  "); err != nil {
+		return "", err
+	}
+	buf.WriteString("\n")
+
+	for _, name := range JWTTimeClaims {
+		at, ok := t.TimeClaim(name)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&buf, "%s: %s (%s)\n", name, at.Format(time.RFC3339), relativeTime(at, now))
+	}
+	if exp, ok := t.TimeClaim("exp"); ok && !now.Before(exp) {
+		buf.WriteString("Token is expired\n")
+	}
+	if nbf, ok := t.TimeClaim("nbf"); ok && now.Before(nbf) {
+		buf.WriteString("Token is not valid yet\n")
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+func relativeTime(at, now time.Time) string {
+	d := at.Sub(now).Round(time.Second)
+	if d < 0 {
+		return (-d).String() + " ago"
+	}
+	return "in " + d.String()
+}
+
+// readKey returns the key given on the command line, reading it from a file
+// when it's prefixed with @ like curl does.
+func readKey(key string) ([]byte, error) {
+	if strings.HasPrefix(key, "@") {
+		return ioutil.ReadFile(key[1:])
+	}
+	return []byte(key), nil
+}
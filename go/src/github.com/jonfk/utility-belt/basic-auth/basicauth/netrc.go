@@ -0,0 +1,254 @@
+package basicauth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// DefaultMachine names the netrc default entry, which matches every machine
+// without an entry of its own.
+const DefaultMachine = "default"
+
+// DefaultNetrc is the netrc file used by curl: $NETRC, otherwise ~/.netrc,
+// or ~/_netrc on windows.
+func DefaultNetrc() (string, error) {
+	if netrc := os.Getenv("NETRC"); netrc != "" {
+		return netrc, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc"), nil
+	}
+	return filepath.Join(home, ".netrc"), nil
+}
+
+// netrcToken is a token of a netrc file, with its unquoted value and its
+// position in the file.
+type netrcToken struct {
+	value      string
+	start, end int
+}
+
+// tokenizeNetrc splits a netrc file into its tokens, skipping the comments
+// and the bodies of the macdef macros, which run until an empty line.
+func tokenizeNetrc(data string) []netrcToken {
+	tokens := []netrcToken{}
+	i := 0
+	macdef := 0
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+			continue
+		case '#':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			continue
+		}
+		start := i
+		var value strings.Builder
+		if data[i] == '"' {
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' && i+1 < len(data) {
+					i++
+				}
+				value.WriteByte(data[i])
+			}
+			i++
+		} else {
+			for ; i < len(data) && !strings.ContainsRune(" \t\r\n", rune(data[i])); i++ {
+				value.WriteByte(data[i])
+			}
+		}
+		if i > len(data) {
+			i = len(data)
+		}
+		tokens = append(tokens, netrcToken{value: value.String(), start: start, end: i})
+
+		switch {
+		case value.String() == "macdef" && macdef == 0:
+			macdef = 1
+		case macdef == 1:
+			// the macro name is read, skip its body
+			macdef = 0
+			if end := strings.Index(data[i:], "\n\n"); end >= 0 {
+				i += end + 2
+			} else {
+				i = len(data)
+			}
+		}
+	}
+	return tokens
+}
+
+// netrcEntry is the span of the tokens of a machine or default entry.
+type netrcEntry struct {
+	machine string
+	// first is the index of the machine or default keyword, end the one
+	// after the last token of the entry
+	first, end int
+}
+
+func netrcEntries(tokens []netrcToken) []netrcEntry {
+	entries := []netrcEntry{}
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i].value {
+		case "machine", DefaultMachine:
+			if len(entries) > 0 {
+				entries[len(entries)-1].end = i
+			}
+			entry := netrcEntry{machine: DefaultMachine, first: i, end: len(tokens)}
+			if tokens[i].value == "machine" && i+1 < len(tokens) {
+				i++
+				entry.machine = tokens[i].value
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// netrcQuote quotes the values which aren't a single plain token, as curl
+// reads them.
+func netrcQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\r\n\"\\") && !strings.HasPrefix(s, "#") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// netrcEdit replaces data[start:end] with text.
+type netrcEdit struct {
+	start, end int
+	text       string
+}
+
+func applyNetrcEdits(data string, edits []netrcEdit) string {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, edit := range edits {
+		data = data[:edit.start] + edit.text + data[edit.end:]
+	}
+	return data
+}
+
+// SetNetrc creates or updates the login and password of machine in
+// filename, DefaultMachine for the default entry, and reports whether the
+// entry existed. The other entries, comments and macros are kept as they are.
+// The file is created when it doesn't exist and is only readable by its
+// owner, as ftp and some curl builds require.
+func SetNetrc(filename, machine, login, password string) (bool, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	data := string(content)
+	tokens := tokenizeNetrc(data)
+
+	edits := []netrcEdit{}
+	updated := false
+	for _, entry := range netrcEntries(tokens) {
+		if entry.machine != machine {
+			continue
+		}
+		updated = true
+		values := map[string]string{"login": login, "password": password}
+		// skip the keyword and the machine name
+		from := entry.first + 1
+		if machine != DefaultMachine {
+			from++
+		}
+		for i := from; i < entry.end; i++ {
+			if value, ok := values[tokens[i].value]; ok && i+1 < entry.end {
+				edits = append(edits, netrcEdit{tokens[i+1].start, tokens[i+1].end, netrcQuote(value)})
+				delete(values, tokens[i].value)
+				i++
+			}
+		}
+		// add the login and password missing from the entry after its name
+		missing := ""
+		for _, name := range []string{"login", "password"} {
+			if value, ok := values[name]; ok {
+				missing += " " + name + " " + netrcQuote(value)
+			}
+		}
+		if missing != "" {
+			after := tokens[entry.first].end
+			if machine != DefaultMachine && entry.first+1 < entry.end {
+				after = tokens[entry.first+1].end
+			}
+			edits = append(edits, netrcEdit{after, after, missing})
+		}
+		break
+	}
+	if !updated {
+		line := fmt.Sprintf("login %s password %s\n", netrcQuote(login), netrcQuote(password))
+		if machine == DefaultMachine {
+			line = "default " + line
+		} else {
+			line = "machine " + netrcQuote(machine) + " " + line
+		}
+		at := len(data)
+		// the default entry matches every machine so it must stay last
+		for _, entry := range netrcEntries(tokens) {
+			if entry.machine == DefaultMachine && machine != DefaultMachine {
+				at = tokens[entry.first].start
+				break
+			}
+		}
+		if at == len(data) && data != "" && !strings.HasSuffix(data, "\n") {
+			line = "\n" + line
+		}
+		edits = append(edits, netrcEdit{at, at, line})
+	}
+	return updated, writeNetrc(filename, applyNetrcEdits(data, edits))
+}
+
+// DeleteNetrc removes the entry of machine from filename.
+func DeleteNetrc(filename, machine string) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	data := string(content)
+	tokens := tokenizeNetrc(data)
+	for _, entry := range netrcEntries(tokens) {
+		if entry.machine != machine {
+			continue
+		}
+		end := len(data)
+		if entry.end < len(tokens) {
+			end = tokens[entry.end].start
+		}
+		return writeNetrc(filename, applyNetrcEdits(data, []netrcEdit{{tokens[entry.first].start, end, ""}}))
+	}
+	return fmt.Errorf("Machine %s not found in %s", machine, filename)
+}
+
+// writeNetrc writes the netrc file, first tightening the permissions of an
+// existing file readable by others.
+func writeNetrc(filename, data string) error {
+	if err := os.Chmod(filename, 0600); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return ioutil.WriteFile(filename, []byte(data), 0600)
+}
+
+// FormatCurlConfig renders options as the lines of a curl --config file,
+// with their values quoted.
+func FormatCurlConfig(options [][2]string) string {
+	lines := []string{}
+	for _, option := range options {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\t", `\t`, "\n", `\n`, "\r", `\r`).Replace(option[1])
+		lines = append(lines, fmt.Sprintf("%s = \"%s\"", option[0], value))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,162 @@
+package basicauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SigV4Algorithm  = "AWS4-HMAC-SHA256"
+	SigV4TimeFormat = "20060102T150405Z"
+	SigV4DateFormat = "20060102"
+)
+
+type SigV4Request struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+	Service      string
+	Method       string
+	URL          string
+	Body         []byte
+	Time         time.Time
+}
+
+// SignV4 computes the headers needed to authenticate the request with AWS
+// Signature Version 4. The host, x-amz-date and x-amz-content-sha256 headers are signed.
+func SignV4(req SigV4Request) ([]Header, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("URL %q has no host", req.URL)
+	}
+
+	amzTime := req.Time.UTC().Format(SigV4TimeFormat)
+	amzDate := req.Time.UTC().Format(SigV4DateFormat)
+	payloadHash := sha256Hex(req.Body)
+
+	signed := map[string]string{
+		"host":                 u.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzTime,
+	}
+	if req.SessionToken != "" {
+		signed["x-amz-security-token"] = req.SessionToken
+	}
+	names := []string{}
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := ""
+	for _, name := range names {
+		canonicalHeaders += name + ":" + strings.TrimSpace(signed[name]) + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		strings.ToUpper(req.Method),
+		canonicalURI(u, req.Service),
+		canonicalQuery(u),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{amzDate, req.Region, req.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		SigV4Algorithm,
+		amzTime,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+req.SecretKey), amzDate)
+	key = hmacSHA256(key, req.Region)
+	key = hmacSHA256(key, req.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	headers := []Header{
+		AuthorizationHeader(fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+			SigV4Algorithm, req.AccessKey, scope, signedHeaders, signature)),
+		{Name: "X-Amz-Date", Value: amzTime},
+		{Name: "X-Amz-Content-Sha256", Value: payloadHash},
+	}
+	if req.SessionToken != "" {
+		headers = append(headers, Header{Name: "X-Amz-Security-Token", Value: req.SessionToken})
+	}
+	return headers, nil
+}
+
+func canonicalURI(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		// S3 expects each segment to be encoded once, every other service twice
+		if service == "s3" {
+			if unescaped, err := url.PathUnescape(segment); err == nil {
+				segment = unescaped
+			}
+		}
+		segments[i] = awsURIEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(u *url.URL) string {
+	pairs := [][2]string{}
+	for key, values := range u.Query() {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{awsURIEncode(key), awsURIEncode(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	encoded := []string{}
+	for _, pair := range pairs {
+		encoded = append(encoded, pair[0]+"="+pair[1])
+	}
+	return strings.Join(encoded, "&")
+}
+
+func awsURIEncode(s string) string {
+	var buf strings.Builder
+	for _, b := range []byte(s) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' {
+			buf.WriteByte(b)
+		} else {
+			fmt.Fprintf(&buf, "%%%02X", b)
+		}
+	}
+	return buf.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
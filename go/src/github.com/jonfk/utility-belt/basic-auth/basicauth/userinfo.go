@@ -0,0 +1,60 @@
+package basicauth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// escapeUserinfo percent-encodes everything but the RFC 3986 unreserved
+// characters. The sub-delims are allowed in userinfo but tools splitting
+// the URL by hand, or decoding + as a space, often mangle them.
+func escapeUserinfo(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// EmbedCredentials returns rawURL with username and password as its
+// userinfo, replacing any there. An empty password is left out.
+func EmbedCredentials(rawURL, username, password string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("Expected an absolute URL like https://host/path, got %s", rawURL)
+	}
+	userinfo := escapeUserinfo(username)
+	if password != "" {
+		userinfo += ":" + escapeUserinfo(password)
+	}
+	u.User = nil
+	// the userinfo is spliced in since url.URL would re-escape it
+	// differently
+	rest := strings.TrimPrefix(u.String(), u.Scheme+"://")
+	return u.Scheme + "://" + userinfo + "@" + rest, nil
+}
+
+// ExtractCredentials returns the decoded username and password of rawURL
+// and the URL without them.
+func ExtractCredentials(rawURL string) (string, string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", "", err
+	}
+	if u.User == nil {
+		return "", "", "", fmt.Errorf("No credentials in %s", rawURL)
+	}
+	username := u.User.Username()
+	password, _ := u.User.Password()
+	u.User = nil
+	return username, password, u.String(), nil
+}
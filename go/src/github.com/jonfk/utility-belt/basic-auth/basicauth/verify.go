@@ -0,0 +1,64 @@
+package basicauth
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/urfave/cli"
+)
+
+var verifyFlag = cli.StringFlag{
+	Name:  "verify",
+	Usage: "Send a request with the generated headers to `URL` and report the response status",
+}
+
+// httpClient is configured from the httpx flags before any command runs
+var httpClient *http.Client
+
+// Verify sends a request authenticated with headers to url and reports the status
+// code, along with the WWW-Authenticate challenge when the credentials are refused.
+func Verify(method, url string, headers []Header, body []byte) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for _, h := range headers {
+		req.Header.Set(h.Name, h.Value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	fmt.Printf("%s %s: %s\n", method, url, resp.Status)
+	for _, challenge := range resp.Header["Www-Authenticate"] {
+		fmt.Printf("WWW-Authenticate: %s\n", challenge)
+	}
+	if resp.StatusCode >= 400 {
+		return cli.NewExitError("Credentials were not accepted", 1)
+	}
+	return nil
+}
+
+// FetchChallenge sends an unauthenticated request to url and returns the
+// WWW-Authenticate header of the response.
+func FetchChallenge(method, url string) (string, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if challenge == "" {
+		return "", fmt.Errorf("%s %s returned %s without a WWW-Authenticate challenge", method, url, resp.Status)
+	}
+	return challenge, nil
+}
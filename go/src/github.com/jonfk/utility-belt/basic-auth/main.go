@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/basic-auth/basicauth"
+	"github.com/jonfk/utility-belt/internal/belt"
+)
+
+func main() {
+	belt.Run(belt.NewApp("basic-auth", basicauth.Command()))
+}
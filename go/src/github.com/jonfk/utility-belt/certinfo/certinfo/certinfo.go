@@ -0,0 +1,163 @@
+package certinfo
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+)
+
+type Certificate struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	Serial      string    `json:"serial"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+	DaysLeft    int       `json:"daysLeft"`
+	DNSNames    []string  `json:"dnsNames,omitempty"`
+	IPAddresses []string  `json:"ipAddresses,omitempty"`
+	IsCA        bool      `json:"isCA"`
+	Algorithm   string    `json:"signatureAlgorithm"`
+	SHA256      string    `json:"sha256"`
+}
+
+type Report struct {
+	Source string        `json:"source"`
+	Chain  []Certificate `json:"chain"`
+	// Errors lists why the chain doesn't verify against the system roots
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Fetch connects to addr, a host:port with port 443 by default, and returns
+// the chain presented by the server without verifying it.
+func Fetch(addr, serverName string, timeout time.Duration) ([]*x509.Certificate, string, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "443")
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	if serverName == "" {
+		serverName = host
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates, serverName, nil
+}
+
+// ReadPEM reads every certificate of a PEM file, the leaf first.
+func ReadPEM(filename string) ([]*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	certs := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s: no certificate found", filename)
+	}
+	return certs, nil
+}
+
+// Inspect describes chain and verifies it against the system roots for
+// dnsName, the host name check is skipped when dnsName is empty.
+func Inspect(source string, chain []*x509.Certificate, dnsName string, now time.Time) Report {
+	report := Report{Source: source}
+	for _, cert := range chain {
+		report.Chain = append(report.Chain, Describe(cert, now))
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       dnsName,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	return report
+}
+
+// Describe summarizes cert, with the days left before it expires at now.
+func Describe(cert *x509.Certificate, now time.Time) Certificate {
+	sum := sha256.Sum256(cert.Raw)
+	ips := []string{}
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	return Certificate{
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		Serial:      cert.SerialNumber.Text(16),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+		DaysLeft:    int(cert.NotAfter.Sub(now).Hours() / 24),
+		DNSNames:    cert.DNSNames,
+		IPAddresses: ips,
+		IsCA:        cert.IsCA,
+		Algorithm:   cert.SignatureAlgorithm.String(),
+		SHA256:      hex.EncodeToString(sum[:]),
+	}
+}
+
+func FormatReport(report Report) string {
+	lines := []string{report.Source}
+	for i, cert := range report.Chain {
+		lines = append(lines,
+			fmt.Sprintf("[%d] %s", i, cert.Subject),
+			fmt.Sprintf("    Issuer:     %s", cert.Issuer),
+			fmt.Sprintf("    Valid:      %s to %s", cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339)),
+			fmt.Sprintf("    Expires:    %s", expiry(cert.DaysLeft)),
+		)
+		if len(cert.DNSNames) > 0 || len(cert.IPAddresses) > 0 {
+			lines = append(lines, fmt.Sprintf("    SANs:       %s", strings.Join(append(append([]string{}, cert.DNSNames...), cert.IPAddresses...), ", ")))
+		}
+		lines = append(lines,
+			fmt.Sprintf("    Serial:     %s", cert.Serial),
+			fmt.Sprintf("    Algorithm:  %s", cert.Algorithm),
+			fmt.Sprintf("    SHA256:     %s", cert.SHA256),
+		)
+	}
+	if len(report.Errors) == 0 {
+		lines = append(lines, "Chain is valid")
+	}
+	for _, err := range report.Errors {
+		lines = append(lines, "Invalid chain: "+err)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func expiry(daysLeft int) string {
+	if daysLeft < 0 {
+		return fmt.Sprintf("expired %d days ago", -daysLeft)
+	}
+	return fmt.Sprintf("in %d days", daysLeft)
+}
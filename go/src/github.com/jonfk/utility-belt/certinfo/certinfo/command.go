@@ -0,0 +1,80 @@
+package certinfo
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// Command inspects certificates, run standalone as certinfo or as ub cert.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "cert",
+		Usage:     "Prints the certificate chain of a server or a PEM file",
+		ArgsUsage: "host[:port]|file.pem",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "servername,n",
+				Usage: "Server name sent with SNI and checked against the certificate, defaults to the host",
+			},
+			cli.IntFlag{
+				Name:  "warn-days,w",
+				Usage: "Exit with status 2 when the leaf certificate expires within `DAYS`",
+				Value: 0,
+			},
+			cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Connection timeout",
+				Value: 10 * time.Second,
+			},
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the report as json",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("cert takes a host or a PEM file")
+			}
+			source := c.Args().First()
+
+			var (
+				chain   []*x509.Certificate
+				dnsName string
+				err     error
+			)
+			if _, statErr := os.Stat(source); statErr == nil {
+				chain, err = ReadPEM(source)
+				dnsName = c.String("servername")
+			} else {
+				chain, dnsName, err = Fetch(source, c.String("servername"), c.Duration("timeout"))
+			}
+			if err != nil {
+				return err
+			}
+
+			report := Inspect(source, chain, dnsName, time.Now())
+			if c.Bool("json") {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				fmt.Println(FormatReport(report))
+			}
+
+			if len(report.Errors) > 0 {
+				return cli.NewExitError("", 1)
+			}
+			if warnDays := c.Int("warn-days"); warnDays > 0 && report.Chain[0].DaysLeft < warnDays {
+				return cli.NewExitError(fmt.Sprintf("Certificate expires within %d days", warnDays), 2)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/certinfo/certinfo"
+	"github.com/jonfk/utility-belt/internal/belt"
+)
+
+func main() {
+	belt.Run(belt.NewApp("certinfo", certinfo.Command()))
+}
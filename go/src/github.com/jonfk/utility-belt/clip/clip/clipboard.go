@@ -0,0 +1,67 @@
+package clip
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands returns the commands writing and reading the clipboard
+// of the local machine: pbcopy on macOS, wl-clipboard on Wayland then xclip
+// or xsel on X11, clip.exe and PowerShell on Windows.
+func clipboardCommands() ([]string, []string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"pbcopy"}, []string{"pbpaste"}, nil
+	case "windows":
+		return []string{"clip.exe"}, []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"}, nil
+	}
+	candidates := [][2][]string{
+		{{"xclip", "-selection", "clipboard", "-in"}, {"xclip", "-selection", "clipboard", "-out"}},
+		{{"xsel", "--clipboard", "--input"}, {"xsel", "--clipboard", "--output"}},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append([][2][]string{{{"wl-copy"}, {"wl-paste", "--no-newline"}}}, candidates...)
+	}
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate[0][0]); err == nil {
+			return candidate[0], candidate[1], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("No clipboard command found, install wl-clipboard, xclip or xsel")
+}
+
+// WriteClipboard replaces the content of the local clipboard.
+func WriteClipboard(data []byte) error {
+	copyCmd, _, err := clipboardCommands()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(copyCmd[0], copyCmd[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %v %s", copyCmd[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ReadClipboard returns the content of the local clipboard.
+func ReadClipboard() ([]byte, error) {
+	_, pasteCmd, err := clipboardCommands()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(pasteCmd[0], pasteCmd[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v %s", pasteCmd[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
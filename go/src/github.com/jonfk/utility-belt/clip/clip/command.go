@@ -0,0 +1,154 @@
+package clip
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/jonfk/utility-belt/internal/httpx"
+	"github.com/urfave/cli"
+)
+
+// DefaultPort is forwarded over ssh with -R 2224:localhost:2224.
+const DefaultPort = "2224"
+
+var tokenFlag = cli.StringFlag{
+	Name:  "token,t",
+	Usage: "Token shared by the server and the clients, set it with `ub config set-secret clip.token`",
+}
+
+var serverFlag = cli.StringFlag{
+	Name:  "server,s",
+	Usage: "`URL` of the clip server, usually forwarded over ssh",
+	Value: "http://localhost:" + DefaultPort,
+}
+
+// Command copies and pastes to the clipboard of the local machine from ssh
+// sessions, run standalone as clip or as ub clip.
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "clip",
+		Usage: "Copy and paste across ssh sessions to the clipboard of your machine",
+		Description: "Run `clip serve` on your machine, connect with ssh -R " + DefaultPort + ":localhost:" + DefaultPort + " host\n" +
+			"   then pipe into `clip copy` or read `clip paste` on the host. Both ends share a token.",
+		Subcommands: []cli.Command{
+			{
+				Name:  "serve",
+				Usage: "Serve the local clipboard to the clients with the token",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "addr,a",
+						Usage: "Address to listen on, keep it on localhost and forward it over ssh",
+						Value: "localhost:" + DefaultPort,
+					},
+					tokenFlag,
+				},
+				Action: func(c *cli.Context) error {
+					token, err := resolveToken(c)
+					if err != nil {
+						return err
+					}
+					if token == "" {
+						if token, err = NewToken(); err != nil {
+							return err
+						}
+						fmt.Printf("No token configured, the clients need --token %s\n", token)
+					}
+					if host, _, err := net.SplitHostPort(c.String("addr")); err == nil {
+						if ip := net.ParseIP(host); host == "" || (ip != nil && !ip.IsLoopback()) {
+							fmt.Fprintf(os.Stderr, "Listening on %s exposes the clipboard beyond this machine\n", c.String("addr"))
+						}
+					}
+					fmt.Printf("serving the clipboard on %s, forward it with ssh -R %s:%s host\n", c.String("addr"), DefaultPort, c.String("addr"))
+					return http.ListenAndServe(c.String("addr"), Handler(LocalClipboard, token))
+				},
+			},
+			{
+				Name:      "copy",
+				Usage:     "Copy stdin, or the arguments, to the clipboard of the server",
+				ArgsUsage: "[TEXT...]",
+				Flags:     []cli.Flag{serverFlag, tokenFlag},
+				Action: func(c *cli.Context) error {
+					var data []byte
+					if c.NArg() > 0 {
+						data = []byte(strings.Join(c.Args(), " "))
+					} else {
+						var err error
+						if data, err = ioutil.ReadAll(os.Stdin); err != nil {
+							return err
+						}
+					}
+					_, err := request(c, "POST", "/copy", data)
+					return err
+				},
+			},
+			{
+				Name:  "paste",
+				Usage: "Print the clipboard of the server",
+				Flags: []cli.Flag{serverFlag, tokenFlag},
+				Action: func(c *cli.Context) error {
+					data, err := request(c, "GET", "/paste", nil)
+					if err != nil {
+						return err
+					}
+					_, err = os.Stdout.Write(data)
+					return err
+				},
+			},
+		},
+	}
+}
+
+func resolveToken(c *cli.Context) (string, error) {
+	cfg, err := config.Load("clip")
+	if err != nil {
+		return "", err
+	}
+	return cfg.Secret(c, "token")
+}
+
+// request sends a request to the server with the token, returning the
+// response body.
+func request(c *cli.Context, method, path string, body []byte) ([]byte, error) {
+	token, err := resolveToken(c)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, fmt.Errorf("No token, pass the one of the server with --token")
+	}
+	cfg, err := config.Load("clip")
+	if err != nil {
+		return nil, err
+	}
+	server := strings.TrimSuffix(cfg.String(c, "server"), "/")
+
+	client, err := httpx.NewClient(httpx.DefaultOptions)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, server+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Could not reach the clip server, is it forwarded with ssh -R %s:localhost:%s? %v", DefaultPort, DefaultPort, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
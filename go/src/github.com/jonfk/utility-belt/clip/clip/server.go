@@ -0,0 +1,84 @@
+package clip
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// MaxSize is the largest text accepted by the server.
+const MaxSize = 10 << 20
+
+// Clipboard is the clipboard of the machine running the server, replaced
+// in tests or by other backends.
+type Clipboard struct {
+	Write func([]byte) error
+	Read  func() ([]byte, error)
+}
+
+var LocalClipboard = Clipboard{Write: WriteClipboard, Read: ReadClipboard}
+
+// Handler serves the clipboard to the clients presenting token as a bearer
+// token: POST /copy writes the body to it and GET /paste returns it.
+func Handler(clipboard Clipboard, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/copy", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(r.Body, MaxSize+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(data) > MaxSize {
+			http.Error(w, fmt.Sprintf("Larger than %d bytes", MaxSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err := clipboard.Write(data); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Printf("copied %d bytes from %s\n", len(data), r.RemoteAddr)
+	})
+	mux.HandleFunc("/paste", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := clipboard.Read()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(data)
+		fmt.Printf("pasted %d bytes to %s\n", len(data), r.RemoteAddr)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// NewToken returns a random token for a server started without one.
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,127 @@
+package cronwhen
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+const timeFormat = "Mon 2006-01-02 15:04 MST"
+
+// Command explains cron expressions, run standalone as cronwhen or as ub cron.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "cron",
+		Usage:     "Explains a cron expression and lists its next runs",
+		ArgsUsage: "EXPRESSION",
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:  "count,n",
+				Usage: "Number of runs to list",
+				Value: 5,
+			},
+			cli.StringFlag{
+				Name:  "tz",
+				Usage: "Time zone of the schedule, e.g. America/Toronto, defaults to the local one",
+			},
+			cli.StringFlag{
+				Name:  "from",
+				Usage: "List the runs after `TIME` (RFC3339) instead of now",
+			},
+			cli.StringFlag{
+				Name:  "diff",
+				Usage: "Compare with the runs of `EXPRESSION`",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("Expected a cron expression, e.g. \"*/15 9-17 * * MON-FRI\"")
+			}
+			// the fields may be passed unquoted as separate arguments
+			schedule, err := Parse(strings.Join(c.Args(), " "))
+			if err != nil {
+				return err
+			}
+
+			location := time.Local
+			if c.String("tz") != "" {
+				if location, err = time.LoadLocation(c.String("tz")); err != nil {
+					return err
+				}
+			}
+			from := time.Now()
+			if c.String("from") != "" {
+				if from, err = time.Parse(time.RFC3339, c.String("from")); err != nil {
+					return fmt.Errorf("Invalid --from %s, expected RFC3339 e.g. 2024-01-31T09:00:00Z", c.String("from"))
+				}
+			}
+			from = from.In(location)
+
+			if c.String("diff") == "" {
+				fmt.Println(schedule.Describe())
+				runs := schedule.NextN(from, c.Int("count"))
+				if len(runs) == 0 {
+					fmt.Printf("Never runs within %d years\n", MaxYears)
+				}
+				for _, run := range runs {
+					fmt.Println(run.Format(timeFormat))
+				}
+				return nil
+			}
+
+			other, err := Parse(c.String("diff"))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("A: %s\t%s\nB: %s\t%s\n\n", schedule.Expr, schedule.Describe(), other.Expr, other.Describe())
+			for _, run := range Diff(schedule, other, from, c.Int("count")) {
+				fmt.Printf("%s  %s\n", run.Time.Format(timeFormat), run.Marker())
+			}
+			return nil
+		},
+	}
+}
+
+// DiffRun is a run of either or both schedules of a diff.
+type DiffRun struct {
+	Time time.Time
+	A, B bool
+}
+
+func (r DiffRun) Marker() string {
+	switch {
+	case r.A && r.B:
+		return "A B"
+	case r.A:
+		return "A"
+	default:
+		return "  B"
+	}
+}
+
+// Diff merges the next n runs of a and b in order.
+func Diff(a, b *Schedule, from time.Time, n int) []DiffRun {
+	runs := []DiffRun{}
+	nextA, nextB := a.Next(from), b.Next(from)
+	for len(runs) < n && (!nextA.IsZero() || !nextB.IsZero()) {
+		run := DiffRun{}
+		switch {
+		case nextB.IsZero() || (!nextA.IsZero() && nextA.Before(nextB)):
+			run.Time, run.A = nextA, true
+		case nextA.IsZero() || nextB.Before(nextA):
+			run.Time, run.B = nextB, true
+		default:
+			run.Time, run.A, run.B = nextA, true, true
+		}
+		if run.A {
+			nextA = a.Next(nextA)
+		}
+		if run.B {
+			nextB = b.Next(nextB)
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
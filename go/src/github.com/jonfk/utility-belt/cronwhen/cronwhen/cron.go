@@ -0,0 +1,228 @@
+package cronwhen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression of the standard 5 fields.
+type Schedule struct {
+	Expr   string
+	Fields [5]Field
+}
+
+// Field is a cron field, the terms are kept to describe it.
+type Field struct {
+	Kind  FieldKind
+	Terms []Term
+	// Any is true for * which matches every value
+	Any bool
+	// Star is true for the fields starting with *, such as */2, which cron
+	// doesn't count as restricting the days to either field
+	Star    bool
+	matches map[int]bool
+}
+
+// Term is a value, range or step of a field, e.g. 9, 1-5, */15 or 0-30/10.
+type Term struct {
+	Start, End, Step int
+	Star             bool
+}
+
+type FieldKind struct {
+	Name     string
+	Min, Max int
+	Names    []string
+}
+
+var (
+	MinuteField  = FieldKind{Name: "minute", Min: 0, Max: 59}
+	HourField    = FieldKind{Name: "hour", Min: 0, Max: 23}
+	DayField     = FieldKind{Name: "day", Min: 1, Max: 31}
+	MonthField   = FieldKind{Name: "month", Min: 1, Max: 12, Names: []string{"", "JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}}
+	WeekdayField = FieldKind{Name: "weekday", Min: 0, Max: 7, Names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}}
+
+	fieldKinds = [5]FieldKind{MinuteField, HourField, DayField, MonthField, WeekdayField}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a 5 field cron expression or one of the @ macros.
+func Parse(expr string) (*Schedule, error) {
+	expanded := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(expanded)]; ok {
+		expanded = macro
+	}
+	parts := strings.Fields(expanded)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("Invalid cron expression %q, expected 5 fields: minute hour day month weekday", expr)
+	}
+	s := &Schedule{Expr: expr}
+	for i, part := range parts {
+		field, err := parseField(part, fieldKinds[i])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s field %q: %v", fieldKinds[i].Name, part, err)
+		}
+		s.Fields[i] = field
+	}
+	return s, nil
+}
+
+func parseField(s string, kind FieldKind) (Field, error) {
+	field := Field{Kind: kind, Any: s == "*" || s == "?", Star: strings.HasPrefix(s, "*") || s == "?", matches: map[int]bool{}}
+	for _, part := range strings.Split(s, ",") {
+		term, err := parseTerm(part, kind)
+		if err != nil {
+			return field, err
+		}
+		field.Terms = append(field.Terms, term)
+		for v := term.Start; v <= term.End; v += term.Step {
+			if kind.Name == WeekdayField.Name && v == 7 {
+				// 7 is sunday as well as 0
+				field.matches[0] = true
+			}
+			field.matches[v] = true
+		}
+	}
+	return field, nil
+}
+
+func parseTerm(s string, kind FieldKind) (Term, error) {
+	term := Term{Step: 1}
+	rangePart := s
+	if i := strings.Index(s, "/"); i >= 0 {
+		step, err := strconv.Atoi(s[i+1:])
+		if err != nil || step <= 0 {
+			return term, fmt.Errorf("invalid step %q", s[i+1:])
+		}
+		term.Step, rangePart = step, s[:i]
+	}
+
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		term.Star, term.Start, term.End = true, kind.Min, kind.Max
+		if kind.Name == WeekdayField.Name {
+			term.End = 6
+		}
+	case strings.Contains(rangePart, "-"):
+		bounds := strings.SplitN(rangePart, "-", 2)
+		start, err := parseValue(bounds[0], kind)
+		if err != nil {
+			return term, err
+		}
+		end, err := parseValue(bounds[1], kind)
+		if err != nil {
+			return term, err
+		}
+		if end < start {
+			return term, fmt.Errorf("range %s ends before it starts", rangePart)
+		}
+		term.Start, term.End = start, end
+	default:
+		value, err := parseValue(rangePart, kind)
+		if err != nil {
+			return term, err
+		}
+		term.Start, term.End = value, value
+		if term.Step > 1 {
+			// a/n steps from a until the end of the field
+			term.End = kind.Max
+		}
+	}
+	return term, nil
+}
+
+func parseValue(s string, kind FieldKind) (int, error) {
+	for i, name := range kind.Names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < kind.Min || v > kind.Max {
+		return 0, fmt.Errorf("%d is out of range %d-%d", v, kind.Min, kind.Max)
+	}
+	return v, nil
+}
+
+func (f Field) Matches(v int) bool {
+	return f.matches[v]
+}
+
+func (s *Schedule) Minute() Field  { return s.Fields[0] }
+func (s *Schedule) Hour() Field    { return s.Fields[1] }
+func (s *Schedule) Day() Field     { return s.Fields[2] }
+func (s *Schedule) Month() Field   { return s.Fields[3] }
+func (s *Schedule) Weekday() Field { return s.Fields[4] }
+
+// dayMatches follows cron in running on either the day of the month or the
+// weekday when both are restricted, a field starting with * such as */2
+// not being a restriction there so that both must match.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	day, weekday := s.Day().Matches(t.Day()), s.Weekday().Matches(int(t.Weekday()))
+	if s.Day().Star || s.Weekday().Star {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// MaxYears bounds the search of the next run, which can be years away for
+// expressions such as february 29th on a monday.
+const MaxYears = 50
+
+// Next returns the first run strictly after t in the location of t, the zero
+// time when there is none.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.Year() + MaxYears
+	for next.Year() <= limit {
+		y, m, d := next.Date()
+		var candidate time.Time
+		switch {
+		case !s.Month().Matches(int(m)):
+			candidate = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(next):
+			candidate = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		case !s.Hour().Matches(next.Hour()):
+			candidate = time.Date(y, m, d, next.Hour()+1, 0, 0, 0, loc)
+		case !s.Minute().Matches(next.Minute()):
+			candidate = time.Date(y, m, d, next.Hour(), next.Minute()+1, 0, 0, loc)
+		default:
+			return next
+		}
+		// time.Date may pick the earlier of two instants on daylight saving
+		// time transitions, always move forward
+		if !candidate.After(next) {
+			candidate = next.Add(time.Minute)
+		}
+		next = candidate
+	}
+	return time.Time{}
+}
+
+// NextN returns up to n runs after t.
+func (s *Schedule) NextN(t time.Time, n int) []time.Time {
+	runs := []time.Time{}
+	for len(runs) < n {
+		t = s.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs
+}
@@ -0,0 +1,133 @@
+package cronwhen
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ordinals = map[int]string{1: "st", 2: "nd", 3: "rd", 21: "st", 22: "nd", 23: "rd", 31: "st"}
+
+func ordinal(n int) string {
+	suffix, ok := ordinals[n]
+	if !ok {
+		suffix = "th"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+func weekdayName(v int) string { return time.Weekday(v % 7).String() }
+func monthName(v int) string   { return time.Month(v).String() }
+
+func joinAnd(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// describeTerms describes the terms of a field, unit names the step, e.g.
+// "every 15 minutes from 0 through 30".
+func describeTerms(f Field, unit string, format func(int) string) string {
+	parts := []string{}
+	for _, term := range f.Terms {
+		var part string
+		switch {
+		case term.Star:
+			part = fmt.Sprintf("every %d %ss", term.Step, unit)
+		case term.Start == term.End:
+			part = format(term.Start)
+		case term.Step == 1:
+			part = fmt.Sprintf("%s through %s", format(term.Start), format(term.End))
+		case term.End == f.Kind.Max:
+			part = fmt.Sprintf("every %d %ss starting at %s", term.Step, unit, format(term.Start))
+		default:
+			part = fmt.Sprintf("every %d %ss from %s through %s", term.Step, unit, format(term.Start), format(term.End))
+		}
+		parts = append(parts, part)
+	}
+	return joinAnd(parts)
+}
+
+// singles returns the values of a field made only of single values.
+func singles(f Field) ([]int, bool) {
+	values := []int{}
+	for _, term := range f.Terms {
+		if term.Star || term.Start != term.End {
+			return nil, false
+		}
+		values = append(values, term.Start)
+	}
+	return values, true
+}
+
+// Describe explains the schedule in English, e.g. "At 09:30, Monday through
+// Friday".
+func (s *Schedule) Describe() string {
+	var b strings.Builder
+	minutes, singleMinutes := singles(s.Minute())
+	hours, singleHours := singles(s.Hour())
+
+	switch {
+	case singleMinutes && singleHours && len(minutes)*len(hours) <= 6:
+		times := []string{}
+		for _, h := range hours {
+			for _, m := range minutes {
+				times = append(times, fmt.Sprintf("%02d:%02d", h, m))
+			}
+		}
+		b.WriteString("At " + joinAnd(times))
+	default:
+		switch {
+		case s.Minute().Any:
+			b.WriteString("Every minute")
+		case singleMinutes:
+			b.WriteString("At minute " + describeTerms(s.Minute(), "minute", func(v int) string { return fmt.Sprint(v) }))
+		default:
+			b.WriteString(upperFirst(describeTerms(s.Minute(), "minute", func(v int) string { return fmt.Sprint(v) })))
+			if !strings.HasPrefix(b.String(), "Every") {
+				b.WriteString(" minutes")
+			}
+		}
+		switch {
+		case s.Hour().Any:
+			if !s.Minute().Any {
+				b.WriteString(" past every hour")
+			}
+		case singleHours && len(hours) > 1:
+			b.WriteString(" past hours " + describeTerms(s.Hour(), "hour", func(v int) string { return fmt.Sprint(v) }))
+		case singleHours:
+			b.WriteString(" past hour " + describeTerms(s.Hour(), "hour", func(v int) string { return fmt.Sprint(v) }))
+		default:
+			b.WriteString(", " + describeTerms(s.Hour(), "hour", func(v int) string { return fmt.Sprintf("%02d:00", v) }))
+		}
+	}
+
+	dayOfMonth := describeTerms(s.Day(), "day", ordinal)
+	weekday := describeTerms(s.Weekday(), "day", weekdayName)
+	switch {
+	case !s.Day().Any && !s.Weekday().Any && (s.Day().Star || s.Weekday().Star):
+		// cron runs when both match as either starts with *
+		if !strings.HasPrefix(dayOfMonth, "every") {
+			dayOfMonth = "on the " + dayOfMonth
+		}
+		fmt.Fprintf(&b, ", %s of the month when it is %s", dayOfMonth, weekday)
+	case !s.Day().Any && !s.Weekday().Any:
+		fmt.Fprintf(&b, ", on the %s of the month or on %s", dayOfMonth, weekday)
+	case !s.Day().Any:
+		fmt.Fprintf(&b, ", on the %s of the month", dayOfMonth)
+	case !s.Weekday().Any:
+		fmt.Fprintf(&b, ", on %s", weekday)
+	}
+	if !s.Month().Any {
+		fmt.Fprintf(&b, ", in %s", describeTerms(s.Month(), "month", monthName))
+	}
+	return b.String()
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/cronwhen/cronwhen"
+	"github.com/jonfk/utility-belt/internal/belt"
+)
+
+func main() {
+	belt.Run(belt.NewApp("cronwhen", cronwhen.Command()))
+}
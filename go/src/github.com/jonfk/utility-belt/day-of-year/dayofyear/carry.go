@@ -0,0 +1,117 @@
+package dayofyear
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CarriedOverHeading is the section of the entry receiving the open todos.
+const CarriedOverHeading = "## Carried over"
+
+var openTodo = regexp.MustCompile(`^\s*[-*+] \[ \] \S`)
+
+// OpenTodos returns the unchecked `- [ ]` items of content with their
+// indentation, so nested items stay nested.
+func OpenTodos(content string) []string {
+	todos := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		if openTodo.MatchString(line) {
+			todos = append(todos, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return todos
+}
+
+// PreviousEntry returns the latest entry before date.
+func PreviousEntry(entries []Entry, date time.Time) (Entry, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Date.Before(date) {
+			return entries[i], true
+		}
+	}
+	return Entry{}, false
+}
+
+// CarryOver adds the todos missing from content to its carried over section,
+// creating it before the navigation links or at the end. It returns content
+// unchanged when every todo is already there.
+func CarryOver(content string, todos []string) (string, []string) {
+	present := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+	added := []string{}
+	for _, todo := range todos {
+		if !present[strings.TrimSpace(todo)] {
+			added = append(added, todo)
+		}
+	}
+	if len(added) == 0 {
+		return content, added
+	}
+	items := strings.Join(added, "\n") + "\n"
+
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != CarriedOverHeading {
+			continue
+		}
+		// append after the last item of the existing section
+		end := i + 1
+		for j := i + 1; j < len(lines); j++ {
+			trimmed := strings.TrimSpace(lines[j])
+			if strings.HasPrefix(trimmed, "#") || trimmed == NavMarker {
+				break
+			}
+			if trimmed != "" {
+				end = j + 1
+			}
+		}
+		if end == i+1 {
+			items = "\n" + items
+		}
+		if !strings.HasSuffix(lines[end-1], "\n") {
+			items = "\n" + items
+		}
+		return strings.Join(lines[:end], "") + items + strings.Join(lines[end:], ""), added
+	}
+
+	section := CarriedOverHeading + "\n\n" + items
+	if i := strings.Index(content, NavMarker); i >= 0 {
+		return strings.TrimRight(content[:i], "\n") + "\n\n" + section + "\n" + content[i:], added
+	}
+	return strings.TrimRight(content, "\n") + "\n\n" + section, added
+}
+
+// carryInto carries the open todos of the entry preceding date into the
+// entry file, returning the todos added.
+func carryInto(filename string, date time.Time, dryRun bool) ([]string, error) {
+	entries, err := ListEntries(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	previous, ok := PreviousEntry(entries, date)
+	if !ok {
+		return nil, nil
+	}
+	previousContent, err := ReadEntry(previous)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s doesn't exist, create it with new --carry", filename)
+	} else if err != nil {
+		return nil, err
+	}
+	updated, added := CarryOver(string(content), OpenTodos(string(previousContent)))
+	if len(added) == 0 || dryRun {
+		return added, nil
+	}
+	return added, ioutil.WriteFile(filename, []byte(updated), 0644)
+}
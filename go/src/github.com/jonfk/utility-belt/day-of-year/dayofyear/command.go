@@ -0,0 +1,838 @@
+package dayofyear
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/urfave/cli"
+)
+
+const (
+	DateLayout = "2006-01-02"
+	// compactLayout is the format without dashes rename fixes
+	compactLayout = "20060102"
+)
+
+var errOut *log.Logger
+
+// location is the time zone today is computed in, set by --tz
+var location = time.Local
+
+func init() {
+	errOut = log.New(os.Stderr, "", 0)
+
+}
+
+// Command is the day-of-year command tree, run standalone as day-of-year or as ub day.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "day",
+		Usage:     "Get the day of the year for journal entries",
+		ArgsUsage: "[date...]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "identity",
+				Usage: "age identity `FILE` used to read encrypted entries",
+			},
+			cli.StringFlag{
+				Name:  "tz",
+				Usage: "IANA time zone of today, e.g. America/Toronto, defaults to the local one",
+			},
+			cli.StringFlag{
+				Name:  "journal,j",
+				Usage: "Operate on the journal `NAME` configured as journal.NAME.dir, .template, .git and .date-format in the day section, instead of the current directory",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			cfg, err := config.Load("day")
+			if err != nil {
+				return err
+			}
+			identity = cfg.String(c, "identity")
+			if tz := cfg.String(c, "tz"); tz != "" {
+				if location, err = time.LoadLocation(tz); err != nil {
+					return err
+				}
+			}
+			journal, err = LoadJournal(cfg, cfg.String(c, "journal"))
+			return err
+		},
+		Action: dayAction,
+		Subcommands: []cli.Command{
+			{
+				Name:    "commit",
+				Aliases: []string{"c"},
+				Usage:   "commit file with day and date",
+				BashComplete: func(c *cli.Context) {
+					for _, name := range journalEntries(journal.Dir) {
+						fmt.Println(name)
+					}
+				},
+				Action: func(c *cli.Context) error {
+					if len(c.Args()) < 1 {
+						return fmt.Errorf("No argument")
+					}
+					file := journal.Path(c.Args().First())
+					day, err := parseDate(filepath.Base(file))
+					if err != nil {
+						return err
+					}
+					err = commitFile(file, getDateMessage(day))
+					if err != nil {
+						return err
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:    "rename",
+				Aliases: []string{"r"},
+				Usage:   "rename files with the wrong format in the journal directory",
+				Flags: []cli.Flag{cli.BoolFlag{
+					Name:  "dry-run,d",
+					Usage: "Do a dry run",
+				}},
+				Action: func(c *cli.Context) error {
+					files, err := ioutil.ReadDir(journal.Dir)
+					if err != nil {
+						return err
+					}
+					if c.Bool("dry-run") {
+						fmt.Println("Running dry run")
+					}
+
+					for _, file := range files {
+						name := file.Name()
+						if file.IsDir() || !strings.HasSuffix(name, ".md") {
+							continue
+						}
+						if _, err := parseDate(name); err == nil {
+							continue
+						}
+						// the entries used to be named without dashes
+						if len(name) < len(compactLayout) {
+							continue
+						}
+						date, err := time.Parse(compactLayout, name[:len(compactLayout)])
+						if err != nil {
+							continue
+						}
+						newFileName := date.Format(journal.DateLayout) + name[len(compactLayout):]
+						fmt.Printf("Renaming %s to %s\n", name, newFileName)
+						if !c.Bool("dry-run") {
+							os.Rename(journal.Path(name), journal.Path(newFileName))
+						}
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "new",
+				Aliases:   []string{"n"},
+				Usage:     "Create the entry of today or date from a template",
+				ArgsUsage: "[date]",
+				Description: "The template is a text/template with .Date, .Message and .Vars. Variables are\n" +
+					"   the trimmed output of shell commands given with --var or as var.NAME keys in\n" +
+					"   the day section of the config file, e.g. {{.Vars.weather}} or {{var \"weather\"}}.",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "template,t",
+						Usage: "text/template `FILE` for the new entry",
+					},
+					cli.StringSliceFlag{
+						Name:  "var",
+						Usage: "Template variable as `NAME=COMMAND`, run with sh -c",
+					},
+					cli.DurationFlag{
+						Name:  "var-timeout",
+						Usage: "Time allowed to each variable command",
+						Value: 5 * time.Second,
+					},
+					cli.StringFlag{
+						Name:  "placeholder",
+						Usage: "Value of the variables whose command failed",
+						Value: "n/a",
+					},
+					cli.BoolFlag{
+						Name:  "stdout",
+						Usage: "Print the entry instead of writing it",
+					},
+					cli.BoolFlag{
+						Name:  "force,f",
+						Usage: "Overwrite an existing entry",
+					},
+					cli.BoolFlag{
+						Name:  "carry",
+						Usage: "Carry over the unchecked todos of the previous entry",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					date := today()
+					if c.NArg() > 0 {
+						var err error
+						if date, err = parseDate(c.Args().First()); err != nil {
+							return err
+						}
+					}
+					cfg, err := config.Load("day")
+					if err != nil {
+						return err
+					}
+
+					tmpl := DefaultEntryTemplate
+					file := journal.Template
+					if c.IsSet("template") || file == "" {
+						file = cfg.String(c, "template")
+					}
+					if file != "" {
+						content, err := ioutil.ReadFile(file)
+						if err != nil {
+							return err
+						}
+						tmpl = string(content)
+					}
+					commands := cfg.Prefixed(VarPrefix)
+					for _, v := range c.StringSlice("var") {
+						parts := strings.SplitN(v, "=", 2)
+						if len(parts) != 2 || parts[0] == "" {
+							return fmt.Errorf("Invalid --var %s, expected NAME=COMMAND", v)
+						}
+						commands[parts[0]] = parts[1]
+					}
+
+					data := EntryData{
+						Date:    date,
+						Message: getDateMessage(date),
+						Vars:    ResolveVars(commands, c.Duration("var-timeout"), c.String("placeholder")),
+					}
+					out, err := RenderEntry(tmpl, data, c.String("placeholder"))
+					if err != nil {
+						return err
+					}
+
+					if c.Bool("carry") {
+						entries, err := ListEntries(journal.Dir)
+						if err != nil {
+							return err
+						}
+						if previous, ok := PreviousEntry(entries, date); ok {
+							content, err := ReadEntry(previous)
+							if err != nil {
+								return err
+							}
+							out, _ = CarryOver(out, OpenTodos(string(content)))
+						}
+					}
+
+					if c.Bool("stdout") {
+						fmt.Print(out)
+						return nil
+					}
+					filename := journal.EntryPath(date)
+					if _, err := os.Stat(filename); err == nil && !c.Bool("force") {
+						return fmt.Errorf("%s already exists, pass --force to overwrite it", filename)
+					}
+					if err := ioutil.WriteFile(filename, []byte(out), 0644); err != nil {
+						return err
+					}
+					fmt.Println(filename)
+					return nil
+				},
+			},
+			{
+				Name:      "carry",
+				Usage:     "Carry over the unchecked todos of the previous entry into the entry of today or date",
+				ArgsUsage: "[date]",
+				Flags: []cli.Flag{cli.BoolFlag{
+					Name:  "dry-run,d",
+					Usage: "Print the todos which would be carried over",
+				}},
+				Action: func(c *cli.Context) error {
+					date := today()
+					if c.NArg() > 0 {
+						var err error
+						if date, err = parseDate(c.Args().First()); err != nil {
+							return err
+						}
+					}
+					filename := journal.EntryPath(date)
+					added, err := carryInto(filename, date, c.Bool("dry-run"))
+					if err != nil {
+						return err
+					}
+					if len(added) == 0 {
+						fmt.Println("Nothing to carry over")
+					}
+					for _, todo := range added {
+						fmt.Println(todo)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "link",
+				Usage: "Add or update previous and next links at the bottom of the entries",
+				Flags: []cli.Flag{cli.BoolFlag{
+					Name:  "dry-run,d",
+					Usage: "Print the entries which would change",
+				}},
+				Action: func(c *cli.Context) error {
+					entries, err := ListEntries(journal.Dir)
+					if err != nil {
+						return err
+					}
+					changed, err := LinkEntries(entries, c.Bool("dry-run"))
+					for _, path := range changed {
+						fmt.Printf("Linked %s\n", path)
+					}
+					return err
+				},
+			},
+			{
+				Name:      "rollup",
+				Usage:     "Write a review file linking the entries of a week or month, e.g. 2024-W34.md",
+				ArgsUsage: "[date]",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "period,p",
+						Usage: "week or month",
+						Value: WeekPeriod,
+					},
+					cli.StringFlag{
+						Name:  "template,t",
+						Usage: "text/template `FILE` for the rollup skeleton",
+					},
+					cli.BoolFlag{
+						Name:  "concat,c",
+						Usage: "Include the content of the entries",
+					},
+					cli.BoolFlag{
+						Name:  "stdout",
+						Usage: "Print the rollup instead of writing it",
+					},
+					cli.BoolFlag{
+						Name:  "force,f",
+						Usage: "Overwrite an existing rollup file",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					date := today()
+					if c.NArg() > 0 {
+						var err error
+						if date, err = parseDate(c.Args().First()); err != nil {
+							return err
+						}
+					}
+
+					tmpl := DefaultRollupTemplate
+					if c.String("template") != "" {
+						content, err := ioutil.ReadFile(c.String("template"))
+						if err != nil {
+							return err
+						}
+						tmpl = string(content)
+					}
+
+					entries, err := ListEntries(journal.Dir)
+					if err != nil {
+						return err
+					}
+					rollup, err := BuildRollup(entries, journal.Dir, c.String("period"), date, c.Bool("concat"))
+					if err != nil {
+						return err
+					}
+					out, err := RenderRollup(tmpl, rollup)
+					if err != nil {
+						return err
+					}
+
+					if c.Bool("stdout") {
+						fmt.Print(out)
+						return nil
+					}
+					filename := filepath.Join(journal.Dir, rollup.Title+".md")
+					if _, err := os.Stat(filename); err == nil && !c.Bool("force") {
+						return fmt.Errorf("%s already exists, pass --force to overwrite it", filename)
+					}
+					if err := ioutil.WriteFile(filename, []byte(out), 0644); err != nil {
+						return err
+					}
+					fmt.Printf("Wrote %s, %d of %d days missing\n", filename, len(rollup.Missing), len(rollup.Days))
+					return nil
+				},
+			},
+			{
+				Name:      "search",
+				Aliases:   []string{"s"},
+				Usage:     "Search the entries for a regular expression, matches are grouped by day",
+				ArgsUsage: "pattern",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "since",
+						Usage: "Only search entries from `DATE` on",
+					},
+					cli.StringFlag{
+						Name:  "until",
+						Usage: "Only search entries up to `DATE` included",
+					},
+					cli.BoolFlag{
+						Name:  "ignore-case,i",
+						Usage: "Case insensitive search",
+					},
+					cli.BoolFlag{
+						Name:  "files-with-matches,l",
+						Usage: "Only print the entries with matches",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("search takes a pattern")
+					}
+					expr := c.Args().First()
+					if c.Bool("ignore-case") {
+						expr = "(?i)" + expr
+					}
+					pattern, err := regexp.Compile(expr)
+					if err != nil {
+						return err
+					}
+
+					entries, err := ListEntries(journal.Dir)
+					if err != nil {
+						return err
+					}
+					since, until, err := parseRange(c.String("since"), c.String("until"))
+					if err != nil {
+						return err
+					}
+					results, err := Search(EntriesBetween(entries, since, until), pattern)
+					if err != nil {
+						return err
+					}
+
+					for i, result := range results {
+						if c.Bool("files-with-matches") {
+							fmt.Println(result.Entry.Path)
+							continue
+						}
+						if i > 0 {
+							fmt.Println()
+						}
+						fmt.Printf("%s (%s)\n", getDateMessage(result.Entry.Date), result.Entry.Path)
+						for _, match := range result.Matches {
+							fmt.Printf("%4d: %s\n", match.Line, match.Text)
+						}
+					}
+					if len(results) == 0 {
+						return cli.NewExitError("", 1)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "wc",
+				Usage: "Print the word counts of the entries by month with rolling averages and sparklines",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "since",
+						Usage: "Only count entries from `DATE` on",
+					},
+					cli.StringFlag{
+						Name:  "until",
+						Usage: "Only count entries up to `DATE` included, the rolling averages end there",
+					},
+					cli.IntFlag{
+						Name:  "days",
+						Usage: "Number of days of the rolling averages",
+						Value: 30,
+					},
+					cli.BoolFlag{
+						Name:  "entries,e",
+						Usage: "Print the word count of each entry instead",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.Int("days") < 1 {
+						return fmt.Errorf("Invalid --days %d", c.Int("days"))
+					}
+					entries, err := ListEntries(journal.Dir)
+					if err != nil {
+						return err
+					}
+					since, until, err := parseRange(c.String("since"), c.String("until"))
+					if err != nil {
+						return err
+					}
+					counts, err := CountWords(EntriesBetween(entries, since, until))
+					if err != nil {
+						return err
+					}
+					if c.Bool("entries") {
+						for _, count := range counts {
+							fmt.Printf("%7d %s\n", count.Words, count.Entry.Path)
+						}
+						return nil
+					}
+					end := today()
+					if c.String("until") != "" {
+						end = until
+					}
+					fmt.Println(FormatWordStats(BuildWordStats(counts), end, c.Int("days")))
+					return nil
+				},
+			},
+			{
+				Name:  "remind",
+				Usage: "Send a reminder when the entry of today is still missing in the evening",
+				Description: "Runs as a daemon checking once a day at --at, or checks right away with --once\n" +
+					"   for cron. The destinations are the ntfy, webhook and smtp settings of the day\n" +
+					"   section, e.g. ntfy: my-journal-topic, and the password in smtp-password.",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "at",
+						Usage: "Time of the daily check as `HH:MM`",
+						Value: "20:00",
+					},
+					cli.BoolFlag{
+						Name:  "once",
+						Usage: "Check today now and exit, for cron",
+					},
+					cli.StringFlag{
+						Name:  "quiet-days",
+						Usage: "Comma separated weekdays and dates without reminders, e.g. sat,sun,2024-12-25",
+					},
+					cli.StringFlag{
+						Name:  "ntfy",
+						Usage: "ntfy `TOPIC`, or the URL of a topic on another server",
+					},
+					cli.StringFlag{
+						Name:  "webhook",
+						Usage: "`URL` receiving the reminder as JSON with a title and a text",
+					},
+					cli.StringFlag{
+						Name:  "smtp",
+						Usage: "Mail server as `HOST:PORT` to send the reminder by email",
+					},
+					cli.StringFlag{
+						Name:  "smtp-user",
+						Usage: "User of the mail server",
+					},
+					cli.StringFlag{
+						Name:  "smtp-password",
+						Usage: "Password of the mail server",
+					},
+					cli.StringFlag{
+						Name:  "email-from",
+						Usage: "Sender `ADDRESS` of the email",
+					},
+					cli.StringFlag{
+						Name:  "email-to",
+						Usage: "Comma separated recipient `ADDRESSES` of the email",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := config.Load("day")
+					if err != nil {
+						return err
+					}
+					at, err := ParseClock(cfg.String(c, "at"))
+					if err != nil {
+						return err
+					}
+					quiet, err := ParseQuietDays(cfg.String(c, "quiet-days"))
+					if err != nil {
+						return err
+					}
+					notifier := Notifier{Ntfy: cfg.String(c, "ntfy"), Webhook: cfg.String(c, "webhook")}
+					if addr := cfg.String(c, "smtp"); addr != "" {
+						password, err := cfg.Secret(c, "smtp-password")
+						if err != nil {
+							return err
+						}
+						notifier.Email = &SMTP{
+							Addr:     addr,
+							User:     cfg.String(c, "smtp-user"),
+							Password: password,
+							From:     cfg.String(c, "email-from"),
+						}
+						for _, to := range strings.Split(cfg.String(c, "email-to"), ",") {
+							if to = strings.TrimSpace(to); to != "" {
+								notifier.Email.To = append(notifier.Email.To, to)
+							}
+						}
+					}
+					if !notifier.Configured() {
+						return fmt.Errorf("No reminder destination, set ntfy, webhook or smtp")
+					}
+
+					if !c.Bool("once") {
+						fmt.Printf("Checking %s every day at %s\n", journal.Dir, cfg.String(c, "at"))
+						RemindDaily(notifier, quiet, at)
+					}
+					sent, err := Remind(notifier, quiet, today())
+					if sent && err == nil {
+						fmt.Println("Reminder sent")
+					}
+					return err
+				},
+			},
+			{
+				Name:      "encrypt",
+				Usage:     "Encrypt entries with age to file.md.age, removing the plaintext",
+				ArgsUsage: "file...",
+				Flags: []cli.Flag{
+					cli.StringSliceFlag{
+						Name:  "recipient,r",
+						Usage: "age public key or file of public keys to encrypt to, can be repeated",
+						Value: &cli.StringSlice{},
+					},
+					cli.BoolFlag{
+						Name:  "keep,k",
+						Usage: "Keep the plaintext files",
+					},
+				},
+				BashComplete: func(c *cli.Context) {
+					for _, name := range journalEntries(journal.Dir) {
+						if !strings.HasSuffix(name, EncryptedExt) {
+							fmt.Println(name)
+						}
+					}
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("encrypt takes at least one file")
+					}
+					if len(c.StringSlice("recipient")) == 0 {
+						return fmt.Errorf("encrypt needs at least one --recipient")
+					}
+					for _, file := range c.Args() {
+						file = journal.Path(file)
+						encrypted, err := EncryptFile(file, c.StringSlice("recipient"), c.Bool("keep"))
+						if err != nil {
+							return err
+						}
+						fmt.Printf("Encrypted %s to %s\n", file, encrypted)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "decrypt",
+				Usage:     "Decrypt file.md.age entries back to plaintext, removing the encrypted files",
+				ArgsUsage: "file...",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "keep,k",
+						Usage: "Keep the encrypted files",
+					},
+				},
+				BashComplete: func(c *cli.Context) {
+					for _, name := range journalEntries(journal.Dir) {
+						if strings.HasSuffix(name, EncryptedExt) {
+							fmt.Println(name)
+						}
+					}
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("decrypt takes at least one file")
+					}
+					if identity == "" {
+						return fmt.Errorf("decrypt needs an --identity")
+					}
+					for _, file := range c.Args() {
+						file = journal.Path(file)
+						plain, err := DecryptFile(file, identity, c.Bool("keep"))
+						if err != nil {
+							return err
+						}
+						fmt.Printf("Decrypted %s to %s\n", file, plain)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "publish",
+				Usage:     "Render the entries into a static html site with an index by month and an RSS feed",
+				ArgsUsage: "[dir]",
+				Description: "Writes index.html, a page per month and per entry, style.css and feed.xml to\n" +
+					"   dir, site by default. Links between entries point to their pages and the other\n" +
+					"   files of the journal they link to, such as images, are copied along. Encrypted\n" +
+					"   entries are left out unless --decrypt is given.",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "title",
+						Usage: "Title of the site, defaults to the name of the journal",
+					},
+					cli.StringFlag{
+						Name:  "base-url",
+						Usage: "Absolute `URL` the site is served from, used by the links of the feed",
+					},
+					cli.StringFlag{
+						Name:  "since",
+						Usage: "Only publish entries from `DATE` on",
+					},
+					cli.StringFlag{
+						Name:  "until",
+						Usage: "Only publish entries up to `DATE` included",
+					},
+					cli.IntFlag{
+						Name:  "feed-items",
+						Usage: "Number of the latest entries in the feed",
+						Value: DefaultFeedItems,
+					},
+					cli.BoolFlag{
+						Name:  "decrypt",
+						Usage: "Publish the encrypted entries too, with the --identity",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					out := "site"
+					if c.NArg() > 0 {
+						out = c.Args().First()
+					}
+					opts := PublishOptions{
+						Title:     c.String("title"),
+						BaseURL:   c.String("base-url"),
+						FeedItems: c.Int("feed-items"),
+						Decrypt:   c.Bool("decrypt"),
+					}
+					if opts.Title == "" {
+						opts.Title = "Journal"
+						if journal.Name != "" {
+							opts.Title = journal.Name
+						}
+					}
+					if opts.Decrypt && identity == "" {
+						return fmt.Errorf("--decrypt needs an --identity")
+					}
+
+					entries, err := ListEntries(journal.Dir)
+					if err != nil {
+						return err
+					}
+					since, until, err := parseRange(c.String("since"), c.String("until"))
+					if err != nil {
+						return err
+					}
+					result, err := Publish(EntriesBetween(entries, since, until), journal.Dir, out, opts)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Published %d entries in %d months to %s\n", result.Pages, result.Months, out)
+					if len(result.Assets) > 0 {
+						fmt.Printf("Copied %d linked files\n", len(result.Assets))
+					}
+					if len(result.Skipped) > 0 {
+						errOut.Printf("Skipped %d encrypted entries, pass --decrypt to publish them", len(result.Skipped))
+					}
+					if opts.BaseURL == "" {
+						errOut.Printf("The links of the feed are relative, pass --base-url for feed readers")
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func dayAction(c *cli.Context) error {
+	args := c.Args()
+	if len(args) > 0 {
+		for _, d := range args {
+			day, err := parseDate(d)
+			if err != nil {
+				return err
+			}
+			fmt.Println(getDateMessage(day))
+		}
+	} else {
+		day := today()
+		fmt.Println(getDateMessage(day))
+	}
+	return nil
+}
+
+// journalEntries lists the names of the files of dir named after a date.
+func journalEntries(dir string) []string {
+	entries, _ := ListEntries(dir)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, filepath.Base(entry.Path))
+	}
+	return names
+}
+
+// parseRange parses optional since and until dates, an empty bound is unlimited.
+func parseRange(since, until string) (time.Time, time.Time, error) {
+	start, end := time.Time{}, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	var err error
+	if since != "" {
+		if start, err = parseDate(since); err != nil {
+			return start, end, err
+		}
+	}
+	if until != "" {
+		if end, err = parseDate(until); err != nil {
+			return start, end, err
+		}
+	}
+	return start, end, nil
+}
+
+// today is the current date in location.
+func today() time.Time {
+	now := time.Now().In(location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func getDateMessage(date time.Time) string {
+	return fmt.Sprintf("Day %d: %s", date.YearDay(), date.Format(journal.DateLayout))
+}
+
+// parseDate parses a date in the layout of the journal, or the name of an
+// entry with its .md or .md.age extension. Only those extensions are
+// stripped since the layout can hold dots, e.g. 02.01.2006.
+func parseDate(dateStr string) (time.Time, error) {
+	dateStr = strings.TrimSuffix(strings.TrimSuffix(dateStr, EncryptedExt), ".md")
+	var (
+		date time.Time
+		err  error
+	)
+	date, err = time.Parse(journal.DateLayout, dateStr)
+	if err != nil {
+		return date, err
+	}
+	return date, nil
+}
+
+// commitFile commits file to the git repository of the journal.
+func commitFile(file, message string) error {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	out, err := exec.Command("git", "-C", journal.GitDir(), "add", file).Output()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+
+	out, err = exec.Command("git", "-C", journal.GitDir(), "commit", "-m", message).Output()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package dayofyear
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// EncryptedExt is appended to the entries encrypted with age, e.g. 2024-08-21.md.age.
+const EncryptedExt = ".age"
+
+// identity is the age identity file used to read encrypted entries, it's
+// resolved from the flags, environment and config before any command runs.
+var identity string
+
+func (e Entry) Encrypted() bool {
+	return strings.HasSuffix(e.Path, EncryptedExt)
+}
+
+// ReadEntry returns the content of entry, decrypting it with the identity
+// file when it's encrypted.
+func ReadEntry(entry Entry) ([]byte, error) {
+	if !entry.Encrypted() {
+		return ioutil.ReadFile(entry.Path)
+	}
+	if identity == "" {
+		return nil, fmt.Errorf("%s is encrypted, pass --identity to read it", entry.Path)
+	}
+	return runAge("--decrypt", "--identity", identity, entry.Path)
+}
+
+// EncryptFile encrypts filename to filename.age for recipients, either age
+// public keys or files listing them, and removes the plaintext unless keep is set.
+func EncryptFile(filename string, recipients []string, keep bool) (string, error) {
+	if strings.HasSuffix(filename, EncryptedExt) {
+		return "", fmt.Errorf("%s is already encrypted", filename)
+	}
+	args := []string{"--encrypt", "--output", filename + EncryptedExt}
+	for _, recipient := range recipients {
+		if _, err := os.Stat(recipient); err == nil {
+			args = append(args, "--recipients-file", recipient)
+		} else {
+			args = append(args, "--recipient", recipient)
+		}
+	}
+	if _, err := runAge(append(args, filename)...); err != nil {
+		return "", err
+	}
+	if !keep {
+		return filename + EncryptedExt, os.Remove(filename)
+	}
+	return filename + EncryptedExt, nil
+}
+
+// DecryptFile decrypts filename.age back to filename and removes the
+// encrypted file unless keep is set.
+func DecryptFile(filename, identity string, keep bool) (string, error) {
+	if !strings.HasSuffix(filename, EncryptedExt) {
+		return "", fmt.Errorf("%s is not encrypted", filename)
+	}
+	plain := strings.TrimSuffix(filename, EncryptedExt)
+	if _, err := runAge("--decrypt", "--identity", identity, "--output", plain, filename); err != nil {
+		return "", err
+	}
+	if !keep {
+		return plain, os.Remove(filename)
+	}
+	return plain, nil
+}
+
+func runAge(args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("age"); err != nil {
+		return nil, fmt.Errorf("age is required for encrypted entries, see https://age-encryption.org")
+	}
+	cmd := exec.Command("age", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("age %s: %v %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,133 @@
+package dayofyear
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/config"
+)
+
+// JournalPrefix marks the settings of the named journals in the day section
+// of the config file, e.g. journal.work.dir: ~/journals/work
+const JournalPrefix = "journal."
+
+// Journal is a directory of entries with its own template, git repository
+// and date format. The default journal is the current directory.
+type Journal struct {
+	Name string
+	Dir  string
+	// Template of the new entries, the template setting when empty
+	Template string
+	// Git is the repository the entries are committed to, Dir when empty
+	Git        string
+	DateLayout string
+}
+
+// journal is the journal the subcommands operate on, selected with --journal
+var journal = Journal{Dir: ".", DateLayout: DateLayout}
+
+// LoadJournal reads the settings of the journal name from cfg, the empty
+// name is the default journal.
+func LoadJournal(cfg *config.Config, name string) (Journal, error) {
+	if name == "" {
+		return Journal{Dir: ".", DateLayout: DateLayout}, nil
+	}
+	settings := cfg.Prefixed(JournalPrefix + name + ".")
+	if settings["dir"] == "" {
+		return Journal{}, fmt.Errorf("Unknown journal %s, set %s%s.dir in the day section of %s", name, JournalPrefix, name, config.Path())
+	}
+	j := Journal{
+		Name:       name,
+		Dir:        expandHome(settings["dir"]),
+		Template:   expandHome(settings["template"]),
+		Git:        expandHome(settings["git"]),
+		DateLayout: settings["date-format"],
+	}
+	if j.DateLayout == "" {
+		j.DateLayout = DateLayout
+	}
+	// the entries are files of the journal directory, which isn't walked
+	if strings.ContainsAny(j.DateLayout, `/\`) {
+		return j, fmt.Errorf("The date-format of journal %s, %s, can't hold a path separator", name, j.DateLayout)
+	}
+	if info, err := os.Stat(j.Dir); err != nil {
+		return j, err
+	} else if !info.IsDir() {
+		return j, fmt.Errorf("The dir of journal %s, %s, is not a directory", name, j.Dir)
+	}
+	return j, nil
+}
+
+// EntryPath is the path of the plaintext entry of date.
+func (j Journal) EntryPath(date time.Time) string {
+	return filepath.Join(j.Dir, date.Format(j.DateLayout)+".md")
+}
+
+// Path resolves file relative to the journal directory.
+func (j Journal) Path(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(j.Dir, file)
+}
+
+func (j Journal) GitDir() string {
+	if j.Git != "" {
+		return j.Git
+	}
+	return j.Dir
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// Entry is a journal file named after its date, e.g. 2024-08-21.md.
+type Entry struct {
+	Date time.Time
+	Path string
+}
+
+// ListEntries returns the entries of dir sorted by date.
+func ListEntries(dir string) ([]Entry, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		date, err := parseDate(file.Name())
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{Date: date, Path: filepath.Join(dir, file.Name())})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Date.Before(entries[j].Date) })
+	return entries, nil
+}
+
+// EntriesBetween filters entries to the ones from start to end included.
+func EntriesBetween(entries []Entry, start, end time.Time) []Entry {
+	filtered := []Entry{}
+	for _, entry := range entries {
+		if !entry.Date.Before(start) && !entry.Date.After(end) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
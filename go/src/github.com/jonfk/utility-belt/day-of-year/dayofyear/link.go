@@ -0,0 +1,82 @@
+package dayofyear
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// NavMarker starts the navigation links of an entry, everything after it is
+// replaced when the links are updated.
+const NavMarker = "<!-- day-of-year: navigation -->"
+
+// NavLinks returns the navigation line linking prev and next, either may be
+// nil at the ends of the journal.
+func NavLinks(prev, next *Entry) string {
+	parts := []string{}
+	if prev != nil {
+		parts = append(parts, fmt.Sprintf("[← %s](%s)", prev.Date.Format(journal.DateLayout), navHref(*prev)))
+	}
+	if next != nil {
+		parts = append(parts, fmt.Sprintf("[%s →](%s)", next.Date.Format(journal.DateLayout), navHref(*next)))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func navHref(entry Entry) string {
+	return (&url.URL{Path: filepath.Base(entry.Path)}).String()
+}
+
+// WithNavigation returns content with its navigation block set to links,
+// appending the block when it has none.
+func WithNavigation(content, links string) string {
+	if i := strings.Index(content, NavMarker); i >= 0 {
+		content = content[:i]
+	}
+	content = strings.TrimRight(content, "\n")
+	if links == "" {
+		return content + "\n"
+	}
+	return content + "\n\n" + NavMarker + "\n" + links + "\n"
+}
+
+// LinkEntries updates the navigation of the markdown entries, each linking to
+// the previous and next existing entry so gaps are skipped. Encrypted entries
+// are left out. It returns the paths of the entries which changed.
+func LinkEntries(entries []Entry, dryRun bool) ([]string, error) {
+	markdown := []Entry{}
+	for _, entry := range entries {
+		if !entry.Encrypted() && filepath.Ext(entry.Path) == ".md" {
+			markdown = append(markdown, entry)
+		}
+	}
+
+	changed := []string{}
+	for i, entry := range markdown {
+		var prev, next *Entry
+		if i > 0 {
+			prev = &markdown[i-1]
+		}
+		if i+1 < len(markdown) {
+			next = &markdown[i+1]
+		}
+		content, err := ioutil.ReadFile(entry.Path)
+		if err != nil {
+			return changed, err
+		}
+		updated := WithNavigation(string(content), NavLinks(prev, next))
+		if updated == string(content) {
+			continue
+		}
+		changed = append(changed, entry.Path)
+		if dryRun {
+			continue
+		}
+		if err := ioutil.WriteFile(entry.Path, []byte(updated), 0644); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
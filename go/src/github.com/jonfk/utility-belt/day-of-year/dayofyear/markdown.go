@@ -0,0 +1,381 @@
+package dayofyear
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// markdown renders the subset of markdown journals are written in to html:
+// headings, paragraphs, nested and task lists, block quotes, fenced code,
+// rules, emphasis, code spans, links and images. Raw html is escaped.
+type markdown struct {
+	// link rewrites the targets of the links and images, e.g. to point at the
+	// pages of other entries
+	link func(href string) string
+}
+
+var (
+	headingPattern  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	listPattern     = regexp.MustCompile(`^( {0,3})([-*+]|(\d{1,9})[.)])( +|$)`)
+	autolinkPattern = regexp.MustCompile(`^<((?:https?://|mailto:)[^ <>]+)>`)
+)
+
+// Render converts text to html.
+func (m markdown) Render(text string) string {
+	var buf bytes.Buffer
+	m.blocks(&buf, strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n"), false)
+	return buf.String()
+}
+
+// blocks renders lines, tight is set for the items of lists without blank
+// lines whose paragraphs aren't wrapped in <p>.
+func (m markdown) blocks(buf *bytes.Buffer, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "":
+			i++
+		case isFence(trimmed):
+			fence := trimmed[:3]
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), fence) {
+				end++
+			}
+			lang := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			if lang != "" {
+				fmt.Fprintf(buf, `<pre><code class="language-%s">`, html.EscapeString(strings.Fields(lang)[0]))
+			} else {
+				buf.WriteString("<pre><code>")
+			}
+			if end > i+1 {
+				buf.WriteString(html.EscapeString(strings.Join(lines[i+1:end], "\n")) + "\n")
+			}
+			buf.WriteString("</code></pre>\n")
+			i = end + 1
+		case headingPattern.MatchString(lines[i]):
+			match := headingPattern.FindStringSubmatch(lines[i])
+			fmt.Fprintf(buf, "<h%d>%s</h%d>\n", len(match[1]), m.inline(match[2]), len(match[1]))
+			i++
+		case isRule(trimmed):
+			buf.WriteString("<hr>\n")
+			i++
+		case strings.HasPrefix(trimmed, ">"):
+			quoted := []string{}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				line := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(line, " "))
+			}
+			buf.WriteString("<blockquote>\n")
+			m.blocks(buf, quoted, false)
+			buf.WriteString("</blockquote>\n")
+		case listPattern.MatchString(lines[i]):
+			i = m.list(buf, lines, i)
+		default:
+			end := i + 1
+			for end < len(lines) && strings.TrimSpace(lines[end]) != "" && !startsBlock(lines[end]) {
+				end++
+			}
+			paragraph := make([]string, 0, end-i)
+			for _, line := range lines[i:end] {
+				// two trailing spaces are a hard line break
+				if strings.HasSuffix(line, "  ") {
+					line = strings.TrimRight(line, " ") + `\`
+				}
+				paragraph = append(paragraph, strings.TrimSpace(line))
+			}
+			text := strings.TrimSuffix(strings.Join(paragraph, "\n"), `\`)
+			if tight {
+				buf.WriteString(m.inline(text) + "\n")
+			} else {
+				buf.WriteString("<p>" + m.inline(text) + "</p>\n")
+			}
+			i = end
+		}
+	}
+}
+
+func isFence(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// isRule matches ---, *** and ___, spaces allowed between the characters.
+func isRule(trimmed string) bool {
+	compact := strings.Replace(trimmed, " ", "", -1)
+	if len(compact) < 3 {
+		return false
+	}
+	return strings.Trim(compact, compact[:1]) == "" && strings.ContainsAny(compact[:1], "-*_")
+}
+
+// startsBlock reports whether line interrupts a paragraph.
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return isFence(trimmed) || headingPattern.MatchString(line) || isRule(trimmed) ||
+		strings.HasPrefix(trimmed, ">") || listPattern.MatchString(line)
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// list renders the list starting at lines[start] and returns the index of the
+// line following it. The lines indented past the marker of an item belong to
+// it, which nests the lists.
+func (m markdown) list(buf *bytes.Buffer, lines []string, start int) int {
+	first := listPattern.FindStringSubmatch(lines[start])
+	ordered := first[3] != ""
+	indent := len(first[1])
+	sameList := func(match []string) bool {
+		return match != nil && len(match[1]) == indent && (match[3] != "") == ordered &&
+			match[2][len(match[2])-1:] == first[2][len(first[2])-1:]
+	}
+
+	items := [][]string{}
+	contentIndent := 0
+	loose := false
+	i := start
+	for i < len(lines) {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			next := i
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next == len(lines) || !(sameList(listPattern.FindStringSubmatch(lines[next])) || leadingSpaces(lines[next]) >= contentIndent) {
+				break
+			}
+			loose = loose || sameList(listPattern.FindStringSubmatch(lines[next]))
+			items[len(items)-1] = append(items[len(items)-1], "")
+			i = next
+			continue
+		}
+		if match := listPattern.FindStringSubmatch(line); sameList(match) {
+			contentIndent = len(match[0])
+			if strings.TrimSpace(match[4]) == "" && len(match[4]) > 4 {
+				// content indented as code keeps its spaces past one
+				contentIndent = len(match[0]) - len(match[4]) + 1
+			}
+			items = append(items, []string{line[contentIndent:]})
+		} else if leadingSpaces(line) >= contentIndent {
+			items[len(items)-1] = append(items[len(items)-1], line[contentIndent:])
+		} else if item := items[len(items)-1]; !startsBlock(line) && strings.TrimSpace(item[len(item)-1]) != "" {
+			// lazy continuation of the paragraph of the item
+			items[len(items)-1] = append(item, strings.TrimSpace(line))
+		} else {
+			break
+		}
+		i++
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	if n, _ := strconv.Atoi(first[3]); ordered && n != 1 {
+		fmt.Fprintf(buf, "<ol start=\"%d\">\n", n)
+	} else {
+		buf.WriteString("<" + tag + ">\n")
+	}
+	for _, item := range items {
+		buf.WriteString("<li>")
+		if checked, ok := taskMarker(item[0]); ok {
+			if checked {
+				buf.WriteString(`<input type="checkbox" checked disabled> `)
+			} else {
+				buf.WriteString(`<input type="checkbox" disabled> `)
+			}
+			item[0] = item[0][4:]
+		}
+		var inner bytes.Buffer
+		m.blocks(&inner, item, !loose)
+		buf.WriteString(strings.TrimSuffix(inner.String(), "\n") + "</li>\n")
+	}
+	buf.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// taskMarker matches the [ ] and [x] starting the items of task lists.
+func taskMarker(text string) (bool, bool) {
+	if len(text) < 4 || text[0] != '[' || text[2] != ']' || text[3] != ' ' {
+		return false, false
+	}
+	switch text[1] {
+	case ' ':
+		return false, true
+	case 'x', 'X':
+		return true, true
+	}
+	return false, false
+}
+
+const escapable = "\\`*_{}[]()#+-.!~<>|"
+
+// inline renders the spans of text: code, emphasis, strikethrough, links,
+// images, autolinks and backslash escapes.
+func (m markdown) inline(text string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			buf.WriteString("<br>\n")
+			i += 2
+		case c == '\\' && i+1 < len(text) && strings.IndexByte(escapable, text[i+1]) >= 0:
+			buf.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+		case c == '`':
+			ticks := delimiterRun(text, i)
+			end := strings.Index(text[i+len(ticks):], ticks)
+			if end < 0 {
+				buf.WriteString(ticks)
+				i += len(ticks)
+				break
+			}
+			code := text[i+len(ticks) : i+len(ticks)+end]
+			buf.WriteString("<code>" + html.EscapeString(strings.TrimSpace(code)) + "</code>")
+			i += 2*len(ticks) + end
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			label, href, end, ok := parseLink(text, i+1)
+			if !ok {
+				buf.WriteString("!")
+				i++
+				break
+			}
+			fmt.Fprintf(&buf, `<img src="%s" alt="%s">`, html.EscapeString(m.rewrite(href)), html.EscapeString(label))
+			i = end
+		case c == '[':
+			label, href, end, ok := parseLink(text, i)
+			if !ok {
+				buf.WriteString("[")
+				i++
+				break
+			}
+			fmt.Fprintf(&buf, `<a href="%s">%s</a>`, html.EscapeString(m.rewrite(href)), m.inline(label))
+			i = end
+		case c == '<' && autolinkPattern.MatchString(text[i:]):
+			link := autolinkPattern.FindStringSubmatch(text[i:])
+			fmt.Fprintf(&buf, `<a href="%s">%s</a>`, html.EscapeString(link[1]), html.EscapeString(strings.TrimPrefix(link[1], "mailto:")))
+			i += len(link[0])
+		case c == '*' || c == '_' || c == '~':
+			delimiter := delimiterRun(text, i)
+			if len(delimiter) > 3 {
+				delimiter = delimiter[:3]
+			}
+			end := closingDelimiter(text, i, delimiter)
+			if end < 0 || (c == '~' && len(delimiter) != 2) {
+				buf.WriteString(delimiter)
+				i += len(delimiter)
+				break
+			}
+			inner := m.inline(text[i+len(delimiter) : end])
+			switch {
+			case c == '~':
+				buf.WriteString("<del>" + inner + "</del>")
+			case len(delimiter) == 1:
+				buf.WriteString("<em>" + inner + "</em>")
+			case len(delimiter) == 2:
+				buf.WriteString("<strong>" + inner + "</strong>")
+			default:
+				buf.WriteString("<em><strong>" + inner + "</strong></em>")
+			}
+			i = end + len(delimiter)
+		default:
+			buf.WriteString(html.EscapeString(text[i : i+1]))
+			i++
+		}
+	}
+	return buf.String()
+}
+
+func (m markdown) rewrite(href string) string {
+	if m.link == nil {
+		return href
+	}
+	return m.link(href)
+}
+
+// delimiterRun returns the run of the character at text[i].
+func delimiterRun(text string, i int) string {
+	end := i
+	for end < len(text) && text[end] == text[i] {
+		end++
+	}
+	return text[i:end]
+}
+
+// closingDelimiter returns the index of the delimiter closing the one at
+// text[start], -1 when there is none. Delimiters must hug the text they
+// emphasize and underscores must be at word boundaries, as in snake_case.
+func closingDelimiter(text string, start int, delimiter string) int {
+	open := start + len(delimiter)
+	if open >= len(text) || text[open] == ' ' || text[open] == '\n' {
+		return -1
+	}
+	if delimiter[0] == '_' && start > 0 && isWordByte(text[start-1]) {
+		return -1
+	}
+	for i := open + 1; i+len(delimiter) <= len(text); i++ {
+		if text[i] == '`' {
+			// emphasis doesn't close inside code spans
+			ticks := delimiterRun(text, i)
+			if end := strings.Index(text[i+len(ticks):], ticks); end >= 0 {
+				i += 2*len(ticks) + end - 1
+			}
+			continue
+		}
+		if !strings.HasPrefix(text[i:], delimiter) || text[i-1] == ' ' || text[i-1] == '\n' {
+			continue
+		}
+		after := i + len(delimiter)
+		if after < len(text) && text[after] == delimiter[0] {
+			// part of a longer run, e.g. the ** closing *a **b***
+			continue
+		}
+		if delimiter[0] == '_' && after < len(text) && isWordByte(text[after]) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// parseLink parses [label](href "title") at text[start], returning the index
+// following it.
+func parseLink(text string, start int) (string, string, int, bool) {
+	depth := 0
+	close := -1
+	for i := start; i < len(text) && close < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				close = i
+			}
+		}
+	}
+	if close < 0 || close+1 >= len(text) || text[close+1] != '(' {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(text[close+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	target := strings.TrimSpace(text[close+2 : close+2+end])
+	if fields := strings.Fields(target); len(fields) > 0 {
+		target = fields[0]
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	return text[start+1 : close], target, close + 3 + end, true
+}
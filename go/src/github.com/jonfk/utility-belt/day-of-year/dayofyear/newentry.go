@@ -0,0 +1,85 @@
+package dayofyear
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// DefaultEntryTemplate is used for new entries when no --template is given.
+const DefaultEntryTemplate = `# {{.Message}}
+
+`
+
+// VarPrefix marks the template variables in the day section of the config
+// file, e.g. var.weather: curl -s 'wttr.in?format=3'
+const VarPrefix = "var."
+
+type EntryData struct {
+	Date    time.Time
+	Message string
+	// Vars holds the output of the variable commands, e.g. {{.Vars.weather}}
+	Vars map[string]string
+}
+
+// ResolveVars runs the shell commands of vars concurrently, a command which
+// fails or runs longer than timeout resolves to placeholder.
+func ResolveVars(commands map[string]string, timeout time.Duration, placeholder string) map[string]string {
+	vars := map[string]string{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, command := range commands {
+		wg.Add(1)
+		go func(name, command string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			var stderr bytes.Buffer
+			cmd := exec.CommandContext(ctx, "sh", "-c", command)
+			cmd.Stderr = &stderr
+			// children of the shell may hold its output open after it is killed
+			cmd.WaitDelay = 100 * time.Millisecond
+			out, err := cmd.Output()
+
+			value := strings.TrimSpace(string(out))
+			if ctx.Err() == context.DeadlineExceeded {
+				errOut.Printf("var %s: timed out after %s", name, timeout)
+				value = placeholder
+			} else if err != nil {
+				errOut.Printf("var %s: %v %s", name, err, strings.TrimSpace(stderr.String()))
+				value = placeholder
+			}
+			mu.Lock()
+			vars[name] = value
+			mu.Unlock()
+		}(name, command)
+	}
+	wg.Wait()
+	return vars
+}
+
+// RenderEntry renders the template of a new entry, variables missing from
+// data.Vars render as placeholder.
+func RenderEntry(text string, data EntryData, placeholder string) (string, error) {
+	funcs := template.FuncMap{
+		"var": func(name string) string {
+			if value, ok := data.Vars[name]; ok {
+				return value
+			}
+			return placeholder
+		},
+	}
+	tmpl, err := template.New("entry").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
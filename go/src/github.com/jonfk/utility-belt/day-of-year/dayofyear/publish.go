@@ -0,0 +1,377 @@
+package dayofyear
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultFeedItems is the number of entries of the feed when --feed-items isn't given.
+const DefaultFeedItems = 20
+
+// PublishOptions configure the static site written by Publish.
+type PublishOptions struct {
+	Title string
+	// BaseURL is the absolute URL the site is served from, the links of the
+	// feed are relative without it
+	BaseURL string
+	// FeedItems is the number of the latest entries in feed.xml
+	FeedItems int
+	// Decrypt publishes the encrypted entries, which are skipped otherwise
+	Decrypt bool
+}
+
+// PublishResult reports what Publish wrote.
+type PublishResult struct {
+	Pages  int
+	Months int
+	// Skipped are the encrypted entries left out
+	Skipped []Entry
+	// Assets are the files linked from the entries copied along, relative
+	// to the journal directory
+	Assets []string
+}
+
+type sitePage struct {
+	Date    time.Time
+	Message string
+	// Href is relative to the root of the site, e.g. 2024-08/2024-08-21.html
+	Href    string
+	Excerpt string
+	Content template.HTML
+	Prev    *sitePage
+	Next    *sitePage
+}
+
+type siteMonth struct {
+	Name  string
+	Title string
+	Pages []*sitePage
+}
+
+type siteData struct {
+	Title string
+	// Root is the relative path to the root of the site from the page
+	Root   string
+	Months []*siteMonth
+	Month  *siteMonth
+	Page   *sitePage
+}
+
+// Publish renders the markdown entries of the journal dir into a static site
+// in out: an index of the months, a page listing the entries of each month,
+// a page per entry linking the previous and next ones, and an RSS feed. The
+// links between entries point to their pages and the other files of the
+// journal they link to are copied along.
+func Publish(entries []Entry, dir, out string, opts PublishOptions) (PublishResult, error) {
+	result := PublishResult{}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return result, err
+	}
+	absOut, err := filepath.Abs(out)
+	if err != nil {
+		return result, err
+	}
+	if absDir == absOut {
+		return result, fmt.Errorf("The site can't be written to the journal directory")
+	}
+
+	published := []Entry{}
+	hrefs := map[string]string{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(filepath.Base(entry.Path), EncryptedExt)
+		if filepath.Ext(name) != ".md" {
+			continue
+		}
+		if entry.Encrypted() && !opts.Decrypt {
+			result.Skipped = append(result.Skipped, entry)
+			continue
+		}
+		published = append(published, entry)
+		hrefs[filepath.Clean(entry.Path)] = pageHref(entry.Date)
+	}
+
+	assets := map[string]bool{}
+	pages := []*sitePage{}
+	months := []*siteMonth{}
+	for _, entry := range published {
+		content, err := ReadEntry(entry)
+		if err != nil {
+			return result, err
+		}
+		text := string(content)
+		if i := strings.Index(text, NavMarker); i >= 0 {
+			text = text[:i]
+		}
+		md := markdown{link: func(href string) string {
+			return publishedLink(href, entry, dir, hrefs, assets)
+		}}
+		rendered := md.Render(text)
+		page := &sitePage{
+			Date:    entry.Date,
+			Message: getDateMessage(entry.Date),
+			Href:    pageHref(entry.Date),
+			Excerpt: excerpt(rendered, 30),
+			Content: template.HTML(rendered),
+		}
+		if len(pages) > 0 {
+			page.Prev = pages[len(pages)-1]
+			page.Prev.Next = page
+		}
+		pages = append(pages, page)
+
+		name := entry.Date.Format("2006-01")
+		if len(months) == 0 || months[len(months)-1].Name != name {
+			months = append(months, &siteMonth{Name: name, Title: entry.Date.Format("January 2006")})
+		}
+		month := months[len(months)-1]
+		month.Pages = append(month.Pages, page)
+	}
+	// the latest months are listed first
+	for i, j := 0, len(months)-1; i < j; i, j = i+1, j-1 {
+		months[i], months[j] = months[j], months[i]
+	}
+
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return result, err
+	}
+	write := func(name string, data siteData) error {
+		var buf bytes.Buffer
+		if err := siteTemplate.Execute(&buf, data); err != nil {
+			return err
+		}
+		filename := filepath.Join(out, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+	}
+	if err := write("index.html", siteData{Title: opts.Title, Root: "", Months: months}); err != nil {
+		return result, err
+	}
+	for _, month := range months {
+		if err := write(month.Name+"/index.html", siteData{Title: opts.Title, Root: "../", Month: month}); err != nil {
+			return result, err
+		}
+		for _, page := range month.Pages {
+			if err := write(page.Href, siteData{Title: opts.Title, Root: "../", Month: month, Page: page}); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(out, "style.css"), []byte(siteStyle), 0644); err != nil {
+		return result, err
+	}
+	if err := writeFeed(filepath.Join(out, "feed.xml"), pages, opts); err != nil {
+		return result, err
+	}
+
+	for asset := range assets {
+		if err := copyFile(filepath.Join(dir, asset), filepath.Join(out, asset)); err != nil {
+			return result, err
+		}
+		result.Assets = append(result.Assets, asset)
+	}
+	result.Pages, result.Months = len(pages), len(months)
+	return result, nil
+}
+
+// pageHref is the path of the page of the entry of date from the root of the
+// site, in the default date layout whatever the layout of the journal.
+func pageHref(date time.Time) string {
+	return date.Format("2006-01") + "/" + date.Format(DateLayout) + ".html"
+}
+
+// publishedLink rewrites the relative link href of entry: links to the
+// published entries point to their pages and links to the other files of the
+// journal are recorded in assets to be copied.
+func publishedLink(href string, entry Entry, dir string, hrefs map[string]string, assets map[string]bool) string {
+	u, err := url.Parse(href)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+		return href
+	}
+	target := filepath.Join(filepath.Dir(entry.Path), filepath.FromSlash(u.Path))
+	suffix := ""
+	if u.Fragment != "" {
+		suffix = "#" + u.Fragment
+	}
+	if page, ok := hrefs[filepath.Clean(target)]; ok {
+		return "../" + page + suffix
+	}
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return href
+	}
+	if info, err := os.Stat(target); err != nil || info.IsDir() || strings.HasSuffix(target, EncryptedExt) {
+		return href
+	}
+	assets[rel] = true
+	return "../" + (&url.URL{Path: filepath.ToSlash(rel)}).String() + suffix
+}
+
+var (
+	skippedElements = regexp.MustCompile(`(?s)<h\d>.*?</h\d>|<pre>.*?</pre>`)
+	htmlTags        = regexp.MustCompile(`<[^>]*>`)
+)
+
+// excerpt returns the first words of the rendered entry, without its
+// headings, code blocks and markup.
+func excerpt(rendered string, words int) string {
+	text := htmlTags.ReplaceAllString(skippedElements.ReplaceAllString(rendered, " "), " ")
+	fields := strings.Fields(html.UnescapeString(text))
+	if len(fields) > words {
+		return strings.Join(fields[:words], " ") + "…"
+	}
+	return strings.Join(fields, " ")
+}
+
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+}
+
+type rssFeed struct {
+	XMLName       xml.Name  `xml:"rss"`
+	Version       string    `xml:"version,attr"`
+	Title         string    `xml:"channel>title"`
+	Link          string    `xml:"channel>link"`
+	Description   string    `xml:"channel>description"`
+	LastBuildDate string    `xml:"channel>lastBuildDate"`
+	Items         []rssItem `xml:"channel>item"`
+}
+
+// writeFeed writes the RSS 2.0 feed of the latest pages.
+func writeFeed(filename string, pages []*sitePage, opts PublishOptions) error {
+	base := strings.TrimSuffix(opts.BaseURL, "/")
+	feed := rssFeed{
+		Version:       "2.0",
+		Title:         opts.Title,
+		Link:          base + "/",
+		Description:   opts.Title,
+		LastBuildDate: time.Now().Format(time.RFC1123Z),
+	}
+	for i := len(pages) - 1; i >= 0 && len(feed.Items) < opts.FeedItems; i-- {
+		link := base + "/" + pages[i].Href
+		feed.Items = append(feed.Items, rssItem{
+			Title:       pages[i].Message,
+			Link:        link,
+			GUID:        link,
+			PubDate:     pages[i].Date.Format(time.RFC1123Z),
+			Description: string(pages[i].Content),
+		})
+	}
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+var siteTemplate = template.Must(template.New("site").Funcs(template.FuncMap{
+	"base": path.Base,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{with .Page}}{{.Message}} · {{else}}{{with .Month}}{{.Title}} · {{end}}{{end}}{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+<link rel="alternate" type="application/rss+xml" title="{{.Title}}" href="{{.Root}}feed.xml">
+</head>
+<body>
+<header><a href="{{.Root}}index.html">{{.Title}}</a>{{with .Month}} › <a href="index.html">{{.Title}}</a>{{end}}</header>
+<main>
+{{- if .Page}}{{with .Page}}
+<h1>{{.Message}}</h1>
+<article>
+{{.Content}}</article>
+<nav>
+{{- with .Prev}}<a href="{{$.Root}}{{.Href}}">← {{.Message}}</a>{{end}}
+{{- with .Next}}<a class="next" href="{{$.Root}}{{.Href}}">{{.Message}} →</a>{{end}}
+</nav>
+{{- end}}
+{{- else if .Month}}{{with .Month}}
+<h1>{{.Title}}</h1>
+<ul class="entries">
+{{- range .Pages}}
+<li><a href="{{base .Href}}">{{.Message}}</a><p>{{.Excerpt}}</p></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- else}}
+<h1>{{.Title}}</h1>
+<ul class="months">
+{{- range .Months}}
+<li><a href="{{.Name}}/index.html">{{.Title}}</a> <span>{{len .Pages}} {{if eq (len .Pages) 1}}entry{{else}}entries{{end}}</span></li>
+{{- else}}
+<li>No entries yet</li>
+{{- end}}
+</ul>
+{{- end}}
+</main>
+</body>
+</html>
+`))
+
+const siteStyle = `body {
+  max-width: 42rem;
+  margin: 0 auto;
+  padding: 1rem;
+  font: 1.05rem/1.6 Georgia, serif;
+  color: #222;
+  background: #fdfdfb;
+}
+header { font-family: sans-serif; font-size: .9rem; margin-bottom: 2rem; }
+a { color: #2a5db0; }
+h1 { line-height: 1.2; }
+pre { overflow-x: auto; padding: .75rem; background: #f3f3f0; }
+code { font-size: .9em; }
+blockquote { margin-left: 0; padding-left: 1rem; border-left: 3px solid #ddd; color: #555; }
+img { max-width: 100%; }
+nav { display: flex; margin-top: 3rem; font-family: sans-serif; font-size: .9rem; }
+nav .next { margin-left: auto; }
+ul.entries, ul.months { list-style: none; padding: 0; }
+ul.entries p { margin-top: .25rem; color: #555; }
+ul.months span { color: #777; }
+@media (prefers-color-scheme: dark) {
+  body { color: #ddd; background: #1b1b1b; }
+  a { color: #8ab4f8; }
+  pre { background: #262626; }
+  blockquote, ul.entries p, ul.months span { color: #aaa; }
+}
+`
@@ -0,0 +1,228 @@
+package dayofyear
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/httpx"
+)
+
+// DefaultNtfyServer is used for the ntfy settings which are a bare topic.
+const DefaultNtfyServer = "https://ntfy.sh/"
+
+// RemindPoll is how often the remind daemon checks the time, short enough to
+// notice a wake from sleep past the reminder time.
+const RemindPoll = time.Minute
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// QuietDays are the days without reminders.
+type QuietDays struct {
+	Weekdays map[time.Weekday]bool
+	Dates    map[string]bool
+}
+
+// ParseQuietDays parses a comma separated list of weekdays, e.g. sat or
+// saturday, and dates, e.g. 2024-12-25.
+func ParseQuietDays(s string) (QuietDays, error) {
+	quiet := QuietDays{Weekdays: map[time.Weekday]bool{}, Dates: map[string]bool{}}
+	for _, day := range strings.Split(s, ",") {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if day == "" {
+			continue
+		}
+		if len(day) >= 3 {
+			if weekday, ok := weekdays[day[:3]]; ok && strings.HasPrefix(strings.ToLower(weekday.String()), day) {
+				quiet.Weekdays[weekday] = true
+				continue
+			}
+		}
+		date, err := time.Parse(DateLayout, day)
+		if err != nil {
+			return quiet, fmt.Errorf("Invalid quiet day %s, expected a weekday or a date", day)
+		}
+		quiet.Dates[date.Format(DateLayout)] = true
+	}
+	return quiet, nil
+}
+
+func (q QuietDays) Quiet(date time.Time) bool {
+	return q.Weekdays[date.Weekday()] || q.Dates[date.Format(DateLayout)]
+}
+
+// ParseClock parses a time of day as 15:04, returning the offset from midnight.
+func ParseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid time %s, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// HasEntry reports whether entries holds the entry of date, plain or encrypted.
+func HasEntry(entries []Entry, date time.Time) bool {
+	for _, entry := range entries {
+		if entry.Date.Equal(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReminderMessage tells that the entry of date is missing along with the
+// number of entries written in the week before.
+func ReminderMessage(entries []Entry, date time.Time) string {
+	written := len(EntriesBetween(entries, date.AddDate(0, 0, -7), date.AddDate(0, 0, -1)))
+	return fmt.Sprintf("No journal entry for %s yet, %d of the last 7 days written", date.Format(journal.DateLayout), written)
+}
+
+// SMTP is the mail server reminders are sent through.
+type SMTP struct {
+	// Addr is host:port
+	Addr     string
+	User     string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notifier sends reminders to every configured destination.
+type Notifier struct {
+	// Ntfy is a topic of DefaultNtfyServer or the URL of a topic
+	Ntfy string
+	// Webhook receives a JSON object with the title and the text
+	Webhook string
+	Email   *SMTP
+	Client  *http.Client
+}
+
+func (n Notifier) Configured() bool {
+	return n.Ntfy != "" || n.Webhook != "" || n.Email != nil
+}
+
+// Send sends the reminder to all destinations, trying them all before
+// reporting the failures.
+func (n Notifier) Send(title, text string) error {
+	failures := []string{}
+	if n.Ntfy != "" {
+		topic := n.Ntfy
+		if !strings.Contains(topic, "://") {
+			topic = DefaultNtfyServer + topic
+		}
+		req, err := http.NewRequest("POST", topic, strings.NewReader(text))
+		if err == nil {
+			req.Header.Set("Title", title)
+			req.Header.Set("Tags", "pencil")
+			err = n.post(req)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("ntfy: %v", err))
+		}
+	}
+	if n.Webhook != "" {
+		body, _ := json.Marshal(map[string]string{"title": title, "text": text})
+		req, err := http.NewRequest("POST", n.Webhook, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			err = n.post(req)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("webhook: %v", err))
+		}
+	}
+	if n.Email != nil {
+		if err := n.Email.send(title, text); err != nil {
+			failures = append(failures, fmt.Sprintf("email: %v", err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("Could not send the reminder, %s", strings.Join(failures, ", "))
+	}
+	return nil
+}
+
+func (n Notifier) post(req *http.Request) error {
+	client := n.Client
+	if client == nil {
+		var err error
+		if client, err = httpx.NewClient(httpx.DefaultOptions); err != nil {
+			return err
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+func (s SMTP) send(subject, text string) error {
+	if s.From == "" || len(s.To) == 0 {
+		return fmt.Errorf("Sending email requires a sender and recipients")
+	}
+	var auth smtp.Auth
+	if s.User != "" {
+		host := s.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", s.User, s.Password, host)
+	}
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		s.From, strings.Join(s.To, ", "), subject, text)
+	return smtp.SendMail(s.Addr, auth, s.From, s.To, []byte(message))
+}
+
+// Remind checks the entry of date, sending a reminder when it is missing on
+// a day which isn't quiet. It returns whether a reminder was sent.
+func Remind(notifier Notifier, quiet QuietDays, date time.Time) (bool, error) {
+	if quiet.Quiet(date) {
+		return false, nil
+	}
+	entries, err := ListEntries(journal.Dir)
+	if err != nil {
+		return false, err
+	}
+	if HasEntry(entries, date) {
+		return false, nil
+	}
+	title := "Journal"
+	if journal.Name != "" {
+		title += " " + journal.Name
+	}
+	return true, notifier.Send(title, ReminderMessage(entries, date))
+}
+
+// RemindDaily runs Remind once a day at the offset at from midnight, or as
+// soon as possible once past it, until the process is stopped.
+func RemindDaily(notifier Notifier, quiet QuietDays, at time.Duration) {
+	var checked time.Time
+	for {
+		date := today()
+		now := time.Now().In(location)
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+		if !date.Equal(checked) && !now.Before(midnight.Add(at)) {
+			checked = date
+			sent, err := Remind(notifier, quiet, date)
+			switch {
+			case err != nil:
+				errOut.Println(err)
+			case sent:
+				fmt.Printf("%s reminded about %s\n", now.Format("15:04"), date.Format(journal.DateLayout))
+			}
+		}
+		time.Sleep(RemindPoll)
+	}
+}
@@ -0,0 +1,112 @@
+package dayofyear
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	WeekPeriod  = "week"
+	MonthPeriod = "month"
+)
+
+// DefaultRollupTemplate is used when no --template is given.
+const DefaultRollupTemplate = `# {{.Title}}
+
+{{range .Days}}- [{{if .Path}}x{{else}} {{end}}] {{if .Path}}[{{.Message}}]({{.Link}}){{else}}{{.Message}}{{end}}
+{{end}}{{if .Missing}}
+Missing {{len .Missing}} of {{len .Days}} days.
+{{end}}{{range .Days}}{{if .Content}}
+## {{.Message}}
+
+{{.Content}}
+{{end}}{{end}}`
+
+type RollupDay struct {
+	Date    time.Time
+	Message string
+	// Path and Link are empty when there is no entry for the day
+	Path    string
+	Link    string
+	Content string
+}
+
+type Rollup struct {
+	Title   string
+	Period  string
+	Start   time.Time
+	End     time.Time
+	Days    []RollupDay
+	Missing []RollupDay
+}
+
+// PeriodBounds returns the name and first and last days of the week or month
+// containing date, e.g. 2024-W34 or 2024-08. Weeks are ISO weeks starting on Monday.
+func PeriodBounds(period string, date time.Time) (string, time.Time, time.Time, error) {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case WeekPeriod:
+		year, week := date.ISOWeek()
+		start := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+		return fmt.Sprintf("%d-W%02d", year, week), start, start.AddDate(0, 0, 6), nil
+	case MonthPeriod:
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01"), start, start.AddDate(0, 1, -1), nil
+	default:
+		return "", time.Time{}, time.Time{}, fmt.Errorf("Unknown period %q, expected week or month", period)
+	}
+}
+
+// BuildRollup gathers the entries of the period of date found in entries,
+// relative links are computed from dir. The content of the entries is
+// included when concat is set.
+func BuildRollup(entries []Entry, dir, period string, date time.Time, concat bool) (Rollup, error) {
+	name, start, end, err := PeriodBounds(period, date)
+	if err != nil {
+		return Rollup{}, err
+	}
+	byDate := map[string]Entry{}
+	for _, entry := range EntriesBetween(entries, start, end) {
+		byDate[entry.Date.Format(DateLayout)] = entry
+	}
+
+	rollup := Rollup{Title: name, Period: period, Start: start, End: end}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		rollupDay := RollupDay{Date: day, Message: getDateMessage(day)}
+		if entry, ok := byDate[day.Format(DateLayout)]; ok {
+			rollupDay.Path = entry.Path
+			if rollupDay.Link, err = filepath.Rel(dir, entry.Path); err != nil {
+				rollupDay.Link = entry.Path
+			}
+			rollupDay.Link = filepath.ToSlash(rollupDay.Link)
+			if concat {
+				content, err := ReadEntry(entry)
+				if err != nil {
+					return Rollup{}, err
+				}
+				rollupDay.Content = strings.TrimSpace(string(content))
+			}
+		} else {
+			rollup.Missing = append(rollup.Missing, rollupDay)
+		}
+		rollup.Days = append(rollup.Days, rollupDay)
+	}
+	return rollup, nil
+}
+
+// RenderRollup executes the text/template tmpl with rollup.
+func RenderRollup(tmpl string, rollup Rollup) (string, error) {
+	t, err := template.New("rollup").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, rollup); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,42 @@
+package dayofyear
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+)
+
+type Match struct {
+	Line int
+	Text string
+}
+
+type SearchResult struct {
+	Entry   Entry
+	Matches []Match
+}
+
+// Search returns the lines of entries matching pattern, grouped by entry.
+func Search(entries []Entry, pattern *regexp.Regexp) ([]SearchResult, error) {
+	results := []SearchResult{}
+	for _, entry := range entries {
+		content, err := ReadEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		result := SearchResult{Entry: entry}
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for line := 1; scanner.Scan(); line++ {
+			if pattern.MatchString(scanner.Text()) {
+				result.Matches = append(result.Matches, Match{Line: line, Text: scanner.Text()})
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		if len(result.Matches) > 0 {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,137 @@
+package dayofyear
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/spark"
+)
+
+// EntryWords is the word count of an entry, without its navigation links.
+type EntryWords struct {
+	Entry Entry
+	Words int
+}
+
+// CountWords counts the words of entries, decrypting the encrypted ones.
+func CountWords(entries []Entry) ([]EntryWords, error) {
+	counts := []EntryWords{}
+	for _, entry := range entries {
+		content, err := ReadEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		text := string(content)
+		if i := strings.Index(text, NavMarker); i >= 0 {
+			text = text[:i]
+		}
+		counts = append(counts, EntryWords{Entry: entry, Words: len(strings.Fields(text))})
+	}
+	return counts, nil
+}
+
+type MonthWords struct {
+	// Month is formatted as 2006-01
+	Month   string
+	Entries int
+	Words   int
+	// Daily holds the words of each day of the month, 0 when missing
+	Daily []int
+}
+
+// WordStats are the writing statistics of a range of entries.
+type WordStats struct {
+	Entries  []EntryWords
+	Months   []MonthWords
+	Words    int
+	Longest  EntryWords
+	Shortest EntryWords
+}
+
+// BuildWordStats groups counts, sorted by date, by month.
+func BuildWordStats(counts []EntryWords) WordStats {
+	stats := WordStats{Entries: counts}
+	for i, count := range counts {
+		stats.Words += count.Words
+		if i == 0 || count.Words > stats.Longest.Words {
+			stats.Longest = count
+		}
+		if i == 0 || count.Words < stats.Shortest.Words {
+			stats.Shortest = count
+		}
+		month := count.Entry.Date.Format("2006-01")
+		if len(stats.Months) == 0 || stats.Months[len(stats.Months)-1].Month != month {
+			first := time.Date(count.Entry.Date.Year(), count.Entry.Date.Month(), 1, 0, 0, 0, 0, time.UTC)
+			stats.Months = append(stats.Months, MonthWords{Month: month, Daily: make([]int, first.AddDate(0, 1, -1).Day())})
+		}
+		m := &stats.Months[len(stats.Months)-1]
+		m.Entries++
+		m.Words += count.Words
+		m.Daily[count.Entry.Date.Day()-1] += count.Words
+	}
+	return stats
+}
+
+// DailyWords returns the words written each of the days days up to end
+// included, 0 for the days without entry.
+func DailyWords(counts []EntryWords, end time.Time, days int) []int {
+	daily := make([]int, days)
+	start := end.AddDate(0, 0, -days+1)
+	for _, count := range counts {
+		if day := int(count.Entry.Date.Sub(start).Hours() / 24); day >= 0 && day < days {
+			daily[day] += count.Words
+		}
+	}
+	return daily
+}
+
+// RollingAverage returns the average of the window values up to each of
+// values, over fewer values at the start.
+func RollingAverage(values []int, window int) []float64 {
+	averages := make([]float64, len(values))
+	sum := 0
+	for i, v := range values {
+		sum += v
+		n := window
+		if i >= window {
+			sum -= values[i-window]
+		} else {
+			n = i + 1
+		}
+		averages[i] = float64(sum) / float64(n)
+	}
+	return averages
+}
+
+// FormatWordStats renders the monthly totals with a sparkline of their days,
+// the rolling averages of the days days up to end and the extremes.
+func FormatWordStats(stats WordStats, end time.Time, days int) string {
+	if len(stats.Entries) == 0 {
+		return "No entries"
+	}
+	lines := []string{}
+	for _, m := range stats.Months {
+		lines = append(lines, fmt.Sprintf("%s  %-31s  %3d entries  %7d words  %5d per entry",
+			m.Month, spark.Line(m.Daily), m.Entries, m.Words, m.Words/m.Entries))
+	}
+	lines = append(lines, "", fmt.Sprintf("%d entries, %d words, %d per entry", len(stats.Entries), stats.Words, stats.Words/len(stats.Entries)))
+
+	daily := DailyWords(stats.Entries, end, days)
+	lines = append(lines, fmt.Sprintf("Last %d days  %s", days, spark.Line(daily)))
+	for _, window := range []int{7, 30} {
+		if window > days {
+			continue
+		}
+		averages := RollingAverage(daily, window)
+		rounded := make([]int, len(averages))
+		for i, average := range averages {
+			rounded[i] = int(average + 0.5)
+		}
+		lines = append(lines, fmt.Sprintf("%2d-day avg    %s  %.0f words per day", window, spark.Line(rounded), averages[len(averages)-1]))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Longest   %s  %d words", stats.Longest.Entry.Date.Format(journal.DateLayout), stats.Longest.Words),
+		fmt.Sprintf("Shortest  %s  %d words", stats.Shortest.Entry.Date.Format(journal.DateLayout), stats.Shortest.Words))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,151 @@
+package dupes
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Command finds duplicate files, run standalone as dupes or as ub dupes.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "dupes",
+		Usage:     "Finds duplicate files in directories",
+		ArgsUsage: "[DIR...]",
+		Flags: []cli.Flag{
+			cli.Int64Flag{
+				Name:  "min-size",
+				Usage: "Ignore files smaller than `BYTES`",
+				Value: 1,
+			},
+			cli.IntFlag{
+				Name:  "jobs,j",
+				Usage: "Number of files hashed in parallel",
+				Value: runtime.NumCPU(),
+			},
+			cli.BoolFlag{
+				Name:  "hardlink",
+				Usage: "Replace the duplicates with hardlinks to the first file of their group",
+			},
+			cli.BoolFlag{
+				Name:  "delete",
+				Usage: "Ask which file of every group to keep and delete the others",
+			},
+			cli.BoolFlag{
+				Name:  "dry-run,n",
+				Usage: "Print what --hardlink or --delete would do without doing it",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Bool("hardlink") && c.Bool("delete") {
+				return fmt.Errorf("Pass either --hardlink or --delete, not both")
+			}
+			dirs := c.Args()
+			if len(dirs) == 0 {
+				dirs = []string{"."}
+			}
+			groups, err := Find(dirs, c.Int64("min-size"), c.Int("jobs"))
+			if err != nil {
+				return err
+			}
+
+			var wasted int64
+			for _, g := range groups {
+				wasted += g.Wasted()
+			}
+			switch {
+			case c.Bool("hardlink"):
+				return hardlinkGroups(groups, c.Bool("dry-run"))
+			case c.Bool("delete"):
+				return deleteGroups(groups, c.Bool("dry-run"))
+			}
+			for _, g := range groups {
+				fmt.Printf("%s x %d (%s)\n", FormatSize(g.Size), len(g.Files), g.Hash[:12])
+				for _, f := range g.Files {
+					fmt.Printf("  %s\n", f)
+				}
+			}
+			fmt.Printf("%d groups of duplicates wasting %s\n", len(groups), FormatSize(wasted))
+			return nil
+		},
+	}
+}
+
+func hardlinkGroups(groups []Group, dryRun bool) error {
+	var saved int64
+	for _, g := range groups {
+		for _, duplicate := range g.Files[1:] {
+			fmt.Printf("%s => %s\n", duplicate, g.Files[0])
+			if dryRun {
+				continue
+			}
+			if err := Hardlink(g.Files[0], duplicate); err != nil {
+				return err
+			}
+		}
+		saved += g.Wasted()
+	}
+	fmt.Printf("Saved %s\n", FormatSize(saved))
+	return nil
+}
+
+func deleteGroups(groups []Group, dryRun bool) error {
+	in := bufio.NewReader(os.Stdin)
+	var saved int64
+	for _, g := range groups {
+		fmt.Printf("%s x %d\n", FormatSize(g.Size), len(g.Files))
+		for i, f := range g.Files {
+			fmt.Printf("  [%d] %s\n", i+1, f)
+		}
+		keep, err := askKeep(in, len(g.Files))
+		if err != nil {
+			return err
+		}
+		if keep == 0 {
+			continue
+		}
+		for i, f := range g.Files {
+			if i+1 == keep {
+				continue
+			}
+			fmt.Printf("  deleting %s\n", f)
+			if dryRun {
+				continue
+			}
+			if err := os.Remove(f); err != nil {
+				return err
+			}
+		}
+		saved += g.Wasted()
+	}
+	fmt.Printf("Saved %s\n", FormatSize(saved))
+	return nil
+}
+
+// askKeep asks for the number of the file to keep, 0 skips the group.
+func askKeep(in *bufio.Reader, n int) (int, error) {
+	for {
+		fmt.Printf("Keep which file? [1-%d, s to skip, q to quit] (1): ", n)
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		answer := strings.TrimSpace(line)
+		switch answer {
+		case "":
+			return 1, nil
+		case "s":
+			return 0, nil
+		case "q":
+			return 0, fmt.Errorf("Aborted")
+		}
+		if keep, err := strconv.Atoi(answer); err == nil && keep >= 1 && keep <= n {
+			return keep, nil
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package dupes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jonfk/utility-belt/hash/hash"
+)
+
+// Algorithm hashes the files of the same size to confirm they are duplicates.
+const Algorithm = "sha256"
+
+// Group is a set of files with the same content.
+type Group struct {
+	Size  int64
+	Hash  string
+	Files []string
+}
+
+// Wasted is the space taken by the copies beyond the first.
+func (g Group) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+type file struct {
+	path string
+	info os.FileInfo
+}
+
+// Find walks dirs for regular files of at least minSize bytes, groups them by
+// size then hashes the candidates over jobs workers. Hardlinks of the same
+// file count once. The groups are sorted by wasted space.
+func Find(dirs []string, minSize int64, jobs int) ([]Group, error) {
+	bySize := map[int64][]file{}
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+				return nil
+			}
+			if !info.Mode().IsRegular() || info.Size() < minSize {
+				return nil
+			}
+			for _, other := range bySize[info.Size()] {
+				if other.path == path || os.SameFile(other.info, info) {
+					return nil
+				}
+			}
+			bySize[info.Size()] = append(bySize[info.Size()], file{path, info})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	candidates := []string{}
+	for _, files := range bySize {
+		if len(files) > 1 {
+			for _, f := range files {
+				candidates = append(candidates, f.path)
+			}
+		}
+	}
+	sums, err := hash.Files(Algorithm, candidates, jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	byHash := map[string]*Group{}
+	for _, sum := range sums {
+		if sum.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", sum.File, sum.Err)
+			continue
+		}
+		g := byHash[sum.Hex]
+		if g == nil {
+			info, err := os.Stat(sum.File)
+			if err != nil {
+				continue
+			}
+			g = &Group{Size: info.Size(), Hash: sum.Hex}
+			byHash[sum.Hex] = g
+		}
+		g.Files = append(g.Files, sum.File)
+	}
+
+	groups := []Group{}
+	for _, g := range byHash {
+		if len(g.Files) > 1 {
+			sort.Strings(g.Files)
+			groups = append(groups, *g)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Wasted() != groups[j].Wasted() {
+			return groups[i].Wasted() > groups[j].Wasted()
+		}
+		return groups[i].Files[0] < groups[j].Files[0]
+	})
+	return groups, nil
+}
+
+// Hardlink replaces duplicate with a hardlink to original, going through a
+// temporary link so duplicate is never missing.
+func Hardlink(original, duplicate string) error {
+	tmp := duplicate + ".dupes-tmp"
+	if err := os.Link(original, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, duplicate); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
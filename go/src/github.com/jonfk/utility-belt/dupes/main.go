@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/dupes/dupes"
+	"github.com/jonfk/utility-belt/internal/belt"
+)
+
+func main() {
+	belt.Run(belt.NewApp("dupes", dupes.Command()))
+}
@@ -0,0 +1,281 @@
+package dur
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/ts/ts"
+	"github.com/urfave/cli"
+)
+
+var (
+	toFlag = cli.StringFlag{
+		Name:  "to,t",
+		Usage: "Output `FORMAT`: " + strings.Join(Formats, ", "),
+	}
+	tzFlag = cli.StringFlag{
+		Name:  "tz",
+		Usage: "Time zone of the dates read without a zone and printed, e.g. America/Toronto",
+	}
+	workdaysFlag = cli.BoolFlag{
+		Name:  "workdays,w",
+		Usage: "Only count Monday to Friday, without the --holiday dates",
+	}
+	holidayFlag = cli.StringSliceFlag{
+		Name:  "holiday",
+		Usage: "With --workdays, skip the `DATE` as 2006-01-02, repeated or comma separated",
+	}
+)
+
+// Command computes with durations, run standalone as dur or as ub dur.
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "dur",
+		Usage: "Computes the time between dates, adds durations to dates and sums durations",
+		Description: "Dates are read as ts reads them: unix timestamps, dates such as 2024-01-31 or\n" +
+			"   2024-01-31T09:00:00Z, now, today or relative times such as \"3 hours ago\".\n" +
+			"   Durations are written as 1h30m, 2d, 1w, 1mo, 1y, 1.5h or 1:30.",
+		Subcommands: []cli.Command{
+			{
+				Name:      "between",
+				Aliases:   []string{"diff"},
+				Usage:     "Print the time between two dates, from now with one date, or the number of workdays with --workdays",
+				ArgsUsage: "FROM [TO]",
+				Flags:     []cli.Flag{toFlag, tzFlag, workdaysFlag, holidayFlag},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 || c.NArg() > 2 {
+						return fmt.Errorf("between takes one or two dates")
+					}
+					loc, workdays, err := dateOptions(c)
+					if err != nil {
+						return err
+					}
+					now := time.Now()
+					from, _, err := ts.Parse(c.Args().Get(0), now, loc)
+					if err != nil {
+						return err
+					}
+					to := now
+					if c.NArg() == 2 {
+						if to, _, err = ts.Parse(c.Args().Get(1), now, loc); err != nil {
+							return err
+						}
+					}
+					if workdays != nil {
+						// the time spent on workdays is calendar time, of 24
+						// hours a day, so only the days are printed
+						if c.String("to") != "" {
+							return fmt.Errorf("--to formats durations, --workdays prints a number of workdays")
+						}
+						_, days := workdays.Between(from.In(loc), to.In(loc))
+						fmt.Println(days)
+						return nil
+					}
+					return printDuration(c, to.Sub(from), nil)
+				},
+			},
+			{
+				Name:      "add",
+				Usage:     "Add durations to a date, negative ones such as -1d subtract",
+				ArgsUsage: "DATE DURATION...",
+				Flags:     addFlags(),
+				Action: func(c *cli.Context) error {
+					return addAction(c, 1)
+				},
+			},
+			{
+				Name:      "sub",
+				Usage:     "Subtract durations from a date",
+				ArgsUsage: "DATE DURATION...",
+				Flags:     addFlags(),
+				Action: func(c *cli.Context) error {
+					return addAction(c, -1)
+				},
+			},
+			{
+				Name:      "sum",
+				Usage:     "Sum the durations of the lines of timesheets, read from stdin without files",
+				ArgsUsage: "[FILE...]",
+				Description: "Every range such as 09:00-12:30 and duration with a unit such as 1h30m or 45m\n" +
+					"   of a line is summed, the other words are ignored. A lone clock time such as\n" +
+					"   09:00 is a time of day, not a duration.",
+				Flags: []cli.Flag{
+					toFlag,
+					cli.BoolFlag{
+						Name:  "workdays,w",
+						Usage: "Also print the total in workdays of --day-length",
+					},
+					cli.StringFlag{
+						Name:  "day-length",
+						Usage: "With --workdays, the `DURATION` of a workday",
+						Value: "8h",
+					},
+					cli.BoolFlag{
+						Name:  "lines,l",
+						Usage: "Print the duration of every line before the total",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					var total time.Duration
+					sum := func(r io.Reader, name string) error {
+						scanner := bufio.NewScanner(r)
+						for scanner.Scan() {
+							d, ok := LineDuration(scanner.Text())
+							if !ok {
+								continue
+							}
+							total += d
+							if c.Bool("lines") {
+								fmt.Printf("%-10s %s\n", Human(d), strings.TrimSpace(scanner.Text()))
+							}
+						}
+						if err := scanner.Err(); err != nil {
+							return fmt.Errorf("%s: %v", name, err)
+						}
+						return nil
+					}
+					if c.NArg() == 0 {
+						if err := sum(os.Stdin, "stdin"); err != nil {
+							return err
+						}
+					}
+					for _, filename := range c.Args() {
+						f, err := os.Open(filename)
+						if err != nil {
+							return err
+						}
+						err = sum(f, filename)
+						f.Close()
+						if err != nil {
+							return err
+						}
+					}
+
+					extra := [][2]string{}
+					if c.Bool("workdays") {
+						dayLength, err := ParseDuration(c.String("day-length"))
+						if err != nil {
+							return err
+						}
+						if dayLength <= 0 {
+							return fmt.Errorf("--day-length must be positive")
+						}
+						extra = append(extra, [2]string{"workdays", fmt.Sprint(round(float64(total) / float64(dayLength)))})
+					}
+					return printDuration(c, total, extra)
+				},
+			},
+		},
+	}
+}
+
+func addFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "to,t",
+			Usage: "Output `FORMAT`: " + strings.Join(ts.Formats, ", ") + ", rfc3339 by default or a date for dates without a time",
+		},
+		tzFlag,
+		cli.BoolFlag{
+			Name:  "workdays,w",
+			Usage: "Count the days and weeks of the durations in workdays, Monday to Friday without the --holiday dates",
+		},
+		holidayFlag,
+	}
+}
+
+// addAction adds the durations to the date when sign is 1, subtracts them
+// when it is -1.
+func addAction(c *cli.Context, sign int) error {
+	if c.NArg() < 2 {
+		return fmt.Errorf("%s takes a date and durations", c.Command.Name)
+	}
+	loc, workdays, err := dateOptions(c)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	input := c.Args().First()
+	t, _, err := ts.Parse(input, now, loc)
+	if err != nil {
+		return err
+	}
+	for _, arg := range c.Args().Tail() {
+		o, err := ParseOffset(arg)
+		if err != nil {
+			return err
+		}
+		if sign < 0 {
+			o = o.Neg()
+		}
+		t = Add(t, o, workdays)
+	}
+
+	to := c.String("to")
+	if to == "" {
+		to = ts.RFC3339Format
+		if _, err := time.Parse("2006-01-02", strings.TrimSpace(input)); err == nil && t.In(loc).Format("15:04:05.999999999") == "00:00:00" {
+			// keep dates as dates
+			fmt.Println(t.In(loc).Format("2006-01-02"))
+			return nil
+		}
+	}
+	out, err := ts.Format(t, to, now, loc)
+	if err != nil {
+		return err
+	}
+	fmt.Println(out)
+	return nil
+}
+
+// dateOptions returns the location of --tz and the workdays with --workdays.
+func dateOptions(c *cli.Context) (*time.Location, *Workdays, error) {
+	loc := time.Local
+	if c.String("tz") != "" {
+		var err error
+		if loc, err = time.LoadLocation(c.String("tz")); err != nil {
+			return nil, nil, err
+		}
+	}
+	holidays := []string{}
+	for _, value := range c.StringSlice("holiday") {
+		for _, holiday := range strings.Split(value, ",") {
+			if holiday = strings.TrimSpace(holiday); holiday != "" {
+				holidays = append(holidays, holiday)
+			}
+		}
+	}
+	if !c.Bool("workdays") {
+		if len(holidays) > 0 {
+			return nil, nil, fmt.Errorf("--holiday is only used with --workdays")
+		}
+		return loc, nil, nil
+	}
+	workdays, err := NewWorkdays(holidays)
+	return loc, workdays, err
+}
+
+// printDuration prints d in the format of --to, or in every format followed
+// by the extra lines.
+func printDuration(c *cli.Context, d time.Duration, extra [][2]string) error {
+	if to := c.String("to"); to != "" {
+		out, err := Format(d, to)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	}
+	for _, format := range Formats {
+		out, _ := Format(d, format)
+		fmt.Printf("%-9s %s\n", format, out)
+	}
+	for _, line := range extra {
+		fmt.Printf("%-9s %s\n", line[0], line[1])
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package dur
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats of the durations.
+const (
+	HumanFormat   = "human"
+	GoFormat      = "go"
+	SecondsFormat = "seconds"
+	MinutesFormat = "minutes"
+	HoursFormat   = "hours"
+	DaysFormat    = "days"
+)
+
+var Formats = []string{HumanFormat, GoFormat, SecondsFormat, MinutesFormat, HoursFormat, DaysFormat}
+
+const day = 24 * time.Hour
+
+// Offset is an amount of time to add to a date. The years, months and days
+// are calendar units added with AddDate, so that a day stays a day across
+// daylight saving changes, the rest is an exact duration.
+type Offset struct {
+	Years, Months, Days int
+	Clock               time.Duration
+}
+
+var (
+	offsetPart   = regexp.MustCompile(`^(\d+(?:\.\d+)?)([a-zµ]+)`)
+	clockPattern = regexp.MustCompile(`^(\d+):(\d{2})(?::(\d{2}))?$`)
+)
+
+var clockUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond, "µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second, "sec": time.Second,
+	"m": time.Minute, "min": time.Minute,
+	"h": time.Hour, "hr": time.Hour,
+}
+
+// ParseOffset parses offsets such as 1h30m, -2d, +1y2mo, 1.5h or 1:30. The
+// units are y, mo, w, d, h, m, s, ms, us and ns, the calendar ones take
+// whole numbers.
+func ParseOffset(input string) (Offset, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	sign := 1
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+	s = strings.TrimLeft(s, "+-")
+	invalid := fmt.Errorf("Invalid duration %q, expected e.g. 1h30m, 2d, 1w, 1mo, 1y or 1:30", input)
+
+	var o Offset
+	if match := clockPattern.FindStringSubmatch(s); match != nil {
+		hours, _ := strconv.Atoi(match[1])
+		minutes, _ := strconv.Atoi(match[2])
+		seconds, _ := strconv.Atoi(match[3])
+		if minutes > 59 || seconds > 59 {
+			return o, invalid
+		}
+		o.Clock = time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+		return o.scale(sign), nil
+	}
+	if s == "" {
+		return o, invalid
+	}
+	for s != "" {
+		match := offsetPart.FindStringSubmatch(s)
+		if match == nil {
+			return o, invalid
+		}
+		s = s[len(match[0]):]
+		amount, unit := match[1], match[2]
+		n, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return o, invalid
+		}
+		calendar := map[string]*int{"y": &o.Years, "mo": &o.Months, "d": &o.Days}
+		multiple := 1
+		if unit == "w" {
+			unit, multiple = "d", 7
+		}
+		if field, ok := calendar[unit]; ok {
+			if n != math.Trunc(n) {
+				return o, fmt.Errorf("Invalid duration %q, %s takes a whole number", input, match[2])
+			}
+			*field += int(n) * multiple
+			continue
+		}
+		d, ok := clockUnits[unit]
+		if !ok {
+			return o, invalid
+		}
+		o.Clock += time.Duration(n * float64(d))
+	}
+	return o.scale(sign), nil
+}
+
+func (o Offset) scale(sign int) Offset {
+	return Offset{Years: sign * o.Years, Months: sign * o.Months, Days: sign * o.Days, Clock: time.Duration(sign) * o.Clock}
+}
+
+func (o Offset) Neg() Offset {
+	return o.scale(-1)
+}
+
+// ParseDuration parses an exact duration as ParseOffset does, days and weeks
+// being 24 hours. Months and years aren't a fixed duration.
+func ParseDuration(input string) (time.Duration, error) {
+	o, err := ParseOffset(input)
+	if err != nil {
+		return 0, err
+	}
+	if o.Years != 0 || o.Months != 0 {
+		return 0, fmt.Errorf("Months and years aren't a fixed duration, got %q", input)
+	}
+	return time.Duration(o.Days)*day + o.Clock, nil
+}
+
+// Add adds o to t, counting the days as workdays when workdays isn't nil.
+// The years and months keep the day of the month, clamped to the last day
+// of shorter months: a month after 2024-01-31 is 2024-02-29.
+func Add(t time.Time, o Offset, workdays *Workdays) time.Time {
+	t = addMonths(t, 12*o.Years+o.Months)
+	if workdays != nil {
+		t = workdays.AddDays(t, o.Days)
+	} else {
+		t = t.AddDate(0, 0, o.Days)
+	}
+	return t.Add(o.Clock)
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	hour, min, sec := t.Clock()
+	first := time.Date(y, m+time.Month(months), 1, hour, min, sec, t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, hour, min, sec, t.Nanosecond(), t.Location())
+}
+
+// Format formats d as one of Formats.
+func Format(d time.Duration, format string) (string, error) {
+	switch format {
+	case HumanFormat:
+		return Human(d), nil
+	case GoFormat:
+		return d.String(), nil
+	case SecondsFormat:
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64), nil
+	case MinutesFormat:
+		return strconv.FormatFloat(round(d.Minutes()), 'f', -1, 64), nil
+	case HoursFormat:
+		return strconv.FormatFloat(round(d.Hours()), 'f', -1, 64), nil
+	case DaysFormat:
+		return strconv.FormatFloat(round(float64(d)/float64(day)), 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("Unknown format %s, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// round keeps two decimals.
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// Human writes d in days, hours, minutes and seconds, e.g. "3d 4h 5m".
+func Human(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	parts := []string{}
+	for _, unit := range []struct {
+		name string
+		d    time.Duration
+	}{{"d", day}, {"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}} {
+		if n := d / unit.d; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, unit.name))
+			d -= n * unit.d
+		}
+	}
+	if len(parts) == 0 {
+		return sign + d.String()
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+// Workdays are the days from Monday to Friday which aren't holidays.
+type Workdays struct {
+	// Holidays holds dates as 2006-01-02
+	Holidays map[string]bool
+}
+
+// NewWorkdays parses holidays as 2006-01-02 dates.
+func NewWorkdays(holidays []string) (*Workdays, error) {
+	w := &Workdays{Holidays: map[string]bool{}}
+	for _, holiday := range holidays {
+		if _, err := time.Parse("2006-01-02", holiday); err != nil {
+			return nil, fmt.Errorf("Invalid holiday %q, expected a date as 2006-01-02", holiday)
+		}
+		w.Holidays[holiday] = true
+	}
+	return w, nil
+}
+
+func (w *Workdays) IsWorkday(t time.Time) bool {
+	weekday := t.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday && !w.Holidays[t.Format("2006-01-02")]
+}
+
+// AddDays moves t by n workdays, skipping the weekends and holidays.
+func (w *Workdays) AddDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if w.IsWorkday(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// Between returns the time between from and to spent on workdays and the
+// number of workdays started in it.
+func (w *Workdays) Between(from, to time.Time) (time.Duration, int) {
+	sign := time.Duration(1)
+	if to.Before(from) {
+		from, to, sign = to, from, -1
+	}
+	var total time.Duration
+	days := 0
+	for start := from; start.Before(to); {
+		y, m, d := start.Date()
+		next := time.Date(y, m, d+1, 0, 0, 0, 0, start.Location())
+		end := next
+		if to.Before(end) {
+			end = to
+		}
+		if w.IsWorkday(start) {
+			total += end.Sub(start)
+			days++
+		}
+		start = next
+	}
+	return sign * total, int(sign) * days
+}
+
+var rangePattern = regexp.MustCompile(`\b(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})\b`)
+
+// LineDuration sums the durations of a timesheet line: ranges such as
+// 09:00-12:30, overnight when the end is before the start, and durations
+// with units such as 1h30m or 45m. The other words are ignored, a lone
+// clock time such as the 09:00 of "09:00 standup" being a time of day.
+func LineDuration(line string) (time.Duration, bool) {
+	var total time.Duration
+	found := false
+	line = rangePattern.ReplaceAllStringFunc(line, func(r string) string {
+		match := rangePattern.FindStringSubmatch(r)
+		clock := func(h, m string) time.Duration {
+			hours, _ := strconv.Atoi(h)
+			minutes, _ := strconv.Atoi(m)
+			return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+		}
+		d := clock(match[3], match[4]) - clock(match[1], match[2])
+		if d < 0 {
+			d += day
+		}
+		total += d
+		found = true
+		return " "
+	})
+	for _, word := range strings.Fields(line) {
+		word = strings.Trim(word, ",;()[]")
+		if clockPattern.MatchString(strings.TrimLeft(word, "+-")) {
+			continue
+		}
+		if d, err := ParseDuration(word); err == nil {
+			total += d
+			found = true
+		}
+	}
+	return total, found
+}
@@ -0,0 +1,44 @@
+package dur
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddClampsToMonthEnd(t *testing.T) {
+	tests := []struct {
+		date, offset, want string
+	}{
+		{"2024-01-31", "1mo", "2024-02-29"},
+		{"2023-01-31", "1mo", "2023-02-28"},
+		{"2024-03-31", "-1mo", "2024-02-29"},
+		{"2024-01-31", "2mo", "2024-03-31"},
+		{"2024-05-31", "1mo", "2024-06-30"},
+		{"2024-02-29", "1y", "2025-02-28"},
+		{"2024-02-29", "4y", "2028-02-29"},
+		{"2024-12-31", "2mo", "2025-02-28"},
+		{"2024-01-15", "1mo", "2024-02-15"},
+		{"2024-01-31", "1mo1d", "2024-03-01"},
+	}
+	for _, test := range tests {
+		date, err := time.Parse("2006-01-02", test.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o, err := ParseOffset(test.offset)
+		if err != nil {
+			t.Fatalf("ParseOffset(%q): %v", test.offset, err)
+		}
+		if got := Add(date, o, nil).Format("2006-01-02"); got != test.want {
+			t.Errorf("%s + %s = %s, want %s", test.date, test.offset, got, test.want)
+		}
+	}
+}
+
+func TestAddKeepsTheClock(t *testing.T) {
+	date := time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)
+	o, _ := ParseOffset("1mo")
+	if got, want := Add(date, o, nil), time.Date(2024, 2, 29, 9, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Add = %s, want %s", got, want)
+	}
+}
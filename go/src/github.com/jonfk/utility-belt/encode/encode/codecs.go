@@ -0,0 +1,95 @@
+package encode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type Codec struct {
+	Name   string
+	Usage  string
+	Encode func([]byte) []byte
+	Decode func([]byte) ([]byte, error)
+}
+
+var Codecs = map[string]Codec{
+	"base64": {
+		Name:   "base64",
+		Usage:  "standard base64 with padding",
+		Encode: func(b []byte) []byte { return []byte(Base64(b)) },
+		Decode: func(b []byte) ([]byte, error) { return DecodeBase64(string(b)) },
+	},
+	"base64url": {
+		Name:   "base64url",
+		Usage:  "url safe base64 without padding",
+		Encode: func(b []byte) []byte { return []byte(Base64URL(b)) },
+		Decode: func(b []byte) ([]byte, error) { return DecodeBase64URL(string(b)) },
+	},
+	"hex": {
+		Name:   "hex",
+		Usage:  "lowercase hexadecimal",
+		Encode: func(b []byte) []byte { return []byte(hex.EncodeToString(b)) },
+		Decode: func(b []byte) ([]byte, error) { return hex.DecodeString(string(stripSpace(b))) },
+	},
+	"url": {
+		Name:   "url",
+		Usage:  "query string percent-encoding",
+		Encode: func(b []byte) []byte { return []byte(url.QueryEscape(string(b))) },
+		Decode: func(b []byte) ([]byte, error) {
+			s, err := url.QueryUnescape(strings.TrimSpace(string(b)))
+			return []byte(s), err
+		},
+	},
+	"html": {
+		Name:   "html",
+		Usage:  "html entities",
+		Encode: func(b []byte) []byte { return []byte(html.EscapeString(string(b))) },
+		Decode: func(b []byte) ([]byte, error) { return []byte(html.UnescapeString(string(b))), nil },
+	},
+}
+
+// Names lists the codecs in alphabetical order.
+func Names() []string {
+	names := []string{}
+	for name := range Codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Lookup(name string) (Codec, error) {
+	codec, ok := Codecs[name]
+	if !ok {
+		return Codec{}, fmt.Errorf("Unknown codec %q, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	return codec, nil
+}
+
+func Base64(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func Base64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeBase64 decodes standard base64, ignoring whitespace and missing padding.
+func DecodeBase64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(string(stripSpace([]byte(s))), "="))
+}
+
+// DecodeBase64URL decodes url safe base64, ignoring whitespace and padding.
+func DecodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(string(stripSpace([]byte(s))), "="))
+}
+
+func stripSpace(b []byte) []byte {
+	return bytes.Join(bytes.Fields(b), nil)
+}
@@ -0,0 +1,85 @@
+package encode
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Command encodes and decodes stdin or files, run standalone as encode or as ub encode.
+func Command() cli.Command {
+	return cli.Command{
+		Name:        "encode",
+		Usage:       "Encodes or decodes stdin or a file",
+		ArgsUsage:   "codec [file]",
+		Description: codecsDescription(),
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "decode,d",
+				Usage: "Decode instead of encoding",
+			},
+			cli.StringFlag{
+				Name:  "output,o",
+				Usage: "Write to `FILE` instead of stdout",
+			},
+			cli.BoolFlag{
+				Name:  "no-newline,n",
+				Usage: "Don't print a newline after the encoded output",
+			},
+		},
+		BashComplete: func(c *cli.Context) {
+			if c.NArg() == 0 {
+				fmt.Println(strings.Join(Names(), "\n"))
+			}
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("encode takes a codec: %s", strings.Join(Names(), ", "))
+			}
+			codec, err := Lookup(c.Args().First())
+			if err != nil {
+				return err
+			}
+
+			var input []byte
+			if file := c.Args().Get(1); file != "" && file != "-" {
+				input, err = ioutil.ReadFile(file)
+			} else {
+				input, err = ioutil.ReadAll(os.Stdin)
+			}
+			if err != nil {
+				return err
+			}
+
+			var output []byte
+			if c.Bool("decode") {
+				output, err = codec.Decode(input)
+				if err != nil {
+					return fmt.Errorf("Decoding %s: %v", codec.Name, err)
+				}
+			} else {
+				output = codec.Encode(input)
+				if !c.Bool("no-newline") {
+					output = append(output, '\n')
+				}
+			}
+
+			if c.String("output") != "" {
+				return ioutil.WriteFile(c.String("output"), output, 0644)
+			}
+			_, err = os.Stdout.Write(output)
+			return err
+		},
+	}
+}
+
+func codecsDescription() string {
+	lines := []string{"Codecs:"}
+	for _, name := range Names() {
+		lines = append(lines, fmt.Sprintf("%-10s %s", name, Codecs[name].Usage))
+	}
+	return strings.Join(lines, "\n   ")
+}
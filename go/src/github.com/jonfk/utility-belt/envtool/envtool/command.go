@@ -0,0 +1,183 @@
+package envtool
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	DefaultFile    = ".env"
+	DefaultExample = ".env.example"
+)
+
+var outputFlag = cli.StringFlag{
+	Name:  "output,o",
+	Usage: "Write to `FILE` instead of stdout",
+}
+
+// Command manages .env files, run standalone as envtool or as ub env.
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "env",
+		Usage: "Validates, diffs and merges .env files",
+		Subcommands: []cli.Command{
+			{
+				Name:      "check",
+				Usage:     "Report syntax errors, duplicate keys and keys missing compared to the example",
+				ArgsUsage: "[file]",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "example,e",
+						Usage: "Example file listing the expected keys, skipped when it doesn't exist",
+						Value: DefaultExample,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					file, errs := Read(argOr(c, 0, DefaultFile))
+					problems := len(errs)
+					for _, err := range errs {
+						fmt.Println(err)
+					}
+					if file == nil {
+						return cli.NewExitError("", 1)
+					}
+					for _, key := range file.Duplicates() {
+						fmt.Printf("%s: %s is defined more than once\n", file.Name, key)
+						problems++
+					}
+
+					if _, err := os.Stat(c.String("example")); err == nil {
+						example, errs := Read(c.String("example"))
+						for _, err := range errs {
+							fmt.Println(err)
+						}
+						if example != nil {
+							for _, key := range file.Missing(example) {
+								fmt.Printf("%s: %s is missing, it's in %s\n", file.Name, key, example.Name)
+								problems++
+							}
+							for _, key := range example.Missing(file) {
+								fmt.Printf("%s: %s is not in %s\n", file.Name, key, example.Name)
+							}
+						}
+					}
+
+					if problems > 0 {
+						return cli.NewExitError(fmt.Sprintf("%d problems found", problems), 1)
+					}
+					fmt.Printf("%s: OK\n", file.Name)
+					return nil
+				},
+			},
+			{
+				Name:      "diff",
+				Usage:     "List the keys added, removed or changed between two files, values are not printed",
+				ArgsUsage: "a b",
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 2 {
+						return fmt.Errorf("diff takes two files")
+					}
+					a, err := readValid(c.Args().Get(0))
+					if err != nil {
+						return err
+					}
+					b, err := readValid(c.Args().Get(1))
+					if err != nil {
+						return err
+					}
+					changes := Diff(a, b)
+					symbols := map[string]string{"added": "+", "removed": "-", "changed": "~"}
+					for _, change := range changes {
+						fmt.Printf("%s %s\n", symbols[change.Kind], change.Key)
+					}
+					if len(changes) > 0 {
+						return cli.NewExitError("", 1)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "merge",
+				Usage:     "Merge files, the values of the later files win",
+				ArgsUsage: "file...",
+				Flags:     []cli.Flag{outputFlag},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("merge takes at least one file")
+					}
+					files := []*File{}
+					for _, name := range c.Args() {
+						file, err := readValid(name)
+						if err != nil {
+							return err
+						}
+						files = append(files, file)
+					}
+					return output(c, Format(Merge(files)))
+				},
+			},
+			{
+				Name:      "example",
+				Usage:     "Generate an example file with the values stripped",
+				ArgsUsage: "[file]",
+				Flags:     []cli.Flag{outputFlag},
+				Action: func(c *cli.Context) error {
+					file, err := readValid(argOr(c, 0, DefaultFile))
+					if err != nil {
+						return err
+					}
+					return output(c, Format(Example(file)))
+				},
+			},
+			{
+				Name:      "sort",
+				Usage:     "Sort the keys of a file, comments are dropped",
+				ArgsUsage: "[file]",
+				Flags: []cli.Flag{
+					outputFlag,
+					cli.BoolFlag{
+						Name:  "write,w",
+						Usage: "overwrite to file",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					file, err := readValid(argOr(c, 0, DefaultFile))
+					if err != nil {
+						return err
+					}
+					if c.Bool("write") {
+						return ioutil.WriteFile(file.Name, []byte(Format(Sorted(file))), 0600)
+					}
+					return output(c, Format(Sorted(file)))
+				},
+			},
+		},
+	}
+}
+
+// readValid reads filename, failing on the first syntax error.
+func readValid(filename string) (*File, error) {
+	file, errs := Read(filename)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return file, nil
+}
+
+func argOr(c *cli.Context, i int, fallback string) string {
+	if arg := c.Args().Get(i); arg != "" {
+		return arg
+	}
+	return fallback
+}
+
+func output(c *cli.Context, content string) error {
+	if c.String("output") != "" {
+		return ioutil.WriteFile(c.String("output"), []byte(content), 0600)
+	}
+	fmt.Print(content)
+	return nil
+}
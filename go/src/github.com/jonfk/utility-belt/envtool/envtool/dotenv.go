@@ -0,0 +1,259 @@
+package envtool
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Entry is a KEY=value line of a .env file, comments and blank lines are
+// entries without a key so files can be rewritten as they were.
+type Entry struct {
+	Key   string
+	Value string
+	Line  int
+	Raw   string
+}
+
+type File struct {
+	Name    string
+	Entries []Entry
+}
+
+type SyntaxError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Read parses filename, every malformed line is reported in the returned errors.
+func Read(filename string) (*File, []error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, []error{err}
+	}
+	defer f.Close()
+	return Parse(filename, f)
+}
+
+func Parse(name string, r io.Reader) (*File, []error) {
+	file := &File{Name: name}
+	errs := []error{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Text()
+		text := strings.TrimSpace(raw)
+		if text == "" || strings.HasPrefix(text, "#") {
+			file.Entries = append(file.Entries, Entry{Line: line, Raw: raw})
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		eq := strings.Index(text, "=")
+		if eq < 0 {
+			errs = append(errs, SyntaxError{name, line, "expected KEY=value"})
+			continue
+		}
+		key := strings.TrimSpace(text[:eq])
+		if !keyPattern.MatchString(key) {
+			errs = append(errs, SyntaxError{name, line, fmt.Sprintf("invalid key %q", key)})
+			continue
+		}
+		value, err := parseValue(strings.TrimSpace(text[eq+1:]))
+		if err != nil {
+			errs = append(errs, SyntaxError{name, line, err.Error()})
+			continue
+		}
+		file.Entries = append(file.Entries, Entry{Key: key, Value: value, Line: line, Raw: raw})
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return file, errs
+}
+
+func parseValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch quote := value[0]; quote {
+	case '"', '\'':
+		end := strings.LastIndexByte(value, quote)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated %c quoted value", quote)
+		}
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after quoted value", rest)
+		}
+		inner := value[1:end]
+		if quote == '"' {
+			inner = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(inner)
+		}
+		return inner, nil
+	default:
+		// Unquoted values end at an inline comment
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+}
+
+// Keys lists the keys in the order of the file.
+func (f *File) Keys() []string {
+	keys := []string{}
+	for _, entry := range f.Entries {
+		if entry.Key != "" {
+			keys = append(keys, entry.Key)
+		}
+	}
+	return keys
+}
+
+// Values maps the keys to their values, the last definition wins.
+func (f *File) Values() map[string]string {
+	values := map[string]string{}
+	for _, entry := range f.Entries {
+		if entry.Key != "" {
+			values[entry.Key] = entry.Value
+		}
+	}
+	return values
+}
+
+// Duplicates lists the keys defined more than once.
+func (f *File) Duplicates() []string {
+	seen := map[string]int{}
+	duplicates := []string{}
+	for _, key := range f.Keys() {
+		seen[key]++
+		if seen[key] == 2 {
+			duplicates = append(duplicates, key)
+		}
+	}
+	return duplicates
+}
+
+// Missing lists the keys of other absent from f.
+func (f *File) Missing(other *File) []string {
+	values := f.Values()
+	missing := []string{}
+	for _, key := range other.Keys() {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+type Change struct {
+	Key  string
+	Kind string // added, removed or changed
+}
+
+// Diff lists the keys added, removed or changed from a to b.
+func Diff(a, b *File) []Change {
+	aValues, bValues := a.Values(), b.Values()
+	changes := []Change{}
+	for _, key := range uniqueKeys(a.Keys(), b.Keys()) {
+		aValue, inA := aValues[key]
+		bValue, inB := bValues[key]
+		switch {
+		case !inA:
+			changes = append(changes, Change{key, "added"})
+		case !inB:
+			changes = append(changes, Change{key, "removed"})
+		case aValue != bValue:
+			changes = append(changes, Change{key, "changed"})
+		}
+	}
+	return changes
+}
+
+// Merge combines files, the values of the later files override the earlier
+// ones while the keys keep the position of their first definition.
+func Merge(files []*File) *File {
+	merged := &File{}
+	index := map[string]int{}
+	for _, file := range files {
+		for _, entry := range file.Entries {
+			if entry.Key == "" {
+				continue
+			}
+			if i, ok := index[entry.Key]; ok {
+				merged.Entries[i].Value = entry.Value
+				continue
+			}
+			index[entry.Key] = len(merged.Entries)
+			merged.Entries = append(merged.Entries, Entry{Key: entry.Key, Value: entry.Value})
+		}
+	}
+	return merged
+}
+
+// Example strips the values of f, keeping its comments.
+func Example(f *File) *File {
+	example := &File{Name: f.Name}
+	for _, entry := range f.Entries {
+		if entry.Key != "" {
+			entry = Entry{Key: entry.Key}
+		}
+		example.Entries = append(example.Entries, entry)
+	}
+	return example
+}
+
+// Sorted orders the entries of f by key, dropping comments and blank lines.
+func Sorted(f *File) *File {
+	sorted := &File{Name: f.Name}
+	for _, entry := range f.Entries {
+		if entry.Key != "" {
+			sorted.Entries = append(sorted.Entries, Entry{Key: entry.Key, Value: entry.Value})
+		}
+	}
+	sort.SliceStable(sorted.Entries, func(i, j int) bool { return sorted.Entries[i].Key < sorted.Entries[j].Key })
+	return sorted
+}
+
+// Format renders f as a .env file, comments are written back as they were read.
+func Format(f *File) string {
+	var buf strings.Builder
+	for _, entry := range f.Entries {
+		if entry.Key == "" {
+			buf.WriteString(entry.Raw + "\n")
+			continue
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", entry.Key, quote(entry.Value))
+	}
+	return buf.String()
+}
+
+func quote(value string) string {
+	if value == "" || !strings.ContainsAny(value, " \t\n\"'#\\$") {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func uniqueKeys(lists ...[]string) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, list := range lists {
+		for _, key := range list {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,252 @@
+package githubanalytics
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/jonfk/utility-belt/internal/githubql"
+)
+
+// AnonymizedPrefix starts the aliases of the private repositories in the
+// anonymized reports, e.g. jonfk/private-3fa2c1d9e0.
+const AnonymizedPrefix = "private-"
+
+type privateRepositoriesQuery struct {
+	Viewer struct {
+		Repositories struct {
+			PageInfo githubql.PageInfo
+			Nodes    []struct {
+				NameWithOwner string `json:"nameWithOwner"`
+			}
+		} `graphql:"repositories(first: 100, after: $after, privacy: PRIVATE, affiliations: [OWNER, COLLABORATOR, ORGANIZATION_MEMBER], ownerAffiliations: [OWNER, COLLABORATOR, ORGANIZATION_MEMBER])"`
+	}
+}
+
+// Anonymizer strips the private details out of the reports so they can be
+// shared publicly: the names of the private repositories are replaced by
+// aliases and their descriptions and URLs dropped, as are those of the secret
+// gists. An alias is an HMAC of the name with a key kept in the data
+// directory, so a repository keeps its alias across reports and runs while
+// its name can't be guessed back from it. A nil Anonymizer changes nothing.
+type Anonymizer struct {
+	key []byte
+	// private holds the lower cased names with owner of the private repositories
+	private map[string]bool
+}
+
+func NewAnonymizer(key []byte, private []string) *Anonymizer {
+	a := &Anonymizer{key: key, private: map[string]bool{}}
+	for _, name := range private {
+		a.private[strings.ToLower(name)] = true
+	}
+	return a
+}
+
+// loadAnonymizer returns the anonymizer of the reports when --anonymize is
+// set, fetching the private repositories the viewer can access.
+func loadAnonymizer() (*Anonymizer, error) {
+	if !anonymize {
+		return nil, nil
+	}
+	key, err := anonymizeKey()
+	if err != nil {
+		return nil, err
+	}
+	var q privateRepositoriesQuery
+	if err := client.Paginate(&q, nil); err != nil {
+		return nil, err
+	}
+	private := []string{}
+	for _, node := range q.Viewer.Repositories.Nodes {
+		private = append(private, node.NameWithOwner)
+	}
+	return NewAnonymizer(key, private), nil
+}
+
+// anonymizeKey reads the key of the aliases, generating it on first use.
+func anonymizeKey() ([]byte, error) {
+	dir, err := config.DataDir("github")
+	if err != nil {
+		return nil, err
+	}
+	filename := filepath.Join(dir, "anonymize.key")
+	data, err := ioutil.ReadFile(filename)
+	if err == nil {
+		return hex.DecodeString(strings.TrimSpace(string(data)))
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filename, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("Could not save the anonymization key: %v", err)
+	}
+	return key, nil
+}
+
+func (a *Anonymizer) alias(id string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(strings.ToLower(id)))
+	return AnonymizedPrefix + hex.EncodeToString(mac.Sum(nil))[:10]
+}
+
+// Private reports whether the repository nameWithOwner is private.
+func (a *Anonymizer) Private(nameWithOwner string) bool {
+	return a != nil && a.private[strings.ToLower(nameWithOwner)]
+}
+
+// Repo returns the alias of the private repository nameWithOwner, keeping
+// its owner, and the name of the other repositories.
+func (a *Anonymizer) Repo(nameWithOwner string) string {
+	if !a.Private(nameWithOwner) {
+		return nameWithOwner
+	}
+	owner := path.Dir(nameWithOwner)
+	return owner + "/" + a.alias(nameWithOwner)
+}
+
+// Snapshot returns a copy of snapshot with its private repositories renamed.
+func (a *Anonymizer) Snapshot(snapshot Snapshot) Snapshot {
+	if a == nil {
+		return snapshot
+	}
+	anonymized := snapshot
+	anonymized.Repositories = make([]RepoSnapshot, len(snapshot.Repositories))
+	for i, repo := range snapshot.Repositories {
+		name := snapshot.Account + "/" + repo.Name
+		if repo.IsPrivate {
+			a.private[strings.ToLower(name)] = true
+		}
+		repo.Name = path.Base(a.Repo(name))
+		anonymized.Repositories[i] = repo
+	}
+	return anonymized
+}
+
+// Repository returns a copy of repo of owner with its name replaced by its
+// alias and its description dropped when it is private.
+func (a *Anonymizer) Repository(owner string, repo Repository) Repository {
+	if a == nil {
+		return repo
+	}
+	name := owner + "/" + repo.Name
+	if repo.IsPrivate {
+		a.private[strings.ToLower(name)] = true
+	}
+	if a.Private(name) {
+		repo.Name = path.Base(a.Repo(name))
+		repo.Description = ""
+	}
+	return repo
+}
+
+// LiveStore returns a copy of store keyed by the anonymized names, the
+// repositories being private when their webhook payloads said so.
+func (a *Anonymizer) LiveStore(store LiveStore) LiveStore {
+	if a == nil {
+		return store
+	}
+	anonymized := LiveStore{}
+	for repo, stats := range store {
+		if stats.Private {
+			a.private[strings.ToLower(repo)] = true
+		}
+		anonymized[a.Repo(repo)] = stats
+	}
+	return anonymized
+}
+
+func (a *Anonymizer) Snapshots(snapshots []Snapshot) []Snapshot {
+	anonymized := make([]Snapshot, len(snapshots))
+	for i, snapshot := range snapshots {
+		anonymized[i] = a.Snapshot(snapshot)
+	}
+	return anonymized
+}
+
+func (a *Anonymizer) Inventories(inventories []Inventory) []Inventory {
+	anonymized := make([]Inventory, len(inventories))
+	for i, inventory := range inventories {
+		inventory.Repository = a.Repo(inventory.Repository)
+		anonymized[i] = inventory
+	}
+	return anonymized
+}
+
+func (a *Anonymizer) AuditResults(results []AuditResult) []AuditResult {
+	anonymized := make([]AuditResult, len(results))
+	for i, result := range results {
+		if a.Private(result.Repository.NameWithOwner) {
+			result.Repository.NameWithOwner = a.Repo(result.Repository.NameWithOwner)
+			result.Repository.Description = ""
+		}
+		anonymized[i] = result
+	}
+	return anonymized
+}
+
+func (a *Anonymizer) MessagesResults(results []MessagesResult) []MessagesResult {
+	anonymized := make([]MessagesResult, len(results))
+	for i, result := range results {
+		result.Repository = a.Repo(result.Repository)
+		anonymized[i] = result
+	}
+	return anonymized
+}
+
+// Traffic returns a copy of store keyed by the anonymized names.
+func (a *Anonymizer) Traffic(store TrafficStore) TrafficStore {
+	anonymized := TrafficStore{}
+	for repo, days := range store {
+		anonymized[a.Repo(repo)] = days
+	}
+	return anonymized
+}
+
+func (a *Anonymizer) Starred(repositories []StarredRepository) []StarredRepository {
+	anonymized := make([]StarredRepository, len(repositories))
+	for i, repo := range repositories {
+		if a.Private(repo.Name) {
+			repo.Name = a.Repo(repo.Name)
+			repo.URL = ""
+			repo.Description = ""
+		}
+		anonymized[i] = repo
+	}
+	return anonymized
+}
+
+// Gists drops the URL, the secret of a secret gist, along with its
+// description and file names, keeping their extensions.
+func (a *Anonymizer) Gists(gists []Gist) []Gist {
+	if a == nil {
+		return gists
+	}
+	anonymized := make([]Gist, len(gists))
+	for i, gist := range gists {
+		if !gist.IsPublic {
+			files := make([]GistFile, len(gist.Files))
+			for j, file := range gist.Files {
+				file.Name = fmt.Sprintf("file%d%s", j+1, path.Ext(file.Name))
+				files[j] = file
+			}
+			gist.Name = a.alias("gist:" + gist.Name)
+			gist.URL = ""
+			gist.Description = ""
+			gist.Files = files
+		}
+		anonymized[i] = gist
+	}
+	return anonymized
+}
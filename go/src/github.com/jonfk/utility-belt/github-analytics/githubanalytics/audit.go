@@ -0,0 +1,165 @@
+package githubanalytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/githubql"
+)
+
+type auditQuery struct {
+	Viewer struct {
+		Repositories struct {
+			PageInfo githubql.PageInfo
+			Nodes    []AuditRepository
+		} `graphql:"repositories(first: 50, after: $after, ownerAffiliations: OWNER)"`
+	}
+}
+
+// Staleness thresholds of the audit
+const (
+	StaleAfter  = 365 * 24 * time.Hour
+	DeleteAfter = 3 * 365 * 24 * time.Hour
+)
+
+type AuditRepository struct {
+	NameWithOwner string `json:"nameWithOwner"`
+	Description   string `json:"description"`
+	IsFork        bool   `json:"isFork"`
+	IsArchived    bool   `json:"isArchived"`
+	PushedAt      time.Time
+	PullRequests  struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"pullRequests" graphql:"pullRequests(states: OPEN)"`
+	Readme *struct {
+		ID string `json:"id"`
+	} `json:"readme" graphql:"readme: object(expression: \"HEAD:README.md\")"`
+	DefaultBranchRef *struct {
+		Target struct {
+			CommittedDate     time.Time `json:"committedDate"`
+			StatusCheckRollup *struct {
+				State string `json:"state"`
+			} `json:"statusCheckRollup"`
+		} `json:"target" graphql:"target,on=Commit"`
+	} `json:"defaultBranchRef"`
+}
+
+// LastCommit is the date of the last commit of the default branch, the last
+// push for empty repositories.
+func (r AuditRepository) LastCommit() time.Time {
+	if r.DefaultBranchRef != nil && !r.DefaultBranchRef.Target.CommittedDate.IsZero() {
+		return r.DefaultBranchRef.Target.CommittedDate
+	}
+	return r.PushedAt
+}
+
+func (r AuditRepository) CIState() string {
+	if r.DefaultBranchRef == nil || r.DefaultBranchRef.Target.StatusCheckRollup == nil {
+		return ""
+	}
+	return r.DefaultBranchRef.Target.StatusCheckRollup.State
+}
+
+type AuditResult struct {
+	Repository AuditRepository
+	Score      int
+	Actions    []string
+}
+
+func fetchAuditRepositories() ([]AuditRepository, error) {
+	var q auditQuery
+	err := client.Paginate(&q, nil)
+	return q.Viewer.Repositories.Nodes, err
+}
+
+// Audit scores the staleness of repo, higher is staler, and lists the
+// recommended actions.
+func Audit(repo AuditRepository, now time.Time) AuditResult {
+	result := AuditResult{Repository: repo}
+	idle := now.Sub(repo.LastCommit())
+
+	switch {
+	case repo.IsArchived && idle > DeleteAfter:
+		result.Score += 3
+		result.Actions = append(result.Actions, "delete, archived and untouched for over 3 years")
+	case repo.IsArchived:
+		return result
+	case repo.IsFork && idle > StaleAfter:
+		result.Score += 3
+		result.Actions = append(result.Actions, "delete the stale fork")
+	case idle > StaleAfter:
+		result.Score += 2 + int(idle/StaleAfter)
+		result.Actions = append(result.Actions, fmt.Sprintf("archive, no commit since %s", repo.LastCommit().Format("2006-01-02")))
+	}
+	if repo.IsArchived {
+		return result
+	}
+
+	if n := repo.PullRequests.TotalCount; n > 0 {
+		result.Score += n
+		result.Actions = append(result.Actions, fmt.Sprintf("triage %d open pull requests", n))
+	}
+	if state := repo.CIState(); state == "FAILURE" || state == "ERROR" {
+		result.Score += 2
+		result.Actions = append(result.Actions, "fix CI on the default branch")
+	}
+	if repo.Readme == nil && !repo.IsFork {
+		result.Score++
+		result.Actions = append(result.Actions, "add a README")
+	} else if idle > StaleAfter && !repo.IsFork {
+		result.Actions = append(result.Actions, "update the README to state the project is unmaintained")
+	}
+	if repo.Description == "" && !repo.IsFork {
+		result.Score++
+		result.Actions = append(result.Actions, "add a description")
+	}
+	return result
+}
+
+// AuditAll audits repositories, keeping the ones with actions sorted by score.
+func AuditAll(repositories []AuditRepository, now time.Time) []AuditResult {
+	results := []AuditResult{}
+	for _, repo := range repositories {
+		if result := Audit(repo, now); len(result.Actions) > 0 {
+			results = append(results, result)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
+
+func FormatChecklist(result AuditResult) string {
+	lines := []string{}
+	for _, action := range result.Actions {
+		lines = append(lines, "- [ ] "+action)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func FormatAudit(results []AuditResult) string {
+	sections := []string{}
+	for _, result := range results {
+		repo := result.Repository
+		header := fmt.Sprintf("## %s (score %d, last commit %s)", repo.NameWithOwner, result.Score, repo.LastCommit().Format("2006-01-02"))
+		sections = append(sections, header+"\n\n"+FormatChecklist(result))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// CreateAuditIssue opens an issue listing the actions on the repository,
+// archived repositories are read only and skipped.
+func CreateAuditIssue(result AuditResult) (string, error) {
+	if result.Repository.IsArchived {
+		return "", nil
+	}
+	var issue struct {
+		HTMLURL string `json:"html_url"`
+	}
+	err := client.REST("POST", "/repos/"+result.Repository.NameWithOwner+"/issues", map[string]string{
+		"title": "Repository maintenance",
+		"body":  "Recommended by github-analytics audit:\n\n" + FormatChecklist(result),
+	}, &issue)
+	return issue.HTMLURL, err
+}
@@ -0,0 +1,876 @@
+package githubanalytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/jonfk/utility-belt/inspection-server/inspectionserver"
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/jonfk/utility-belt/internal/githubql"
+	"github.com/jonfk/utility-belt/internal/httpx"
+	"github.com/urfave/cli"
+	git "gopkg.in/src-d/go-git.v3"
+)
+
+var (
+	client *githubql.Client
+	// settings resolved from the flags, environment, keychain and config file
+	token     string
+	username  string
+	cacheFile string
+	// anonymize strips the private repositories out of the reports, see Anonymizer
+	anonymize bool
+)
+
+// Command is the github-analytics command tree, run standalone as github-analytics or as ub github.
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "github",
+		Usage: "Analyzes your github repositories",
+		Before: func(c *cli.Context) error {
+			cfg, err := config.Load("github")
+			if err != nil {
+				return err
+			}
+			token, err = cfg.Secret(c, "token")
+			if err != nil {
+				return err
+			}
+			// listen only receives webhooks and never calls the API, the
+			// completions are read from the local stores
+			if token == "" && c.Args().First() != "listen" && !belt.Completing() {
+				return fmt.Errorf("No token passed as argument, set it with --token, %s or `ub config set-secret github.token`", cfg.EnvVar("token"))
+			}
+			username = cfg.String(c, "username")
+			anonymize = c.Bool("anonymize")
+			cacheFile = cfg.String(c, "cache")
+			if cacheFile == "" {
+				dir, err := config.CacheDir("github")
+				if err != nil {
+					return err
+				}
+				cacheFile = filepath.Join(dir, "repositories.json")
+			}
+			httpClient, err := httpx.FromContext(c)
+			if err != nil {
+				return err
+			}
+			client = githubql.NewClient(httpClient, token)
+			return nil
+		},
+		Action: func(c *cli.Context) error {
+			repositories, err := FetchRepositoriesFromNetOrFile(cacheFile)
+			if err != nil {
+				return err
+			}
+
+			anon, err := loadAnonymizer()
+			if err != nil {
+				return err
+			}
+			failed := 0
+			for _, repo := range repositories {
+				if err := AnalyzeGithubRepo(username, repo, anon); err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+					failed++
+				}
+			}
+			fmt.Printf("Total Count : %d\n", len(repositories))
+			if failed > 0 {
+				return cli.NewExitError(fmt.Sprintf("Could not analyze %d repositories", failed), 1)
+			}
+			return nil
+		},
+		Subcommands: []cli.Command{
+			{
+				Name:    "ratelimit",
+				Aliases: []string{},
+				Usage:   "Check the github ratelimit",
+				Action: func(c *cli.Context) error {
+					rateLimit, err := GithubCheckRateLimit()
+					if err != nil {
+						return err
+					}
+					spew.Dump(rateLimit)
+					return nil
+				},
+			},
+			{
+				Name:  "traffic",
+				Usage: "Collect the views and clones of your repositories and chart their long term trends",
+				Description: "Github only retains 14 days of traffic, every run appends it to a local store\n" +
+					"   which is charted from its first recorded day. Run it at least every two weeks,\n" +
+					"   e.g. from cron, to keep the series continuous.",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "store",
+						Usage: "`FILE` accumulating the traffic, defaults to the XDG data directory",
+					},
+					cli.BoolFlag{
+						Name:  "offline",
+						Usage: "Only chart the stored traffic without collecting",
+					},
+					cli.StringFlag{
+						Name:  "repo",
+						Usage: "Chart every period of `OWNER/NAME` instead of a summary of all repositories",
+					},
+					cli.StringFlag{
+						Name:  "period",
+						Usage: "Group the traffic by `PERIOD`: day, week or month",
+						Value: "week",
+					},
+					cli.StringFlag{
+						Name:  "since",
+						Usage: "Chart from `DATE` (YYYY-MM-DD) instead of the first recorded day",
+					},
+				},
+				BashComplete: func(c *cli.Context) {
+					if !belt.CompletingFlag("repo") {
+						return
+					}
+					path, err := trafficStorePath(c)
+					if err != nil {
+						return
+					}
+					store, err := LoadTrafficStore(path)
+					if err != nil {
+						return
+					}
+					for _, repo := range store.Repositories() {
+						fmt.Println(repo)
+					}
+				},
+				Action: func(c *cli.Context) error {
+					period := c.String("period")
+					if period != "day" && period != "week" && period != "month" {
+						return fmt.Errorf("Unknown period %s, expected day, week or month", period)
+					}
+					var since time.Time
+					if c.String("since") != "" {
+						var err error
+						since, err = time.Parse("2006-01-02", c.String("since"))
+						if err != nil {
+							return fmt.Errorf("Invalid --since date %s, expected YYYY-MM-DD", c.String("since"))
+						}
+					}
+					path, err := trafficStorePath(c)
+					if err != nil {
+						return err
+					}
+					store, err := LoadTrafficStore(path)
+					if err != nil {
+						return err
+					}
+
+					if !c.Bool("offline") {
+						repos := []string{c.String("repo")}
+						if repos[0] == "" {
+							if repos, err = fetchOwnedRepositoryNames(); err != nil {
+								return err
+							}
+						}
+						for _, repo := range repos {
+							if err := store.CollectTraffic(repo); err != nil {
+								return err
+							}
+						}
+						if err := store.Save(path); err != nil {
+							return err
+						}
+					}
+
+					anon, err := loadAnonymizer()
+					if err != nil {
+						return err
+					}
+					until := time.Now().UTC()
+					if repo := c.String("repo"); repo != "" {
+						if _, ok := store[repo]; !ok {
+							return fmt.Errorf("No traffic recorded for %s", repo)
+						}
+						fmt.Println(FormatTrafficChart(anon.Traffic(store), anon.Repo(repo), period, since, until))
+						return nil
+					}
+					store = anon.Traffic(store)
+					fmt.Println(FormatTrafficSummary(store, store.Repositories(), period, since, until))
+					return nil
+				},
+			},
+			{
+				Name:      "snapshot",
+				Usage:     "Save the stars, forks, commits and languages of the repositories of an account",
+				ArgsUsage: "[ACCOUNT]",
+				Description: "With --anonymize the snapshot is still stored as is for the trends and comparisons,\n" +
+					"   the anonymized one is written to --output or printed.",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "output,o",
+						Usage: "Write the snapshot to `FILE`, defaults to ACCOUNT-DATE.json in the XDG data directory",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					account := c.Args().First()
+					if account == "" {
+						var err error
+						if account, err = fetchViewerLogin(); err != nil {
+							return err
+						}
+					}
+					snapshot, err := TakeSnapshot(account)
+					if err != nil {
+						return err
+					}
+					anon, err := loadAnonymizer()
+					if err != nil {
+						return err
+					}
+					filename := c.String("output")
+					if anon != nil {
+						dir, err := snapshotDir()
+						if err != nil {
+							return err
+						}
+						if _, err := storeSnapshot(dir, snapshot); err != nil {
+							return err
+						}
+						anonymized := anon.Snapshot(snapshot)
+						if filename == "" {
+							data, err := json.MarshalIndent(anonymized, "", "  ")
+							if err != nil {
+								return err
+							}
+							fmt.Println(string(data))
+							return nil
+						}
+						if err := anonymized.Save(filename); err != nil {
+							return err
+						}
+					} else if filename == "" {
+						dir, err := snapshotDir()
+						if err != nil {
+							return err
+						}
+						if filename, err = storeSnapshot(dir, snapshot); err != nil {
+							return err
+						}
+					} else if err := snapshot.Save(filename); err != nil {
+						return err
+					}
+					fmt.Println(filename)
+					return nil
+				},
+			},
+			{
+				Name:      "trend",
+				Usage:     "Chart the stars, commit velocity and code size of the repositories across the stored snapshots",
+				ArgsUsage: "[ACCOUNT]",
+				Description: "Snapshots are stored by the snapshot and compare commands, one per account and day;\n" +
+					"   run trend --collect daily, e.g. from cron, to build the history. The code size is the\n" +
+					"   bytes of the languages github detects, its closest measure of lines of code",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "repo",
+						Usage: "Chart the repository `NAME` snapshot by snapshot instead of summarizing all of them",
+					},
+					cli.StringFlag{
+						Name:  "since",
+						Usage: "Only use the snapshots taken from `DATE` (2006-01-02)",
+					},
+					cli.BoolFlag{
+						Name:  "collect",
+						Usage: "Take and store a snapshot of the account first",
+					},
+					cli.StringFlag{
+						Name:  "svg",
+						Usage: "Write the chart of the repository, or of the account totals, as SVG to `FILE`, - for stdout",
+					},
+					cli.StringFlag{
+						Name:  "store",
+						Usage: "Read the snapshots from `DIR` instead of the XDG data directory",
+					},
+				},
+				BashComplete: func(c *cli.Context) {
+					if !belt.CompletingFlag("repo") {
+						return
+					}
+					dir := c.String("store")
+					if dir == "" {
+						var err error
+						if dir, err = snapshotDir(); err != nil {
+							return
+						}
+					}
+					for _, name := range SnapshotRepositoryNames(dir, c.Args().First()) {
+						fmt.Println(name)
+					}
+				},
+				Action: func(c *cli.Context) error {
+					account := c.Args().First()
+					if account == "" {
+						var err error
+						if account, err = fetchViewerLogin(); err != nil {
+							return err
+						}
+					}
+					var since time.Time
+					if c.String("since") != "" {
+						var err error
+						if since, err = time.Parse("2006-01-02", c.String("since")); err != nil {
+							return fmt.Errorf("Invalid date %s, expected 2006-01-02", c.String("since"))
+						}
+					}
+					dir := c.String("store")
+					if dir == "" {
+						var err error
+						if dir, err = snapshotDir(); err != nil {
+							return err
+						}
+					}
+					if c.Bool("collect") {
+						snapshot, err := TakeSnapshot(account)
+						if err != nil {
+							return err
+						}
+						if _, err := storeSnapshot(dir, snapshot); err != nil {
+							return err
+						}
+					}
+					snapshots, err := LoadSnapshots(dir, account)
+					if err != nil {
+						return err
+					}
+					if len(snapshots) == 0 {
+						return fmt.Errorf("No snapshots of %s in %s, take some with the snapshot command or --collect", account, dir)
+					}
+
+					anon, err := loadAnonymizer()
+					if err != nil {
+						return err
+					}
+					// also learns which repositories were private from the snapshots
+					anonymized := anon.Snapshots(snapshots)
+
+					repo := c.String("repo")
+					if repo == "" && c.String("svg") == "" {
+						fmt.Println(FormatTrendSummary(anonymized, since))
+						return nil
+					}
+					points := Trend(snapshots, repo, since)
+					if len(points) == 0 {
+						return fmt.Errorf("No snapshots of %s/%s", account, repo)
+					}
+					if svg := c.String("svg"); svg != "" {
+						title := account
+						if repo != "" {
+							title = anon.Repo(account + "/" + repo)
+						}
+						chart := TrendSVG(title, points)
+						if svg == "-" {
+							fmt.Print(chart)
+							return nil
+						}
+						return ioutil.WriteFile(svg, []byte(chart), 0644)
+					}
+					fmt.Println(FormatTrendChart(points))
+					return nil
+				},
+			},
+			{
+				Name:      "compare",
+				Usage:     "Report what changed between two snapshots or accounts as Markdown",
+				ArgsUsage: "BEFORE AFTER",
+				Description: "BEFORE and AFTER are snapshot files saved by the snapshot command or account\n" +
+					"   names which are fetched live, e.g. compare last-month.json jonfk",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 2 {
+						return fmt.Errorf("Expected 2 snapshots or accounts to compare, got %d", c.NArg())
+					}
+					before, err := ResolveSnapshot(c.Args().Get(0))
+					if err != nil {
+						return err
+					}
+					after, err := ResolveSnapshot(c.Args().Get(1))
+					if err != nil {
+						return err
+					}
+					anon, err := loadAnonymizer()
+					if err != nil {
+						return err
+					}
+					fmt.Print(CompareSnapshots(anon.Snapshot(before), anon.Snapshot(after)))
+					return nil
+				},
+			},
+			{
+				Name:  "audit",
+				Usage: "Score the staleness of your repositories and print recommended actions as a checklist",
+				Flags: []cli.Flag{
+					cli.IntFlag{
+						Name:  "min-score",
+						Usage: "Only report repositories scoring at least `N`",
+						Value: 1,
+					},
+					cli.BoolFlag{
+						Name:  "create-issues",
+						Usage: "Open an issue with the checklist on every reported repository",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if anonymize && c.Bool("create-issues") {
+						return fmt.Errorf("--create-issues can't be combined with --anonymize")
+					}
+					anon, err := loadAnonymizer()
+					if err != nil {
+						return err
+					}
+					repositories, err := fetchAuditRepositories()
+					if err != nil {
+						return err
+					}
+					results := []AuditResult{}
+					for _, result := range AuditAll(repositories, time.Now()) {
+						if result.Score >= c.Int("min-score") {
+							results = append(results, result)
+						}
+					}
+					if len(results) == 0 {
+						fmt.Println("Nothing to do")
+						return nil
+					}
+					fmt.Println(FormatAudit(anon.AuditResults(results)))
+
+					if !c.Bool("create-issues") {
+						return nil
+					}
+					fmt.Println()
+					for _, result := range results {
+						url, err := CreateAuditIssue(result)
+						if err != nil {
+							return err
+						}
+						if url != "" {
+							fmt.Printf("Opened %s\n", url)
+						}
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "inventory",
+				Usage: "Check your repositories for CODEOWNERS, a license, CI, dependabot and branch protection",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "format,f",
+						Usage: "Output `FORMAT`: text, markdown to paste into an issue, or json",
+						Value: "text",
+					},
+					cli.BoolFlag{
+						Name:  "missing",
+						Usage: "Only list the repositories lacking something",
+					},
+					cli.BoolFlag{
+						Name:  "forks",
+						Usage: "Include forks",
+					},
+					cli.BoolFlag{
+						Name:  "archived",
+						Usage: "Include archived repositories",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					format := c.String("format")
+					if format != "text" && format != "markdown" && format != "json" {
+						return fmt.Errorf("Unknown format %s, expected text, markdown or json", format)
+					}
+					repositories, err := fetchInventoryRepositories()
+					if err != nil {
+						return err
+					}
+					anon, err := loadAnonymizer()
+					if err != nil {
+						return err
+					}
+					inventories := anon.Inventories(TakeInventories(repositories, c.Bool("forks"), c.Bool("archived"), c.Bool("missing")))
+					if format == "json" {
+						data, err := json.MarshalIndent(inventories, "", "  ")
+						if err != nil {
+							return err
+						}
+						fmt.Println(string(data))
+						return nil
+					}
+					fmt.Println(FormatInventory(inventories, format == "markdown"))
+					return nil
+				},
+			},
+			{
+				Name:  "messages",
+				Usage: "Score the commit messages of your repositories: conventional commits, subject length and WIP/fixup noise",
+				Flags: []cli.Flag{
+					cli.IntFlag{
+						Name:  "commits,n",
+						Usage: "Number of commits of the default branch to analyze per repository, at most 100",
+						Value: 100,
+					},
+					cli.BoolFlag{
+						Name:  "all-authors",
+						Usage: "Include the commits of other authors",
+					},
+					cli.BoolFlag{
+						Name:  "forks",
+						Usage: "Include forks",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					commits := c.Int("commits")
+					if commits < 1 || commits > 100 {
+						return fmt.Errorf("--commits must be between 1 and 100")
+					}
+					repositories, login, err := fetchMessagesRepositories(commits)
+					if err != nil {
+						return err
+					}
+					if c.Bool("all-authors") {
+						login = ""
+					}
+					anon, err := loadAnonymizer()
+					if err != nil {
+						return err
+					}
+					fmt.Println(FormatMessages(anon.MessagesResults(AnalyzeAllMessages(repositories, login, c.Bool("forks")))))
+					return nil
+				},
+			},
+			{
+				Name:  "stars",
+				Usage: "Report the languages and topics you star the most and your stars on archived repositories",
+				Flags: []cli.Flag{
+					cli.IntFlag{
+						Name:  "top",
+						Usage: "Report the `N` most starred languages and topics",
+						Value: 10,
+					},
+					cli.StringFlag{
+						Name:  "export",
+						Usage: "Also write the starred repositories as json to `FILE`, as a backup",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					repositories, err := fetchStarredRepositories()
+					if err != nil {
+						return err
+					}
+					anon, err := loadAnonymizer()
+					if err != nil {
+						return err
+					}
+					repositories = anon.Starred(repositories)
+					if c.String("export") != "" {
+						if err := exportJSON(c.String("export"), repositories); err != nil {
+							return err
+						}
+					}
+					fmt.Println(FormatStars(repositories, c.Int("top")))
+					return nil
+				},
+			},
+			{
+				Name:  "gists",
+				Usage: "List your public and secret gists",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "export",
+						Usage: "Also write the gists and their files as json to `FILE`, as a backup",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					gists, err := fetchGists()
+					if err != nil {
+						return err
+					}
+					anon, err := loadAnonymizer()
+					if err != nil {
+						return err
+					}
+					gists = anon.Gists(gists)
+					if c.String("export") != "" {
+						if err := exportJSON(c.String("export"), gists); err != nil {
+							return err
+						}
+					}
+					fmt.Println(FormatGists(gists))
+					return nil
+				},
+			},
+			{
+				Name:  "listen",
+				Usage: "Keep live stats of your repositories from their push, star and issues webhooks and serve them",
+				Description: "Point the repository or organization webhooks, with the json content type, at\n" +
+					"   " + WebhookPath + ". The stats are served as Markdown on / and as json on /stats.json.",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "addr,a",
+						Usage: "Address to listen on",
+						Value: ":8080",
+					},
+					cli.StringFlag{
+						Name:  "store",
+						Usage: "`FILE` keeping the stats, defaults to the XDG data directory",
+					},
+					cli.StringFlag{
+						Name:  "webhook-secret",
+						Usage: "Secret of the webhooks, deliveries without a valid X-Hub-Signature-256 are rejected",
+					},
+					cli.StringFlag{
+						Name:  "captures",
+						Usage: "Also save every delivery in `DIR` as an inspection-server capture",
+					},
+					cli.StringFlag{
+						Name:  "ui-auth",
+						Usage: "Protect the stats with basic auth as `USER:PASS`",
+					},
+					cli.StringFlag{
+						Name:  "ui-token",
+						Usage: "Protect the stats with a bearer `TOKEN`, also accepted as ?token=",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := config.Load("github")
+					if err != nil {
+						return err
+					}
+					secret, err := cfg.Secret(c, "webhook-secret")
+					if err != nil {
+						return err
+					}
+					userPass, err := cfg.Secret(c, "ui-auth")
+					if err != nil {
+						return err
+					}
+					uiToken, err := cfg.Secret(c, "ui-token")
+					if err != nil {
+						return err
+					}
+					auth, err := inspectionserver.ParseUIAuth(userPass, uiToken)
+					if err != nil {
+						return err
+					}
+					path := c.String("store")
+					if path == "" {
+						dir, err := config.DataDir("github")
+						if err != nil {
+							return err
+						}
+						path = filepath.Join(dir, "live.json")
+					}
+					store, err := LoadLiveStore(path)
+					if err != nil {
+						return err
+					}
+					l := &listener{path: path, secret: secret, store: store}
+					if anonymize {
+						// listen never calls the API, the payloads tell which
+						// repositories are private
+						key, err := anonymizeKey()
+						if err != nil {
+							return err
+						}
+						l.anonymizer = NewAnonymizer(key, nil)
+					}
+					if c.String("captures") != "" {
+						if l.captures, err = inspectionserver.NewStore(c.String("captures")); err != nil {
+							return err
+						}
+					}
+
+					mux := http.NewServeMux()
+					mux.HandleFunc(WebhookPath, l.webhookHandler)
+					mux.HandleFunc("/", auth.Wrap(l.statsHandler))
+
+					fmt.Printf("listening for webhooks on %s%s, keeping stats in %s\n", c.String("addr"), WebhookPath, path)
+					if secret == "" {
+						fmt.Printf("Accepting unsigned deliveries, set --webhook-secret when exposed publicly\n")
+					}
+					return http.ListenAndServe(c.String("addr"), mux)
+				},
+			},
+		},
+		Flags: append([]cli.Flag{
+			cli.StringFlag{
+				Name:  "token,t",
+				Usage: "Github access token",
+				Value: "",
+			},
+			cli.StringFlag{
+				Name:  "username,u",
+				Usage: "Github username",
+				Value: "",
+			},
+			cli.StringFlag{
+				Name:  "cache",
+				Usage: "File caching the list of repositories, defaults to the XDG cache directory",
+				Value: "",
+			},
+			cli.BoolFlag{
+				Name:  "anonymize",
+				Usage: "Replace the names of private repositories by stable aliases and drop their descriptions, to share the reports publicly",
+			},
+		}, httpx.Flags...),
+	}
+}
+
+// trafficStorePath is the --store of the traffic command, traffic.json in
+// the XDG data directory by default.
+func trafficStorePath(c *cli.Context) (string, error) {
+	if path := c.String("store"); path != "" {
+		return path, nil
+	}
+	dir, err := config.DataDir("github")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "traffic.json"), nil
+}
+
+type repositoriesQuery struct {
+	Viewer struct {
+		Repositories struct {
+			PageInfo githubql.PageInfo
+			Nodes    []Repository
+		} `graphql:"repositories(first: 30, after: $after)"`
+	}
+}
+
+func getAllGithubRepositories() ([]Repository, error) {
+	var q repositoriesQuery
+	err := client.Paginate(&q, nil)
+	return q.Viewer.Repositories.Nodes, err
+}
+
+type Repository struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	IsFork      bool   `json:"isFork"`
+	IsPrivate   bool   `json:"isPrivate"`
+	Description string `json:"description"`
+}
+
+// AnalyzeGithubRepo prints the first and last commits of repo, with the
+// private repositories renamed by anon.
+func AnalyzeGithubRepo(username string, repo Repository, anon *Anonymizer) error {
+	if repo.IsFork {
+		return nil
+	}
+	listed := anon.Repository(username, repo)
+	repoUrl := ToGithubGitHttpsUrl(username, repo.Name)
+	r, err := git.NewRepository(repoUrl, nil)
+	if err != nil {
+		return fmt.Errorf("Could not open %s: %v", listed.Name, err)
+	}
+
+	if err := r.PullDefault(); err != nil {
+		return fmt.Errorf("Could not pull %s: %v", listed.Name, err)
+	}
+
+	iter, err := r.Commits()
+	if err != nil {
+		return fmt.Errorf("Could not list the commits of %s: %v", listed.Name, err)
+	}
+	defer iter.Close()
+
+	var commits []git.Commit
+
+	for {
+		//the commits are not shorted in any special order
+		commit, err := iter.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("Could not read the commits of %s: %v", listed.Name, err)
+		}
+
+		commits = append(commits, *commit)
+	}
+	sort.Sort(ByTime(commits))
+	// TODO complete analysis print the commit properly and something smarter with frequency and recent commits
+	if listed.Name != repo.Name {
+		repoUrl = ""
+	}
+	if len(commits) == 0 {
+		fmt.Printf("* %s\n\t* %s\n\t* %s\n\t* Commits: none\n", listed.Name, repoUrl, listed.Description)
+		return nil
+	}
+	fmt.Printf("* %s\n\t* %s\n\t* %s\n\t* Commits:\n\t\t* First %s\n\t\t* Last %s\n", listed.Name, repoUrl, listed.Description, commits[0].Author.When, commits[len(commits)-1].Author.When.String())
+	return nil
+}
+
+func ToGithubGitHttpsUrl(username, repoName string) string {
+	return fmt.Sprintf("https://github.com/%s/%s", username, repoName)
+}
+
+type ByTime []git.Commit
+
+func (a ByTime) Len() int           { return len(a) }
+func (a ByTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a ByTime) Less(i, j int) bool { return a[i].Author.When.Before(a[j].Author.When) }
+
+func SaveRepositoriesToFile(repositories []Repository, filename string) error {
+	repositoriesByte, err := json.MarshalIndent(repositories, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, repositoriesByte, 0644)
+}
+
+func FetchRepositoriesFromNetOrFile(filename string) ([]Repository, error) {
+	repositoriesByte, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		repositories, err := getAllGithubRepositories()
+		if err != nil {
+			return nil, err
+		}
+		return repositories, SaveRepositoriesToFile(repositories, filename)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var repositories []Repository
+	err = json.Unmarshal(repositoriesByte, &repositories)
+	return repositories, err
+}
+
+func GithubCheckRateLimit() (GithubRateLimitModel, error) {
+	rateLimit := GithubRateLimitModel{}
+	err := client.REST("GET", "/rate_limit", nil, &rateLimit)
+	return rateLimit, err
+}
+
+type GithubRateLimitModel struct {
+	Resources struct {
+		Core struct {
+			Limit     int `json:"limit"`
+			Remaining int `json:"remaining"`
+			Reset     int `json:"reset"`
+		} `json:"core"`
+		Search struct {
+			Limit     int `json:"limit"`
+			Remaining int `json:"remaining"`
+			Reset     int `json:"reset"`
+		} `json:"search"`
+		Graphql struct {
+			Limit     int `json:"limit"`
+			Remaining int `json:"remaining"`
+			Reset     int `json:"reset"`
+		} `json:"graphql"`
+	} `json:"resources"`
+}
@@ -0,0 +1,197 @@
+package githubanalytics
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jonfk/utility-belt/internal/githubql"
+)
+
+type inventoryQuery struct {
+	Viewer struct {
+		Repositories struct {
+			PageInfo githubql.PageInfo
+			Nodes    []InventoryRepository
+		} `graphql:"repositories(first: 25, after: $after, ownerAffiliations: OWNER)"`
+	}
+}
+
+type gitObject struct {
+	ID string `json:"id"`
+}
+
+type InventoryRepository struct {
+	NameWithOwner string `json:"nameWithOwner"`
+	IsFork        bool   `json:"isFork"`
+	IsArchived    bool   `json:"isArchived"`
+	LicenseInfo   *struct {
+		SpdxID string `json:"spdxId"`
+	} `json:"licenseInfo"`
+	Codeowners       *gitObject `json:"codeowners" graphql:"codeowners: object(expression: \"HEAD:CODEOWNERS\")"`
+	GithubCodeowners *gitObject `json:"githubCodeowners" graphql:"githubCodeowners: object(expression: \"HEAD:.github/CODEOWNERS\")"`
+	DocsCodeowners   *gitObject `json:"docsCodeowners" graphql:"docsCodeowners: object(expression: \"HEAD:docs/CODEOWNERS\")"`
+	Workflows        *struct {
+		Entries []struct {
+			Name string `json:"name"`
+		} `json:"entries"`
+	} `json:"workflows" graphql:"workflows: object(expression: \"HEAD:.github/workflows\"),on=Tree"`
+	Travis           *gitObject `json:"travis" graphql:"travis: object(expression: \"HEAD:.travis.yml\")"`
+	CircleCI         *gitObject `json:"circleci" graphql:"circleci: object(expression: \"HEAD:.circleci/config.yml\")"`
+	Dependabot       *gitObject `json:"dependabot" graphql:"dependabot: object(expression: \"HEAD:.github/dependabot.yml\")"`
+	DependabotYaml   *gitObject `json:"dependabotYaml" graphql:"dependabotYaml: object(expression: \"HEAD:.github/dependabot.yaml\")"`
+	DefaultBranchRef *struct {
+		BranchProtectionRule *gitObject `json:"branchProtectionRule"`
+	} `json:"defaultBranchRef"`
+}
+
+func fetchInventoryRepositories() ([]InventoryRepository, error) {
+	var q inventoryQuery
+	err := client.Paginate(&q, nil)
+	return q.Viewer.Repositories.Nodes, err
+}
+
+// Inventory is the hygiene of a repository, the empty strings are missing.
+type Inventory struct {
+	Repository string `json:"repository"`
+	Codeowners string `json:"codeowners"`
+	// License is the SPDX id, NOASSERTION for unrecognized licenses
+	License    string `json:"license"`
+	CI         string `json:"ci"`
+	Dependabot string `json:"dependabot"`
+	Protected  bool   `json:"protected"`
+}
+
+// Missing lists the hygiene items the repository lacks.
+func (i Inventory) Missing() []string {
+	missing := []string{}
+	for _, item := range []struct {
+		name    string
+		present bool
+	}{
+		{"CODEOWNERS", i.Codeowners != ""},
+		{"license", i.License != ""},
+		{"CI", i.CI != ""},
+		{"dependabot", i.Dependabot != ""},
+		{"branch protection", i.Protected},
+	} {
+		if !item.present {
+			missing = append(missing, item.name)
+		}
+	}
+	return missing
+}
+
+// TakeInventory finds the hygiene files of repo.
+func TakeInventory(repo InventoryRepository) Inventory {
+	inventory := Inventory{Repository: repo.NameWithOwner}
+	switch {
+	case repo.GithubCodeowners != nil:
+		inventory.Codeowners = ".github/CODEOWNERS"
+	case repo.Codeowners != nil:
+		inventory.Codeowners = "CODEOWNERS"
+	case repo.DocsCodeowners != nil:
+		inventory.Codeowners = "docs/CODEOWNERS"
+	}
+	if repo.LicenseInfo != nil {
+		inventory.License = repo.LicenseInfo.SpdxID
+	}
+	ci := []string{}
+	if repo.Workflows != nil && len(repo.Workflows.Entries) > 0 {
+		ci = append(ci, fmt.Sprintf("actions (%d)", len(repo.Workflows.Entries)))
+	}
+	if repo.Travis != nil {
+		ci = append(ci, "travis")
+	}
+	if repo.CircleCI != nil {
+		ci = append(ci, "circleci")
+	}
+	inventory.CI = strings.Join(ci, ", ")
+	switch {
+	case repo.Dependabot != nil:
+		inventory.Dependabot = ".github/dependabot.yml"
+	case repo.DependabotYaml != nil:
+		inventory.Dependabot = ".github/dependabot.yaml"
+	}
+	inventory.Protected = repo.DefaultBranchRef != nil && repo.DefaultBranchRef.BranchProtectionRule != nil
+	return inventory
+}
+
+// TakeInventories takes the inventory of repositories, skipping the forks and
+// the archived ones unless asked, and the complete ones when missingOnly.
+func TakeInventories(repositories []InventoryRepository, forks, archived, missingOnly bool) []Inventory {
+	inventories := []Inventory{}
+	for _, repo := range repositories {
+		if (repo.IsFork && !forks) || (repo.IsArchived && !archived) {
+			continue
+		}
+		inventory := TakeInventory(repo)
+		if missingOnly && len(inventory.Missing()) == 0 {
+			continue
+		}
+		inventories = append(inventories, inventory)
+	}
+	return inventories
+}
+
+// FormatInventory renders the compliance matrix as an aligned table or as
+// Markdown, followed by how many repositories lack each item.
+func FormatInventory(inventories []Inventory, markdown bool) string {
+	if len(inventories) == 0 {
+		return "No repositories"
+	}
+	check := func(value string) string {
+		if value == "" {
+			return "✗"
+		}
+		return value
+	}
+	rows := [][]string{{"Repository", "CODEOWNERS", "License", "CI", "Dependabot", "Protected"}}
+	lacking := map[string]int{}
+	for _, i := range inventories {
+		protected := ""
+		if i.Protected {
+			protected = "✓"
+		}
+		codeowners, dependabot := "", ""
+		if i.Codeowners != "" {
+			codeowners = "✓"
+		}
+		if i.Dependabot != "" {
+			dependabot = "✓"
+		}
+		rows = append(rows, []string{i.Repository, check(codeowners), check(i.License), check(i.CI), check(dependabot), check(protected)})
+		for _, item := range i.Missing() {
+			lacking[item]++
+		}
+	}
+
+	var b strings.Builder
+	if markdown {
+		for n, row := range rows {
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(row, " | "))
+			if n == 0 {
+				b.WriteString("|---|:-:|:-:|:-:|:-:|:-:|\n")
+			}
+		}
+	} else {
+		w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		w.Flush()
+	}
+
+	counts := []string{}
+	for _, item := range []string{"CODEOWNERS", "license", "CI", "dependabot", "branch protection"} {
+		if lacking[item] > 0 {
+			counts = append(counts, fmt.Sprintf("%d without %s", lacking[item], item))
+		}
+	}
+	if len(counts) == 0 {
+		fmt.Fprintf(&b, "\nAll %d repositories have the basics", len(inventories))
+	} else {
+		fmt.Fprintf(&b, "\n%d repositories: %s", len(inventories), strings.Join(counts, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,245 @@
+package githubanalytics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonfk/utility-belt/inspection-server/inspectionserver"
+)
+
+// WebhookPath receives the webhook deliveries, the other paths serve the
+// stats.
+const WebhookPath = "/webhook"
+
+// LiveStats are the counters of a repository kept up to date by its
+// webhooks. Stars, forks and open issues are copied from the repository of
+// every payload, the others accumulate from the events.
+type LiveStats struct {
+	Stars      int            `json:"stars"`
+	Forks      int            `json:"forks"`
+	OpenIssues int            `json:"open_issues"`
+	Private    bool           `json:"private,omitempty"`
+	Pushes     int            `json:"pushes"`
+	Commits    int            `json:"commits"`
+	LastPush   time.Time      `json:"last_push,omitempty"`
+	LastEvent  time.Time      `json:"last_event"`
+	Events     map[string]int `json:"events"`
+}
+
+// LiveStore is the LiveStats by repository full name, saved after every
+// delivery.
+type LiveStore map[string]*LiveStats
+
+func LoadLiveStore(path string) (LiveStore, error) {
+	store := LiveStore{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("Could not read live store %s: %v", path, err)
+	}
+	return store, nil
+}
+
+func (store LiveStore) Save(path string) error {
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// webhookPayload holds the fields of the push, star, watch, fork and issues
+// payloads used by the store.
+type webhookPayload struct {
+	Action     string `json:"action"`
+	Repository *struct {
+		FullName        string `json:"full_name"`
+		Private         bool   `json:"private"`
+		StargazersCount int    `json:"stargazers_count"`
+		ForksCount      int    `json:"forks_count"`
+		OpenIssuesCount int    `json:"open_issues_count"`
+	} `json:"repository"`
+	Commits    []json.RawMessage `json:"commits"`
+	HeadCommit *struct {
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"head_commit"`
+}
+
+// Apply updates the store with the delivery of event, returning the
+// repository it concerns. Events without a repository, like ping, are
+// ignored.
+func (store LiveStore) Apply(event string, body []byte, now time.Time) (string, error) {
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("Invalid %s payload: %v", event, err)
+	}
+	if payload.Repository == nil || payload.Repository.FullName == "" {
+		return "", nil
+	}
+	repo := payload.Repository.FullName
+	stats := store[repo]
+	if stats == nil {
+		stats = &LiveStats{Events: map[string]int{}}
+		store[repo] = stats
+	}
+	stats.Stars = payload.Repository.StargazersCount
+	stats.Forks = payload.Repository.ForksCount
+	stats.OpenIssues = payload.Repository.OpenIssuesCount
+	stats.Private = payload.Repository.Private
+	stats.LastEvent = now
+	stats.Events[event]++
+
+	if event == "push" {
+		stats.Pushes++
+		stats.Commits += len(payload.Commits)
+		stats.LastPush = now
+		if payload.HeadCommit != nil && !payload.HeadCommit.Timestamp.IsZero() {
+			stats.LastPush = payload.HeadCommit.Timestamp
+		}
+	}
+	return repo, nil
+}
+
+// Repositories returns the names of the repositories of the store, most
+// starred first.
+func (store LiveStore) Repositories() []string {
+	repos := []string{}
+	for repo := range store {
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if store[repos[i]].Stars != store[repos[j]].Stars {
+			return store[repos[i]].Stars > store[repos[j]].Stars
+		}
+		return repos[i] < repos[j]
+	})
+	return repos
+}
+
+// FormatLiveStats formats the store as a Markdown table.
+func FormatLiveStats(store LiveStore) string {
+	var b strings.Builder
+	b.WriteString("| Repository | Stars | Forks | Open issues | Pushes | Commits | Last push |\n")
+	b.WriteString("|---|---:|---:|---:|---:|---:|---|\n")
+	for _, repo := range store.Repositories() {
+		stats := store[repo]
+		lastPush := ""
+		if !stats.LastPush.IsZero() {
+			lastPush = stats.LastPush.UTC().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %d | %s |\n", repo, stats.Stars, stats.Forks, stats.OpenIssues, stats.Pushes, stats.Commits, lastPush)
+	}
+	return b.String()
+}
+
+// ValidSignature checks the X-Hub-Signature-256 header of a delivery
+// against the webhook secret.
+func ValidSignature(secret, header string, body []byte) bool {
+	if !strings.HasPrefix(header, "sha256=") {
+		return false
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// listener applies the webhook deliveries to its store and serves the
+// stats.
+type listener struct {
+	path   string
+	secret string
+	// captures saves every delivery when set, for inspection-server's tools
+	captures *inspectionserver.Store
+	// anonymizer renames the private repositories in the stats with --anonymize
+	anonymizer *Anonymizer
+
+	mu    sync.Mutex
+	store LiveStore
+}
+
+func (l *listener) webhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if l.secret != "" && !ValidSignature(l.secret, r.Header.Get("X-Hub-Signature-256"), body) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+	if l.captures != nil {
+		capture := inspectionserver.NewCapture(r, body)
+		if err := l.captures.Save(&capture); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not save capture: %v\n", err)
+		}
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	if event == "" {
+		http.Error(w, "Missing X-GitHub-Event header", http.StatusBadRequest)
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	repo, err := l.store.Apply(event, body, time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if repo == "" {
+		fmt.Printf("%s\n", event)
+		return
+	}
+	if err := l.store.Save(l.path); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not save %s: %v\n", l.path, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Printf("%s %s\n", event, repo)
+}
+
+// statsHandler serves the stats as Markdown, or as json under /stats.json,
+// anonymized with --anonymize.
+func (l *listener) statsHandler(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	store := l.anonymizer.LiveStore(l.store)
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		fmt.Fprint(w, FormatLiveStats(store))
+	case "/stats.json":
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(store)
+	default:
+		http.NotFound(w, r)
+	}
+}
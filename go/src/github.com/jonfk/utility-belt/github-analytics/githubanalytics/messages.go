@@ -0,0 +1,188 @@
+package githubanalytics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jonfk/utility-belt/internal/githubql"
+)
+
+type messagesQuery struct {
+	Viewer struct {
+		Repositories struct {
+			PageInfo githubql.PageInfo
+			Nodes    []MessagesRepository
+		} `graphql:"repositories(first: 25, after: $after, ownerAffiliations: OWNER)"`
+	}
+}
+
+// SubjectLimit is the subject length above which a commit message is too long.
+const SubjectLimit = 72
+
+var (
+	conventionalCommit = regexp.MustCompile(`^[a-zA-Z]+(\([^()]+\))?!?: \S`)
+	noiseCommit        = regexp.MustCompile(`(?i)^(fixup!|squash!|amend!|wip\b|tmp\b|temp\b|\.+$|(fix|update|changes|stuff|test|commit)$)`)
+)
+
+type MessagesRepository struct {
+	NameWithOwner    string `json:"nameWithOwner"`
+	IsFork           bool   `json:"isFork"`
+	DefaultBranchRef *struct {
+		Target struct {
+			History struct {
+				Nodes []struct {
+					MessageHeadline string `json:"messageHeadline"`
+					Author          struct {
+						User *struct {
+							Login string `json:"login"`
+						} `json:"user"`
+					} `json:"author"`
+				} `json:"nodes"`
+			} `json:"history" graphql:"history(first: $commits)"`
+		} `json:"target" graphql:"target,on=Commit"`
+	} `json:"defaultBranchRef"`
+}
+
+// Subjects returns the subjects of the fetched commits, only those authored
+// by login unless it is empty.
+func (r MessagesRepository) Subjects(login string) []string {
+	subjects := []string{}
+	if r.DefaultBranchRef == nil {
+		return subjects
+	}
+	for _, commit := range r.DefaultBranchRef.Target.History.Nodes {
+		if login != "" && (commit.Author.User == nil || !strings.EqualFold(commit.Author.User.Login, login)) {
+			continue
+		}
+		subjects = append(subjects, commit.MessageHeadline)
+	}
+	return subjects
+}
+
+// fetchMessagesRepositories returns the repositories of the viewer with the
+// last commits of their default branch and the login of the viewer.
+func fetchMessagesRepositories(commits int) ([]MessagesRepository, string, error) {
+	login, err := fetchViewerLogin()
+	if err != nil {
+		return nil, "", err
+	}
+	var q messagesQuery
+	err = client.Paginate(&q, githubql.Variables{"commits": commits})
+	return q.Viewer.Repositories.Nodes, login, err
+}
+
+type MessagesResult struct {
+	Repository    string
+	Commits       int
+	Conventional  int
+	TooLong       int
+	Noise         int
+	AverageLength float64
+	// Types counts the conventional commit types, e.g. feat or fix
+	Types map[string]int
+	// Score from 0 to 100, higher is better
+	Score int
+}
+
+// AnalyzeMessages checks the conventional commit compliance, the length and
+// the WIP and fixup noise of the commit subjects of repository.
+func AnalyzeMessages(repository string, subjects []string) MessagesResult {
+	result := MessagesResult{Repository: repository, Commits: len(subjects), Types: map[string]int{}}
+	if len(subjects) == 0 {
+		return result
+	}
+	total := 0
+	for _, subject := range subjects {
+		subject = strings.TrimSpace(subject)
+		length := len([]rune(subject))
+		total += length
+		if length > SubjectLimit {
+			result.TooLong++
+		}
+		if noiseCommit.MatchString(subject) {
+			result.Noise++
+		} else if conventionalCommit.MatchString(subject) {
+			result.Conventional++
+			result.Types[strings.ToLower(subject[:strings.IndexAny(subject, "(!:")])]++
+		}
+	}
+	n := float64(len(subjects))
+	result.AverageLength = float64(total) / n
+	// Half of the score is the conventional commits, the rest is split between
+	// reasonable subject lengths and the lack of noise
+	score := 50*float64(result.Conventional)/n + 30*(n-float64(result.TooLong))/n + 20*(n-float64(result.Noise))/n
+	result.Score = int(score + 0.5)
+	return result
+}
+
+// AnalyzeAllMessages analyzes the repositories with commits, forks are
+// skipped unless forks is set, sorted by score.
+func AnalyzeAllMessages(repositories []MessagesRepository, login string, forks bool) []MessagesResult {
+	results := []MessagesResult{}
+	for _, repo := range repositories {
+		if repo.IsFork && !forks {
+			continue
+		}
+		if result := AnalyzeMessages(repo.NameWithOwner, repo.Subjects(login)); result.Commits > 0 {
+			results = append(results, result)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
+
+// FormatMessages renders results as a Markdown table followed by the totals.
+func FormatMessages(results []MessagesResult) string {
+	lines := []string{
+		"| Repository | Score | Commits | Conventional | Avg length | Too long | WIP/fixup |",
+		"|---|---:|---:|---:|---:|---:|---:|",
+	}
+	var commits, conventional, tooLong, noise int
+	var length float64
+	types := map[string]int{}
+	for _, r := range results {
+		lines = append(lines, fmt.Sprintf("| %s | %d | %d | %s | %.0f | %d | %d |",
+			r.Repository, r.Score, r.Commits, percent(r.Conventional, r.Commits), r.AverageLength, r.TooLong, r.Noise))
+		commits += r.Commits
+		conventional += r.Conventional
+		tooLong += r.TooLong
+		noise += r.Noise
+		length += r.AverageLength * float64(r.Commits)
+		for name, count := range r.Types {
+			types[name] += count
+		}
+	}
+	if commits == 0 {
+		return "No commits found"
+	}
+
+	lines = append(lines, "", fmt.Sprintf("%d commits in %d repositories: %s conventional, average subject of %.0f characters, %s over %d characters, %s WIP or fixup",
+		commits, len(results), percent(conventional, commits), length/float64(commits), percent(tooLong, commits), SubjectLimit, percent(noise, commits)))
+	if len(types) > 0 {
+		names := []string{}
+		for name := range types {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if types[names[i]] != types[names[j]] {
+				return types[names[i]] > types[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		counts := []string{}
+		for _, name := range names {
+			counts = append(counts, fmt.Sprintf("%s %d", name, types[name]))
+		}
+		lines = append(lines, "Conventional types: "+strings.Join(counts, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(n)/float64(total))
+}
@@ -0,0 +1,300 @@
+package githubanalytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/jonfk/utility-belt/internal/githubql"
+)
+
+// snapshotQuery fetches the repositories of an account, the owner is null
+// when there is no such account.
+type snapshotQuery struct {
+	RepositoryOwner *struct {
+		Repositories struct {
+			PageInfo githubql.PageInfo
+			Nodes    []struct {
+				Name            string `json:"name"`
+				IsFork          bool   `json:"isFork"`
+				IsArchived      bool   `json:"isArchived"`
+				IsPrivate       bool   `json:"isPrivate"`
+				StargazerCount  int    `json:"stargazerCount"`
+				ForkCount       int    `json:"forkCount"`
+				PrimaryLanguage *struct {
+					Name string `json:"name"`
+				} `json:"primaryLanguage"`
+				Languages struct {
+					Edges []struct {
+						Size int `json:"size"`
+						Node struct {
+							Name string `json:"name"`
+						} `json:"node"`
+					} `json:"edges"`
+				} `json:"languages" graphql:"languages(first: 20)"`
+				DefaultBranchRef *struct {
+					Target struct {
+						History struct {
+							TotalCount int `json:"totalCount"`
+						} `json:"history"`
+					} `json:"target" graphql:"target,on=Commit"`
+				} `json:"defaultBranchRef"`
+			}
+		} `graphql:"repositories(first: 50, after: $after, ownerAffiliations: OWNER)"`
+	} `graphql:"repositoryOwner(login: $login)"`
+}
+
+// Snapshot is the state of the repositories of an account at a point in
+// time, saved to be compared with later ones.
+type Snapshot struct {
+	Account      string         `json:"account"`
+	TakenAt      time.Time      `json:"taken_at"`
+	Repositories []RepoSnapshot `json:"repositories"`
+}
+
+type RepoSnapshot struct {
+	Name            string         `json:"name"`
+	IsFork          bool           `json:"is_fork"`
+	IsArchived      bool           `json:"is_archived"`
+	IsPrivate       bool           `json:"is_private"`
+	Stars           int            `json:"stars"`
+	Forks           int            `json:"forks"`
+	Commits         int            `json:"commits"`
+	PrimaryLanguage string         `json:"primary_language"`
+	Languages       map[string]int `json:"languages"`
+}
+
+func fetchViewerLogin() (string, error) {
+	var data struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+	err := client.Query(&data, nil)
+	return data.Viewer.Login, err
+}
+
+// TakeSnapshot fetches the repositories owned by account.
+func TakeSnapshot(account string) (Snapshot, error) {
+	snapshot := Snapshot{Account: account, TakenAt: time.Now().UTC(), Repositories: []RepoSnapshot{}}
+	var q snapshotQuery
+	err := client.Paginate(&q, githubql.Variables{"login": account})
+	if githubql.IsNotFound(err) {
+		return snapshot, fmt.Errorf("No github account %s", account)
+	} else if err != nil {
+		return snapshot, err
+	}
+	for _, node := range q.RepositoryOwner.Repositories.Nodes {
+		repo := RepoSnapshot{
+			Name:       node.Name,
+			IsFork:     node.IsFork,
+			IsArchived: node.IsArchived,
+			IsPrivate:  node.IsPrivate,
+			Stars:      node.StargazerCount,
+			Forks:      node.ForkCount,
+			Languages:  map[string]int{},
+		}
+		if node.PrimaryLanguage != nil {
+			repo.PrimaryLanguage = node.PrimaryLanguage.Name
+		}
+		for _, edge := range node.Languages.Edges {
+			repo.Languages[edge.Node.Name] = edge.Size
+		}
+		if node.DefaultBranchRef != nil {
+			repo.Commits = node.DefaultBranchRef.Target.History.TotalCount
+		}
+		snapshot.Repositories = append(snapshot.Repositories, repo)
+	}
+	return snapshot, nil
+}
+
+func (s Snapshot) Save(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+func LoadSnapshot(filename string) (Snapshot, error) {
+	var s Snapshot
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("Could not read snapshot %s: %v", filename, err)
+	}
+	return s, nil
+}
+
+// ResolveSnapshot loads arg when it is a snapshot file, otherwise it takes
+// a snapshot of the account named arg and stores it for the trends.
+func ResolveSnapshot(arg string) (Snapshot, error) {
+	if _, err := os.Stat(arg); err == nil {
+		return LoadSnapshot(arg)
+	}
+	if strings.ContainsAny(arg, "/.") {
+		return Snapshot{}, fmt.Errorf("No snapshot file %s", arg)
+	}
+	snapshot, err := TakeSnapshot(arg)
+	if err != nil {
+		return snapshot, err
+	}
+	dir, err := snapshotDir()
+	if err != nil {
+		return snapshot, err
+	}
+	_, err = storeSnapshot(dir, snapshot)
+	return snapshot, err
+}
+
+// snapshotDir is the dated store of the snapshots, one per account and day.
+func snapshotDir() (string, error) {
+	return config.DataDir(filepath.Join("github", "snapshots"))
+}
+
+// storeSnapshot saves snapshot as ACCOUNT-DATE.json in dir, replacing the
+// one of the same day.
+func storeSnapshot(dir string, snapshot Snapshot) (string, error) {
+	filename := filepath.Join(dir, fmt.Sprintf("%s-%s.json", snapshot.Account, snapshot.TakenAt.Format("2006-01-02")))
+	return filename, snapshot.Save(filename)
+}
+
+func (s Snapshot) totals() (stars, forks, commits int, languages map[string]int) {
+	languages = map[string]int{}
+	for _, repo := range s.Repositories {
+		stars += repo.Stars
+		forks += repo.Forks
+		commits += repo.Commits
+		for language, size := range repo.Languages {
+			languages[language] += size
+		}
+	}
+	return
+}
+
+func signed(n int) string {
+	if n > 0 {
+		return fmt.Sprintf("+%d", n)
+	}
+	return fmt.Sprint(n)
+}
+
+func formatBytes(n int) string {
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case abs >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func snapshotLabel(s Snapshot) string {
+	return fmt.Sprintf("%s (%s)", s.Account, s.TakenAt.Format("2006-01-02"))
+}
+
+// CompareSnapshots reports the changes from before to after as Markdown.
+// Repositories are matched by name so two accounts can be compared as well.
+func CompareSnapshots(before, after Snapshot) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s → %s\n\n", snapshotLabel(before), snapshotLabel(after))
+
+	stars0, forks0, commits0, languages0 := before.totals()
+	stars1, forks1, commits1, languages1 := after.totals()
+	b.WriteString("| | Before | After | Change |\n|---|---:|---:|---:|\n")
+	fmt.Fprintf(&b, "| Repositories | %d | %d | %s |\n", len(before.Repositories), len(after.Repositories), signed(len(after.Repositories)-len(before.Repositories)))
+	fmt.Fprintf(&b, "| Stars | %d | %d | %s |\n", stars0, stars1, signed(stars1-stars0))
+	fmt.Fprintf(&b, "| Forks | %d | %d | %s |\n", forks0, forks1, signed(forks1-forks0))
+	fmt.Fprintf(&b, "| Commits | %d | %d | %s |\n", commits0, commits1, signed(commits1-commits0))
+
+	previous := map[string]RepoSnapshot{}
+	for _, repo := range before.Repositories {
+		previous[repo.Name] = repo
+	}
+	current := map[string]bool{}
+	added, changed := []string{}, []string{}
+	for _, repo := range after.Repositories {
+		current[repo.Name] = true
+		old, ok := previous[repo.Name]
+		if !ok {
+			added = append(added, fmt.Sprintf("| %s | %d | %d | %s |", repo.Name, repo.Stars, repo.Commits, repo.PrimaryLanguage))
+			continue
+		}
+		if old.Stars == repo.Stars && old.Forks == repo.Forks && old.Commits == repo.Commits &&
+			old.PrimaryLanguage == repo.PrimaryLanguage && old.IsArchived == repo.IsArchived {
+			continue
+		}
+		language := repo.PrimaryLanguage
+		if old.PrimaryLanguage != repo.PrimaryLanguage {
+			language = fmt.Sprintf("%s → %s", old.PrimaryLanguage, repo.PrimaryLanguage)
+		}
+		if !old.IsArchived && repo.IsArchived {
+			language += " (archived)"
+		}
+		changed = append(changed, fmt.Sprintf("| %s | %d (%s) | %d (%s) | %d (%s) | %s |",
+			repo.Name, repo.Stars, signed(repo.Stars-old.Stars), repo.Commits, signed(repo.Commits-old.Commits),
+			repo.Forks, signed(repo.Forks-old.Forks), language))
+	}
+	removed := []string{}
+	for _, repo := range before.Repositories {
+		if !current[repo.Name] {
+			removed = append(removed, "- "+repo.Name)
+		}
+	}
+
+	if len(added) > 0 {
+		sort.Strings(added)
+		b.WriteString("\n## New repositories\n\n| Repository | Stars | Commits | Language |\n|---|---:|---:|---|\n")
+		b.WriteString(strings.Join(added, "\n") + "\n")
+	}
+	if len(removed) > 0 {
+		sort.Strings(removed)
+		b.WriteString("\n## Removed repositories\n\n")
+		b.WriteString(strings.Join(removed, "\n") + "\n")
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		b.WriteString("\n## Changed repositories\n\n| Repository | Stars | Commits | Forks | Language |\n|---|---:|---:|---:|---|\n")
+		b.WriteString(strings.Join(changed, "\n") + "\n")
+	}
+
+	languages := []string{}
+	for language := range languages0 {
+		languages = append(languages, language)
+	}
+	for language := range languages1 {
+		if _, ok := languages0[language]; !ok {
+			languages = append(languages, language)
+		}
+	}
+	sort.Slice(languages, func(i, j int) bool { return languages1[languages[i]] > languages1[languages[j]] })
+	rows := []string{}
+	for _, language := range languages {
+		if delta := languages1[language] - languages0[language]; delta != 0 {
+			change := formatBytes(delta)
+			if delta > 0 {
+				change = "+" + change
+			}
+			rows = append(rows, fmt.Sprintf("| %s | %s | %s | %s |", language,
+				formatBytes(languages0[language]), formatBytes(languages1[language]), change))
+		}
+	}
+	if len(rows) > 0 {
+		b.WriteString("\n## Languages\n\n| Language | Before | After | Change |\n|---|---:|---:|---:|\n")
+		b.WriteString(strings.Join(rows, "\n") + "\n")
+	}
+	return b.String()
+}
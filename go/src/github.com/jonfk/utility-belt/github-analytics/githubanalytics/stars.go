@@ -0,0 +1,251 @@
+package githubanalytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/githubql"
+)
+
+type starredQuery struct {
+	Viewer struct {
+		StarredRepositories struct {
+			PageInfo githubql.PageInfo
+			Nodes    []struct {
+				NameWithOwner   string    `json:"nameWithOwner"`
+				URL             string    `json:"url"`
+				Description     string    `json:"description"`
+				IsArchived      bool      `json:"isArchived"`
+				StargazerCount  int       `json:"stargazerCount"`
+				PushedAt        time.Time `json:"pushedAt"`
+				PrimaryLanguage *struct {
+					Name string `json:"name"`
+				} `json:"primaryLanguage"`
+				RepositoryTopics struct {
+					Nodes []struct {
+						Topic struct {
+							Name string `json:"name"`
+						} `json:"topic"`
+					} `json:"nodes"`
+				} `json:"repositoryTopics" graphql:"repositoryTopics(first: 20)"`
+			}
+		} `graphql:"starredRepositories(first: 100, after: $after, orderBy: {field: STARRED_AT, direction: DESC})"`
+	}
+}
+
+type gistsQuery struct {
+	Viewer struct {
+		Gists struct {
+			PageInfo githubql.PageInfo
+			Nodes    []struct {
+				Name           string    `json:"name"`
+				Description    string    `json:"description"`
+				URL            string    `json:"url"`
+				IsPublic       bool      `json:"isPublic"`
+				StargazerCount int       `json:"stargazerCount"`
+				UpdatedAt      time.Time `json:"updatedAt"`
+				Files          []struct {
+					Name     string `json:"name"`
+					Size     int    `json:"size"`
+					Language *struct {
+						Name string `json:"name"`
+					} `json:"language"`
+				} `json:"files" graphql:"files(limit: 30)"`
+			}
+		} `graphql:"gists(first: 100, after: $after, privacy: ALL, orderBy: {field: UPDATED_AT, direction: DESC})"`
+	}
+}
+
+// StarredRepository is a repository starred by the viewer, as exported by
+// stars --export.
+type StarredRepository struct {
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	IsArchived  bool      `json:"is_archived"`
+	Stars       int       `json:"stars"`
+	PushedAt    time.Time `json:"pushed_at"`
+	Language    string    `json:"language"`
+	Topics      []string  `json:"topics"`
+}
+
+func fetchStarredRepositories() ([]StarredRepository, error) {
+	var q starredQuery
+	if err := client.Paginate(&q, nil); err != nil {
+		return nil, err
+	}
+	repositories := []StarredRepository{}
+	for _, node := range q.Viewer.StarredRepositories.Nodes {
+		repo := StarredRepository{
+			Name:        node.NameWithOwner,
+			URL:         node.URL,
+			Description: node.Description,
+			IsArchived:  node.IsArchived,
+			Stars:       node.StargazerCount,
+			PushedAt:    node.PushedAt,
+			Topics:      []string{},
+		}
+		if node.PrimaryLanguage != nil {
+			repo.Language = node.PrimaryLanguage.Name
+		}
+		for _, topic := range node.RepositoryTopics.Nodes {
+			repo.Topics = append(repo.Topics, topic.Topic.Name)
+		}
+		repositories = append(repositories, repo)
+	}
+	return repositories, nil
+}
+
+// FormatStars reports the languages and topics starred the most, at most
+// top of each, and the stale stars on archived repositories.
+func FormatStars(repositories []StarredRepository, top int) string {
+	languages, topics := map[string]int{}, map[string]int{}
+	archived := []StarredRepository{}
+	for _, repo := range repositories {
+		if repo.Language != "" {
+			languages[repo.Language]++
+		}
+		for _, topic := range repo.Topics {
+			topics[topic]++
+		}
+		if repo.IsArchived {
+			archived = append(archived, repo)
+		}
+	}
+
+	lines := []string{fmt.Sprintf("%d starred repositories", len(repositories)), ""}
+	lines = append(lines, formatCounts("Language", languages, len(repositories), top)...)
+	lines = append(lines, "")
+	lines = append(lines, formatCounts("Topic", topics, len(repositories), top)...)
+	if len(archived) > 0 {
+		lines = append(lines, "", fmt.Sprintf("%d stale stars on archived repositories:", len(archived)))
+		for _, repo := range archived {
+			link := repo.URL
+			if link == "" {
+				link = repo.Name
+			}
+			lines = append(lines, fmt.Sprintf("- [ ] %s, last pushed %s", link, repo.PushedAt.Format("2006-01-02")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatCounts renders the top names of counts as a Markdown table, with
+// their share of total.
+func formatCounts(header string, counts map[string]int, total, top int) []string {
+	if len(counts) == 0 {
+		return []string{fmt.Sprintf("No %s found", strings.ToLower(header))}
+	}
+	names := []string{}
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if top > 0 && len(names) > top {
+		names = names[:top]
+	}
+	lines := []string{fmt.Sprintf("| %s | Stars | Share |", header), "|---|---:|---:|"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("| %s | %d | %s |", name, counts[name], percent(counts[name], total)))
+	}
+	return lines
+}
+
+// Gist is a gist of the viewer, secret ones included.
+type Gist struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	URL         string     `json:"url"`
+	IsPublic    bool       `json:"is_public"`
+	Stars       int        `json:"stars"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Files       []GistFile `json:"files"`
+}
+
+type GistFile struct {
+	Name     string `json:"name"`
+	Size     int    `json:"size"`
+	Language string `json:"language"`
+}
+
+func fetchGists() ([]Gist, error) {
+	var q gistsQuery
+	if err := client.Paginate(&q, nil); err != nil {
+		return nil, err
+	}
+	gists := []Gist{}
+	for _, node := range q.Viewer.Gists.Nodes {
+		gist := Gist{
+			Name:        node.Name,
+			Description: node.Description,
+			URL:         node.URL,
+			IsPublic:    node.IsPublic,
+			Stars:       node.StargazerCount,
+			UpdatedAt:   node.UpdatedAt,
+			Files:       []GistFile{},
+		}
+		for _, file := range node.Files {
+			f := GistFile{Name: file.Name, Size: file.Size}
+			if file.Language != nil {
+				f.Language = file.Language.Name
+			}
+			gist.Files = append(gist.Files, f)
+		}
+		gists = append(gists, gist)
+	}
+	return gists, nil
+}
+
+// FormatGists renders gists as a Markdown table, most recently updated
+// first.
+func FormatGists(gists []Gist) string {
+	if len(gists) == 0 {
+		return "No gists found"
+	}
+	lines := []string{
+		"| Gist | Files | Languages | Visibility | Stars | Updated |",
+		"|---|---:|---|---|---:|---|",
+	}
+	for _, gist := range gists {
+		title := gist.Description
+		if title == "" && len(gist.Files) > 0 {
+			title = gist.Files[0].Name
+		}
+		languages, seen := []string{}, map[string]bool{}
+		for _, file := range gist.Files {
+			if file.Language != "" && !seen[file.Language] {
+				seen[file.Language] = true
+				languages = append(languages, file.Language)
+			}
+		}
+		visibility := "secret"
+		if gist.IsPublic {
+			visibility = "public"
+		}
+		title = strings.Replace(title, "|", `\|`, -1)
+		if gist.URL != "" {
+			title = fmt.Sprintf("[%s](%s)", title, gist.URL)
+		}
+		lines = append(lines, fmt.Sprintf("| %s | %d | %s | %s | %d | %s |",
+			title, len(gist.Files), strings.Join(languages, ", "), visibility, gist.Stars, gist.UpdatedAt.Format("2006-01-02")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exportJSON writes v indented to filename, for backups.
+func exportJSON(filename string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,275 @@
+package githubanalytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/githubql"
+	"github.com/jonfk/utility-belt/internal/spark"
+)
+
+type repositoryNamesQuery struct {
+	Viewer struct {
+		Repositories struct {
+			PageInfo githubql.PageInfo
+			Nodes    []struct {
+				NameWithOwner string `json:"nameWithOwner"`
+				IsArchived    bool   `json:"isArchived"`
+			}
+		} `graphql:"repositories(first: 100, after: $after, ownerAffiliations: OWNER)"`
+	}
+}
+
+// TrafficPoint is the traffic of a repository on one day.
+type TrafficPoint struct {
+	Views        int `json:"views"`
+	ViewUniques  int `json:"view_uniques"`
+	Clones       int `json:"clones"`
+	CloneUniques int `json:"clone_uniques"`
+}
+
+// TrafficStore is the time series of the traffic by repository then by day
+// formatted as 2006-01-02. Github only keeps 14 days of traffic so the store
+// accumulates it across runs.
+type TrafficStore map[string]map[string]TrafficPoint
+
+type trafficCounts struct {
+	Timestamp time.Time `json:"timestamp"`
+	Count     int       `json:"count"`
+	Uniques   int       `json:"uniques"`
+}
+
+func LoadTrafficStore(path string) (TrafficStore, error) {
+	store := TrafficStore{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("Could not read traffic store %s: %v", path, err)
+	}
+	return store, nil
+}
+
+func (store TrafficStore) Save(path string) error {
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func fetchOwnedRepositoryNames() ([]string, error) {
+	var q repositoryNamesQuery
+	if err := client.Paginate(&q, nil); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, node := range q.Viewer.Repositories.Nodes {
+		if !node.IsArchived {
+			names = append(names, node.NameWithOwner)
+		}
+	}
+	return names, nil
+}
+
+// CollectTraffic fetches the views and clones of the last 14 days of repo
+// into store. The counts of a day already stored are replaced since the
+// current day is partial.
+func (store TrafficStore) CollectTraffic(repo string) error {
+	var views struct {
+		Views []trafficCounts `json:"views"`
+	}
+	if err := client.REST("GET", "/repos/"+repo+"/traffic/views", nil, &views); err != nil {
+		return err
+	}
+	var clones struct {
+		Clones []trafficCounts `json:"clones"`
+	}
+	if err := client.REST("GET", "/repos/"+repo+"/traffic/clones", nil, &clones); err != nil {
+		return err
+	}
+
+	days := store[repo]
+	if days == nil {
+		days = map[string]TrafficPoint{}
+		store[repo] = days
+	}
+	for _, v := range views.Views {
+		day := v.Timestamp.UTC().Format("2006-01-02")
+		point := days[day]
+		point.Views, point.ViewUniques = v.Count, v.Uniques
+		days[day] = point
+	}
+	for _, v := range clones.Clones {
+		day := v.Timestamp.UTC().Format("2006-01-02")
+		point := days[day]
+		point.Clones, point.CloneUniques = v.Count, v.Uniques
+		days[day] = point
+	}
+	return nil
+}
+
+// TrafficBucket is the traffic summed over a period starting on Start.
+type TrafficBucket struct {
+	Start time.Time
+	TrafficPoint
+}
+
+// bucketStart returns the start of the day, week or month containing t.
+func bucketStart(t time.Time, period string) time.Time {
+	switch period {
+	case "week":
+		return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return t
+	}
+}
+
+func nextBucket(t time.Time, period string) time.Time {
+	switch period {
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// Buckets sums the traffic of repo by period (day, week or month) from since,
+// or the first recorded day when zero, until until. Periods without data are
+// zero so the series is continuous.
+func (store TrafficStore) Buckets(repo, period string, since, until time.Time) []TrafficBucket {
+	days := store[repo]
+	if since.IsZero() {
+		if since = store.firstDay(repo); since.IsZero() {
+			return nil
+		}
+	}
+
+	buckets := []TrafficBucket{}
+	index := map[time.Time]int{}
+	for t := bucketStart(since, period); !t.After(until); t = nextBucket(t, period) {
+		index[t] = len(buckets)
+		buckets = append(buckets, TrafficBucket{Start: t})
+	}
+	for day, point := range days {
+		t, err := time.Parse("2006-01-02", day)
+		if err != nil || t.Before(since) || t.After(until) {
+			continue
+		}
+		i, ok := index[bucketStart(t, period)]
+		if !ok {
+			continue
+		}
+		b := &buckets[i]
+		b.Views += point.Views
+		b.ViewUniques += point.ViewUniques
+		b.Clones += point.Clones
+		b.CloneUniques += point.CloneUniques
+	}
+	return buckets
+}
+
+// firstDay returns the first recorded day of repos, of every repository
+// when none is given.
+func (store TrafficStore) firstDay(repos ...string) time.Time {
+	if len(repos) == 0 {
+		for repo := range store {
+			repos = append(repos, repo)
+		}
+	}
+	var first time.Time
+	for _, repo := range repos {
+		for day := range store[repo] {
+			t, err := time.Parse("2006-01-02", day)
+			if err == nil && (first.IsZero() || t.Before(first)) {
+				first = t
+			}
+		}
+	}
+	return first
+}
+
+// Repositories lists the repositories of the store by descending total views.
+func (store TrafficStore) Repositories() []string {
+	totals := map[string]int{}
+	repos := []string{}
+	for repo, days := range store {
+		repos = append(repos, repo)
+		for _, point := range days {
+			totals[repo] += point.Views
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if totals[repos[i]] != totals[repos[j]] {
+			return totals[repos[i]] > totals[repos[j]]
+		}
+		return repos[i] < repos[j]
+	})
+	return repos
+}
+
+// FormatTrafficSummary prints a line per repository with sparklines of the
+// views and clones and their totals.
+func FormatTrafficSummary(store TrafficStore, repos []string, period string, since, until time.Time) string {
+	width := 0
+	for _, repo := range repos {
+		if len(repo) > width {
+			width = len(repo)
+		}
+	}
+	if since.IsZero() {
+		// align the series of every repository
+		since = store.firstDay(repos...)
+	}
+	lines := []string{}
+	for _, repo := range repos {
+		buckets := store.Buckets(repo, period, since, until)
+		views, clones := []int{}, []int{}
+		totalViews, totalClones := 0, 0
+		for _, b := range buckets {
+			views = append(views, b.Views)
+			clones = append(clones, b.Clones)
+			totalViews += b.Views
+			totalClones += b.Clones
+		}
+		lines = append(lines, fmt.Sprintf("%-*s  views %s %6d  clones %s %6d", width, repo, spark.Line(views), totalViews, spark.Line(clones), totalClones))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatTrafficChart prints a bar per period of the views of repo.
+func FormatTrafficChart(store TrafficStore, repo, period string, since, until time.Time) string {
+	const barWidth = 40
+	buckets := store.Buckets(repo, period, since, until)
+	max := 0
+	for _, b := range buckets {
+		if b.Views > max {
+			max = b.Views
+		}
+	}
+	lines := []string{fmt.Sprintf("%-10s  %6s %6s  %6s %6s", period, "views", "unique", "clones", "unique")}
+	for _, b := range buckets {
+		bar := ""
+		if max > 0 {
+			bar = strings.Repeat("█", b.Views*barWidth/max)
+		}
+		lines = append(lines, fmt.Sprintf("%-10s  %6d %6d  %6d %6d  %s", b.Start.Format("2006-01-02"), b.Views, b.ViewUniques, b.Clones, b.CloneUniques, bar))
+	}
+	return strings.Join(lines, "\n")
+}
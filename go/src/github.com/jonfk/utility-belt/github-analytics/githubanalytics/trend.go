@@ -0,0 +1,269 @@
+package githubanalytics
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/spark"
+)
+
+// LoadSnapshots loads the snapshots of account stored in dir, oldest first.
+func LoadSnapshots(dir, account string) ([]Snapshot, error) {
+	files, err := filepath.Glob(filepath.Join(dir, account+"-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []Snapshot{}
+	for _, file := range files {
+		snapshot, err := LoadSnapshot(file)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(snapshot.Account, account) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].TakenAt.Before(snapshots[j].TakenAt) })
+	return snapshots, nil
+}
+
+// SnapshotRepositoryNames lists the repositories of the snapshots of account
+// stored in dir, of every account when it is empty. The snapshots that can't
+// be read are skipped.
+func SnapshotRepositoryNames(dir, account string) []string {
+	files, _ := filepath.Glob(filepath.Join(dir, account+"*.json"))
+	seen := map[string]bool{}
+	names := []string{}
+	for _, file := range files {
+		snapshot, err := LoadSnapshot(file)
+		if err != nil || (account != "" && !strings.EqualFold(snapshot.Account, account)) {
+			continue
+		}
+		for _, repo := range snapshot.Repositories {
+			if !seen[repo.Name] {
+				seen[repo.Name] = true
+				names = append(names, repo.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TrendPoint is the state of a repository, or of the whole account, in a
+// snapshot.
+type TrendPoint struct {
+	TakenAt time.Time
+	Stars   int
+	Commits int
+	// Size is the bytes of code of the languages, the closest to lines of
+	// code github reports
+	Size int
+	// Velocity is the commits per week since the previous point
+	Velocity float64
+}
+
+// Trend returns the points of repo, or the totals of the account when repo
+// is empty, in the snapshots taken from since. Snapshots without repo are
+// skipped.
+func Trend(snapshots []Snapshot, repo string, since time.Time) []TrendPoint {
+	points := []TrendPoint{}
+	for _, snapshot := range snapshots {
+		if snapshot.TakenAt.Before(since) {
+			continue
+		}
+		point, found := TrendPoint{TakenAt: snapshot.TakenAt}, repo == ""
+		for _, r := range snapshot.Repositories {
+			if repo != "" && r.Name != repo {
+				continue
+			}
+			found = true
+			point.Stars += r.Stars
+			point.Commits += r.Commits
+			for _, size := range r.Languages {
+				point.Size += size
+			}
+		}
+		if !found {
+			continue
+		}
+		if n := len(points); n > 0 {
+			weeks := point.TakenAt.Sub(points[n-1].TakenAt).Hours() / (24 * 7)
+			if weeks > 0 {
+				point.Velocity = float64(point.Commits-points[n-1].Commits) / weeks
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+func trendSeries(points []TrendPoint) (stars, commits, velocity, size []int) {
+	for _, p := range points {
+		stars = append(stars, p.Stars)
+		commits = append(commits, p.Commits)
+		v := int(p.Velocity + 0.5)
+		if v < 0 {
+			v = 0
+		}
+		velocity = append(velocity, v)
+		size = append(size, p.Size)
+	}
+	return
+}
+
+// FormatTrendSummary prints a line per repository, and the account total
+// first, with sparklines of the stars, the commits per week and the size and
+// their change over the snapshots.
+func FormatTrendSummary(snapshots []Snapshot, since time.Time) string {
+	repos := []string{}
+	seen := map[string]bool{}
+	for _, snapshot := range snapshots {
+		for _, r := range snapshot.Repositories {
+			if !seen[r.Name] && !r.IsFork {
+				seen[r.Name] = true
+				repos = append(repos, r.Name)
+			}
+		}
+	}
+	sort.Strings(repos)
+	width := len("Total")
+	for _, repo := range repos {
+		if len(repo) > width {
+			width = len(repo)
+		}
+	}
+	lines := []string{}
+	for _, repo := range append([]string{""}, repos...) {
+		points := Trend(snapshots, repo, since)
+		if len(points) == 0 {
+			continue
+		}
+		name := repo
+		if name == "" {
+			name = "Total"
+		}
+		first, last := points[0], points[len(points)-1]
+		stars, _, velocity, size := trendSeries(points)
+		if len(velocity) > 1 {
+			// the first point has no previous one to measure the velocity from
+			velocity = velocity[1:]
+		}
+		sizeChange := formatBytes(last.Size - first.Size)
+		if last.Size > first.Size {
+			sizeChange = "+" + sizeChange
+		}
+		lines = append(lines, fmt.Sprintf("%-*s  stars %s %6d %6s  commits/week %s %5.1f  size %s %10s %s",
+			width, name, spark.Line(stars), last.Stars, signed(last.Stars-first.Stars),
+			spark.Line(velocity), last.Velocity,
+			spark.Line(size), formatBytes(last.Size), sizeChange))
+	}
+	if len(lines) == 0 {
+		return "No snapshots"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatTrendChart prints a row per snapshot with a bar of the stars.
+func FormatTrendChart(points []TrendPoint) string {
+	const barWidth = 40
+	max := 0
+	for _, p := range points {
+		if p.Stars > max {
+			max = p.Stars
+		}
+	}
+	lines := []string{fmt.Sprintf("%-10s  %6s %8s %12s %10s", "snapshot", "stars", "commits", "commits/week", "size")}
+	for _, p := range points {
+		bar := ""
+		if max > 0 {
+			bar = strings.Repeat("█", p.Stars*barWidth/max)
+		}
+		lines = append(lines, fmt.Sprintf("%-10s  %6d %8d %12.1f %10s  %s",
+			p.TakenAt.Format("2006-01-02"), p.Stars, p.Commits, p.Velocity, formatBytes(p.Size), bar))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TrendSVG draws the stars, the commits per week and the size of points as
+// stacked line charts.
+func TrendSVG(title string, points []TrendPoint) string {
+	const (
+		width       = 720
+		panelHeight = 150
+		margin      = 50
+	)
+	panels := []struct {
+		label  string
+		values []float64
+		format func(float64) string
+	}{
+		{"Stars", nil, func(v float64) string { return fmt.Sprintf("%.0f", v) }},
+		{"Commits per week", nil, func(v float64) string { return fmt.Sprintf("%.1f", v) }},
+		{"Size", nil, func(v float64) string { return formatBytes(int(v)) }},
+	}
+	for _, p := range points {
+		panels[0].values = append(panels[0].values, float64(p.Stars))
+		panels[1].values = append(panels[1].values, p.Velocity)
+		panels[2].values = append(panels[2].values, float64(p.Size))
+	}
+
+	height := 40 + len(panels)*(panelHeight+margin)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	fmt.Fprintf(&b, `<text x="%d" y="24" font-size="16" font-weight="bold">%s</text>`+"\n", margin, html.EscapeString(title))
+	if len(points) == 0 {
+		b.WriteString("</svg>\n")
+		return b.String()
+	}
+	start, end := points[0].TakenAt, points[len(points)-1].TakenAt
+	span := end.Sub(start).Seconds()
+	plotWidth := float64(width - 2*margin)
+	x := func(t time.Time) float64 {
+		if span == 0 {
+			return float64(margin) + plotWidth/2
+		}
+		return float64(margin) + t.Sub(start).Seconds()/span*plotWidth
+	}
+
+	for n, panel := range panels {
+		top := 40 + n*(panelHeight+margin) + 20
+		low, high := panel.values[0], panel.values[0]
+		for _, v := range panel.values {
+			if v < low {
+				low = v
+			}
+			if v > high {
+				high = v
+			}
+		}
+		if high == low {
+			high = low + 1
+		}
+		y := func(v float64) float64 {
+			return float64(top+panelHeight) - (v-low)/(high-low)*panelHeight
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-weight="bold">%s</text>`+"\n", margin, top-6, panel.label)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="#ddd"/>`+"\n", margin, top, width-2*margin, panelHeight)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" fill="#666">%s</text>`+"\n", margin-4, top+10, panel.format(high))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" fill="#666">%s</text>`+"\n", margin-4, top+panelHeight, panel.format(low))
+		coords := []string{}
+		for i, v := range panel.values {
+			coords = append(coords, fmt.Sprintf("%.1f,%.1f", x(points[i].TakenAt), y(v)))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="#2f6fb0" stroke-width="2"/>`+"\n", strings.Join(coords, " "))
+		for _, coord := range coords {
+			xy := strings.Split(coord, ",")
+			fmt.Fprintf(&b, `<circle cx="%s" cy="%s" r="2.5" fill="#2f6fb0"/>`+"\n", xy[0], xy[1])
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#666">%s</text>`+"\n", margin, top+panelHeight+16, start.Format("2006-01-02"))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" fill="#666">%s</text>`+"\n", width-margin, top+panelHeight+16, end.Format("2006-01-02"))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
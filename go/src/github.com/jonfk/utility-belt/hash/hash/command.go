@@ -0,0 +1,109 @@
+package hash
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Command computes and checks file checksums, run standalone as hash or as ub hash.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "hash",
+		Usage:     "Computes or checks checksums of files or stdin",
+		ArgsUsage: "[file...]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "algorithm,a",
+				Usage: "Hash algorithm: " + strings.Join(AlgorithmNames(), ", "),
+				Value: "sha256",
+			},
+			cli.StringFlag{
+				Name:  "check,c",
+				Usage: "Verify the files listed in a checksum `FILE` instead of hashing the arguments",
+			},
+			cli.IntFlag{
+				Name:  "jobs,j",
+				Usage: "Number of files hashed in parallel",
+				Value: runtime.NumCPU(),
+			},
+			cli.BoolFlag{
+				Name:  "quiet,q",
+				Usage: "Only report failures when checking",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.String("check") != "" {
+				return check(c)
+			}
+
+			filenames := []string(c.Args())
+			if len(filenames) == 0 {
+				filenames = []string{Stdin}
+			}
+			sums, err := Files(c.String("algorithm"), filenames, c.Int("jobs"))
+			if err != nil {
+				return err
+			}
+			failed := 0
+			for _, sum := range sums {
+				if sum.Err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", sum.File, sum.Err)
+					failed++
+					continue
+				}
+				fmt.Printf("%s  %s\n", sum.Hex, sum.File)
+			}
+			if failed > 0 {
+				return cli.NewExitError(fmt.Sprintf("%d of %d files could not be read", failed, len(sums)), 1)
+			}
+			return nil
+		},
+	}
+}
+
+func check(c *cli.Context) error {
+	f := os.Stdin
+	if c.String("check") != Stdin {
+		var err error
+		f, err = os.Open(c.String("check"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+	expected, err := ParseSums(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", c.String("check"), err)
+	}
+
+	filenames := []string{}
+	for _, sum := range expected {
+		filenames = append(filenames, sum.File)
+	}
+	actual, err := Files(c.String("algorithm"), filenames, c.Int("jobs"))
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for i, sum := range actual {
+		switch {
+		case sum.Err != nil:
+			fmt.Printf("%s: FAILED open or read: %v\n", sum.File, sum.Err)
+			failed++
+		case sum.Hex != expected[i].Hex:
+			fmt.Printf("%s: FAILED\n", sum.File)
+			failed++
+		case !c.Bool("quiet"):
+			fmt.Printf("%s: OK\n", sum.File)
+		}
+	}
+	if failed > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d checksums did not match", failed, len(actual)), 1)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package hash
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Stdin is the file name standing for the standard input.
+const Stdin = "-"
+
+var Algorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"blake3": NewBlake3,
+}
+
+func AlgorithmNames() []string {
+	names := []string{}
+	for name := range Algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupAlgorithm(name string) (func() hash.Hash, error) {
+	newHash, ok := Algorithms[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown algorithm %q, expected one of %s", name, strings.Join(AlgorithmNames(), ", "))
+	}
+	return newHash, nil
+}
+
+type Sum struct {
+	File string
+	Hex  string
+	Err  error
+}
+
+// File hashes the content of filename, or stdin for Stdin.
+func File(algorithm, filename string) (string, error) {
+	newHash, err := lookupAlgorithm(algorithm)
+	if err != nil {
+		return "", err
+	}
+	var r io.Reader = os.Stdin
+	if filename != Stdin {
+		f, err := os.Open(filename)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+	h := newHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Files hashes filenames over jobs workers, the sums are in the order of filenames.
+func Files(algorithm string, filenames []string, jobs int) ([]Sum, error) {
+	if _, err := lookupAlgorithm(algorithm); err != nil {
+		return nil, err
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+	sums := make([]Sum, len(filenames))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				sum, err := File(algorithm, filenames[i])
+				sums[i] = Sum{File: filenames[i], Hex: sum, Err: err}
+			}
+		}()
+	}
+	for i := range filenames {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return sums, nil
+}
+
+// ParseSums reads a checksum file in the `<hex>  <file>` format of sha256sum
+// and friends, a `*` before the file name marks binary mode and is ignored.
+func ParseSums(r io.Reader) ([]Sum, error) {
+	sums := []Sum{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.IndexAny(text, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected a checksum followed by a file name", line)
+		}
+		file := strings.TrimLeft(text[i:], " \t")
+		sums = append(sums, Sum{Hex: strings.ToLower(text[:i]), File: strings.TrimPrefix(file, "*")})
+	}
+	return sums, scanner.Err()
+}
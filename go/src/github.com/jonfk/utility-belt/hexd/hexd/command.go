@@ -0,0 +1,162 @@
+package hexd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/urfave/cli"
+)
+
+// Command dumps files in hex, run standalone as hexd or as ub hexd.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "hexd",
+		Usage:     "Dumps files in canonical hex+ASCII and compares binary files",
+		ArgsUsage: "[FILE|-] | --diff FILE_A FILE_B",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "diff,d",
+				Usage: "Print the lines of two files which differ with the differing bytes highlighted, exit 1 when they differ",
+			},
+			cli.StringFlag{
+				Name:  "seek,s",
+				Usage: "Start at `OFFSET`, decimal or 0x hexadecimal",
+			},
+			cli.StringFlag{
+				Name:  "length,n",
+				Usage: "Only read `LENGTH` bytes, decimal or 0x hexadecimal",
+			},
+			cli.BoolFlag{
+				Name:  "capture,c",
+				Usage: "The files are inspection-server captures, dump their request bodies",
+			},
+			cli.BoolFlag{
+				Name:  "no-squeeze,v",
+				Usage: "Print repeated lines instead of a *",
+			},
+			cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Underline the differing bytes instead of coloring them, also when NO_COLOR is set or the output isn't a terminal",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			seek, err := parseOffset(c.String("seek"))
+			if err != nil {
+				return fmt.Errorf("Invalid --seek: %v", err)
+			}
+			length := int64(-1)
+			if c.String("length") != "" {
+				if length, err = parseOffset(c.String("length")); err != nil {
+					return fmt.Errorf("Invalid --length: %v", err)
+				}
+			}
+			read := func(filename string) ([]byte, error) {
+				r, err := openWindow(filename, c.Bool("capture"), seek, length)
+				if err != nil {
+					return nil, err
+				}
+				return ioutil.ReadAll(r)
+			}
+
+			if !c.Bool("diff") {
+				filename := "-"
+				if c.NArg() > 0 {
+					filename = c.Args().First()
+				}
+				r, err := openWindow(filename, c.Bool("capture"), seek, length)
+				if err != nil {
+					return err
+				}
+				return Dump(os.Stdout, r, seek, c.Bool("no-squeeze"))
+			}
+
+			if c.NArg() != 2 {
+				return fmt.Errorf("--diff takes two files")
+			}
+			a, err := read(c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			b, err := read(c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+			color := !c.Bool("no-color") && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+			if differing := Diff(os.Stdout, a, b, seek, color); differing > 0 {
+				return cli.NewExitError(fmt.Sprintf("%d bytes differ, %s has %d bytes and %s %d", differing, c.Args().Get(0), len(a), c.Args().Get(1), len(b)), 1)
+			}
+			return nil
+		},
+	}
+}
+
+func parseOffset(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 0, 64)
+	if err == nil && n < 0 {
+		err = fmt.Errorf("%s is negative", s)
+	}
+	return n, err
+}
+
+// openWindow returns the length bytes, or up to the end when negative,
+// from offset seek of filename, - being stdin. Captures are decoded first.
+func openWindow(filename string, capture bool, seek, length int64) (io.Reader, error) {
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		r = f
+	}
+	if capture {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = captureBody(data); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		r = bytes.NewReader(data)
+	}
+	if seeker, ok := r.(io.Seeker); ok && filename != "-" {
+		if _, err := seeker.Seek(seek, io.SeekStart); err != nil {
+			return nil, err
+		}
+	} else if _, err := io.CopyN(ioutil.Discard, r, seek); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if length >= 0 {
+		r = io.LimitReader(r, length)
+	}
+	return r, nil
+}
+
+// captureBody decodes the body of an inspection-server capture file.
+func captureBody(data []byte) ([]byte, error) {
+	var capture struct {
+		Body         string `json:"body"`
+		BodyEncoding string `json:"body_encoding"`
+	}
+	if err := json.Unmarshal(data, &capture); err != nil {
+		return nil, fmt.Errorf("Invalid capture: %v", err)
+	}
+	if capture.BodyEncoding == "base64" {
+		return base64.StdEncoding.DecodeString(capture.Body)
+	}
+	return []byte(capture.Body), nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,126 @@
+package hexd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// BytesPerLine is the width of the canonical hexdump -C output.
+const BytesPerLine = 16
+
+const (
+	colorReset   = "\033[0m"
+	colorChanged = "\033[1;31m"
+)
+
+// Line formats the bytes of one line at offset like hexdump -C, the bytes
+// whose index is set in highlight are colored when color is set.
+func Line(offset int64, data []byte, highlight map[int]bool, color bool) string {
+	var hex, ascii strings.Builder
+	for i := 0; i < BytesPerLine; i++ {
+		if i == BytesPerLine/2 {
+			hex.WriteByte(' ')
+		}
+		if i >= len(data) {
+			hex.WriteString("   ")
+			continue
+		}
+		b, char := fmt.Sprintf("%02x", data[i]), "."
+		if data[i] >= 0x20 && data[i] < 0x7f {
+			char = string(data[i])
+		}
+		if highlight[i] && color {
+			b, char = colorChanged+b+colorReset, colorChanged+char+colorReset
+		}
+		hex.WriteString(b + " ")
+		ascii.WriteString(char)
+	}
+	return fmt.Sprintf("%08x  %s |%s|", offset, hex.String(), ascii.String())
+}
+
+// Marks underlines the bytes set in highlight with ^^ for the output of Line.
+func Marks(highlight map[int]bool) string {
+	marks := strings.Repeat(" ", 10)
+	for i := 0; i < BytesPerLine; i++ {
+		if i == BytesPerLine/2 {
+			marks += " "
+		}
+		if highlight[i] {
+			marks += "^^ "
+		} else {
+			marks += "   "
+		}
+	}
+	return strings.TrimRight(marks, " ")
+}
+
+// Dump writes r like hexdump -C starting the offsets at start, repeated lines
+// are squeezed into a * unless verbose.
+func Dump(w io.Writer, r io.Reader, start int64, verbose bool) error {
+	buf := make([]byte, BytesPerLine)
+	var previous []byte
+	squeezed := false
+	offset := start
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if !verbose && previous != nil && n == BytesPerLine && bytes.Equal(buf, previous) {
+				if !squeezed {
+					fmt.Fprintln(w, "*")
+					squeezed = true
+				}
+			} else {
+				fmt.Fprintln(w, Line(offset, buf[:n], nil, false))
+				squeezed = false
+			}
+			previous = append(previous[:0], buf[:n]...)
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			fmt.Fprintf(w, "%08x\n", offset)
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// Diff writes the lines of a and b which differ, a's prefixed with - and b's
+// with +, highlighting the differing bytes in color or underlining them. It returns the number of
+// differing bytes, bytes past the end of the shorter input included.
+func Diff(w io.Writer, a, b []byte, start int64, color bool) int {
+	differing := 0
+	for i := 0; i < len(a) || i < len(b); i += BytesPerLine {
+		lineA, lineB := window(a, i), window(b, i)
+		highlight := map[int]bool{}
+		for j := 0; j < len(lineA) || j < len(lineB); j++ {
+			if j >= len(lineA) || j >= len(lineB) || lineA[j] != lineB[j] {
+				highlight[j] = true
+			}
+		}
+		if len(highlight) == 0 {
+			continue
+		}
+		differing += len(highlight)
+		offset := start + int64(i)
+		fmt.Fprintln(w, "-"+Line(offset, lineA, highlight, color))
+		fmt.Fprintln(w, "+"+Line(offset, lineB, highlight, color))
+		if !color {
+			fmt.Fprintln(w, " "+Marks(highlight))
+		}
+	}
+	return differing
+}
+
+func window(data []byte, i int) []byte {
+	if i >= len(data) {
+		return nil
+	}
+	end := i + BytesPerLine
+	if end > len(data) {
+		end = len(data)
+	}
+	return data[i:end]
+}
@@ -0,0 +1,106 @@
+package httpprobe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// Command probes an url, run standalone as httpprobe or as ub probe.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "probe",
+		Usage:     "Sends a request and prints the timing of each phase",
+		ArgsUsage: "url",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "method,X",
+				Usage: "HTTP method of the request",
+				Value: "GET",
+			},
+			cli.StringSliceFlag{
+				Name:  "header,H",
+				Usage: "Request header as 'Name: value', can be repeated",
+				Value: &cli.StringSlice{},
+			},
+			cli.StringFlag{
+				Name:  "body,d",
+				Usage: "File containing the request body, - for stdin",
+			},
+			cli.BoolFlag{
+				Name:  "location,L",
+				Usage: "Follow redirects",
+			},
+			cli.BoolFlag{
+				Name:  "insecure,k",
+				Usage: "Skip the verification of the server certificate",
+			},
+			cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Timeout of each request",
+				Value: 30 * time.Second,
+			},
+			cli.IntFlag{
+				Name:  "requests,n",
+				Usage: "Number of requests to send, a summary is printed when more than one",
+				Value: 1,
+			},
+			cli.IntFlag{
+				Name:  "concurrency,c",
+				Usage: "Number of requests in flight at the same time",
+				Value: 1,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("probe takes an url")
+			}
+			req := Request{
+				Method:  strings.ToUpper(c.String("method")),
+				URL:     c.Args().First(),
+				Headers: http.Header{},
+			}
+			for _, header := range c.StringSlice("header") {
+				parts := strings.SplitN(header, ":", 2)
+				if len(parts) != 2 {
+					return fmt.Errorf("Invalid header %q, expected 'Name: value'", header)
+				}
+				req.Headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+			}
+			var err error
+			switch c.String("body") {
+			case "":
+			case "-":
+				req.Body, err = ioutil.ReadAll(os.Stdin)
+			default:
+				req.Body, err = ioutil.ReadFile(c.String("body"))
+			}
+			if err != nil {
+				return err
+			}
+
+			client := NewClient(c.Duration("timeout"), c.Bool("location"), c.Bool("insecure"))
+
+			if n := c.Int("requests"); n > 1 {
+				start := time.Now()
+				results := Load(client, req, n, c.Int("concurrency"))
+				fmt.Println(Summarize(results, time.Since(start)))
+				return nil
+			}
+
+			result := Probe(client, req)
+			if result.Err != nil {
+				fmt.Println(FormatTiming(result.Timing))
+				return result.Err
+			}
+			fmt.Printf("%s %s: %s %s, %d bytes\n", req.Method, req.URL, result.Proto, result.Status, result.Size)
+			fmt.Println(FormatTiming(result.Timing))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,179 @@
+package httpprobe
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Request struct {
+	Method  string
+	URL     string
+	Headers http.Header
+	Body    []byte
+}
+
+// Timing holds the duration of each phase of a request, phases skipped
+// because of a reused connection are zero.
+type Timing struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	TTFB    time.Duration
+	Total   time.Duration
+}
+
+type Result struct {
+	Status     string
+	StatusCode int
+	Proto      string
+	Size       int64
+	Timing     Timing
+	Err        error
+}
+
+// Probe sends req with client and measures its phases. The body of the
+// response is read fully so Total includes the transfer.
+func Probe(client *http.Client, req Request) Result {
+	var (
+		start, dnsStart, connectStart, tlsStart time.Time
+		timing                                  Timing
+	)
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { timing.DNS = time.Since(dnsStart) },
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { timing.Connect = time.Since(connectStart) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { timing.TLS = time.Since(tlsStart) },
+		GotFirstResponseByte: func() {
+			timing.TTFB = time.Since(start)
+		},
+	}
+
+	httpReq, err := http.NewRequest(req.Method, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return Result{Err: err}
+	}
+	for name, values := range req.Headers {
+		for _, value := range values {
+			httpReq.Header.Add(name, value)
+		}
+	}
+	if host := req.Headers.Get("Host"); host != "" {
+		httpReq.Host = host
+	}
+	httpReq = httpReq.WithContext(httptrace.WithClientTrace(httpReq.Context(), trace))
+
+	start = time.Now()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return Result{Err: err, Timing: timing}
+	}
+	defer resp.Body.Close()
+	size, err := io.Copy(ioutil.Discard, resp.Body)
+	timing.Total = time.Since(start)
+
+	return Result{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Proto:      resp.Proto,
+		Size:       size,
+		Timing:     timing,
+		Err:        err,
+	}
+}
+
+// Load sends n requests over concurrency workers.
+func Load(client *http.Client, req Request, n, concurrency int) []Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]Result, n)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = Probe(client, req)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func FormatTiming(t Timing) string {
+	return fmt.Sprintf("DNS %s, connect %s, TLS %s, TTFB %s, total %s",
+		round(t.DNS), round(t.Connect), round(t.TLS), round(t.TTFB), round(t.Total))
+}
+
+// Summarize reports the status codes, errors and total time percentiles of results.
+func Summarize(results []Result, elapsed time.Duration) string {
+	statuses := map[string]int{}
+	errors := map[string]int{}
+	totals := []time.Duration{}
+	for _, r := range results {
+		if r.Err != nil {
+			errors[r.Err.Error()]++
+			continue
+		}
+		statuses[r.Status]++
+		totals = append(totals, r.Timing.Total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i] < totals[j] })
+
+	lines := []string{fmt.Sprintf("%d requests in %s, %.1f req/s", len(results), round(elapsed), float64(len(results))/elapsed.Seconds())}
+	for _, status := range sortedKeys(statuses) {
+		lines = append(lines, fmt.Sprintf("  %s: %d", status, statuses[status]))
+	}
+	for _, err := range sortedKeys(errors) {
+		lines = append(lines, fmt.Sprintf("  error %s: %d", err, errors[err]))
+	}
+	if len(totals) > 0 {
+		var sum time.Duration
+		for _, d := range totals {
+			sum += d
+		}
+		lines = append(lines, fmt.Sprintf("Total time: min %s, avg %s, p50 %s, p95 %s, p99 %s, max %s",
+			round(totals[0]), round(sum/time.Duration(len(totals))), round(percentile(totals, 50)),
+			round(percentile(totals, 95)), round(percentile(totals, 99)), round(totals[len(totals)-1])))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// percentile expects sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,108 @@
+package idgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+type ID struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// Command generates ids, run standalone as idgen or as ub id.
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "id",
+		Usage: "Generates UUIDs, ULIDs and nanoids",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "type,t",
+				Usage: "Id type: uuid4, uuid7, ulid or nanoid",
+				Value: UUID4Type,
+			},
+			cli.IntFlag{
+				Name:  "count,n",
+				Usage: "Number of ids to generate",
+				Value: 1,
+			},
+			cli.IntFlag{
+				Name:  "size,s",
+				Usage: "Length of the nanoids",
+				Value: DefaultNanoidSize,
+			},
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the ids as a json array",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ids := []ID{}
+			for i := 0; i < c.Int("count"); i++ {
+				now := time.Now()
+				id, err := Generate(c.String("type"), c.Int("size"), now)
+				if err != nil {
+					return err
+				}
+				generated := ID{ID: id, Type: c.String("type")}
+				if generated.Type == UUID7Type || generated.Type == ULIDType {
+					generated.Timestamp = &now
+				}
+				ids = append(ids, generated)
+			}
+
+			if c.Bool("json") {
+				return printJSON(ids)
+			}
+			for _, id := range ids {
+				fmt.Println(id.ID)
+			}
+			return nil
+		},
+		Subcommands: []cli.Command{
+			{
+				Name:      "time",
+				Usage:     "Extract the timestamp of UUIDv7s and ULIDs",
+				ArgsUsage: "id...",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the ids as a json array",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("time takes at least one id")
+					}
+					ids := []ID{}
+					for _, id := range c.Args() {
+						timestamp, idType, err := Timestamp(id)
+						if err != nil {
+							return err
+						}
+						ids = append(ids, ID{ID: id, Type: idType, Timestamp: &timestamp})
+					}
+
+					if c.Bool("json") {
+						return printJSON(ids)
+					}
+					for _, id := range ids {
+						fmt.Printf("%s %s\n", id.ID, id.Timestamp.Format(time.RFC3339Nano))
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,146 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	UUID4Type  = "uuid4"
+	UUID7Type  = "uuid7"
+	ULIDType   = "ulid"
+	NanoidType = "nanoid"
+
+	DefaultNanoidSize = 21
+	// NanoidAlphabet is the url safe alphabet of the reference implementation
+	NanoidAlphabet = "useandom-26T198340PX75pxJACKVERYMINDBUSHWOLF_GQZbfghjklqvwyzrict"
+	// crockfordAlphabet is the base32 alphabet of ULIDs
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// Generate returns a new id of type, size is only used by nanoids.
+func Generate(idType string, size int, now time.Time) (string, error) {
+	switch idType {
+	case UUID4Type, "uuid", "":
+		return UUID4()
+	case UUID7Type:
+		return UUID7(now)
+	case ULIDType:
+		return ULID(now)
+	case NanoidType:
+		return Nanoid(size)
+	default:
+		return "", fmt.Errorf("Unknown id type %q, expected uuid4, uuid7, ulid or nanoid", idType)
+	}
+}
+
+func UUID4() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return formatUUID(b, 4), nil
+}
+
+// UUID7 is a time ordered UUID starting with the unix time in milliseconds.
+func UUID7(now time.Time) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	putMillis(b, now)
+	return formatUUID(b, 7), nil
+}
+
+func formatUUID(b []byte, version byte) string {
+	b[6] = b[6]&0x0f | version<<4
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// ULID is a 48 bit millisecond timestamp followed by 80 random bits encoded
+// in Crockford's base32.
+func ULID(now time.Time) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	putMillis(b, now)
+
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	out := make([]byte, 26)
+	// 128 bits in 26 characters of 5 bits, the first one only holds 3
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out), nil
+}
+
+func Nanoid(size int) (string, error) {
+	if size <= 0 {
+		size = DefaultNanoidSize
+	}
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	// The alphabet has 64 characters so masking keeps the distribution uniform
+	for i := range b {
+		b[i] = NanoidAlphabet[b[i]&63]
+	}
+	return string(b), nil
+}
+
+func putMillis(b []byte, now time.Time) {
+	ms := uint64(now.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// Timestamp extracts the creation time of a UUIDv7 or a ULID.
+func Timestamp(id string) (time.Time, string, error) {
+	switch {
+	case len(id) == 36 && strings.Count(id, "-") == 4:
+		b, err := hex.DecodeString(strings.Replace(id, "-", "", -1))
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("Invalid UUID %q: %v", id, err)
+		}
+		if version := b[6] >> 4; version != 7 {
+			return time.Time{}, "", fmt.Errorf("UUID version %d has no timestamp, only version 7 does", version)
+		}
+		return millisTime(b), UUID7Type, nil
+	case len(id) == 26:
+		var hi, lo uint64
+		for _, c := range strings.ToUpper(id) {
+			v := strings.IndexRune(crockfordAlphabet, c)
+			if v < 0 {
+				return time.Time{}, "", fmt.Errorf("Invalid ULID %q: unexpected character %q", id, c)
+			}
+			hi = hi<<5 | lo>>59
+			lo = lo<<5 | uint64(v)
+		}
+		b := make([]byte, 16)
+		binary.BigEndian.PutUint64(b[:8], hi)
+		binary.BigEndian.PutUint64(b[8:], lo)
+		return millisTime(b), ULIDType, nil
+	default:
+		return time.Time{}, "", fmt.Errorf("%q is neither a UUIDv7 nor a ULID", id)
+	}
+}
+
+func millisTime(b []byte) time.Time {
+	var ms int64
+	for _, x := range b[:6] {
+		ms = ms<<8 | int64(x)
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
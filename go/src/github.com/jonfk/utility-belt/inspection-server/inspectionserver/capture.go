@@ -0,0 +1,246 @@
+package inspectionserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Capture is a request received by the server, saved as a json file.
+type Capture struct {
+	ID         string              `json:"id"`
+	Time       time.Time           `json:"time"`
+	RemoteAddr string              `json:"remote_addr"`
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Path       string              `json:"path"`
+	Query      map[string][]string `json:"query,omitempty"`
+	Headers    http.Header         `json:"headers"`
+	Body       string              `json:"body,omitempty"`
+	// BodyEncoding is base64 when the body isn't valid utf-8
+	BodyEncoding string `json:"body_encoding,omitempty"`
+	// Rendered is the body made readable according to its Content-Type
+	Rendered *RenderedBody `json:"rendered,omitempty"`
+	// GRPC is set for the gRPC calls in --grpc mode
+	GRPC *GRPCCall `json:"grpc,omitempty"`
+	// TLS is set for the requests received over HTTPS
+	TLS *TLSInfo `json:"tls,omitempty"`
+	// Proxy is set for the requests forwarded to an upstream
+	Proxy *ProxiedResponse `json:"proxy,omitempty"`
+	// Tags and Note are added through the annotation API to find the
+	// capture again
+	Tags []string `json:"tags,omitempty"`
+	Note string   `json:"note,omitempty"`
+}
+
+// NewCapture records r with its body, which must already be read.
+func NewCapture(r *http.Request, body []byte) Capture {
+	capture := Capture{
+		Time:       time.Now().UTC(),
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		URL:        r.RequestURI,
+		Path:       r.URL.Path,
+		Query:      r.URL.Query(),
+		Headers:    r.Header,
+	}
+	capture.Body, capture.BodyEncoding = encodeBody(body)
+	return capture
+}
+
+// encodeBody returns body as a string with its encoding, base64 when it
+// isn't valid utf-8.
+func encodeBody(body []byte) (string, string) {
+	if !utf8.Valid(body) {
+		return base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	return string(body), ""
+}
+
+// RawBody returns the body as it was received.
+func (capture Capture) RawBody() []byte {
+	if capture.BodyEncoding == "base64" {
+		body, err := base64.StdEncoding.DecodeString(capture.Body)
+		if err == nil {
+			return body
+		}
+	}
+	return []byte(capture.Body)
+}
+
+func (capture Capture) ContentType() string {
+	return strings.TrimSpace(strings.Split(capture.Headers.Get("Content-Type"), ";")[0])
+}
+
+func (capture Capture) Form() (url.Values, error) {
+	return url.ParseQuery(capture.Body)
+}
+
+// CaptureStore keeps the captures received by the server.
+type CaptureStore interface {
+	// Save sets the ID of capture and keeps it
+	Save(capture *Capture) error
+	// List returns the captures, oldest first
+	List() ([]Capture, error)
+	// Update replaces the capture with the ID of capture, ErrNoCapture when
+	// there is none
+	Update(capture Capture) error
+}
+
+// ErrNoCapture is returned when updating a capture which isn't kept, or no
+// longer.
+var ErrNoCapture = errors.New("No such capture")
+
+// idLayout is the time at the start of the capture IDs.
+const idLayout = "20060102T150405.000000"
+
+// idSequence names the captures by their time, numbering the ones received
+// in the same microsecond, so that the IDs sort by arrival.
+type idSequence struct {
+	mu   sync.Mutex
+	last string
+	seq  int
+}
+
+func (q *idSequence) next(t time.Time) string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stamp := t.Format(idLayout)
+	if stamp == q.last {
+		q.seq++
+	} else {
+		q.last, q.seq = stamp, 0
+	}
+	return fmt.Sprintf("%s-%03d", stamp, q.seq)
+}
+
+// Store keeps the captures as files in a directory, named by their ID which
+// sorts by arrival.
+type Store struct {
+	Dir string
+
+	ids idSequence
+	// mu serializes Update with Prune so that an update can't write back a
+	// pruned capture
+	mu sync.Mutex
+}
+
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{Dir: dir}, nil
+}
+
+func (s *Store) Save(capture *Capture) error {
+	capture.ID = s.ids.next(capture.Time)
+	data, err := json.MarshalIndent(capture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(s.Dir, capture.ID+".json"), data, 0600)
+}
+
+func (s *Store) Update(capture Capture) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filename := filepath.Join(s.Dir, filepath.Base(capture.ID)+".json")
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return ErrNoCapture
+	}
+	data, err := json.MarshalIndent(capture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0600)
+}
+
+// List reads the captures, oldest first.
+func (s *Store) List() ([]Capture, error) {
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	captures := []Capture{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			// pruned since the glob
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var capture Capture
+		if err := json.Unmarshal(data, &capture); err != nil {
+			return nil, fmt.Errorf("Invalid capture %s: %v", file, err)
+		}
+		captures = append(captures, capture)
+	}
+	return captures, nil
+}
+
+// MemoryStore keeps the last captures in a ring buffer and never touches the
+// disk, for traffic carrying secrets on shared machines. The captures are
+// lost when the server stops.
+type MemoryStore struct {
+	ids idSequence
+
+	mu       sync.Mutex
+	captures []Capture
+	// next is the slot of the next capture, the oldest once the buffer is full
+	next int
+	full bool
+}
+
+// NewMemoryStore keeps the last size captures.
+func NewMemoryStore(size int) (*MemoryStore, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("The capture buffer needs room for at least 1 capture, got %d", size)
+	}
+	return &MemoryStore{captures: make([]Capture, size)}, nil
+}
+
+func (s *MemoryStore) Save(capture *Capture) error {
+	capture.ID = s.ids.next(capture.Time)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captures[s.next] = *capture
+	s.next = (s.next + 1) % len(s.captures)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+func (s *MemoryStore) Update(capture Capture) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.captures {
+		if s.captures[i].ID != "" && s.captures[i].ID == capture.ID {
+			s.captures[i] = capture
+			return nil
+		}
+	}
+	return ErrNoCapture
+}
+
+func (s *MemoryStore) List() ([]Capture, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.full {
+		return append([]Capture{}, s.captures[:s.next]...), nil
+	}
+	return append(append([]Capture{}, s.captures[s.next:]...), s.captures[:s.next]...), nil
+}
@@ -0,0 +1,530 @@
+package inspectionserver
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/urfave/cli"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// InspectPrefix is the path of the endpoints of the server itself, requests
+// under it aren't captured.
+const InspectPrefix = "/_inspect/"
+
+// DefaultBuffer is how many captures --memory-only keeps.
+const DefaultBuffer = 1000
+
+// Command serves the inspection server, run standalone as inspection-server or as ub serve inspect.
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "inspect",
+		Usage: "Prints the requests it receives",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "addr,a",
+				Usage: "Address to listen on",
+				Value: ":8080",
+			},
+			capturesFlag,
+			cli.StringFlag{
+				Name:  "response,r",
+				Usage: "Respond with the Go template in `FILE`, e.g. {\"order_id\": {{.JSON.order_id}}}",
+			},
+			cli.IntFlag{
+				Name:  "status",
+				Usage: "Status code of the response",
+				Value: http.StatusOK,
+			},
+			cli.StringSliceFlag{
+				Name:  "header,H",
+				Usage: "Header of the response as `\"Name: value\"`, the value is a Go template",
+			},
+			cli.StringSliceFlag{
+				Name:  "upstream,u",
+				Usage: "Proxy the requests to an upstream instead of responding, by `ROUTE` as \"/api/* -> http://localhost:3000\", a bare URL for every path",
+			},
+			cli.StringFlag{
+				Name:  "routes",
+				Usage: "Proxy with the routing table in `FILE`, a route per line",
+			},
+			cli.BoolFlag{
+				Name:  "memory-only",
+				Usage: "Keep the captures in memory only, never writing them to disk, they are lost when the server stops, implies --quiet",
+			},
+			cli.BoolFlag{
+				Name:  "quiet,q",
+				Usage: "Don't print the requests to stdout, they are still captured",
+			},
+			cli.IntFlag{
+				Name:  "buffer",
+				Usage: "With --memory-only, keep the last `N` captures",
+				Value: DefaultBuffer,
+			},
+			cli.IntFlag{
+				Name:  "max-captures",
+				Usage: "Keep at most `N` captures, deleting the oldest",
+			},
+			cli.StringFlag{
+				Name:  "max-disk",
+				Usage: "Keep the captures under `SIZE`, e.g. 500MB, deleting the oldest",
+			},
+			cli.DurationFlag{
+				Name:  "retention",
+				Usage: "Delete the captures older than `DURATION`, e.g. 24h",
+			},
+			cli.BoolFlag{
+				Name:  "grpc",
+				Usage: "Accept gRPC calls over cleartext HTTP/2 and log their methods and messages",
+			},
+			cli.StringFlag{
+				Name:  "descriptors",
+				Usage: "Decode the gRPC messages to json with the FileDescriptorSet in `FILE`, from protoc --include_imports --descriptor_set_out",
+			},
+			cli.StringFlag{
+				Name:  "expect",
+				Usage: "Exit once the ordered sequence of requests in the yaml `FILE` is received, non-zero after --timeout",
+			},
+			cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "With --expect, how long to wait for the sequence",
+				Value: time.Minute,
+			},
+			cli.StringFlag{
+				Name:  "tls-cert",
+				Usage: "Serve HTTPS with the certificate chain in the PEM `FILE`",
+			},
+			cli.StringFlag{
+				Name:  "tls-key",
+				Usage: "Private key of --tls-cert as a PEM `FILE`",
+			},
+			cli.StringFlag{
+				Name:  "client-ca",
+				Usage: "Request client certificates and verify them against the CAs in the PEM `FILE`, serving HTTPS with a self-signed certificate without --tls-cert",
+			},
+			cli.BoolFlag{
+				Name:  "require-client-cert",
+				Usage: "With --client-ca, reject the handshakes without a valid client certificate instead of capturing them",
+			},
+			cli.BoolFlag{
+				Name:  "no-render",
+				Usage: "Only store the raw bodies, without rendering the json, forms, xml and images by their Content-Type",
+			},
+			cli.StringFlag{
+				Name:  "ui-auth",
+				Usage: "Protect " + InspectPrefix + " with basic auth as `USER:PASS`",
+			},
+			cli.StringFlag{
+				Name:  "ui-token",
+				Usage: "Protect " + InspectPrefix + " with a bearer `TOKEN`, also accepted as ?token=",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			store, location, err := openCaptureStore(c)
+			if err != nil {
+				return err
+			}
+			response, err := LoadCannedResponse(c.Int("status"), c.String("response"), c.StringSlice("header"))
+			if err != nil {
+				return err
+			}
+			cfg, err := config.Load("inspect")
+			if err != nil {
+				return err
+			}
+			userPass, err := cfg.Secret(c, "ui-auth")
+			if err != nil {
+				return err
+			}
+			token, err := cfg.Secret(c, "ui-token")
+			if err != nil {
+				return err
+			}
+			auth, err := ParseUIAuth(userPass, token)
+			if err != nil {
+				return err
+			}
+			retention := Retention{MaxCaptures: c.Int("max-captures"), MaxAge: c.Duration("retention")}
+			if c.String("max-disk") != "" {
+				if retention.MaxBytes, err = ParseSize(c.String("max-disk")); err != nil {
+					return err
+				}
+			}
+			if retention.Enabled() {
+				disk, ok := store.(*Store)
+				if !ok {
+					return fmt.Errorf("--max-captures, --max-disk and --retention apply to the captures on disk, size the memory with --buffer")
+				}
+				go disk.Janitor(retention)
+			}
+			captures, err := store.List()
+			if err != nil {
+				return err
+			}
+			s := &server{store: store, response: response, grpc: c.Bool("grpc"), render: !c.Bool("no-render"), quiet: c.Bool("quiet") || c.Bool("memory-only"), drift: NewDriftDetector()}
+			for _, capture := range captures {
+				s.drift.Observe(capture)
+			}
+			if c.String("descriptors") != "" {
+				if !s.grpc {
+					return fmt.Errorf("--descriptors is only used with --grpc")
+				}
+				if s.descriptors, err = LoadDescriptors(c.String("descriptors")); err != nil {
+					return err
+				}
+			}
+			if s.router, err = loadRouter(c); err != nil {
+				return err
+			}
+			if s.router != nil {
+				if c.IsSet("response") || c.IsSet("status") || c.IsSet("header") || s.grpc {
+					return fmt.Errorf("--response, --status, --header and --grpc don't apply when proxying")
+				}
+				for _, route := range s.router.Routes {
+					fmt.Printf("proxying %s\n", route)
+				}
+			}
+			if c.String("expect") != "" {
+				if s.expectations, err = LoadExpectations(c.String("expect")); err != nil {
+					return err
+				}
+			}
+
+			var certificate *tls.Certificate
+			switch {
+			case c.String("tls-cert") != "" || c.String("tls-key") != "":
+				if c.String("tls-cert") == "" || c.String("tls-key") == "" {
+					return fmt.Errorf("--tls-cert and --tls-key go together")
+				}
+				pair, err := tls.LoadX509KeyPair(c.String("tls-cert"), c.String("tls-key"))
+				if err != nil {
+					return err
+				}
+				certificate = &pair
+			case c.String("client-ca") != "":
+				pair, err := SelfSignedCertificate()
+				if err != nil {
+					return err
+				}
+				certificate = &pair
+				fmt.Printf("serving a self-signed certificate for localhost, sha256 %s\n", fingerprint(pair))
+			}
+			if c.String("client-ca") != "" {
+				pool, err := LoadClientCAs(c.String("client-ca"))
+				if err != nil {
+					return err
+				}
+				s.mtls = &MTLS{ClientCAs: pool, Require: c.Bool("require-client-cert")}
+			} else if c.Bool("require-client-cert") {
+				return fmt.Errorf("--require-client-cert is only used with --client-ca")
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc(InspectPrefix, auth.Wrap(s.indexHandler))
+			mux.HandleFunc(InspectPrefix+"captures", auth.Wrap(s.capturesHandler))
+			mux.HandleFunc(InspectPrefix+"captures/", auth.Wrap(s.capturesHandler))
+			mux.HandleFunc(InspectPrefix+"openapi", auth.Wrap(s.openAPIHandler))
+			mux.HandleFunc(InspectPrefix+"api/wait", auth.Wrap(s.waitHandler))
+			mux.HandleFunc(InspectPrefix+"api/stream", auth.Wrap(s.streamHandler))
+			mux.HandleFunc(InspectPrefix+"api/drift", auth.Wrap(s.driftHandler))
+			mux.HandleFunc(InspectPrefix+"api/requests/", auth.Wrap(s.tagsHandler))
+			mux.HandleFunc("/", s.handler)
+
+			fmt.Printf("serving on %s, capturing to %s\n", c.String("addr"), location)
+			if !auth.Enabled() {
+				fmt.Printf("%s is readable by anyone, protect it with --ui-auth or --ui-token when exposed publicly\n", InspectPrefix)
+			}
+			srv := &http.Server{Addr: c.String("addr"), Handler: mux}
+			if s.grpc {
+				// gRPC clients mostly speak HTTP/2 without TLS
+				srv.Protocols = new(http.Protocols)
+				srv.Protocols.SetHTTP1(true)
+				srv.Protocols.SetUnencryptedHTTP2(true)
+				srv.Protocols.SetHTTP2(certificate != nil)
+			}
+			serve := srv.ListenAndServe
+			if certificate != nil {
+				srv.TLSConfig = s.mtls.TLSConfig(*certificate)
+				serve = func() error { return srv.ListenAndServeTLS("", "") }
+			}
+			if s.expectations == nil {
+				return serve()
+			}
+
+			errs := make(chan error, 1)
+			go func() { errs <- serve() }()
+			select {
+			case err := <-errs:
+				return err
+			case <-s.expectations.Done():
+				fmt.Println("All expectations observed")
+				return nil
+			case <-time.After(c.Duration("timeout")):
+				status := s.expectations.Status()
+				return cli.NewExitError(fmt.Sprintf("Timed out after observing %d of %d expectations, waiting for %s", status.Observed, status.Total, status.Next), 1)
+			}
+		},
+		Subcommands: []cli.Command{
+			tailCommand(),
+			{
+				Name:  "drift",
+				Usage: "Report the captured json bodies whose shape changed from the previous ones of the same method and path",
+				Flags: []cli.Flag{capturesFlag},
+				Action: func(c *cli.Context) error {
+					store, err := NewStore(c.String("captures"))
+					if err != nil {
+						return err
+					}
+					captures, err := store.List()
+					if err != nil {
+						return err
+					}
+					fmt.Println(FormatDrift(DetectDrift(captures)))
+					return nil
+				},
+			},
+			{
+				Name:  "export",
+				Usage: "Print the captured requests as a json array, those with a tag given with --tag",
+				Flags: []cli.Flag{
+					capturesFlag,
+					cli.StringSliceFlag{
+						Name:  "tag,t",
+						Usage: "Only export the requests tagged `TAG`, repeated or comma separated for any of them",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					store, err := NewStore(c.String("captures"))
+					if err != nil {
+						return err
+					}
+					captures, err := store.List()
+					if err != nil {
+						return err
+					}
+					out, err := json.MarshalIndent(FilterByTag(captures, splitTags(c.StringSlice("tag"))), "", "  ")
+					if err != nil {
+						return err
+					}
+					_, err = fmt.Println(string(out))
+					return err
+				},
+			},
+			{
+				Name:  "openapi",
+				Usage: "Draft an OpenAPI 3 document from the captured requests",
+				Flags: []cli.Flag{
+					capturesFlag,
+					cli.StringFlag{
+						Name:  "format,f",
+						Usage: "Output `FORMAT`: yaml or json",
+						Value: "yaml",
+					},
+					cli.StringFlag{
+						Name:  "title",
+						Usage: "Title of the API",
+						Value: "Inferred API",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					store, err := NewStore(c.String("captures"))
+					if err != nil {
+						return err
+					}
+					captures, err := store.List()
+					if err != nil {
+						return err
+					}
+					out, err := marshalOpenAPI(InferOpenAPI(captures, c.String("title")), c.String("format"))
+					if err != nil {
+						return err
+					}
+					_, err = os.Stdout.Write(out)
+					return err
+				},
+			},
+		},
+	}
+}
+
+var capturesFlag = cli.StringFlag{
+	Name:  "captures",
+	Usage: "`DIR` where the received requests are saved",
+	Value: "captures",
+}
+
+// openCaptureStore returns the store of the captures chosen by the flags and
+// where it keeps them.
+func openCaptureStore(c *cli.Context) (CaptureStore, string, error) {
+	if !c.Bool("memory-only") {
+		if c.IsSet("buffer") {
+			return nil, "", fmt.Errorf("--buffer is only used with --memory-only")
+		}
+		store, err := NewStore(c.String("captures"))
+		if err != nil {
+			return nil, "", err
+		}
+		return store, store.Dir, nil
+	}
+	if c.IsSet("captures") {
+		return nil, "", fmt.Errorf("--captures and --memory-only go against each other")
+	}
+	store, err := NewMemoryStore(c.Int("buffer"))
+	if err != nil {
+		return nil, "", err
+	}
+	return store, fmt.Sprintf("memory, keeping the last %d", c.Int("buffer")), nil
+}
+
+// loadRouter builds the routing table of the proxy from --routes then
+// --upstream, nil when not proxying.
+func loadRouter(c *cli.Context) (*Router, error) {
+	routes := []Route{}
+	if c.String("routes") != "" {
+		var err error
+		if routes, err = LoadRoutes(c.String("routes")); err != nil {
+			return nil, err
+		}
+	}
+	for _, upstream := range c.StringSlice("upstream") {
+		route, err := ParseRoute(upstream)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, route)
+	}
+	if len(routes) == 0 {
+		return nil, nil
+	}
+	return NewRouter(routes), nil
+}
+
+type server struct {
+	store    CaptureStore
+	response *CannedResponse
+	grpc     bool
+	// render is unset with --no-render
+	render bool
+	// quiet is set with --quiet or --memory-only, whose captures stay off
+	// the disk and so out of the terminal
+	quiet       bool
+	descriptors *Descriptors
+	// expectations is set with --expect
+	expectations *Expectations
+	// mtls is set with --client-ca
+	mtls *MTLS
+	// router is set when proxying with --upstream or --routes
+	router *Router
+	drift  *DriftDetector
+	hub    hub
+	// annotating serializes the updates of the tags of the captures
+	annotating sync.Mutex
+}
+
+func (s *server) handler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, InspectPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if s.grpc && IsGRPC(r) {
+		capture := s.newCapture(r, body)
+		s.grpcHandler(w, r, body, &capture)
+		s.record(&capture)
+		return
+	}
+
+	if s.router != nil {
+		s.proxyHandler(w, r, body)
+		return
+	}
+
+	if !s.quiet {
+		fmt.Println("Body:")
+
+		buf := new(bytes.Buffer)
+
+		r.Write(buf)
+
+		reqStr := buf.String()
+		fmt.Println(reqStr)
+	}
+
+	capture := s.newCapture(r, body)
+	s.record(&capture)
+	if err := s.response.Write(w, NewRequestData(r, body)); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not render response: %v\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newCapture records r with its TLS connection and its rendered body.
+func (s *server) newCapture(r *http.Request, body []byte) Capture {
+	capture := NewCapture(r, body)
+	capture.TLS = s.mtls.Describe(r.TLS)
+	if s.render && !IsGRPC(r) {
+		capture.Rendered = RenderBody(r.Header.Get("Content-Type"), body)
+	}
+	return capture
+}
+
+// record saves capture, checks it against the expectations and the schema
+// of the previous bodies of its operation.
+func (s *server) record(capture *Capture) {
+	if err := s.store.Save(capture); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not save capture: %v\n", err)
+	}
+	if s.expectations != nil {
+		s.expectations.Observe(*capture)
+	}
+	if drifts := s.drift.Observe(*capture); len(drifts) > 0 {
+		fmt.Println(FormatDrift([]CaptureDrift{{ID: capture.ID, Operation: capture.Method + " " + capture.Path, Drifts: drifts}}))
+	}
+	s.hub.publish(*capture)
+}
+
+func (s *server) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	captures, err := s.store.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	format := "json"
+	if strings.Contains(r.Header.Get("Accept"), "yaml") || r.URL.Query().Get("format") == "yaml" {
+		format = "yaml"
+	}
+	out, err := marshalOpenAPI(InferOpenAPI(captures, "Inferred API"), format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/"+format)
+	w.Write(out)
+}
+
+func marshalOpenAPI(doc map[string]interface{}, format string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		return yaml.Marshal(doc)
+	case "json":
+		out, err := json.MarshalIndent(doc, "", "  ")
+		return append(out, '\n'), err
+	default:
+		return nil, fmt.Errorf("Unknown format %s, expected yaml or json", format)
+	}
+}
@@ -0,0 +1,196 @@
+package inspectionserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Drift is a difference between a json body and the schema inferred from
+// the previous bodies of the same operation.
+type Drift struct {
+	// Field is the dotted path of the value, [] standing for array items
+	Field string `json:"field"`
+	// Kind is "new field", "missing field" or "type change"
+	Kind     string `json:"kind"`
+	Expected string `json:"expected,omitempty"`
+	Got      string `json:"got,omitempty"`
+}
+
+func (d Drift) String() string {
+	switch d.Kind {
+	case "type change":
+		return fmt.Sprintf("%s %s from %s to %s", d.Kind, d.Field, d.Expected, d.Got)
+	case "new field":
+		return fmt.Sprintf("%s %s (%s)", d.Kind, d.Field, d.Got)
+	default:
+		return fmt.Sprintf("%s %s", d.Kind, d.Field)
+	}
+}
+
+// CaptureDrift reports the drifts of a capture.
+type CaptureDrift struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Drifts    []Drift   `json:"drifts"`
+}
+
+func typeName(s *Schema) string {
+	switch {
+	case s.Type == "" && s.Nullable:
+		return "null"
+	case s.Type == "":
+		return "any"
+	default:
+		return s.Type
+	}
+}
+
+// SchemaDrift compares the schema of a single sample to known, the schema
+// merged from the previous samples. Fields missing from sample are only
+// reported when every previous sample had them.
+func SchemaDrift(known, sample *Schema, field string) []Drift {
+	drifts := []Drift{}
+	name := field
+	if name == "" {
+		name = "(body)"
+	}
+	switch {
+	case known.Type == "" && !known.Nullable:
+		// conflicting types were already seen, anything goes
+		return drifts
+	case sample.Type == "":
+		if !known.Nullable {
+			drifts = append(drifts, Drift{Field: name, Kind: "type change", Expected: typeName(known), Got: "null"})
+		}
+		return drifts
+	case known.Type == "":
+		// only nulls were seen so far
+		return drifts
+	case known.Type == "number" && sample.Type == "integer":
+	case known.Type != sample.Type:
+		return append(drifts, Drift{Field: name, Kind: "type change", Expected: typeName(known), Got: typeName(sample)})
+	}
+
+	prefix := field
+	if prefix != "" {
+		prefix += "."
+	}
+	switch known.Type {
+	case "object":
+		keys := []string{}
+		for key := range known.Properties {
+			keys = append(keys, key)
+		}
+		for key := range sample.Properties {
+			if known.Properties[key] == nil {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			knownProperty, sampleProperty := known.Properties[key], sample.Properties[key]
+			switch {
+			case knownProperty == nil:
+				drifts = append(drifts, Drift{Field: prefix + key, Kind: "new field", Got: typeName(sampleProperty)})
+			case sampleProperty == nil:
+				if known.propCounts[key] == known.samples {
+					drifts = append(drifts, Drift{Field: prefix + key, Kind: "missing field", Expected: typeName(knownProperty)})
+				}
+			default:
+				drifts = append(drifts, SchemaDrift(knownProperty, sampleProperty, prefix+key)...)
+			}
+		}
+	case "array":
+		if known.Items != nil && sample.Items != nil {
+			drifts = append(drifts, SchemaDrift(known.Items, sample.Items, field+"[]")...)
+		}
+	}
+	return drifts
+}
+
+// DriftDetector infers a schema per operation, a method and templated path,
+// from the json bodies it observes and records those which drift from it.
+type DriftDetector struct {
+	mu      sync.Mutex
+	schemas map[string]*Schema
+	reports []CaptureDrift
+}
+
+func NewDriftDetector() *DriftDetector {
+	return &DriftDetector{schemas: map[string]*Schema{}, reports: []CaptureDrift{}}
+}
+
+// Observe compares the body of capture to the schema of its operation then
+// merges it in, returning the drifts found. The first body of an operation
+// has none.
+func (d *DriftDetector) Observe(capture Capture) []Drift {
+	contentType := capture.ContentType()
+	if contentType != "application/json" && !strings.HasSuffix(contentType, "+json") {
+		return nil
+	}
+	sample := bodySchema(capture)
+	if sample == nil {
+		return nil
+	}
+	path, _ := TemplatePath(capture.Path)
+	operation := capture.Method + " " + path
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	known := d.schemas[operation]
+	d.schemas[operation] = MergeSchema(known, sample)
+	if known == nil {
+		return nil
+	}
+	drifts := SchemaDrift(known, sample, "")
+	if len(drifts) > 0 {
+		d.reports = append(d.reports, CaptureDrift{ID: capture.ID, Time: capture.Time, Operation: operation, Drifts: drifts})
+	}
+	return drifts
+}
+
+// Reports returns the drifting captures, oldest first.
+func (d *DriftDetector) Reports() []CaptureDrift {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]CaptureDrift{}, d.reports...)
+}
+
+// DetectDrift replays captures, oldest first, through a new detector.
+func DetectDrift(captures []Capture) []CaptureDrift {
+	detector := NewDriftDetector()
+	for _, capture := range captures {
+		detector.Observe(capture)
+	}
+	return detector.Reports()
+}
+
+// FormatDrift renders a line per drifting capture.
+func FormatDrift(reports []CaptureDrift) string {
+	if len(reports) == 0 {
+		return "No schema drift"
+	}
+	lines := []string{}
+	for _, report := range reports {
+		drifts := []string{}
+		for _, drift := range report.Drifts {
+			drifts = append(drifts, drift.String())
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: %s", report.ID, report.Operation, strings.Join(drifts, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// driftHandler returns the drifting captures as json.
+func (s *server) driftHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(s.drift.Reports())
+}
@@ -0,0 +1,210 @@
+package inspectionserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Expectation matches a request of the sequence given to --expect, e.g.
+//
+//	# expectations.yaml
+//	- method: POST
+//	  path: /webhooks/*
+//	  body:
+//	    contains: paid
+//	    json: {order.id: 42}
+type Expectation struct {
+	// Method is any method when empty
+	Method string `yaml:"method" json:"method,omitempty"`
+	// Path is a path.Match pattern, any path when empty
+	Path string      `yaml:"path" json:"path,omitempty"`
+	Body BodyMatcher `yaml:"body" json:"body,omitempty"`
+}
+
+// BodyMatcher matches the body of a request, every set matcher must match.
+type BodyMatcher struct {
+	Contains string `yaml:"contains" json:"contains,omitempty"`
+	Regex    string `yaml:"regex" json:"regex,omitempty"`
+	// JSON maps dotted paths of the json body to their expected value
+	JSON map[string]interface{} `yaml:"json" json:"json,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+func (e Expectation) String() string {
+	method, p := e.Method, e.Path
+	if method == "" {
+		method = "*"
+	}
+	if p == "" {
+		p = "*"
+	}
+	return method + " " + p
+}
+
+// Matches reports whether capture satisfies e.
+func (e Expectation) Matches(capture Capture) bool {
+	if e.Method != "" && !strings.EqualFold(e.Method, capture.Method) {
+		return false
+	}
+	if e.Path != "" {
+		if ok, _ := path.Match(e.Path, capture.Path); !ok {
+			return false
+		}
+	}
+	body := capture.RawBody()
+	if e.Body.Contains != "" && !bytes.Contains(body, []byte(e.Body.Contains)) {
+		return false
+	}
+	if e.Body.regex != nil && !e.Body.regex.Match(body) {
+		return false
+	}
+	if len(e.Body.JSON) == 0 {
+		return true
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return false
+	}
+	for key, expected := range e.Body.JSON {
+		value, ok := lookupJSON(decoded, key)
+		if !ok || fmt.Sprint(value) != fmt.Sprint(expected) {
+			return false
+		}
+	}
+	return true
+}
+
+func lookupJSON(value interface{}, dotted string) (interface{}, bool) {
+	for _, key := range strings.Split(dotted, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// Expectations tracks an ordered sequence of expected requests, the requests
+// in between which don't match the next expectation are ignored.
+type Expectations struct {
+	list []Expectation
+
+	mu       sync.Mutex
+	observed int
+	done     chan struct{}
+}
+
+// ExpectationStatus is the progress of the sequence.
+type ExpectationStatus struct {
+	Complete bool         `json:"complete"`
+	Observed int          `json:"observed"`
+	Total    int          `json:"total"`
+	Next     *Expectation `json:"next,omitempty"`
+}
+
+// LoadExpectations reads the yaml list of expectations in filename.
+func LoadExpectations(filename string) (*Expectations, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	list := []Expectation{}
+	if err := yaml.Unmarshal(content, &list); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%s: no expectations", filename)
+	}
+	for i := range list {
+		if list[i].Path != "" {
+			if _, err := path.Match(list[i].Path, "/"); err != nil {
+				return nil, fmt.Errorf("%s: expectation %d: invalid path %q: %v", filename, i+1, list[i].Path, err)
+			}
+		}
+		if list[i].Body.Regex != "" {
+			if list[i].Body.regex, err = regexp.Compile(list[i].Body.Regex); err != nil {
+				return nil, fmt.Errorf("%s: expectation %d: %v", filename, i+1, err)
+			}
+		}
+	}
+	return &Expectations{list: list, done: make(chan struct{})}, nil
+}
+
+// Observe advances the sequence when capture matches the next expectation.
+func (e *Expectations) Observe(capture Capture) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.observed == len(e.list) || !e.list[e.observed].Matches(capture) {
+		return
+	}
+	e.observed++
+	fmt.Printf("expectation %d/%d observed: %s\n", e.observed, len(e.list), e.list[e.observed-1])
+	if e.observed == len(e.list) {
+		close(e.done)
+	}
+}
+
+// Done is closed once every expectation has been observed.
+func (e *Expectations) Done() <-chan struct{} {
+	return e.done
+}
+
+func (e *Expectations) Status() ExpectationStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	status := ExpectationStatus{Complete: e.observed == len(e.list), Observed: e.observed, Total: len(e.list)}
+	if !status.Complete {
+		next := e.list[e.observed]
+		status.Next = &next
+	}
+	return status
+}
+
+// waitHandler blocks until the expectations are observed, the request is
+// cancelled or the ?timeout= duration, 30s by default, has passed. It
+// responds with the status, 408 when incomplete.
+func (s *server) waitHandler(w http.ResponseWriter, r *http.Request) {
+	if s.expectations == nil {
+		http.Error(w, "No expectations, start the server with --expect", http.StatusNotFound)
+		return
+	}
+	timeout := 30 * time.Second
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid timeout %q: %v", t, err), http.StatusBadRequest)
+			return
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-s.expectations.Done():
+	case <-timer.C:
+	case <-r.Context().Done():
+		return
+	}
+
+	status := s.expectations.Status()
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Complete {
+		w.WriteHeader(http.StatusRequestTimeout)
+	}
+	json.NewEncoder(w).Encode(status)
+}
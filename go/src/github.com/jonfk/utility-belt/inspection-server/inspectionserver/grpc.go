@@ -0,0 +1,210 @@
+package inspectionserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCCall is the gRPC method and messages of a capture. The messages are
+// json when the method is found in the descriptors, base64 strings of the
+// protobuf encoding otherwise.
+type GRPCCall struct {
+	Method   string            `json:"method"`
+	Requests []json.RawMessage `json:"requests"`
+	Response json.RawMessage   `json:"response,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+func IsGRPC(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// Descriptors resolves the gRPC methods from a FileDescriptorSet, as written
+// by protoc --include_imports --descriptor_set_out.
+type Descriptors struct {
+	files *protoregistry.Files
+}
+
+func LoadDescriptors(filename string) (*Descriptors, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("Invalid descriptor set %s: %v", filename, err)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid descriptor set %s: %v", filename, err)
+	}
+	return &Descriptors{files: files}, nil
+}
+
+// Method finds the method of a gRPC path such as /helloworld.Greeter/SayHello,
+// nil when unknown.
+func (d *Descriptors) Method(path string) protoreflect.MethodDescriptor {
+	if d == nil {
+		return nil
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 2 {
+		return nil
+	}
+	desc, err := d.files.FindDescriptorByName(protoreflect.FullName(parts[0]))
+	if err != nil {
+		return nil
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	return service.Methods().ByName(protoreflect.Name(parts[1]))
+}
+
+// ReadGRPCMessages splits a gRPC body into its length prefixed messages,
+// decompressing them with encoding, the grpc-encoding header.
+func ReadGRPCMessages(body []byte, encoding string) ([][]byte, error) {
+	messages := [][]byte{}
+	for len(body) > 0 {
+		if len(body) < 5 {
+			return messages, fmt.Errorf("Truncated gRPC message header")
+		}
+		compressed, length := body[0] == 1, binary.BigEndian.Uint32(body[1:5])
+		if uint32(len(body)-5) < length {
+			return messages, fmt.Errorf("Truncated gRPC message of %d bytes", length)
+		}
+		message := body[5 : 5+length]
+		body = body[5+length:]
+		if compressed {
+			if encoding != "gzip" {
+				return messages, fmt.Errorf("Unsupported gRPC encoding %s", encoding)
+			}
+			reader, err := gzip.NewReader(bytes.NewReader(message))
+			if err != nil {
+				return messages, err
+			}
+			if message, err = ioutil.ReadAll(reader); err != nil {
+				return messages, err
+			}
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// EncodeGRPCMessage prefixes an uncompressed message with its length.
+func EncodeGRPCMessage(message []byte) []byte {
+	out := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(out[1:], uint32(len(message)))
+	return append(out, message...)
+}
+
+func decodeMessage(desc protoreflect.MessageDescriptor, data []byte) (json.RawMessage, error) {
+	if desc == nil {
+		return json.Marshal(base64.StdEncoding.EncodeToString(data))
+	}
+	message := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(message)
+}
+
+func encodeMessage(desc protoreflect.MessageDescriptor, data []byte) ([]byte, error) {
+	message := dynamicpb.NewMessage(desc)
+	if err := protojson.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(message)
+}
+
+// grpcHandler records the call in capture and answers it. The response is the
+// canned response rendered as the json of the output message when both it and
+// the method descriptor are known, an empty message otherwise.
+func (s *server) grpcHandler(w http.ResponseWriter, r *http.Request, body []byte, capture *Capture) {
+	call := &GRPCCall{Method: r.URL.Path, Requests: []json.RawMessage{}}
+	capture.GRPC = call
+	status, response, err := s.grpcCall(r, body, call)
+	if err != nil {
+		call.Error = err.Error()
+		fmt.Printf("gRPC %s: %v\n", call.Method, err)
+	}
+
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+	if status == 0 {
+		w.Write(EncodeGRPCMessage(response))
+	}
+	w.Header().Set("Grpc-Status", fmt.Sprint(status))
+	if err != nil {
+		w.Header().Set("Grpc-Message", err.Error())
+	}
+}
+
+// grpc status codes
+const (
+	grpcOK       = 0
+	grpcInvalid  = 3
+	grpcInternal = 13
+)
+
+func (s *server) grpcCall(r *http.Request, body []byte, call *GRPCCall) (int, []byte, error) {
+	method := s.descriptors.Method(call.Method)
+	var input, output protoreflect.MessageDescriptor
+	if method != nil {
+		input, output = method.Input(), method.Output()
+	}
+
+	messages, err := ReadGRPCMessages(body, r.Header.Get("Grpc-Encoding"))
+	if err != nil {
+		return grpcInvalid, nil, err
+	}
+	if !s.quiet {
+		fmt.Printf("gRPC %s\n", call.Method)
+	}
+	for _, message := range messages {
+		decoded, err := decodeMessage(input, message)
+		if err != nil {
+			return grpcInvalid, nil, err
+		}
+		if !s.quiet {
+			fmt.Printf("  %s\n", decoded)
+		}
+		call.Requests = append(call.Requests, decoded)
+	}
+
+	if output == nil || !s.response.Templated() {
+		return grpcOK, nil, nil
+	}
+	var first []byte
+	if len(call.Requests) > 0 {
+		first = call.Requests[0]
+	}
+	var rendered bytes.Buffer
+	if err := s.response.Body.Execute(&rendered, NewRequestData(r, first)); err != nil {
+		return grpcInternal, nil, err
+	}
+	response, err := encodeMessage(output, rendered.Bytes())
+	if err != nil {
+		return grpcInternal, nil, fmt.Errorf("Canned response isn't a %s: %v", output.FullName(), err)
+	}
+	call.Response = json.RawMessage(rendered.Bytes())
+	return grpcOK, response, nil
+}
@@ -0,0 +1,161 @@
+package inspectionserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/jonfk/utility-belt/certinfo/certinfo"
+)
+
+// TLSInfo is the TLS connection of a capture and the client certificates
+// presented on it.
+type TLSInfo struct {
+	Version    string `json:"version"`
+	Cipher     string `json:"cipher"`
+	ServerName string `json:"server_name,omitempty"`
+	// ClientChain is the chain presented by the client, the leaf first
+	ClientChain []certinfo.Certificate `json:"client_chain,omitempty"`
+	// Verified is whether ClientChain verifies against --client-ca,
+	// VerifyError says why not
+	Verified    bool   `json:"verified"`
+	VerifyError string `json:"verify_error,omitempty"`
+}
+
+// MTLS requests the client certificates and verifies them against
+// ClientCAs. When Require is set the handshakes without a valid certificate
+// are rejected, otherwise they are captured with the verification result.
+type MTLS struct {
+	ClientCAs *x509.CertPool
+	Require   bool
+}
+
+func LoadClientCAs(filename string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: no certificate found", filename)
+	}
+	return pool, nil
+}
+
+// verify checks the chain presented by a client.
+func (m *MTLS) verify(chain []*x509.Certificate) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("no client certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         m.ClientCAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+// TLSConfig serves certificate, requesting the client certificates when m
+// is set.
+func (m *MTLS) TLSConfig(certificate tls.Certificate) *tls.Config {
+	config := &tls.Config{Certificates: []tls.Certificate{certificate}}
+	if m == nil {
+		return config
+	}
+	// the chain is verified by verify rather than by crypto/tls so the
+	// captures record why it is invalid
+	config.ClientAuth = tls.RequestClientCert
+	if m.Require {
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			chain := []*x509.Certificate{}
+			for _, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return err
+				}
+				chain = append(chain, cert)
+			}
+			if err := m.verify(chain); err != nil {
+				fmt.Fprintf(os.Stderr, "Rejected client certificate %s: %v\n", chain[0].Subject, err)
+				return err
+			}
+			return nil
+		}
+	}
+	return config
+}
+
+// Describe records state, verifying the client chain when m is set.
+func (m *MTLS) Describe(state *tls.ConnectionState) *TLSInfo {
+	if state == nil {
+		return nil
+	}
+	info := &TLSInfo{
+		Version:    tls.VersionName(state.Version),
+		Cipher:     tls.CipherSuiteName(state.CipherSuite),
+		ServerName: state.ServerName,
+	}
+	if m == nil {
+		return info
+	}
+	now := time.Now()
+	for _, cert := range state.PeerCertificates {
+		info.ClientChain = append(info.ClientChain, certinfo.Describe(cert, now))
+	}
+	if err := m.verify(state.PeerCertificates); err != nil {
+		info.VerifyError = err.Error()
+	} else {
+		info.Verified = true
+	}
+	return info
+}
+
+// SelfSignedCertificate generates a certificate for localhost valid for a
+// day, for when TLS is needed without --tls-cert.
+func SelfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "inspection-server"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// fingerprint is the SHA-256 of the leaf of certificate.
+func fingerprint(certificate tls.Certificate) string {
+	sum := sha256.Sum256(certificate.Certificate[0])
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,310 @@
+package inspectionserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is a JSON schema inferred from samples, merged as more are seen.
+type Schema struct {
+	Type       string
+	Nullable   bool
+	Properties map[string]*Schema
+	Items      *Schema
+	Example    interface{}
+
+	samples    int
+	propCounts map[string]int
+}
+
+func InferSchema(v interface{}) *Schema {
+	s := &Schema{samples: 1}
+	switch v := v.(type) {
+	case nil:
+		s.Nullable = true
+	case bool:
+		s.Type, s.Example = "boolean", v
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			s.Type = "integer"
+		} else {
+			s.Type = "number"
+		}
+		s.Example = v
+	case string:
+		s.Type, s.Example = "string", v
+	case []interface{}:
+		s.Type = "array"
+		for _, item := range v {
+			s.Items = MergeSchema(s.Items, InferSchema(item))
+		}
+	case map[string]interface{}:
+		s.Type = "object"
+		s.Properties = map[string]*Schema{}
+		s.propCounts = map[string]int{}
+		for key, value := range v {
+			s.Properties[key] = InferSchema(value)
+			s.propCounts[key] = 1
+		}
+	}
+	return s
+}
+
+// MergeSchema combines two schemas of the same value, a property is required
+// when every sample of the object has it.
+func MergeSchema(a, b *Schema) *Schema {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	merged := &Schema{
+		Type:     a.Type,
+		Nullable: a.Nullable || b.Nullable,
+		Example:  a.Example,
+		samples:  a.samples + b.samples,
+	}
+	switch {
+	case a.Type == "":
+		merged.Type, merged.Example = b.Type, b.Example
+	case b.Type == "" || a.Type == b.Type:
+	case a.Type == "integer" && b.Type == "number", a.Type == "number" && b.Type == "integer":
+		merged.Type = "number"
+	default:
+		// conflicting types, any value is accepted
+		merged.Type, merged.Example = "", nil
+		return merged
+	}
+	if merged.Example == nil {
+		merged.Example = b.Example
+	}
+	if merged.Type == "array" {
+		merged.Items = MergeSchema(a.Items, b.Items)
+	}
+	if merged.Type == "object" {
+		merged.Properties = map[string]*Schema{}
+		merged.propCounts = map[string]int{}
+		for _, s := range []*Schema{a, b} {
+			for key, property := range s.Properties {
+				merged.Properties[key] = MergeSchema(merged.Properties[key], property)
+				merged.propCounts[key] += s.propCounts[key]
+			}
+		}
+	}
+	return merged
+}
+
+// OpenAPI returns the schema as an OpenAPI 3.0 schema object.
+func (s *Schema) OpenAPI() map[string]interface{} {
+	out := map[string]interface{}{}
+	if s.Type != "" {
+		out["type"] = s.Type
+	}
+	if s.Nullable {
+		out["nullable"] = true
+	}
+	if s.Example != nil && s.Type != "object" && s.Type != "array" {
+		out["example"] = exampleValue(s.Example)
+	}
+	if s.Items != nil {
+		out["items"] = s.Items.OpenAPI()
+	}
+	if s.Type == "object" {
+		properties := map[string]interface{}{}
+		required := []string{}
+		for key, property := range s.Properties {
+			properties[key] = property.OpenAPI()
+			if s.propCounts[key] == s.samples {
+				required = append(required, key)
+			}
+		}
+		out["properties"] = properties
+		if len(required) > 0 {
+			sort.Strings(required)
+			out["required"] = required
+		}
+	}
+	return out
+}
+
+func exampleValue(v interface{}) interface{} {
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		f, _ := n.Float64()
+		return f
+	}
+	return v
+}
+
+// scalarSchema infers the schema of a query or form value.
+func scalarSchema(value string) *Schema {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return InferSchema(json.Number(value))
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return InferSchema(json.Number(value))
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return InferSchema(b)
+	}
+	return InferSchema(value)
+}
+
+var idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// TemplatePath replaces the segments of path which look like identifiers
+// with parameters named after the preceding segment, e.g. /orders/42 is
+// /orders/{orderId}.
+func TemplatePath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	params := []string{}
+	for i, segment := range segments {
+		if !idSegment.MatchString(segment) {
+			continue
+		}
+		name := "id"
+		if i > 0 && segments[i-1] != "" && !strings.HasPrefix(segments[i-1], "{") {
+			name = strings.TrimSuffix(segments[i-1], "s") + "Id"
+		}
+		for _, p := range params {
+			if p == name {
+				name = fmt.Sprintf("%s%d", name, len(params)+1)
+			}
+		}
+		params = append(params, name)
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+type operation struct {
+	samples    int
+	pathParams []string
+	query      map[string]*Schema
+	queryCount map[string]int
+	bodies     map[string]*Schema
+}
+
+// InferOpenAPI drafts an OpenAPI 3.0 document of the paths, methods, query
+// parameters and request bodies seen in captures.
+func InferOpenAPI(captures []Capture, title string) map[string]interface{} {
+	operations := map[string]map[string]*operation{}
+	for _, capture := range captures {
+		path, params := TemplatePath(capture.Path)
+		method := strings.ToLower(capture.Method)
+		if operations[path] == nil {
+			operations[path] = map[string]*operation{}
+		}
+		op := operations[path][method]
+		if op == nil {
+			op = &operation{pathParams: params, query: map[string]*Schema{}, queryCount: map[string]int{}, bodies: map[string]*Schema{}}
+			operations[path][method] = op
+		}
+		op.samples++
+		for key, values := range capture.Query {
+			for _, value := range values {
+				op.query[key] = MergeSchema(op.query[key], scalarSchema(value))
+			}
+			op.queryCount[key]++
+		}
+		if body := bodySchema(capture); body != nil {
+			contentType := capture.ContentType()
+			op.bodies[contentType] = MergeSchema(op.bodies[contentType], body)
+		}
+	}
+
+	paths := map[string]interface{}{}
+	for path, methods := range operations {
+		item := map[string]interface{}{}
+		for method, op := range methods {
+			item[method] = op.openAPI(method, path)
+		}
+		paths[path] = item
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       title,
+			"version":     "0.1.0",
+			"description": fmt.Sprintf("Draft inferred from %d captured requests", len(captures)),
+		},
+		"paths": paths,
+	}
+}
+
+func bodySchema(capture Capture) *Schema {
+	if capture.Body == "" {
+		return nil
+	}
+	contentType := capture.ContentType()
+	switch {
+	case contentType == "application/json" || strings.HasSuffix(contentType, "+json"):
+		decoder := json.NewDecoder(strings.NewReader(capture.Body))
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			return nil
+		}
+		return InferSchema(v)
+	case contentType == "application/x-www-form-urlencoded":
+		form, err := capture.Form()
+		if err != nil {
+			return nil
+		}
+		s := &Schema{Type: "object", samples: 1, Properties: map[string]*Schema{}, propCounts: map[string]int{}}
+		for key, values := range form {
+			s.Properties[key] = scalarSchema(values[0])
+			s.propCounts[key] = 1
+		}
+		return s
+	default:
+		return &Schema{Type: "string", samples: 1}
+	}
+}
+
+func (op *operation) openAPI(method, path string) map[string]interface{} {
+	parameters := []interface{}{}
+	for _, name := range op.pathParams {
+		parameters = append(parameters, map[string]interface{}{
+			"name": name, "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	names := []string{}
+	for name := range op.query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parameters = append(parameters, map[string]interface{}{
+			"name": name, "in": "query", "required": op.queryCount[name] == op.samples, "schema": op.query[name].OpenAPI(),
+		})
+	}
+
+	out := map[string]interface{}{
+		"summary": fmt.Sprintf("%s %s (seen %d times)", strings.ToUpper(method), path, op.samples),
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{"description": "OK"},
+		},
+	}
+	if len(parameters) > 0 {
+		out["parameters"] = parameters
+	}
+	if len(op.bodies) > 0 {
+		content := map[string]interface{}{}
+		for contentType, schema := range op.bodies {
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			content[contentType] = map[string]interface{}{"schema": schema.OpenAPI()}
+		}
+		out["requestBody"] = map[string]interface{}{"content": content}
+	}
+	return out
+}
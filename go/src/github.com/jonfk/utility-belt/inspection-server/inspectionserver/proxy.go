@@ -0,0 +1,224 @@
+package inspectionserver
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxProxiedBody is how much of a response body is captured, the rest is
+// still forwarded.
+const MaxProxiedBody = 1 << 20
+
+// Route forwards the requests whose path matches Pattern to Upstream.
+// Patterns ending with * or / match the paths under them, /api/* matching
+// /api too, the others match the path exactly.
+type Route struct {
+	Pattern  string
+	Upstream *url.URL
+}
+
+func (route Route) prefix() (string, bool) {
+	switch {
+	case strings.HasSuffix(route.Pattern, "*"):
+		return strings.TrimSuffix(route.Pattern, "*"), true
+	case strings.HasSuffix(route.Pattern, "/"):
+		return route.Pattern, true
+	default:
+		return route.Pattern, false
+	}
+}
+
+func (route Route) Matches(path string) bool {
+	prefix, isPrefix := route.prefix()
+	if !isPrefix {
+		return path == prefix
+	}
+	return strings.HasPrefix(path, prefix) || path+"/" == prefix
+}
+
+func (route Route) String() string {
+	return route.Pattern + " -> " + route.Upstream.String()
+}
+
+// ParseRoute parses a route as "/api/* -> http://localhost:3000", also
+// written /api/*=http://localhost:3000, a bare URL routes every path.
+func ParseRoute(s string) (Route, error) {
+	pattern, upstream := "/*", strings.TrimSpace(s)
+	if i := strings.Index(s, "->"); i >= 0 {
+		pattern, upstream = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+2:])
+	} else if i := strings.Index(s, "="); i >= 0 && strings.HasPrefix(s, "/") {
+		pattern, upstream = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		return Route{}, fmt.Errorf("Invalid route %s, the pattern must start with /", s)
+	}
+	u, err := url.Parse(upstream)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Route{}, fmt.Errorf("Invalid route %s, expected e.g. \"/api/* -> http://localhost:3000\"", s)
+	}
+	return Route{Pattern: pattern, Upstream: u}, nil
+}
+
+// LoadRoutes reads a routing table with a route per line, skipping the
+// blank lines and the # comments.
+func LoadRoutes(filename string) ([]Route, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	routes := []Route{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		route, err := ParseRoute(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, n, err)
+		}
+		routes = append(routes, route)
+	}
+	return routes, scanner.Err()
+}
+
+// Router picks the route of a path, the most specific pattern winning over
+// the order of the table.
+type Router struct {
+	Routes []Route
+}
+
+func NewRouter(routes []Route) *Router {
+	sorted := append([]Route{}, routes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, prefixI := sorted[i].prefix()
+		pj, prefixJ := sorted[j].prefix()
+		if prefixI != prefixJ {
+			// exact paths first
+			return !prefixI
+		}
+		return len(pi) > len(pj)
+	})
+	return &Router{Routes: sorted}
+}
+
+func (router *Router) Route(path string) (Route, bool) {
+	for _, route := range router.Routes {
+		if route.Matches(path) {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// ProxiedResponse is what the upstream answered to a forwarded request.
+type ProxiedResponse struct {
+	Upstream string      `json:"upstream"`
+	Status   int         `json:"status"`
+	Headers  http.Header `json:"headers,omitempty"`
+	Body     string      `json:"body,omitempty"`
+	// BodyEncoding is base64 when the body isn't valid utf-8
+	BodyEncoding string `json:"body_encoding,omitempty"`
+	// Rendered is the body made readable according to its Content-Type
+	Rendered *RenderedBody `json:"rendered,omitempty"`
+	// Truncated is set when the body was longer than MaxProxiedBody
+	Truncated bool `json:"truncated,omitempty"`
+	// Error is set when the upstream couldn't be reached
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_ms"`
+}
+
+// recordingBody keeps the beginning of a response body as it streams to the
+// client and calls done once it is closed.
+type recordingBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	truncated bool
+	once      sync.Once
+	done      func(body []byte, truncated bool)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := MaxProxiedBody - b.buf.Len(); room < n {
+		b.buf.Write(p[:room])
+		b.truncated = true
+	} else {
+		b.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.buf.Bytes(), b.truncated) })
+	return err
+}
+
+// proxyHandler forwards r to the upstream of its route and records the
+// request with the response.
+func (s *server) proxyHandler(w http.ResponseWriter, r *http.Request, body []byte) {
+	capture := s.newCapture(r, body)
+	route, ok := s.router.Route(r.URL.Path)
+	if !ok {
+		capture.Proxy = &ProxiedResponse{Status: http.StatusBadGateway, Error: "no route"}
+		s.recordProxied(&capture)
+		http.Error(w, "No route for "+r.URL.Path, http.StatusBadGateway)
+		return
+	}
+	start := time.Now()
+	proxied := &ProxiedResponse{Upstream: route.Upstream.String()}
+	capture.Proxy = proxied
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(route.Upstream)
+			pr.SetXForwarded()
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			proxied.Status, proxied.Headers = resp.StatusCode, resp.Header.Clone()
+			resp.Body = &recordingBody{ReadCloser: resp.Body, done: func(body []byte, truncated bool) {
+				proxied.Body, proxied.BodyEncoding = encodeBody(body)
+				proxied.Truncated = truncated
+				if s.render && !truncated && resp.Header.Get("Content-Encoding") == "" {
+					proxied.Rendered = RenderBody(resp.Header.Get("Content-Type"), body)
+				}
+				proxied.Duration = float64(time.Since(start)) / float64(time.Millisecond)
+				s.recordProxied(&capture)
+			}}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if proxied.Status != 0 {
+				// the response failed after its headers were forwarded and
+				// its body closed, it is already recorded
+				return
+			}
+			proxied.Status, proxied.Error = http.StatusBadGateway, err.Error()
+			proxied.Duration = float64(time.Since(start)) / float64(time.Millisecond)
+			s.recordProxied(&capture)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+func (s *server) recordProxied(capture *Capture) {
+	p := capture.Proxy
+	outcome := fmt.Sprint(p.Status)
+	if p.Error != "" {
+		outcome += " " + p.Error
+	}
+	fmt.Printf("%s %s -> %s %s (%.0fms)\n", capture.Method, capture.URL, p.Upstream, outcome, p.Duration)
+	s.record(capture)
+}
@@ -0,0 +1,241 @@
+package inspectionserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+
+	"github.com/jonfk/utility-belt/prettify-json/prettifyjson"
+)
+
+const (
+	// ThumbnailSize bounds the width and height of the thumbnails of images
+	ThumbnailSize = 128
+	// maxImagePixels keeps images which would take too much memory to decode
+	// from being thumbnailed
+	maxImagePixels = 50 * 1000 * 1000
+	// maxRenderedParts bounds the parts of a multipart body which are rendered
+	maxRenderedParts = 100
+)
+
+// RenderedBody is a body made readable according to its Content-Type, kept
+// next to the raw one in the captures.
+type RenderedBody struct {
+	// Kind is json, form, xml or multipart
+	Kind string `json:"kind"`
+	// Text is the indented json or xml
+	Text string `json:"text,omitempty"`
+	// Form holds the decoded fields of a form
+	Form map[string][]string `json:"form,omitempty"`
+	// Parts are the parts of a multipart body
+	Parts []RenderedPart `json:"parts,omitempty"`
+}
+
+type RenderedPart struct {
+	Name        string `json:"name,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int    `json:"size"`
+	// Value is the value of the text fields
+	Value    string        `json:"value,omitempty"`
+	Rendered *RenderedBody `json:"rendered,omitempty"`
+	// Thumbnail is a data URL of a png thumbnail of the images
+	Thumbnail string `json:"thumbnail,omitempty"`
+}
+
+// RenderBody renders body according to contentType, the full header with
+// its parameters. It returns nil when there is nothing to render, for other
+// types and invalid bodies. A body without Content-Type is rendered when it
+// is json.
+func RenderBody(contentType string, body []byte) *RenderedBody {
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil && contentType != "" {
+		return nil
+	}
+	switch {
+	case mediaType == "" && json.Valid(body),
+		mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		indented, err := prettifyjson.Indent(body)
+		if err != nil {
+			return nil
+		}
+		return &RenderedBody{Kind: "json", Text: string(indented)}
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil || len(form) == 0 {
+			return nil
+		}
+		return &RenderedBody{Kind: "form", Form: form}
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		indented, err := indentXML(body)
+		if err != nil {
+			return nil
+		}
+		return &RenderedBody{Kind: "xml", Text: string(indented)}
+	case strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "":
+		return renderMultipart(body, params["boundary"])
+	}
+	return nil
+}
+
+// indentXML indents the elements of body by two spaces. The tokens are kept
+// raw so the namespace prefixes are written as they were received.
+func indentXML(body []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	var out bytes.Buffer
+	encoder := xml.NewEncoder(&out)
+	encoder.Indent("", "  ")
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			// the indentation replaces the whitespace between elements
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+		case xml.StartElement:
+			t.Name = rawName(t.Name)
+			attrs := make([]xml.Attr, len(t.Attr))
+			for i, attr := range t.Attr {
+				attrs[i] = xml.Attr{Name: rawName(attr.Name), Value: attr.Value}
+			}
+			t.Attr = attrs
+			token = t
+		case xml.EndElement:
+			t.Name = rawName(t.Name)
+			token = t
+		}
+		if err := encoder.EncodeToken(token); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// rawName folds the prefix of a raw token into its local name, the encoder
+// would otherwise take the prefix for a namespace URL.
+func rawName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+	return xml.Name{Local: name.Space + ":" + name.Local}
+}
+
+func renderMultipart(body []byte, boundary string) *RenderedBody {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	rendered := &RenderedBody{Kind: "multipart"}
+	for len(rendered.Parts) < maxRenderedParts {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			// a truncated body keeps the parts read so far
+			break
+		}
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			break
+		}
+		contentType := part.Header.Get("Content-Type")
+		rp := RenderedPart{
+			Name:        part.FormName(),
+			Filename:    part.FileName(),
+			ContentType: contentType,
+			Size:        len(data),
+		}
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		switch {
+		case strings.HasPrefix(mediaType, "image/"):
+			rp.Thumbnail = thumbnail(data)
+		case rp.Filename == "" && (mediaType == "" || strings.HasPrefix(mediaType, "text/plain")):
+			rp.Value = string(data)
+		default:
+			rp.Rendered = RenderBody(contentType, data)
+		}
+		rendered.Parts = append(rendered.Parts, rp)
+	}
+	if len(rendered.Parts) == 0 {
+		return nil
+	}
+	return rendered
+}
+
+// thumbnail returns a png thumbnail of the png, jpeg or gif image data as
+// a data URL, empty for the other formats and the images too large to decode.
+func thumbnail(data []byte) string {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return ""
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	var out bytes.Buffer
+	if err := png.Encode(&out, scaleDown(img, ThumbnailSize)); err != nil {
+		return ""
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(out.Bytes())
+}
+
+// scaleDown fits img in a size by size square, averaging the pixels each
+// pixel of the thumbnail covers. Smaller images are returned as they are.
+func scaleDown(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	tw, th := size, h*size/w
+	if h > w {
+		tw, th = w*size/h, size
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+	thumb := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := bounds.Min.Y+y*h/th, bounds.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := bounds.Min.X+x*w/tw, bounds.Min.X+(x+1)*w/tw
+			// sample at most 4x4 pixels of large areas
+			stepX, stepY := (x1-x0+3)/4, (y1-y0+3)/4
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy += stepY {
+				for sx := x0; sx < x1; sx += stepX {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			thumb.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return thumb
+}
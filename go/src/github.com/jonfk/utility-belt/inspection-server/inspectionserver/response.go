@@ -0,0 +1,128 @@
+package inspectionserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// RequestData is the inbound request as seen by the response templates, e.g.
+// {"order_id": {{.JSON.order_id}}, "trace": "{{.Header "X-Trace-Id"}}"}
+type RequestData struct {
+	Method  string
+	Path    string
+	URL     string
+	Query   url.Values
+	Headers http.Header
+	Body    string
+	// JSON is the decoded body when it is json, nil otherwise
+	JSON interface{}
+}
+
+func (d RequestData) Header(name string) string { return d.Headers.Get(name) }
+func (d RequestData) Param(name string) string  { return d.Query.Get(name) }
+
+func NewRequestData(r *http.Request, body []byte) RequestData {
+	data := RequestData{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		URL:     r.RequestURI,
+		Query:   r.URL.Query(),
+		Headers: r.Header,
+		Body:    string(body),
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err == nil {
+		data.JSON = v
+	}
+	return data
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+	"now": func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"default": func(fallback, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return fallback
+		}
+		return v
+	},
+}
+
+// CannedResponse is the response served to every captured request, its body
+// and header values are templates of the RequestData.
+type CannedResponse struct {
+	Status  int
+	Headers map[string]*template.Template
+	Body    *template.Template
+}
+
+// defaultBody is served when no body template is given.
+var defaultBody = template.Must(template.New("body").Parse("ok printed"))
+
+// LoadCannedResponse parses the body template file and the headers given as
+// "Name: value".
+func LoadCannedResponse(status int, bodyFile string, headers []string) (*CannedResponse, error) {
+	response := &CannedResponse{Status: status, Headers: map[string]*template.Template{}, Body: defaultBody}
+	if bodyFile != "" {
+		body, err := ioutil.ReadFile(bodyFile)
+		if err != nil {
+			return nil, err
+		}
+		if response.Body, err = template.New(bodyFile).Funcs(templateFuncs).Parse(string(body)); err != nil {
+			return nil, err
+		}
+	}
+	for _, header := range headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid header %s, expected Name: value", header)
+		}
+		name := strings.TrimSpace(parts[0])
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		response.Headers[name] = tmpl
+	}
+	return response, nil
+}
+
+// Templated reports whether the body is a template rather than the default.
+func (response *CannedResponse) Templated() bool {
+	return response.Body != defaultBody
+}
+
+// Write renders the response for data, nothing is written when a template
+// fails so the error can be reported instead.
+func (response *CannedResponse) Write(w http.ResponseWriter, data RequestData) error {
+	headers := map[string]string{}
+	for name, tmpl := range response.Headers {
+		var value bytes.Buffer
+		if err := tmpl.Execute(&value, data); err != nil {
+			return err
+		}
+		headers[name] = value.String()
+	}
+	var body bytes.Buffer
+	if err := response.Body.Execute(&body, data); err != nil {
+		return err
+	}
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+	w.WriteHeader(response.Status)
+	_, err := w.Write(body.Bytes())
+	return err
+}
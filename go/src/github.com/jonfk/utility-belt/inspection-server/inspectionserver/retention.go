@@ -0,0 +1,123 @@
+package inspectionserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JanitorInterval is how often the retention policy is applied.
+const JanitorInterval = time.Minute
+
+// Retention limits the captures kept by a store, zero fields are unlimited.
+type Retention struct {
+	MaxCaptures int
+	MaxBytes    int64
+	MaxAge      time.Duration
+}
+
+func (r Retention) Enabled() bool {
+	return r.MaxCaptures > 0 || r.MaxBytes > 0 || r.MaxAge > 0
+}
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+}
+
+// ParseSize parses sizes such as 500MB or 1GiB into bytes.
+func ParseSize(size string) (int64, error) {
+	size = strings.TrimSpace(size)
+	i := strings.IndexFunc(size, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(size)
+	}
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(size[i:]))]
+	n, err := strconv.ParseFloat(size[:i], 64)
+	if !ok || err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid size %s, expected e.g. 500MB or 1GiB", size)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// Prune deletes the captures older than MaxAge, then the oldest ones until
+// they satisfy the other limits, and returns how many were deleted.
+func (s *Store) Prune(retention Retention, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	// the capture IDs sort by arrival, oldest first
+	sort.Strings(files)
+
+	infos := []os.FileInfo{}
+	var total int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, err
+		}
+		infos = append(infos, info)
+		total += info.Size()
+	}
+
+	deleted := 0
+	for _, info := range infos {
+		remaining := len(infos) - deleted
+		expired := retention.MaxAge > 0 && now.Sub(captureTime(info)) > retention.MaxAge
+		tooMany := retention.MaxCaptures > 0 && remaining > retention.MaxCaptures
+		tooLarge := retention.MaxBytes > 0 && total > retention.MaxBytes
+		if !expired && !tooMany && !tooLarge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.Dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return deleted, err
+		}
+		deleted++
+		total -= info.Size()
+	}
+	return deleted, nil
+}
+
+// captureTime is the time the capture of the file was received, read from
+// its ID since annotating a capture rewrites the file, or the modification
+// time of the files not named by the store.
+func captureTime(info os.FileInfo) time.Time {
+	name := strings.TrimSuffix(info.Name(), ".json")
+	if len(name) >= len(idLayout) {
+		if t, err := time.Parse(idLayout, name[:len(idLayout)]); err == nil {
+			return t
+		}
+	}
+	return info.ModTime()
+}
+
+// Janitor prunes the store every JanitorInterval, it never returns.
+func (s *Store) Janitor(retention Retention) {
+	for {
+		deleted, err := s.Prune(retention, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not prune captures: %v\n", err)
+		} else if deleted > 0 {
+			fmt.Printf("pruned %d captures\n", deleted)
+		}
+		time.Sleep(JanitorInterval)
+	}
+}
@@ -0,0 +1,84 @@
+package inspectionserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StreamKeepAlive is the interval of the comments keeping idle streams open
+// through proxies.
+const StreamKeepAlive = 15 * time.Second
+
+// hub fans the new captures out to the streams, a stream which can't keep up
+// misses captures rather than slowing the server down.
+type hub struct {
+	mu          sync.Mutex
+	subscribers map[chan Capture]bool
+}
+
+func (h *hub) subscribe() chan Capture {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers == nil {
+		h.subscribers = map[chan Capture]bool{}
+	}
+	ch := make(chan Capture, 64)
+	h.subscribers[ch] = true
+	return ch
+}
+
+func (h *hub) unsubscribe(ch chan Capture) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, ch)
+}
+
+func (h *hub) publish(capture Capture) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- capture:
+		default:
+		}
+	}
+}
+
+// streamHandler sends every new capture as a server-sent event named capture
+// with the json of the capture as data.
+func (s *server) streamHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	captures := s.hub.subscribe()
+	defer s.hub.unsubscribe(captures)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": streaming captures\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(StreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case capture := <-captures:
+			data, err := json.Marshal(capture)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Fprintf(w, "event: capture\nid: %s\ndata: %s\n\n", capture.ID, data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
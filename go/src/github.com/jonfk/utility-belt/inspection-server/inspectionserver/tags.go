@@ -0,0 +1,164 @@
+package inspectionserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Annotation changes the tags and the note of a capture.
+type Annotation struct {
+	// Tags replaces the tags when set
+	Tags *[]string `json:"tags,omitempty"`
+	// Add and Remove change the tags
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+	// Note replaces the note when set, empty to clear it
+	Note *string `json:"note,omitempty"`
+}
+
+// Annotate applies a to capture. The tags are trimmed and kept once, in the
+// order they were added.
+func (capture *Capture) Annotate(a Annotation) {
+	tags := capture.Tags
+	if a.Tags != nil {
+		tags = *a.Tags
+	}
+	removed := map[string]bool{}
+	for _, tag := range a.Remove {
+		removed[strings.TrimSpace(tag)] = true
+	}
+	seen := map[string]bool{}
+	capture.Tags = nil
+	for _, tag := range append(append([]string{}, tags...), a.Add...) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] && !removed[tag] {
+			seen[tag] = true
+			capture.Tags = append(capture.Tags, tag)
+		}
+	}
+	if a.Note != nil {
+		capture.Note = strings.TrimSpace(*a.Note)
+	}
+}
+
+// HasTag reports whether capture has any of tags.
+func (capture Capture) HasTag(tags []string) bool {
+	for _, tag := range tags {
+		for _, t := range capture.Tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterByTag returns the captures with any of tags, every capture when
+// tags is empty.
+func FilterByTag(captures []Capture, tags []string) []Capture {
+	if len(tags) == 0 {
+		return captures
+	}
+	filtered := []Capture{}
+	for _, capture := range captures {
+		if capture.HasTag(tags) {
+			filtered = append(filtered, capture)
+		}
+	}
+	return filtered
+}
+
+// splitTags splits the comma separated tags of the ?tag= parameters and the
+// forms.
+func splitTags(values []string) []string {
+	tags := []string{}
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// tagsHandler serves api/requests/ID/tags: GET returns the tags and the note
+// of the capture, POST annotates it with an Annotation in json, or with the
+// tags and note fields of a form from the index which replace them before
+// redirecting back.
+func (s *server) tagsHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, InspectPrefix+"api/requests/")
+	if !strings.HasSuffix(path, "/tags") || strings.Count(path, "/") != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	id := strings.TrimSuffix(path, "/tags")
+
+	var annotation Annotation
+	form := false
+	switch r.Method {
+	case "GET":
+	case "POST":
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			tags := splitTags(r.PostForm["tags"])
+			note := r.PostForm.Get("note")
+			annotation, form = Annotation{Tags: &tags, Note: &note}, true
+		} else if err := json.NewDecoder(r.Body).Decode(&annotation); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid annotation: %v", err), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.annotating.Lock()
+	defer s.annotating.Unlock()
+	captures, err := s.store.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var capture *Capture
+	for i := range captures {
+		if captures[i].ID == id {
+			capture = &captures[i]
+		}
+	}
+	if capture == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == "POST" {
+		capture.Annotate(annotation)
+		if err := s.store.Update(*capture); err == ErrNoCapture {
+			http.NotFound(w, r)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if form {
+		location := InspectPrefix
+		if token := r.URL.Query().Get("token"); token != "" {
+			location += "?token=" + url.QueryEscape(token)
+		}
+		http.Redirect(w, r, location, http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		ID   string   `json:"id"`
+		Tags []string `json:"tags"`
+		Note string   `json:"note"`
+	}{capture.ID, append([]string{}, capture.Tags...), capture.Note})
+}
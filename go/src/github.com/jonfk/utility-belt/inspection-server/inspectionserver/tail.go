@@ -0,0 +1,282 @@
+package inspectionserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/urfave/cli"
+)
+
+// TailRetry is the delay before reconnecting to a server which went away.
+const TailRetry = 2 * time.Second
+
+const (
+	colorReset = "\033[0m"
+	colorDim   = "\033[2m"
+	colorBold  = "\033[1m"
+	colorRed   = "\033[31m"
+)
+
+var methodColors = map[string]string{
+	"GET":    "\033[32m",
+	"POST":   "\033[33m",
+	"PUT":    "\033[34m",
+	"PATCH":  "\033[36m",
+	"DELETE": colorRed,
+}
+
+// TailFilter selects the captures printed by tail, the zero value prints all.
+type TailFilter struct {
+	Method string
+	// Path is a path.Match pattern
+	Path string
+	// Grep matches the body
+	Grep *regexp.Regexp
+}
+
+func (f TailFilter) Matches(capture Capture) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, capture.Method) {
+		return false
+	}
+	if f.Path != "" {
+		if ok, _ := path.Match(f.Path, capture.Path); !ok {
+			return false
+		}
+	}
+	return f.Grep == nil || f.Grep.Match(capture.RawBody())
+}
+
+// TailFormat controls how tail prints a capture.
+type TailFormat struct {
+	Color   bool
+	Headers bool
+	Body    bool
+}
+
+func (f TailFormat) paint(color, s string) string {
+	if !f.Color || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// Format renders capture as its request line followed by the headers and the
+// body, json and xml bodies are indented.
+func (f TailFormat) Format(capture Capture) string {
+	var b strings.Builder
+	body := capture.RawBody()
+	fmt.Fprintf(&b, "%s %s %s %s\n",
+		f.paint(colorDim, capture.Time.Local().Format("15:04:05")),
+		f.paint(colorBold+methodColors[capture.Method], capture.Method),
+		capture.URL,
+		f.paint(colorDim, fmt.Sprintf("from %s, %d bytes", capture.RemoteAddr, len(body))))
+	if capture.TLS != nil && len(capture.TLS.ClientChain) > 0 {
+		status := f.paint(colorDim, "verified")
+		if !capture.TLS.Verified {
+			status = f.paint(colorRed, capture.TLS.VerifyError)
+		}
+		fmt.Fprintf(&b, "  %s %s, %s\n", f.paint(colorDim, "Client certificate:"), capture.TLS.ClientChain[0].Subject, status)
+	}
+	if p := capture.Proxy; p != nil {
+		status := f.paint(colorBold, fmt.Sprint(p.Status))
+		if p.Error != "" {
+			status = f.paint(colorRed, fmt.Sprintf("%d %s", p.Status, p.Error))
+		}
+		fmt.Fprintf(&b, "  %s %s %s %s\n", f.paint(colorDim, "->"), p.Upstream, status, f.paint(colorDim, fmt.Sprintf("%.0fms", p.Duration)))
+	}
+	if f.Headers {
+		names := []string{}
+		for name := range capture.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, value := range capture.Headers[name] {
+				fmt.Fprintf(&b, "  %s %s\n", f.paint(colorDim, name+":"), value)
+			}
+		}
+	}
+	if f.Body && len(body) > 0 {
+		var indented bytes.Buffer
+		if r := capture.Rendered; r != nil && r.Text != "" {
+			body = []byte(strings.Replace(r.Text, "\n", "\n  ", -1))
+		} else if capture.BodyEncoding == "" && json.Indent(&indented, body, "  ", "  ") == nil {
+			body = indented.Bytes()
+		} else if capture.BodyEncoding != "" {
+			body = []byte(fmt.Sprintf("(%d bytes of binary)", len(body)))
+		}
+		fmt.Fprintf(&b, "  %s\n", body)
+	}
+	return b.String()
+}
+
+// Tail prints the captures streamed in response to req until the stream
+// ends, which is reported as io.ErrUnexpectedEOF.
+func Tail(req *http.Request, filter TailFilter, format TailFormat, out io.Writer) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s %s", req.URL, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	event, data := "", []string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event == "capture" && len(data) > 0 {
+				var capture Capture
+				if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &capture); err != nil {
+					return fmt.Errorf("Invalid capture: %v", err)
+				}
+				if filter.Matches(capture) {
+					fmt.Fprint(out, format.Format(capture))
+				}
+			}
+			event, data = "", data[:0]
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// isConnectionError reports whether err means the server went away rather
+// than refused the stream.
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return err == io.ErrUnexpectedEOF || errors.As(err, &netErr)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func tailCommand() cli.Command {
+	return cli.Command{
+		Name:  "tail",
+		Usage: "Print the requests received by a running inspection server as they arrive",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "server,s",
+				Usage: "`URL` of the inspection server",
+				Value: "http://localhost:8080",
+			},
+			cli.StringFlag{
+				Name:  "ui-auth",
+				Usage: "Basic auth of the server as `USER:PASS`",
+			},
+			cli.StringFlag{
+				Name:  "ui-token",
+				Usage: "Bearer `TOKEN` of the server",
+			},
+			cli.StringFlag{
+				Name:  "method,X",
+				Usage: "Only print the requests with `METHOD`",
+			},
+			cli.StringFlag{
+				Name:  "path,p",
+				Usage: "Only print the requests whose path matches `PATTERN`, e.g. /webhooks/*",
+			},
+			cli.StringFlag{
+				Name:  "grep,g",
+				Usage: "Only print the requests whose body matches `REGEXP`",
+			},
+			cli.BoolFlag{
+				Name:  "headers",
+				Usage: "Print the headers",
+			},
+			cli.BoolFlag{
+				Name:  "no-body",
+				Usage: "Don't print the bodies",
+			},
+			cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Disable the colors, also disabled when NO_COLOR is set or the output isn't a terminal",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			filter := TailFilter{Method: c.String("method"), Path: c.String("path")}
+			if filter.Path != "" {
+				if _, err := path.Match(filter.Path, "/"); err != nil {
+					return fmt.Errorf("Invalid --path %q: %v", filter.Path, err)
+				}
+			}
+			if c.String("grep") != "" {
+				var err error
+				if filter.Grep, err = regexp.Compile(c.String("grep")); err != nil {
+					return err
+				}
+			}
+			format := TailFormat{
+				Color:   !c.Bool("no-color") && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout),
+				Headers: c.Bool("headers"),
+				Body:    !c.Bool("no-body"),
+			}
+
+			cfg, err := config.Load("inspect")
+			if err != nil {
+				return err
+			}
+			userPass, err := cfg.Secret(c, "ui-auth")
+			if err != nil {
+				return err
+			}
+			token, err := cfg.Secret(c, "ui-token")
+			if err != nil {
+				return err
+			}
+			auth, err := ParseUIAuth(userPass, token)
+			if err != nil {
+				return err
+			}
+
+			for {
+				req, err := http.NewRequest("GET", strings.TrimSuffix(c.String("server"), "/")+InspectPrefix+"api/stream", nil)
+				if err != nil {
+					return err
+				}
+				req.Header.Set("Accept", "text/event-stream")
+				if auth.Token != "" {
+					req.Header.Set("Authorization", "Bearer "+auth.Token)
+				} else if auth.User != "" {
+					req.SetBasicAuth(auth.User, auth.Password)
+				}
+
+				err = Tail(req, filter, format, os.Stdout)
+				if !isConnectionError(err) {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Disconnected from %s, retrying in %s\n", c.String("server"), TailRetry)
+				time.Sleep(TailRetry)
+			}
+		},
+	}
+}
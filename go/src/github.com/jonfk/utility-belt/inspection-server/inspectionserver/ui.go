@@ -0,0 +1,173 @@
+package inspectionserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// UIAuth protects the inspection endpoints, which expose the captured
+// payloads and often the secrets they contain. The zero value allows
+// everyone.
+type UIAuth struct {
+	// User and Password of basic auth, from --ui-auth user:pass
+	User, Password string
+	// Token accepted as a bearer token or the token query parameter
+	Token string
+}
+
+func ParseUIAuth(userPass, token string) (UIAuth, error) {
+	auth := UIAuth{Token: token}
+	if userPass != "" {
+		parts := strings.SplitN(userPass, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return auth, fmt.Errorf("Invalid --ui-auth, expected user:pass")
+		}
+		auth.User, auth.Password = parts[0], parts[1]
+	}
+	return auth, nil
+}
+
+func (auth UIAuth) Enabled() bool {
+	return auth.User != "" || auth.Token != ""
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func (auth UIAuth) allowed(r *http.Request) bool {
+	if !auth.Enabled() {
+		return true
+	}
+	if auth.Token != "" {
+		if bearer := r.Header.Get("Authorization"); strings.HasPrefix(bearer, "Bearer ") && equal(strings.TrimPrefix(bearer, "Bearer "), auth.Token) {
+			return true
+		}
+		if token := r.URL.Query().Get("token"); token != "" && equal(token, auth.Token) {
+			return true
+		}
+	}
+	if auth.User != "" {
+		user, password, ok := r.BasicAuth()
+		// evaluate both to not leak which one is wrong through timing
+		userOk, passwordOk := equal(user, auth.User), equal(password, auth.Password)
+		if ok && userOk && passwordOk {
+			return true
+		}
+	}
+	return false
+}
+
+// Wrap rejects the requests to next which aren't authenticated.
+func (auth UIAuth) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.allowed(r) {
+			if auth.User != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="inspection-server"`)
+			}
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><title>inspection-server</title></head>
+<body>
+<h1>{{len .Captures}} captured requests{{if .Tags}} tagged {{range $i, $t := .Tags}}{{if $i}}, {{end}}{{$t}}{{end}}{{end}}</h1>
+<p><a href="openapi{{.Query}}">OpenAPI draft</a> - <a href="api/drift{{.Query}}">{{len .Drift}} with schema drift</a>{{if .Tags}} - <a href=".{{.Query}}">all requests</a>{{end}}</p>
+<table>
+<tr><th>Time</th><th>Remote</th><th>Method</th><th>URL</th><th>Schema drift</th><th>Tags</th><th>Note</th></tr>
+{{range .Captures}}<tr><td><a href="captures/{{.ID}}{{$.Query}}">{{.Time.Format "2006-01-02 15:04:05"}}</a></td><td>{{.RemoteAddr}}</td><td>{{.Method}}</td><td>{{.URL}}</td><td>{{index $.Drift .ID}}</td>
+<td>{{range .Tags}}<a href="{{call $.TagLink .}}">{{.}}</a> {{end}}</td>
+<td><form method="post" action="api/requests/{{.ID}}/tags{{$.Query}}"><input name="tags" placeholder="tags, comma separated" value="{{range $i, $t := .Tags}}{{if $i}}, {{end}}{{$t}}{{end}}"> <input name="note" placeholder="note" value="{{.Note}}"> <button>Save</button></form></td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// indexHandler lists the captures, newest first, only those with a tag of
+// the ?tag= parameters when given.
+func (s *server) indexHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != InspectPrefix {
+		http.NotFound(w, r)
+		return
+	}
+	captures, err := s.store.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tags := splitTags(r.URL.Query()["tag"])
+	captures = FilterByTag(captures, tags)
+	for i, j := 0, len(captures)-1; i < j; i, j = i+1, j-1 {
+		captures[i], captures[j] = captures[j], captures[i]
+	}
+	// keep the token of the query on the links so a browser stays authenticated
+	query := ""
+	token := r.URL.Query().Get("token")
+	if token != "" {
+		query = "?token=" + url.QueryEscape(token)
+	}
+	tagLink := func(tag string) template.URL {
+		link := url.Values{"tag": {tag}}
+		if token != "" {
+			link.Set("token", token)
+		}
+		return template.URL("?" + link.Encode())
+	}
+	drift := map[string]string{}
+	for _, report := range s.drift.Reports() {
+		drifts := []string{}
+		for _, d := range report.Drifts {
+			drifts = append(drifts, d.String())
+		}
+		drift[report.ID] = strings.Join(drifts, ", ")
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = indexTemplate.Execute(w, struct {
+		Captures []Capture
+		Query    template.URL
+		Drift    map[string]string
+		Tags     []string
+		TagLink  func(string) template.URL
+	}{captures, template.URL(query), drift, tags, tagLink})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// capturesHandler returns every capture as a json array, those with a tag of
+// the ?tag= parameters when given, or a single one under captures/ID.
+func (s *server) capturesHandler(w http.ResponseWriter, r *http.Request) {
+	captures, err := s.store.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var out interface{} = FilterByTag(captures, splitTags(r.URL.Query()["tag"]))
+	if id := strings.TrimPrefix(r.URL.Path, InspectPrefix+"captures/"); id != r.URL.Path && id != "" {
+		out = nil
+		for _, capture := range captures {
+			if capture.ID == filepath.Base(id) {
+				out = capture
+			}
+		}
+		if out == nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(out)
+}
@@ -0,0 +1,67 @@
+// Package belt holds what the utility-belt tools share whether they are built
+// standalone or as subcommands of ub.
+package belt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Version, Commit and Date are set at build time with
+// -ldflags "-X github.com/jonfk/utility-belt/internal/belt.Version=..."
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// BuildInfo is the version followed by the commit and date of the build when
+// they are known, e.g. v1.2.0 (commit 1c3603f, built 2026-10-16T09:00:00Z).
+func BuildInfo() string {
+	info := []string{}
+	if Commit != "" {
+		info = append(info, "commit "+Commit)
+	}
+	if Date != "" {
+		info = append(info, "built "+Date)
+	}
+	if len(info) == 0 {
+		return Version
+	}
+	return fmt.Sprintf("%s (%s)", Version, strings.Join(info, ", "))
+}
+
+func init() {
+	// -v is kept for --verbose across the tools
+	cli.VersionFlag = cli.BoolFlag{Name: "version, V", Usage: "print the version"}
+}
+
+// NewApp builds a standalone binary out of the command of a tool.
+func NewApp(name string, cmd cli.Command) *cli.App {
+	app := cli.NewApp()
+	app.Name = name
+	app.Usage = cmd.Usage
+	app.ArgsUsage = cmd.ArgsUsage
+	app.Version = BuildInfo()
+	app.Flags = cmd.Flags
+	app.Before = cmd.Before
+	app.Action = cmd.Action
+	if cmd.BashComplete != nil {
+		app.BashComplete = cmd.BashComplete
+	}
+	app.EnableBashCompletion = true
+	app.Commands = append(append([]cli.Command{}, cmd.Subcommands...), CompletionCommand(name), SelfUpdateCommand(name))
+	return app
+}
+
+// Run runs app with the process arguments, printing the error and exiting
+// with a non-zero status on failure.
+func Run(app *cli.App) {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
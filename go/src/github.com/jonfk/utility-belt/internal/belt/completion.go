@@ -0,0 +1,90 @@
+package belt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// The scripts ask the binary for candidates with urfave/cli's hidden
+// --generate-bash-completion flag, falling back on file names when it has none.
+const (
+	bashCompletion = `_%[1]s_complete() {
+  local cur opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  opts=$( "${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null )
+  COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )
+  return 0
+}
+complete -o bashdefault -o default -F _%[1]s_complete %[2]s
+`
+	zshCompletion = `autoload -U +X compinit && compinit
+autoload -U +X bashcompinit && bashcompinit
+` + bashCompletion
+	fishCompletion = `function __%[1]s_complete
+    set -l args (commandline -opc)
+    $args --generate-bash-completion 2>/dev/null
+end
+complete -c %[2]s -a '(__%[1]s_complete)'
+`
+)
+
+// CompletionCommand prints the shell completion script of the binary name.
+func CompletionCommand(name string) cli.Command {
+	return cli.Command{
+		Name:      "completion",
+		Usage:     "Print the completion script for bash, zsh or fish",
+		ArgsUsage: "bash|zsh|fish",
+		Description: fmt.Sprintf(`Load the completions in the current shell with
+   source <(%[1]s completion bash)
+   %[1]s completion fish | source`, name),
+		BashComplete: func(c *cli.Context) {
+			fmt.Println("bash\nzsh\nfish")
+		},
+		Action: func(c *cli.Context) error {
+			script, err := CompletionScript(c.Args().First(), name)
+			if err != nil {
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		},
+	}
+}
+
+// CompletionScript returns the completion script of the binary name for shell.
+func CompletionScript(shell, name string) (string, error) {
+	fn := strings.Replace(name, "-", "_", -1)
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(bashCompletion, fn, name), nil
+	case "zsh":
+		return fmt.Sprintf(zshCompletion, fn, name), nil
+	case "fish":
+		return fmt.Sprintf(fishCompletion, fn, name), nil
+	default:
+		return "", fmt.Errorf("Unknown shell %q, expected bash, zsh or fish", shell)
+	}
+}
+
+// completionFlag is the flag the scripts end the command line with.
+const completionFlag = "--generate-bash-completion"
+
+// Completing reports whether the binary runs to print completion candidates,
+// so the Before of a command can skip what only the Action needs.
+func Completing() bool {
+	return len(os.Args) > 1 && os.Args[len(os.Args)-1] == completionFlag
+}
+
+// CompletingFlag reports whether the candidates asked for are the value of
+// the flag name, the word before --generate-bash-completion.
+func CompletingFlag(name string) bool {
+	if !Completing() || len(os.Args) < 3 {
+		return false
+	}
+	previous := os.Args[len(os.Args)-2]
+	return previous == "--"+name || previous == "-"+name
+}
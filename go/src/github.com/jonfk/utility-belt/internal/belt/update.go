@@ -0,0 +1,290 @@
+package belt
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/httpx"
+	"github.com/urfave/cli"
+)
+
+// ReleasesURL is the GitHub API of the releases of this repo. A release holds
+// a binary per tool and platform named NAME_GOOS_GOARCH, with .exe on windows,
+// and a checksums.txt of their sha256 in the sha256sum format.
+var ReleasesURL = "https://api.github.com/repos/jonfk/utility-belt/releases"
+
+const checksumsAsset = "checksums.txt"
+
+// Release is the subset of a GitHub release used by self-update.
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Asset returns the asset of the release called name.
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// AssetName is the name of the release binary of the tool name for the
+// running platform.
+func AssetName(name string) string {
+	asset := fmt.Sprintf("%s_%s_%s", name, runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		asset += ".exe"
+	}
+	return asset
+}
+
+// SelfUpdateCommand replaces the binary name with the one of the latest
+// release, or of the release given with --tag.
+func SelfUpdateCommand(name string) cli.Command {
+	return cli.Command{
+		Name:  "self-update",
+		Usage: "Update " + name + " to the latest GitHub release",
+		Description: `Downloads the binary of the release for this platform, verifies its sha256
+   against the checksums of the release then replaces the running executable.`,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "check",
+				Usage: "Only report whether an update is available",
+			},
+			cli.StringFlag{
+				Name:  "tag",
+				Usage: "Install the release `TAG` instead of the latest, e.g. to downgrade",
+			},
+			cli.BoolFlag{
+				Name:  "force, f",
+				Usage: "Install even when the version is current or a development build",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			client, err := httpx.NewClient(httpx.Options{
+				Timeout: 10 * time.Minute,
+				Retries: httpx.DefaultRetries,
+				Backoff: httpx.DefaultBackoff,
+			})
+			if err != nil {
+				return err
+			}
+			release, err := FetchRelease(client, c.String("tag"))
+			if err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+
+			current := !newerVersion(release.TagName, Version)
+			if c.Bool("check") {
+				switch {
+				case Version == "dev":
+					fmt.Printf("%s is a development build, the latest release is %s\n", name, release.TagName)
+				case current:
+					fmt.Printf("%s %s is up to date\n", name, Version)
+				default:
+					fmt.Printf("%s %s is available, this is %s\n", name, release.TagName, Version)
+				}
+				return nil
+			}
+			if !c.Bool("force") {
+				if Version == "dev" {
+					return cli.NewExitError(name+" is a development build, use --force to replace it with "+release.TagName, 1)
+				}
+				if current && c.String("tag") == "" {
+					fmt.Printf("%s %s is up to date\n", name, Version)
+					return nil
+				}
+			}
+
+			exe, err := os.Executable()
+			if err == nil {
+				exe, err = filepath.EvalSymlinks(exe)
+			}
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Could not find the executable: %v", err), 1)
+			}
+			if err := Update(client, release, AssetName(name), exe); err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+			fmt.Printf("Updated %s from %s to %s\n", exe, Version, release.TagName)
+			return nil
+		},
+	}
+}
+
+// FetchRelease returns the release tagged tag, the latest one when empty.
+func FetchRelease(client *http.Client, tag string) (*Release, error) {
+	url := ReleasesURL + "/latest"
+	if tag != "" {
+		url = ReleasesURL + "/tags/" + tag
+	}
+	resp, err := get(client, url, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("Could not read the release: %v", err)
+	}
+	return &release, nil
+}
+
+// Update downloads the asset of release into a temporary file next to exe,
+// verifies its checksum then renames it over exe.
+func Update(client *http.Client, release *Release, asset string, exe string) error {
+	binary, ok := release.Asset(asset)
+	if !ok {
+		return fmt.Errorf("Release %s has no binary %s for this platform", release.TagName, asset)
+	}
+	sums, ok := release.Asset(checksumsAsset)
+	if !ok {
+		return fmt.Errorf("Release %s has no %s, refusing to install an unverified binary", release.TagName, checksumsAsset)
+	}
+	expected, err := fetchChecksum(client, sums.URL, asset)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(exe), "."+filepath.Base(exe)+".")
+	if err != nil {
+		return fmt.Errorf("Could not write next to %s: %v", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	resp, err := get(client, binary.URL, "application/octet-stream")
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	defer resp.Body.Close()
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Could not download %s: %v", asset, err)
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+		return fmt.Errorf("Checksum mismatch for %s: expected %s, got %s", asset, expected, actual)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return replace(tmp.Name(), exe)
+}
+
+// replace renames src over dst. Windows doesn't allow replacing a running
+// executable but allows renaming it, so the old one is moved aside first.
+func replace(src, dst string) error {
+	if runtime.GOOS != "windows" {
+		return os.Rename(src, dst)
+	}
+	old := dst + ".old"
+	os.Remove(old)
+	if err := os.Rename(dst, old); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		os.Rename(old, dst)
+		return err
+	}
+	return nil
+}
+
+// fetchChecksum returns the sha256 of asset from a sha256sum file.
+func fetchChecksum(client *http.Client, url, asset string) (string, error) {
+	resp, err := get(client, url, "application/octet-stream")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// binary mode entries are prefixed with *
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("Could not read the checksums: %v", err)
+	}
+	return "", fmt.Errorf("No checksum for %s", asset)
+}
+
+func get(client *http.Client, url, accept string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// newerVersion reports whether the release tag is newer than version,
+// comparing the numbers of vMAJOR.MINOR.PATCH. A version which isn't a
+// release, e.g. from git describe, is older than any other tag.
+func newerVersion(tag, version string) bool {
+	t, okT := parseVersion(tag)
+	v, okV := parseVersion(version)
+	if !okT || !okV {
+		return tag != version
+	}
+	for i := range t {
+		if t[i] != v[i] {
+			return t[i] > v[i]
+		}
+	}
+	return false
+}
+
+func parseVersion(version string) ([3]int, bool) {
+	var numbers [3]int
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) != 3 {
+		return numbers, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return numbers, false
+		}
+		numbers[i] = n
+	}
+	return numbers, true
+}
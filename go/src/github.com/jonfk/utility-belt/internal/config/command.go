@@ -0,0 +1,68 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Command manages the config file and the secrets in the keychain, run as ub config.
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "config",
+		Usage: "Manage the shared configuration and secrets of the tools",
+		Subcommands: []cli.Command{
+			{
+				Name:  "path",
+				Usage: "Print the location of the config file",
+				Action: func(c *cli.Context) error {
+					fmt.Println(Path())
+					return nil
+				},
+			},
+			{
+				Name:      "set-secret",
+				Usage:     "Store a secret in the OS keychain, the value is read from stdin when omitted",
+				ArgsUsage: "section.key [value]",
+				Action: func(c *cli.Context) error {
+					section, key, err := splitKey(c.Args().First())
+					if err != nil {
+						return err
+					}
+					value := c.Args().Get(1)
+					if value == "" {
+						line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+						if err != nil && line == "" {
+							return fmt.Errorf("No value given as argument or on stdin")
+						}
+						value = strings.TrimRight(line, "\r\n")
+					}
+					return SetSecret(section, key, value)
+				},
+			},
+			{
+				Name:      "delete-secret",
+				Usage:     "Remove a secret from the OS keychain",
+				ArgsUsage: "section.key",
+				Action: func(c *cli.Context) error {
+					section, key, err := splitKey(c.Args().First())
+					if err != nil {
+						return err
+					}
+					return DeleteSecret(section, key)
+				},
+			},
+		},
+	}
+}
+
+func splitKey(name string) (string, string, error) {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("Expected a section.key, e.g. github.token, got %q", name)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,133 @@
+// Package config resolves the settings of the utility-belt tools. A setting is
+// looked up in the command line flags, then the environment, then the OS
+// keychain for secrets, then the config file and finally falls back on the
+// flag default.
+//
+// The config file is YAML with a section per tool:
+//
+//	github:
+//	  username: jonfk
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const AppDir = "utility-belt"
+
+// Config holds the settings of one section of the config file.
+type Config struct {
+	Section string
+	values  map[string]string
+}
+
+// Path returns the location of the config file following the XDG base directory spec.
+func Path() string {
+	return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), AppDir, "config.yaml")
+}
+
+// CacheDir returns the XDG cache directory of section, creating it if needed.
+func CacheDir(section string) (string, error) {
+	dir := filepath.Join(xdgDir("XDG_CACHE_HOME", ".cache"), AppDir, section)
+	return dir, os.MkdirAll(dir, 0755)
+}
+
+// DataDir returns the XDG data directory of section, creating it if needed.
+// Unlike the cache it holds data which can't be fetched again.
+func DataDir(section string) (string, error) {
+	dir := filepath.Join(xdgDir("XDG_DATA_HOME", ".local/share"), AppDir, section)
+	return dir, os.MkdirAll(dir, 0755)
+}
+
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fallback
+	}
+	return filepath.Join(home, fallback)
+}
+
+// Load reads section of the config file, a missing file is an empty config.
+func Load(section string) (*Config, error) {
+	cfg := &Config{Section: section, values: map[string]string{}}
+
+	content, err := ioutil.ReadFile(Path())
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	sections := map[string]map[string]string{}
+	if err := yaml.Unmarshal(content, &sections); err != nil {
+		return nil, fmt.Errorf("%s: %v", Path(), err)
+	}
+	for key, value := range sections[section] {
+		cfg.values[key] = value
+	}
+	return cfg, nil
+}
+
+// EnvVar is the environment variable overriding key, e.g. UB_GITHUB_TOKEN.
+func (cfg *Config) EnvVar(key string) string {
+	name := "UB_" + cfg.Section + "_" + key
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// String resolves the setting key, named like the flag that overrides it.
+func (cfg *Config) String(c *cli.Context, key string) string {
+	if c.IsSet(key) {
+		return c.String(key)
+	}
+	if value := os.Getenv(cfg.EnvVar(key)); value != "" {
+		return value
+	}
+	if value, ok := cfg.values[key]; ok {
+		return value
+	}
+	return c.String(key)
+}
+
+// Prefixed returns the settings of the config file whose key starts with
+// prefix, keyed without it, e.g. the var.weather key is weather for "var.".
+func (cfg *Config) Prefixed(prefix string) map[string]string {
+	values := map[string]string{}
+	for key, value := range cfg.values {
+		if strings.HasPrefix(key, prefix) {
+			values[strings.TrimPrefix(key, prefix)] = value
+		}
+	}
+	return values
+}
+
+// Secret resolves the setting key like String, looking it up in the OS keychain
+// before the config file.
+func (cfg *Config) Secret(c *cli.Context, key string) (string, error) {
+	if c.IsSet(key) {
+		return c.String(key), nil
+	}
+	if value := os.Getenv(cfg.EnvVar(key)); value != "" {
+		return value, nil
+	}
+	value, err := GetSecret(cfg.Section, key)
+	if err != nil {
+		return "", err
+	}
+	if value != "" {
+		return value, nil
+	}
+	if value, ok := cfg.values[key]; ok {
+		return value, nil
+	}
+	return c.String(key), nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// KeychainService is the service name the secrets are stored under.
+const KeychainService = "utility-belt"
+
+// GetSecret reads section.key from the OS keychain, returning an empty string
+// when it isn't stored or no keychain is available.
+func GetSecret(section, key string) (string, error) {
+	account := section + "." + key
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", KeychainService, "-a", account, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", KeychainService, "account", account)
+	default:
+		return "", nil
+	}
+	if _, err := exec.LookPath(cmd.Args[0]); err != nil {
+		return "", nil
+	}
+
+	out, err := cmd.Output()
+	if _, ok := err.(*exec.ExitError); ok {
+		// Both tools exit with a non-zero status when the secret isn't found
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// SetSecret stores section.key in the OS keychain, replacing any previous value.
+func SetSecret(section, key, value string) error {
+	account := section + "." + key
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", KeychainService, "-a", account, "-w", value)
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label", KeychainService+" "+account, "service", KeychainService, "account", account)
+		cmd.Stdin = strings.NewReader(value)
+	default:
+		return fmt.Errorf("No keychain support on %s", runtime.GOOS)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Storing %s in the keychain: %v %s", account, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// DeleteSecret removes section.key from the OS keychain.
+func DeleteSecret(section, key string) error {
+	account := section + "." + key
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", KeychainService, "-a", account)
+	case "linux":
+		cmd = exec.Command("secret-tool", "clear", "service", KeychainService, "account", account)
+	default:
+		return fmt.Errorf("No keychain support on %s", runtime.GOOS)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("Removing %s from the keychain: %v %s", account, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package githubql
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error is an error of a GraphQL response, Type is e.g. NOT_FOUND or FORBIDDEN.
+type Error struct {
+	Type    string        `json:"type"`
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path"`
+}
+
+// Errors are the errors of a GraphQL response.
+type Errors []Error
+
+func (e Errors) Error() string {
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+	return "Github GraphQL error: " + strings.Join(messages, "; ")
+}
+
+func (e Errors) has(errorType string) bool {
+	for _, err := range e {
+		if err.Type == errorType {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPError is a response with a status outside of 2xx.
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s %s: %s %s", e.Method, e.URL, e.Status, e.Body)
+}
+
+// RateLimitError is returned once the rate limit is exhausted, Reset is zero
+// when Github didn't say when it resets.
+type RateLimitError struct {
+	Reset   time.Time
+	Message string
+}
+
+func (e *RateLimitError) Error() string {
+	message := "Github rate limit exceeded"
+	if !e.Reset.IsZero() {
+		message += " until " + e.Reset.Format(time.RFC3339)
+	}
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+func newHTTPError(req *http.Request, resp *http.Response, body []byte) error {
+	message := strings.TrimSpace(string(body))
+	limited := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-Ratelimit-Remaining") == "0")
+	if limited {
+		err := &RateLimitError{Message: message}
+		if reset, parseErr := strconv.ParseInt(resp.Header.Get("X-Ratelimit-Reset"), 10, 64); parseErr == nil {
+			err.Reset = time.Unix(reset, 0)
+		}
+		return err
+	}
+	return &HTTPError{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       message,
+	}
+}
+
+// IsNotFound reports whether err is a 404 or a NOT_FOUND GraphQL error.
+func IsNotFound(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusNotFound
+	}
+	var graphqlErrs Errors
+	return errors.As(err, &graphqlErrs) && graphqlErrs.has("NOT_FOUND")
+}
+
+// IsRateLimited reports whether err is a RateLimitError.
+func IsRateLimited(err error) bool {
+	var rateLimitErr *RateLimitError
+	return errors.As(err, &rateLimitErr)
+}
@@ -0,0 +1,125 @@
+// Package githubql is a client of the Github GraphQL and REST APIs. Queries
+// are built from the structs their responses are decoded into and take
+// their parameters as variables rather than being formatted, the errors are
+// mapped to types telling a missing resource or an exhausted rate limit
+// apart and connections are paginated by Paginate.
+package githubql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	GraphQLURL = "https://api.github.com/graphql"
+	RESTURL    = "https://api.github.com"
+)
+
+type Client struct {
+	HTTP  *http.Client
+	Token string
+	// GraphQLURL and RESTURL default to the ones of github.com
+	GraphQLURL string
+	RESTURL    string
+}
+
+func NewClient(httpClient *http.Client, token string) *Client {
+	return &Client{HTTP: httpClient, Token: token, GraphQLURL: GraphQLURL, RESTURL: RESTURL}
+}
+
+// Variables are the variables of a query, e.g. {"login": "jonfk"} for
+// $login: String!, nil pointers being null.
+type Variables map[string]interface{}
+
+// Request is the body of a GraphQL request.
+type Request struct {
+	Query     string    `json:"query"`
+	Variables Variables `json:"variables,omitempty"`
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors Errors          `json:"errors"`
+}
+
+// Query runs the query built from the struct q points to with variables and
+// decodes the data of the response into q, the GraphQL errors of the
+// response are returned as Errors.
+func (c *Client) Query(q interface{}, variables Variables) error {
+	query, err := Build(q, variables)
+	if err != nil {
+		return err
+	}
+	data, err := c.query(query, variables)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, q)
+}
+
+func (c *Client) query(query string, variables Variables) (json.RawMessage, error) {
+	body, err := json.Marshal(Request{Query: query, Variables: variables})
+	if err != nil {
+		return nil, err
+	}
+	var resp response
+	if err := c.do("POST", c.GraphQLURL, bytes.NewReader(body), &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		if resp.Errors.has("RATE_LIMITED") {
+			return nil, &RateLimitError{Message: resp.Errors.Error()}
+		}
+		return nil, resp.Errors
+	}
+	return resp.Data, nil
+}
+
+// REST calls the REST API at path, e.g. /repos/jonfk/utility-belt, encoding
+// in as the json body when not nil and decoding the response into out.
+func (c *Client) REST(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	return c.do(method, c.RESTURL+path, body, out)
+}
+
+func (c *Client) do(method, url string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	if c.Token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("bearer %s", c.Token))
+	}
+	req.Header.Add("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return newHTTPError(req, resp, respBody)
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
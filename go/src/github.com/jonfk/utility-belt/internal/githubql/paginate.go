@@ -0,0 +1,127 @@
+package githubql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// PageInfo is the pageInfo of a connection.
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+var pageInfoType = reflect.TypeOf(PageInfo{})
+
+// Paginate runs the query built from the struct q points to for every page
+// of its connection, the struct with a PageInfo and a Nodes slice fields,
+// and decodes the last page into q with the nodes of every page. The
+// connection takes the cursor as the $after variable, e.g.
+//
+//	var q struct {
+//		Viewer struct {
+//			Repositories struct {
+//				PageInfo githubql.PageInfo
+//				Nodes    []Repository
+//			} `graphql:"repositories(first: 100, after: $after)"`
+//		}
+//	}
+//
+// A nil pointer on the way to the connection is a NOT_FOUND error, the
+// fields which can be null must be pointers.
+func (c *Client) Paginate(q interface{}, variables Variables) error {
+	v := reflect.ValueOf(q)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Paginate expects a pointer to a struct, got %T", q)
+	}
+	path, err := connectionPath(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+	pageVariables := Variables{"after": (*string)(nil)}
+	for name, value := range variables {
+		pageVariables[name] = value
+	}
+	query, err := Build(q, pageVariables)
+	if err != nil {
+		return err
+	}
+
+	var nodes reflect.Value
+	for {
+		data, err := c.query(query, pageVariables)
+		if err != nil {
+			return err
+		}
+		page := reflect.New(v.Elem().Type())
+		if err := json.Unmarshal(data, page.Interface()); err != nil {
+			return err
+		}
+		conn, err := connectionAt(page.Elem(), path)
+		if err != nil {
+			return err
+		}
+		pageNodes := conn.FieldByName("Nodes")
+		if !nodes.IsValid() {
+			nodes = reflect.MakeSlice(pageNodes.Type(), 0, pageNodes.Len())
+		}
+		nodes = reflect.AppendSlice(nodes, pageNodes)
+		pageInfo := conn.FieldByName("PageInfo").Interface().(PageInfo)
+		if !pageInfo.HasNextPage {
+			pageNodes.Set(nodes)
+			v.Elem().Set(page.Elem())
+			return nil
+		}
+		after := pageInfo.EndCursor
+		pageVariables["after"] = &after
+	}
+}
+
+// isConnection reports whether t has the PageInfo and the Nodes slice of a
+// connection.
+func isConnection(t reflect.Type) bool {
+	pageInfo, ok := t.FieldByName("PageInfo")
+	nodes, hasNodes := t.FieldByName("Nodes")
+	return ok && pageInfo.Type == pageInfoType && hasNodes && nodes.Type.Kind() == reflect.Slice
+}
+
+// connectionPath returns the fields leading to the only connection of t.
+func connectionPath(t reflect.Type) ([]reflect.StructField, error) {
+	paths := [][]reflect.StructField{}
+	var walk func(t reflect.Type, path []reflect.StructField)
+	walk = func(t reflect.Type, path []reflect.StructField) {
+		if isConnection(t) {
+			paths = append(paths, path)
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if field.PkgPath == "" && fieldType.Kind() == reflect.Struct {
+				walk(fieldType, append(append([]reflect.StructField{}, path...), field))
+			}
+		}
+	}
+	walk(t, nil)
+	if len(paths) != 1 {
+		return nil, fmt.Errorf("Paginate expects a query with one connection, %s has %d", t, len(paths))
+	}
+	return paths[0], nil
+}
+
+func connectionAt(v reflect.Value, path []reflect.StructField) (reflect.Value, error) {
+	for _, field := range path {
+		v = v.FieldByIndex(field.Index)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, Errors{{Type: "NOT_FOUND", Message: fmt.Sprintf("%s is null", field.Name)}}
+			}
+			v = v.Elem()
+		}
+	}
+	return v, nil
+}
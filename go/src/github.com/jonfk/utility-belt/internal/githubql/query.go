@@ -0,0 +1,166 @@
+package githubql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Build writes the query selecting the fields of the struct q points to, the
+// struct the response is then decoded into, and declaring the variables
+// with the GraphQL types of their values.
+//
+// A field is selected by the name of its json tag, or its own name starting
+// with a lower case. Its graphql tag replaces the name to pass arguments or
+// set an alias, e.g.
+//
+//	Readme *gitObject `graphql:"readme: object(expression: \"HEAD:README.md\")"`
+//
+// A graphql tag ending with ,on=Type selects the fields inside an inline
+// fragment on Type, e.g. `graphql:"target,on=Commit"` for
+// target { ... on Commit { ... } }. The fields tagged graphql:"-" or json:"-"
+// aren't selected. time.Time and the other types decoding themselves from
+// json are scalars.
+func Build(q interface{}, variables Variables) (string, error) {
+	t := reflect.TypeOf(q)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return "", fmt.Errorf("A query is a pointer to a struct, got %T", q)
+	}
+	var b strings.Builder
+	b.WriteString("query")
+	if len(variables) > 0 {
+		names := []string{}
+		for name := range variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		declarations := []string{}
+		for _, name := range names {
+			graphqlType, err := variableType(variables[name])
+			if err != nil {
+				return "", fmt.Errorf("Variable $%s: %v", name, err)
+			}
+			declarations = append(declarations, fmt.Sprintf("$%s: %s", name, graphqlType))
+		}
+		fmt.Fprintf(&b, "(%s)", strings.Join(declarations, ", "))
+	}
+	b.WriteString(" ")
+	writeSelection(&b, t.Elem())
+	return b.String(), nil
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// selectionType is the struct t selects the fields of, nil for scalars.
+func selectionType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+	return t
+}
+
+func writeSelection(b *strings.Builder, t reflect.Type) {
+	b.WriteString("{")
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, fragment := fieldSelection(field)
+		if name == "" {
+			continue
+		}
+		b.WriteString(" " + name)
+		if selected := selectionType(field.Type); selected != nil {
+			b.WriteString(" ")
+			if fragment != "" {
+				b.WriteString("{ ... on " + fragment + " ")
+				writeSelection(b, selected)
+				b.WriteString(" }")
+			} else {
+				writeSelection(b, selected)
+			}
+		}
+	}
+	b.WriteString(" }")
+}
+
+// fieldSelection returns what selects field and the type of its inline
+// fragment, an empty name when it isn't selected.
+func fieldSelection(field reflect.StructField) (string, string) {
+	jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+	if jsonName == "-" {
+		return "", ""
+	}
+	tag := field.Tag.Get("graphql")
+	if tag == "-" {
+		return "", ""
+	}
+	fragment := ""
+	// the arguments can hold commas, the fragment is last
+	if i := strings.LastIndex(tag, ",on="); i >= 0 {
+		tag, fragment = tag[:i], tag[i+len(",on="):]
+	}
+	switch {
+	case tag != "":
+		return tag, fragment
+	case jsonName != "":
+		return jsonName, fragment
+	default:
+		return lowerFirst(field.Name), fragment
+	}
+}
+
+// lowerFirst lower cases the first word of a Go name: NameWithOwner is
+// nameWithOwner, ID is id and URLPath is urlPath.
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) {
+		// the last capital starts the next word
+		upper--
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// variableType is the GraphQL type of a variable, nullable for pointers.
+func variableType(value interface{}) (string, error) {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		return "", fmt.Errorf("Can't tell the type of nil, pass a typed nil pointer")
+	}
+	nullable := t.Kind() == reflect.Ptr
+	if nullable {
+		t = t.Elem()
+	}
+	var graphqlType string
+	switch t.Kind() {
+	case reflect.String:
+		graphqlType = "String"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		graphqlType = "Int"
+	case reflect.Float32, reflect.Float64:
+		graphqlType = "Float"
+	case reflect.Bool:
+		graphqlType = "Boolean"
+	default:
+		return "", fmt.Errorf("Unsupported variable type %s", t)
+	}
+	if !nullable {
+		graphqlType += "!"
+	}
+	return graphqlType, nil
+}
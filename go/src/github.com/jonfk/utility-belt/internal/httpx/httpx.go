@@ -0,0 +1,186 @@
+// Package httpx builds the http clients of the tools with timeouts, retries
+// with exponential backoff, proxy support and optional request/response dumps.
+package httpx
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	DefaultTimeout = 30 * time.Second
+	DefaultRetries = 2
+	DefaultBackoff = 500 * time.Millisecond
+)
+
+type Options struct {
+	// Timeout of a single attempt, including reading the response body
+	Timeout time.Duration
+	// Retries is the number of attempts after the first one
+	Retries int
+	// Backoff is the delay before the first retry, doubled on every attempt
+	Backoff time.Duration
+	// Proxy URL, the HTTP_PROXY/HTTPS_PROXY environment variables are used when empty
+	Proxy string
+	// Debug dumps the requests and responses to DebugOut
+	Debug    bool
+	DebugOut io.Writer
+}
+
+// DefaultOptions are used by tools that don't expose the http flags.
+var DefaultOptions = Options{
+	Timeout: DefaultTimeout,
+	Retries: DefaultRetries,
+	Backoff: DefaultBackoff,
+}
+
+// Flags are the command line flags read by FromContext.
+var Flags = []cli.Flag{
+	cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "Timeout of each http request",
+		Value: DefaultTimeout,
+	},
+	cli.IntFlag{
+		Name:  "retries",
+		Usage: "Number of retries of failed http requests",
+		Value: DefaultRetries,
+	},
+	cli.StringFlag{
+		Name:   "proxy",
+		Usage:  "Proxy `URL` for the http requests, defaults to HTTP_PROXY/HTTPS_PROXY",
+		EnvVar: "UB_PROXY",
+	},
+	cli.BoolFlag{
+		Name:  "debug-http",
+		Usage: "Dump the http requests and responses to stderr",
+	},
+}
+
+// FromContext builds a client configured by Flags.
+func FromContext(c *cli.Context) (*http.Client, error) {
+	return NewClient(Options{
+		Timeout: c.Duration("timeout"),
+		Retries: c.Int("retries"),
+		Backoff: DefaultBackoff,
+		Proxy:   c.String("proxy"),
+		Debug:   c.Bool("debug-http"),
+	})
+}
+
+func NewClient(opts Options) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid proxy URL %q: %v", opts.Proxy, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+
+	debugOut := opts.DebugOut
+	if debugOut == nil {
+		debugOut = os.Stderr
+	}
+	return &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &retryTransport{
+			next:     transport,
+			retries:  opts.Retries,
+			backoff:  opts.Backoff,
+			debug:    opts.Debug,
+			debugOut: debugOut,
+		},
+	}, nil
+}
+
+type retryTransport struct {
+	next     http.RoundTripper
+	retries  int
+	backoff  time.Duration
+	debug    bool
+	debugOut io.Writer
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := t.backoff
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("Cannot retry %s %s, its body can't be rewound", req.Method, req.URL)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		if t.debug {
+			if dump, err := httputil.DumpRequestOut(req, true); err == nil {
+				fmt.Fprintf(t.debugOut, "> %s\n", dump)
+			}
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		if t.debug && err == nil {
+			if dump, err := httputil.DumpResponse(resp, true); err == nil {
+				fmt.Fprintf(t.debugOut, "< %s\n", dump)
+			}
+		}
+		if attempt >= t.retries || !retryable(req, resp, err) {
+			return resp, err
+		}
+
+		wait := backoff
+		if resp != nil {
+			if after := retryAfter(resp); after > 0 {
+				wait = after
+			}
+			resp.Body.Close()
+		}
+		if t.debug {
+			fmt.Fprintf(t.debugOut, "retrying %s %s in %s\n", req.Method, req.URL, wait)
+		}
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether the attempt failed in a way worth retrying: a
+// network error, a rate limit or a server error.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := time.ParseDuration(value + "s"); err == nil {
+		return seconds
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
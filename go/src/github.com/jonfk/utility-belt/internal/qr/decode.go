@@ -0,0 +1,703 @@
+package qr
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// levelSpecs are the block structures of the error correction levels indexed
+// by their format bits: M, L, H then Q.
+var levelSpecs = [4][MaxVersion + 1]blockSpec{
+	0: {
+		1:  {10, []int{16}},
+		2:  {16, []int{28}},
+		3:  {26, []int{44}},
+		4:  {18, []int{32, 32}},
+		5:  {24, []int{43, 43}},
+		6:  {16, []int{27, 27, 27, 27}},
+		7:  {18, []int{31, 31, 31, 31}},
+		8:  {22, []int{38, 38, 39, 39}},
+		9:  {22, []int{36, 36, 36, 37, 37}},
+		10: {26, []int{43, 43, 43, 43, 44}},
+	},
+	1: specs,
+	2: {
+		1:  {17, []int{9}},
+		2:  {28, []int{16}},
+		3:  {22, []int{13, 13}},
+		4:  {16, []int{9, 9, 9, 9}},
+		5:  {22, []int{11, 11, 12, 12}},
+		6:  {28, []int{15, 15, 15, 15}},
+		7:  {26, []int{13, 13, 13, 13, 14}},
+		8:  {26, []int{14, 14, 14, 14, 15, 15}},
+		9:  {24, []int{12, 12, 12, 12, 13, 13, 13, 13}},
+		10: {28, []int{15, 15, 15, 15, 15, 15, 16, 16}},
+	},
+	3: {
+		1:  {13, []int{13}},
+		2:  {22, []int{22}},
+		3:  {18, []int{17, 17}},
+		4:  {26, []int{24, 24}},
+		5:  {18, []int{15, 15, 16, 16}},
+		6:  {24, []int{19, 19, 19, 19}},
+		7:  {18, []int{14, 14, 15, 15, 15, 15}},
+		8:  {22, []int{18, 18, 18, 18, 19, 19}},
+		9:  {20, []int{16, 16, 16, 16, 17, 17, 17, 17}},
+		10: {24, []int{19, 19, 19, 19, 19, 19, 20, 20}},
+	},
+}
+
+const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// Decode finds the QR code in img and returns its text. The code has to be
+// flat, as in screenshots and straight photos, but may be rotated.
+func Decode(img image.Image) (string, error) {
+	b := binarize(img)
+	tl, tr, bl, ok := selectFinders(b.finderPatterns())
+	if !ok {
+		return "", fmt.Errorf("No QR code found")
+	}
+	module := (tl.module + tr.module + bl.module) / 3
+	dimension := (distance(tl, tr)+distance(tl, bl))/2/module + 7
+	estimate := int(math.Round((dimension - 17) / 4))
+
+	var lastErr error
+	for _, version := range []int{estimate, estimate - 1, estimate + 1} {
+		if version < 1 || version > MaxVersion {
+			continue
+		}
+		text, err := decodeModules(version, b.sample(tl, tr, bl, version*4+17))
+		if err == nil {
+			return text, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		return "", fmt.Errorf("QR code of about %.0f modules is larger than version %d", dimension, MaxVersion)
+	}
+	return "", lastErr
+}
+
+// bitmap is a thresholded image, true for dark pixels.
+type bitmap struct {
+	width, height int
+	dark          []bool
+}
+
+func (b *bitmap) at(x, y int) bool {
+	return x >= 0 && y >= 0 && x < b.width && y < b.height && b.dark[y*b.width+x]
+}
+
+func (b *bitmap) in(x, y int) bool {
+	return x >= 0 && y >= 0 && x < b.width && y < b.height
+}
+
+// binarize thresholds img, transparent pixels are light. Pixels clearly dark
+// or light for the whole image are decided globally, the others against the
+// mean of their neighbourhood to cope with uneven lighting.
+func binarize(img image.Image) *bitmap {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	luminance := make([]int, w*h)
+	low, high := 255, 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			l := int((299*r+587*g+114*b)/1000+0xFFFF-a) >> 8
+			if l > 255 {
+				l = 255
+			}
+			luminance[y*w+x] = l
+			if l < low {
+				low = l
+			}
+			if l > high {
+				high = l
+			}
+		}
+	}
+
+	// integral[y][x] sums the luminance above and left of x, y
+	integral := make([]int, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		row := 0
+		for x := 0; x < w; x++ {
+			row += luminance[y*w+x]
+			integral[(y+1)*(w+1)+x+1] = integral[y*(w+1)+x+1] + row
+		}
+	}
+	radius := w
+	if h > radius {
+		radius = h
+	}
+	if radius /= 16; radius < 8 {
+		radius = 8
+	}
+
+	quarter := (high - low) / 4
+	b := &bitmap{width: w, height: h, dark: make([]bool, w*h)}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			l := luminance[y*w+x]
+			switch {
+			case l < low+quarter:
+				b.dark[y*w+x] = true
+			case l > high-quarter:
+			default:
+				x0, y0, x1, y1 := clamp(x-radius, w), clamp(y-radius, h), clamp(x+radius+1, w), clamp(y+radius+1, h)
+				sum := integral[y1*(w+1)+x1] - integral[y0*(w+1)+x1] - integral[y1*(w+1)+x0] + integral[y0*(w+1)+x0]
+				b.dark[y*w+x] = l*(x1-x0)*(y1-y0) < sum
+			}
+		}
+	}
+	return b
+}
+
+func clamp(n, max int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// finder is a candidate finder pattern centered on x, y.
+type finder struct {
+	x, y, module float64
+	count        int
+}
+
+func distance(a, b finder) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+// finderRatio reports whether counts, the runs across a finder pattern, are
+// in the 1:1:3:1:1 ratio, returning the module size.
+func finderRatio(counts [5]int) (float64, bool) {
+	total := 0
+	for _, n := range counts {
+		if n == 0 {
+			return 0, false
+		}
+		total += n
+	}
+	if total < 7 {
+		return 0, false
+	}
+	module := float64(total) / 7
+	variance := module / 2
+	for i, n := range counts {
+		expected := module
+		if i == 2 {
+			expected = 3 * module
+		}
+		if math.Abs(expected-float64(n)) >= variance*expected/module {
+			return 0, false
+		}
+	}
+	return module, true
+}
+
+// crossCheck measures the finder pattern through the dark pixel x, y along
+// dx, dy, returning the offset of its center from the center of the pixel
+// and the module size.
+func (b *bitmap) crossCheck(x, y, dx, dy int) (float64, float64, bool) {
+	if !b.at(x, y) {
+		return 0, 0, false
+	}
+	var counts [5]int
+	walk := func(sign int, states []int) int {
+		px, py := x, y
+		if sign > 0 {
+			px, py = x+dx, y+dy
+		}
+		core := 0
+		for _, state := range states {
+			dark := state%2 == 0
+			for b.in(px, py) && b.at(px, py) == dark {
+				counts[state]++
+				if state == 2 {
+					core++
+				}
+				px, py = px+sign*dx, py+sign*dy
+			}
+		}
+		return core
+	}
+	back := walk(-1, []int{2, 1, 0})
+	forward := walk(1, []int{2, 3, 4})
+	module, ok := finderRatio(counts)
+	if !ok {
+		return 0, 0, false
+	}
+	// the core spans from 1-back to forward, pixel centers are at +0.5
+	return float64(forward-back+1) / 2, module, true
+}
+
+// finderPatterns scans the rows for the 1:1:3:1:1 runs of finder patterns
+// and confirms them vertically then horizontally again.
+func (b *bitmap) finderPatterns() []finder {
+	finders := []finder{}
+	for y := 0; y < b.height; y++ {
+		starts, lengths := []int{}, []int{}
+		for x := 0; x < b.width; x++ {
+			if x == 0 || b.at(x, y) != b.at(x-1, y) {
+				starts = append(starts, x)
+				lengths = append(lengths, 0)
+			}
+			lengths[len(lengths)-1]++
+		}
+		for i := 0; i+5 <= len(lengths); i++ {
+			if !b.at(starts[i], y) {
+				continue
+			}
+			var counts [5]int
+			copy(counts[:], lengths[i:i+5])
+			if _, ok := finderRatio(counts); !ok {
+				continue
+			}
+			cx := float64(starts[i+2]) + float64(lengths[i+2])/2
+			offset, moduleV, ok := b.crossCheck(int(cx), y, 0, 1)
+			if !ok {
+				continue
+			}
+			cy := float64(y) + 0.5 + offset
+			offset, moduleH, ok := b.crossCheck(int(cx), int(cy), 1, 0)
+			if !ok {
+				continue
+			}
+			cx = math.Floor(cx) + 0.5 + offset
+			finders = addFinder(finders, finder{x: cx, y: cy, module: (moduleV + moduleH) / 2, count: 1})
+		}
+	}
+	return finders
+}
+
+// addFinder merges candidate into the finder it was already found as.
+func addFinder(finders []finder, candidate finder) []finder {
+	for i, f := range finders {
+		if math.Abs(f.x-candidate.x) <= 2*f.module && math.Abs(f.y-candidate.y) <= 2*f.module {
+			n := float64(f.count)
+			finders[i] = finder{
+				x:      (f.x*n + candidate.x) / (n + 1),
+				y:      (f.y*n + candidate.y) / (n + 1),
+				module: (f.module*n + candidate.module) / (n + 1),
+				count:  f.count + 1,
+			}
+			return finders
+		}
+	}
+	return append(finders, candidate)
+}
+
+// selectFinders picks the three finders best forming the corners of a code,
+// returning them as top left, top right and bottom left.
+func selectFinders(finders []finder) (finder, finder, finder, bool) {
+	sort.Slice(finders, func(i, j int) bool { return finders[i].count > finders[j].count })
+	if len(finders) > 10 {
+		finders = finders[:10]
+	}
+	var best [3]finder
+	bestScore := math.Inf(1)
+	for i := 0; i < len(finders); i++ {
+		for j := i + 1; j < len(finders); j++ {
+			for k := j + 1; k < len(finders); k++ {
+				a, b, c := finders[i], finders[j], finders[k]
+				modules := []float64{a.module, b.module, c.module}
+				sort.Float64s(modules)
+				if modules[2] > 1.5*modules[0] {
+					continue
+				}
+				// the top left finder is opposite the longest side
+				if distance(a, c) > distance(b, c) && distance(a, c) > distance(a, b) {
+					a, b = b, a
+				} else if distance(a, b) > distance(b, c) && distance(a, b) > distance(a, c) {
+					a, c = c, a
+				}
+				ab, ac, bc := distance(a, b), distance(a, c), distance(b, c)
+				score := math.Abs(ab-ac)/math.Max(ab, ac) + math.Abs(bc-math.Hypot(ab, ac))/bc
+				if score < bestScore && ab > 7*modules[0] {
+					best, bestScore = [3]finder{a, b, c}, score
+				}
+			}
+		}
+	}
+	if bestScore > 0.3 {
+		return finder{}, finder{}, finder{}, false
+	}
+	tl, tr, bl := best[0], best[1], best[2]
+	// with y pointing down, top right then bottom left turn clockwise
+	if (tr.x-tl.x)*(bl.y-tl.y)-(tr.y-tl.y)*(bl.x-tl.x) < 0 {
+		tr, bl = bl, tr
+	}
+	return tl, tr, bl, true
+}
+
+// sample reads the modules of a code of size whose finder patterns are
+// centered on tl, tr and bl.
+func (b *bitmap) sample(tl, tr, bl finder, size int) [][]bool {
+	modules := newGrid(size)
+	span := float64(size - 7)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			u, v := (float64(x)-3)/span, (float64(y)-3)/span
+			px := tl.x + u*(tr.x-tl.x) + v*(bl.x-tl.x)
+			py := tl.y + u*(tr.y-tl.y) + v*(bl.y-tl.y)
+			modules[y][x] = b.at(int(math.Floor(px)), int(math.Floor(py)))
+		}
+	}
+	return modules
+}
+
+// decodeModules decodes the modules of a code of version.
+func decodeModules(version int, modules [][]bool) (string, error) {
+	code := &Code{Version: version, Size: version*4 + 17}
+	code.Modules = newGrid(code.Size)
+	code.function = newGrid(code.Size)
+	code.drawFunctionPatterns()
+	code.Modules = modules
+
+	format, ok := code.readFormat()
+	if !ok {
+		return "", fmt.Errorf("Unreadable QR code format")
+	}
+	code.applyMask(format & 7)
+	spec := levelSpecs[format>>3][version]
+
+	codewords := code.readCodewords(spec.dataCodewords() + spec.ec*len(spec.blocks))
+	blocks := make([][]byte, len(spec.blocks))
+	i := 0
+	for n := 0; n < spec.blocks[len(spec.blocks)-1]; n++ {
+		for j, size := range spec.blocks {
+			if n < size {
+				blocks[j] = append(blocks[j], codewords[i])
+				i++
+			}
+		}
+	}
+	for n := 0; n < spec.ec; n++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[i])
+			i++
+		}
+	}
+	data := []byte{}
+	for j, block := range blocks {
+		if err := rsCorrect(block, spec.ec); err != nil {
+			return "", err
+		}
+		data = append(data, block[:spec.blocks[j]]...)
+	}
+	return decodeSegments(data, version)
+}
+
+// readFormat returns the level and mask of the format bits, correcting up to
+// 3 errors in either copy.
+func (c *Code) readFormat() (int, bool) {
+	read := [2]int{}
+	for n, positions := range c.formatPositions() {
+		for i, p := range positions {
+			if c.Modules[p[1]][p[0]] {
+				read[n] |= 1 << uint(i)
+			}
+		}
+	}
+	best, bestDistance := 0, 16
+	for data := 0; data < 32; data++ {
+		bits := formatBits(data)
+		for _, r := range read {
+			d := 0
+			for x := bits ^ r; x != 0; x &= x - 1 {
+				d++
+			}
+			if d < bestDistance {
+				best, bestDistance = data, d
+			}
+		}
+	}
+	return best, bestDistance <= 3
+}
+
+// readCodewords reads n codewords in the zigzag order of drawCodewords.
+func (c *Code) readCodewords(n int) []byte {
+	codewords := make([]byte, n)
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if c.function[y][x] || i >= n*8 {
+					continue
+				}
+				if c.Modules[y][x] {
+					codewords[i>>3] |= 1 << uint(7-i&7)
+				}
+				i++
+			}
+		}
+	}
+	return codewords
+}
+
+func gfPower(x byte, n int) byte {
+	result := byte(1)
+	for i := 0; i < n; i++ {
+		result = gfMultiply(result, x)
+	}
+	return result
+}
+
+func gfInverse(x byte) byte {
+	return gfPower(x, 254)
+}
+
+// gfEvaluate evaluates the polynomial with coefficients lowest first at x.
+func gfEvaluate(poly []byte, x byte) byte {
+	var result byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		result = gfMultiply(result, x) ^ poly[i]
+	}
+	return result
+}
+
+// rsSyndromes evaluates block, highest coefficient first, at the ec roots of
+// the generator, all zero when block has no errors.
+func rsSyndromes(block []byte, ec int) ([]byte, bool) {
+	syndromes := make([]byte, ec)
+	clean := true
+	for j := range syndromes {
+		root := gfPower(2, j)
+		for _, c := range block {
+			syndromes[j] = gfMultiply(syndromes[j], root) ^ c
+		}
+		if syndromes[j] != 0 {
+			clean = false
+		}
+	}
+	return syndromes, clean
+}
+
+// rsCorrect corrects up to ec/2 errors in block in place, with the
+// Berlekamp-Massey algorithm for the error locator and Forney's for the
+// error values.
+func rsCorrect(block []byte, ec int) error {
+	syndromes, clean := rsSyndromes(block, ec)
+	if clean {
+		return nil
+	}
+	locator, previous := []byte{1}, []byte{1}
+	errors, shift, discrepancy := 0, 1, byte(1)
+	for i := 0; i < ec; i++ {
+		d := syndromes[i]
+		for j := 1; j <= errors && j < len(locator); j++ {
+			d ^= gfMultiply(locator[j], syndromes[i-j])
+		}
+		if d == 0 {
+			shift++
+			continue
+		}
+		size := len(locator)
+		if len(previous)+shift > size {
+			size = len(previous) + shift
+		}
+		next := make([]byte, size)
+		copy(next, locator)
+		factor := gfMultiply(d, gfInverse(discrepancy))
+		for j, p := range previous {
+			next[j+shift] ^= gfMultiply(factor, p)
+		}
+		if 2*errors <= i {
+			previous, errors, discrepancy, shift = locator, i+1-errors, d, 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+	if 2*errors > ec {
+		return fmt.Errorf("Too many errors in QR code")
+	}
+
+	positions := []int{}
+	for p := 0; p < len(block); p++ {
+		if gfEvaluate(locator, gfInverse(gfPower(2, p))) == 0 {
+			positions = append(positions, p)
+		}
+	}
+	if len(positions) != errors {
+		return fmt.Errorf("Too many errors in QR code")
+	}
+	evaluator := make([]byte, ec)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= gfMultiply(locator[j], syndromes[i-j])
+		}
+	}
+	for _, p := range positions {
+		x := gfPower(2, p)
+		inverse := gfInverse(x)
+		// the formal derivative of the locator only keeps its odd terms
+		var derivative byte
+		for j := 1; j < len(locator); j += 2 {
+			derivative ^= gfMultiply(locator[j], gfPower(inverse, j-1))
+		}
+		if derivative == 0 {
+			return fmt.Errorf("Too many errors in QR code")
+		}
+		block[len(block)-1-p] ^= gfMultiply(gfMultiply(x, gfEvaluate(evaluator, inverse)), gfInverse(derivative))
+	}
+	if _, clean := rsSyndromes(block, ec); !clean {
+		return fmt.Errorf("Too many errors in QR code")
+	}
+	return nil
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) remaining() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *bitReader) read(n int) (int, error) {
+	if n > r.remaining() {
+		return 0, fmt.Errorf("Truncated QR code data")
+	}
+	value := 0
+	for i := 0; i < n; i++ {
+		value = value<<1 | int(r.data[r.pos>>3]>>uint(7-r.pos&7)&1)
+		r.pos++
+	}
+	return value, nil
+}
+
+// countBits returns the size of the character count of mode in version.
+func countBits(mode, version int) int {
+	bits := map[int][2]int{1: {10, 12}, 2: {9, 11}, 4: {8, 16}, 8: {8, 10}}[mode]
+	if version < 10 {
+		return bits[0]
+	}
+	return bits[1]
+}
+
+// decodeSegments decodes the numeric, alphanumeric and byte segments of
+// data. Byte segments are UTF-8 unless invalid or declared Latin-1 by an ECI.
+func decodeSegments(data []byte, version int) (string, error) {
+	r := &bitReader{data: data}
+	var text strings.Builder
+	latin1 := false
+	for r.remaining() >= 4 {
+		mode, _ := r.read(4)
+		switch mode {
+		case 0:
+			return text.String(), nil
+		case 1:
+			count, err := r.read(countBits(mode, version))
+			if err != nil {
+				return "", err
+			}
+			for ; count > 0; count -= 3 {
+				digits, bits := 3, 10
+				if count < 3 {
+					digits, bits = count, []int{0, 4, 7}[count]
+				}
+				value, err := r.read(bits)
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintf(&text, "%0*d", digits, value)
+			}
+		case 2:
+			count, err := r.read(countBits(mode, version))
+			if err != nil {
+				return "", err
+			}
+			for ; count > 0; count -= 2 {
+				if count == 1 {
+					value, err := r.read(6)
+					if err != nil {
+						return "", err
+					}
+					text.WriteByte(alphanumeric[value%45])
+					break
+				}
+				value, err := r.read(11)
+				if err != nil {
+					return "", err
+				}
+				text.WriteByte(alphanumeric[value/45%45])
+				text.WriteByte(alphanumeric[value%45])
+			}
+		case 4:
+			count, err := r.read(countBits(mode, version))
+			if err != nil {
+				return "", err
+			}
+			segment := make([]byte, count)
+			for i := range segment {
+				value, err := r.read(8)
+				if err != nil {
+					return "", err
+				}
+				segment[i] = byte(value)
+			}
+			if latin1 || !utf8.Valid(segment) {
+				for _, b := range segment {
+					text.WriteRune(rune(b))
+				}
+			} else {
+				text.Write(segment)
+			}
+		case 7:
+			first, err := r.read(8)
+			if err != nil {
+				return "", err
+			}
+			designator := first
+			switch {
+			case first&0xC0 == 0x80:
+				next, err := r.read(8)
+				designator = (first&0x3F)<<8 | next
+				if err != nil {
+					return "", err
+				}
+			case first&0xE0 == 0xC0:
+				next, err := r.read(16)
+				designator = (first&0x1F)<<16 | next
+				if err != nil {
+					return "", err
+				}
+			}
+			latin1 = designator == 1 || designator == 3
+		case 3:
+			// structured append, the codes are decoded one by one
+			if _, err := r.read(16); err != nil {
+				return "", err
+			}
+		case 5:
+		case 9:
+			if _, err := r.read(8); err != nil {
+				return "", err
+			}
+		case 8:
+			return "", fmt.Errorf("Kanji QR codes are not supported")
+		default:
+			return "", fmt.Errorf("Invalid QR code mode %d", mode)
+		}
+	}
+	return text.String(), nil
+}
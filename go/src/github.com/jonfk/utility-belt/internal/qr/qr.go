@@ -0,0 +1,504 @@
+// Package qr encodes short texts such as URLs as QR codes, renders them on
+// the terminal or as images and decodes them from images. It encodes in byte
+// mode at error correction level L up to version 10, which holds 271 bytes,
+// and decodes any mode and level up to the same version.
+package qr
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// MaxVersion is the largest supported QR code version.
+const MaxVersion = 10
+
+// blockSpec is the error correction structure of a version at a level: the
+// ec codewords per block and the data codewords of each block.
+type blockSpec struct {
+	ec     int
+	blocks []int
+}
+
+// specs are the block structures at level L, used for encoding.
+var specs = [MaxVersion + 1]blockSpec{
+	1:  {7, []int{19}},
+	2:  {10, []int{34}},
+	3:  {15, []int{55}},
+	4:  {20, []int{80}},
+	5:  {26, []int{108}},
+	6:  {18, []int{68, 68}},
+	7:  {20, []int{78, 78}},
+	8:  {24, []int{97, 97}},
+	9:  {30, []int{116, 116}},
+	10: {18, []int{68, 68, 69, 69}},
+}
+
+var alignments = [MaxVersion + 1][]int{
+	2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34},
+	7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50},
+}
+
+// remainderBits pad the codewords to fill the symbol.
+var remainderBits = [MaxVersion + 1]int{2: 7, 3: 7, 4: 7, 5: 7, 6: 7}
+
+// Code is an encoded QR code, Modules[y][x] is true for dark modules.
+type Code struct {
+	Version int
+	Size    int
+	Modules [][]bool
+
+	function [][]bool
+}
+
+func (s blockSpec) dataCodewords() int {
+	n := 0
+	for _, b := range s.blocks {
+		n += b
+	}
+	return n
+}
+
+// Encode encodes text in the smallest version which fits it.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v <= MaxVersion; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*specs[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("Text of %d bytes is too long for a QR code", len(data))
+	}
+
+	code := &Code{Version: version, Size: version*4 + 17}
+	code.Modules = newGrid(code.Size)
+	code.function = newGrid(code.Size)
+	code.drawFunctionPatterns()
+	code.drawCodewords(addErrorCorrection(encodeData(data, version), specs[version]))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(mask)
+		if penalty := code.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		code.applyMask(mask)
+	}
+	code.applyMask(best)
+	code.drawFormatBits(best)
+	return code, nil
+}
+
+func newGrid(size int) [][]bool {
+	grid := make([][]bool, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+	return grid
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (value>>uint(i))&1 == 1)
+	}
+}
+
+// encodeData returns the data codewords in byte mode, padded to capacity.
+func encodeData(data []byte, version int) []byte {
+	capacity := specs[version].dataCodewords()
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	bits := bitBuffer{}
+	bits.append(0x4, 4)
+	bits.append(len(data), countBits)
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	terminator := capacity*8 - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+
+	codewords := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << uint(7-j)
+			}
+		}
+		codewords = append(codewords, b)
+	}
+	for pad := byte(0xEC); len(codewords) < capacity; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+// addErrorCorrection splits data in blocks, computes their Reed-Solomon
+// codewords and interleaves them.
+func addErrorCorrection(data []byte, spec blockSpec) []byte {
+	blocks, ecBlocks := [][]byte{}, [][]byte{}
+	generator := rsGenerator(spec.ec)
+	for _, n := range spec.blocks {
+		block := data[:n]
+		data = data[n:]
+		blocks = append(blocks, block)
+		ecBlocks = append(ecBlocks, rsRemainder(block, generator))
+	}
+
+	result := []byte{}
+	for i := 0; i < spec.blocks[len(spec.blocks)-1]; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ec; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(256) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x1D)
+		z ^= ((y >> uint(i)) & 1) * x
+	}
+	return z
+}
+
+// rsGenerator returns the coefficients of the generator polynomial of degree,
+// highest first without the leading 1.
+func rsGenerator(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, generator []byte) []byte {
+	result := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range generator {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.Modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+	for _, center := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x < 0 || x >= c.Size || y < 0 || y >= c.Size {
+					continue
+				}
+				dist := abs(dx)
+				if abs(dy) > dist {
+					dist = abs(dy)
+				}
+				c.setFunction(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+	positions := alignments[c.Version]
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// skip the corners of the finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					dist := abs(dx)
+					if abs(dy) > dist {
+						dist = abs(dy)
+					}
+					c.setFunction(x+dx, y+dy, dist != 1)
+				}
+			}
+		}
+	}
+	// reserve the format bits until the mask is chosen
+	c.drawFormatBits(0)
+	if c.Version >= 7 {
+		rem := c.Version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := c.Version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>uint(i))&1 == 1
+			a, b := c.Size-11+i%3, i/3
+			c.setFunction(a, b, dark)
+			c.setFunction(b, a, dark)
+		}
+	}
+}
+
+// formatBits returns the 15 format bits of data, the error correction level
+// followed by the mask, with their BCH code.
+func formatBits(data int) int {
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// formatPositions returns the x, y positions of the format bits in both of
+// their copies, from the lowest bit.
+func (c *Code) formatPositions() [2][15][2]int {
+	var positions [2][15][2]int
+	for i := 0; i < 15; i++ {
+		switch {
+		case i <= 5:
+			positions[0][i] = [2]int{8, i}
+		case i <= 7:
+			positions[0][i] = [2]int{8, i + 1}
+		case i == 8:
+			positions[0][i] = [2]int{7, 8}
+		default:
+			positions[0][i] = [2]int{14 - i, 8}
+		}
+		if i < 8 {
+			positions[1][i] = [2]int{c.Size - 1 - i, 8}
+		} else {
+			positions[1][i] = [2]int{8, c.Size - 15 + i}
+		}
+	}
+	return positions
+}
+
+func (c *Code) drawFormatBits(mask int) {
+	// 01 is error correction level L
+	bits := formatBits(1<<3 | mask)
+	for _, positions := range c.formatPositions() {
+		for i, p := range positions {
+			c.setFunction(p[0], p[1], (bits>>uint(i))&1 == 1)
+		}
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+// drawCodewords places the codewords in the zigzag order of the standard.
+func (c *Code) drawCodewords(codewords []byte) {
+	total := len(codewords)*8 + remainderBits[c.Version]
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if c.function[y][x] || i >= total {
+					continue
+				}
+				if i < len(codewords)*8 {
+					c.Modules[y][x] = (codewords[i>>3]>>uint(7-i&7))&1 == 1
+				}
+				i++
+			}
+		}
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.function[y][x] {
+				c.Modules[y][x] = !c.Modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code is to scan, following the four rules of
+// the standard.
+func (c *Code) penalty() int {
+	penalty := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return c.Modules[x][y]
+		}
+		return c.Modules[y][x]
+	}
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < c.Size; y++ {
+			run := 1
+			for x := 1; x < c.Size; x++ {
+				if at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					if run == 5 {
+						penalty += 3
+					} else if run > 5 {
+						penalty++
+					}
+				} else {
+					run = 1
+				}
+			}
+			for x := 0; x+11 <= c.Size; x++ {
+				for _, pattern := range finderLike {
+					match := true
+					for k, dark := range pattern {
+						if at(x+k, y, vertical) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						penalty += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				color := c.Modules[y][x]
+				if color == c.Modules[y][x+1] && color == c.Modules[y+1][x] && color == c.Modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return penalty + k*10
+}
+
+// QuietZone is the light border around the code required by readers.
+const QuietZone = 4
+
+// Terminal renders the code with half blocks, two rows per line. The colors
+// are explicit so it scans on dark and light terminals alike.
+func (c *Code) Terminal() string {
+	dark := func(x, y int) bool {
+		x, y = x-QuietZone, y-QuietZone
+		return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.Modules[y][x]
+	}
+	size := c.Size + 2*QuietZone
+	var b strings.Builder
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			fg, bg := 97, 107
+			if dark(x, y) {
+				fg = 30
+			}
+			if dark(x, y+1) {
+				bg = 40
+			}
+			fmt.Fprintf(&b, "\033[%d;%dm▀", fg, bg)
+		}
+		b.WriteString("\033[0m\n")
+	}
+	return b.String()
+}
+
+// Image renders the code with its quiet zone, scale pixels per module.
+func (c *Code) Image(scale int) *image.Gray {
+	size := (c.Size + 2*QuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y, row := range c.Modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+QuietZone)*scale+dx, (y+QuietZone)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,25 @@
+// Package spark renders series of counts as sparklines on the terminal.
+package spark
+
+import "strings"
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Line renders a block per value, scaled to the largest one.
+func Line(values []int) string {
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		if max == 0 {
+			b.WriteRune(sparks[0])
+			continue
+		}
+		b.WriteRune(sparks[v*(len(sparks)-1)/max])
+	}
+	return b.String()
+}
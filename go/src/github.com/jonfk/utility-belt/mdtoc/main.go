@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/mdtoc/mdtoc"
+)
+
+func main() {
+	belt.Run(belt.NewApp("mdtoc", mdtoc.Command()))
+}
@@ -0,0 +1,111 @@
+package mdtoc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Command updates the table of contents of markdown files, run standalone as mdtoc or as ub mdtoc.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "mdtoc",
+		Usage:     "Inserts or updates the table of contents of markdown files",
+		ArgsUsage: "FILE|DIR...",
+		Description: "The table of contents is written between " + StartMarker + " and " + EndMarker + "\n" +
+			"   lines, files without them are left untouched. Directories are searched for .md files.",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "check",
+				Usage: "Don't write anything, exit with an error when a table of contents is out of date",
+			},
+			cli.IntFlag{
+				Name:  "min-level",
+				Usage: "Smallest heading level listed, 2 skips the title",
+				Value: 2,
+			},
+			cli.IntFlag{
+				Name:  "max-level",
+				Usage: "Largest heading level listed",
+				Value: 3,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("mdtoc takes markdown files or directories")
+			}
+			minLevel, maxLevel := c.Int("min-level"), c.Int("max-level")
+			if minLevel < 1 || maxLevel > 6 || minLevel > maxLevel {
+				return fmt.Errorf("Expected 1 <= --min-level <= --max-level <= 6")
+			}
+			files, err := markdownFiles(c.Args())
+			if err != nil {
+				return err
+			}
+
+			outdated := 0
+			for _, file := range files {
+				content, err := ioutil.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				updated, ok, err := Update(string(content), minLevel, maxLevel)
+				if err != nil {
+					return fmt.Errorf("%s: %v", file, err)
+				}
+				if !ok || updated == string(content) {
+					continue
+				}
+				outdated++
+				if c.Bool("check") {
+					fmt.Printf("%s: table of contents is out of date\n", file)
+					continue
+				}
+				if err := ioutil.WriteFile(file, []byte(updated), 0644); err != nil {
+					return err
+				}
+				fmt.Printf("Updated %s\n", file)
+			}
+			if c.Bool("check") && outdated > 0 {
+				return cli.NewExitError(fmt.Sprintf("%d tables of contents are out of date, run mdtoc to update them", outdated), 1)
+			}
+			return nil
+		},
+	}
+}
+
+// markdownFiles expands the directories of paths into their .md files,
+// skipping the hidden directories.
+func markdownFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && file != path && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && strings.EqualFold(filepath.Ext(file), ".md") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
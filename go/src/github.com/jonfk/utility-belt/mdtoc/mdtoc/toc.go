@@ -0,0 +1,143 @@
+package mdtoc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// The table of contents is kept between these markers, which are invisible
+// once rendered.
+const (
+	StartMarker = "<!-- toc -->"
+	EndMarker   = "<!-- tocstop -->"
+)
+
+type Heading struct {
+	Level  int
+	Text   string
+	Anchor string
+}
+
+var (
+	atxHeading = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	fence      = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	link       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	image      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+)
+
+// Headings returns the ATX headings of the markdown in lines, skipping the
+// fenced code blocks and the current table of contents, with their anchors
+// slugged like Github does.
+func Headings(lines []string) []Heading {
+	headings := []Heading{}
+	anchors := map[string]int{}
+	inFence, inTOC := "", false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inFence != "":
+			if strings.HasPrefix(trimmed, inFence) {
+				inFence = ""
+			}
+			continue
+		case trimmed == StartMarker:
+			inTOC = true
+			continue
+		case trimmed == EndMarker:
+			inTOC = false
+			continue
+		case inTOC:
+			continue
+		}
+		if match := fence.FindStringSubmatch(line); match != nil {
+			inFence = match[1]
+			continue
+		}
+		match := atxHeading.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		text := image.ReplaceAllString(match[2], "$1")
+		text = link.ReplaceAllString(text, "$1")
+		anchor := Slug(text)
+		if n, ok := anchors[anchor]; ok {
+			anchors[anchor] = n + 1
+			anchor = fmt.Sprintf("%s-%d", anchor, n+1)
+		} else {
+			anchors[anchor] = 0
+		}
+		headings = append(headings, Heading{Level: len(match[1]), Text: text, Anchor: anchor})
+	}
+	return headings
+}
+
+// Slug converts heading text into its Github anchor: lower case, punctuation
+// removed and spaces replaced by dashes.
+func Slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Render lists the headings between minLevel and maxLevel as nested links.
+func Render(headings []Heading, minLevel, maxLevel int) string {
+	lines := []string{}
+	for _, h := range headings {
+		if h.Level < minLevel || h.Level > maxLevel {
+			continue
+		}
+		indent := strings.Repeat("  ", h.Level-minLevel)
+		lines = append(lines, fmt.Sprintf("%s- [%s](#%s)", indent, h.Text, h.Anchor))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Update replaces the table of contents between the markers of content. It
+// reports false when content has no markers.
+func Update(content string, minLevel, maxLevel int) (string, bool, error) {
+	lines := strings.Split(content, "\n")
+	start, end := -1, -1
+	inFence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if inFence != "" {
+			if strings.HasPrefix(trimmed, inFence) {
+				inFence = ""
+			}
+			continue
+		}
+		if match := fence.FindStringSubmatch(line); match != nil {
+			inFence = match[1]
+			continue
+		}
+		if trimmed == StartMarker && start < 0 {
+			start = i
+		} else if trimmed == EndMarker && start >= 0 && end < 0 {
+			end = i
+		}
+	}
+	if start < 0 {
+		return content, false, nil
+	}
+	if end < 0 {
+		return content, false, fmt.Errorf("%s on line %d has no matching %s", StartMarker, start+1, EndMarker)
+	}
+
+	toc := Render(Headings(lines), minLevel, maxLevel)
+	updated := append([]string{}, lines[:start+1]...)
+	updated = append(updated, "")
+	if toc != "" {
+		updated = append(updated, toc, "")
+	}
+	updated = append(updated, lines[end:]...)
+	return strings.Join(updated, "\n"), true, nil
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/mockapi/mockapi"
+)
+
+func main() {
+	belt.Run(belt.NewApp("mockapi", mockapi.Command()))
+}
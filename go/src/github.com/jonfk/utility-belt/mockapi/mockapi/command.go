@@ -0,0 +1,71 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Command serves data files as a REST API, run standalone as mockapi or as ub serve mock.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "mock",
+		Usage:     "Serves json or csv files as a REST API with filtering, sorting and pagination",
+		ArgsUsage: "FILE...",
+		Description: "A json FILE holds an object of resources, arrays of objects served as collections\n" +
+			"   or objects served as is, e.g. {\"users\": [{\"id\": 1, \"name\": \"Ada\"}], \"settings\": {}}.\n" +
+			"   A csv FILE is a collection named after the file, users.csv is served as /users.\n\n" +
+			"   Collections are listed with GET /users, filtered by field=value, field_ne, field_gte,\n" +
+			"   field_lte, field_like or q for a full text search, sorted with _sort=field&_order=desc\n" +
+			"   and paginated with _page and _limit, the X-Total-Count header giving the number of\n" +
+			"   matches. Records are read, replaced, merged and deleted with GET, PUT, PATCH and\n" +
+			"   DELETE /users/ID and created with POST /users. The changes are kept in memory\n" +
+			"   unless --write is given.",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "addr,a",
+				Usage: "Address to listen on",
+				Value: ":3000",
+			},
+			cli.StringFlag{
+				Name:  "base",
+				Usage: "Serve the API under `PATH`, e.g. /api",
+				Value: "/",
+			},
+			cli.StringFlag{
+				Name:  "id",
+				Usage: "`FIELD` identifying the records",
+				Value: "id",
+			},
+			cli.BoolFlag{
+				Name:  "write,w",
+				Usage: "Save the changes back to the files",
+			},
+			cli.BoolFlag{
+				Name:  "read-only",
+				Usage: "Reject the changes",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("Expected the json or csv files to serve")
+			}
+			if c.Bool("write") && c.Bool("read-only") {
+				return fmt.Errorf("--write and --read-only go against each other")
+			}
+			base := c.String("base")
+			if !strings.HasPrefix(base, "/") {
+				return fmt.Errorf("Invalid --base %s, expected a path such as /api", base)
+			}
+			db, err := Load(c.String("id"), c.Args()...)
+			if err != nil {
+				return err
+			}
+			server := &Server{DB: db, Base: base, Persist: c.Bool("write"), ReadOnly: c.Bool("read-only")}
+			fmt.Printf("serving on %s\n%s", c.String("addr"), Routes(db, base))
+			return http.ListenAndServe(c.String("addr"), server)
+		},
+	}
+}
@@ -0,0 +1,278 @@
+package mockapi
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Record is an object of the data files, numbers are kept as json.Number so
+// that they are written back as they were read.
+type Record map[string]interface{}
+
+// dataFile is a file the resources were loaded from and are saved back to.
+type dataFile struct {
+	Path string
+	CSV  bool
+	// Resources are the top level keys of a json file, the file name of a csv
+	Resources []string
+	// Fields are the csv columns, new fields are appended when saving
+	Fields []string
+}
+
+// Database holds the resources of the data files: collections of records,
+// e.g. "users": [{"id": 1}], and single objects, e.g. "profile": {}.
+type Database struct {
+	// IDField names the field identifying the records of the collections
+	IDField     string
+	Collections map[string][]Record
+	Singles     map[string]Record
+
+	mu    sync.Mutex
+	files []dataFile
+}
+
+// Load reads the json and csv files into a database. A json file holds an
+// object of resources, a csv file a collection named after the file.
+func Load(idField string, filenames ...string) (*Database, error) {
+	db := &Database{IDField: idField, Collections: map[string][]Record{}, Singles: map[string]Record{}}
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		file := dataFile{Path: filename}
+		if strings.EqualFold(filepath.Ext(filename), ".csv") {
+			file.CSV = true
+			err = db.loadCSV(&file, data)
+		} else {
+			err = db.loadJSON(&file, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Could not read %s: %v", filename, err)
+		}
+		db.files = append(db.files, file)
+	}
+	return db, nil
+}
+
+func (db *Database) addResource(file *dataFile, name string) error {
+	if _, ok := db.Collections[name]; ok {
+		return fmt.Errorf("Resource %s is defined twice", name)
+	}
+	if _, ok := db.Singles[name]; ok {
+		return fmt.Errorf("Resource %s is defined twice", name)
+	}
+	file.Resources = append(file.Resources, name)
+	return nil
+}
+
+func (db *Database) loadJSON(file *dataFile, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var resources map[string]interface{}
+	if err := decoder.Decode(&resources); err != nil {
+		return fmt.Errorf("Expected an object of resources: %v", err)
+	}
+	names := []string{}
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := db.addResource(file, name); err != nil {
+			return err
+		}
+		switch value := resources[name].(type) {
+		case []interface{}:
+			records := []Record{}
+			for _, item := range value {
+				object, ok := item.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("The items of %s must be objects", name)
+				}
+				records = append(records, object)
+			}
+			db.Collections[name] = records
+		case map[string]interface{}:
+			db.Singles[name] = value
+		default:
+			return fmt.Errorf("Resource %s must be an array of objects or an object", name)
+		}
+	}
+	return nil
+}
+
+func (db *Database) loadCSV(file *dataFile, data []byte) error {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("Expected a header row")
+	}
+	name := strings.TrimSuffix(filepath.Base(file.Path), filepath.Ext(file.Path))
+	if err := db.addResource(file, name); err != nil {
+		return err
+	}
+	file.Fields = rows[0]
+	records := []Record{}
+	for _, row := range rows[1:] {
+		record := Record{}
+		for i, field := range file.Fields {
+			if i < len(row) {
+				record[field] = parseCell(row[i])
+			}
+		}
+		records = append(records, record)
+	}
+	db.Collections[name] = records
+	return nil
+}
+
+// parseCell types the numbers and booleans of a csv, keeping the values such
+// as 007 which wouldn't be written back the same as strings.
+func parseCell(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil && value == strings.TrimSpace(value) && json.Valid([]byte(value)) {
+		return json.Number(value)
+	}
+	return value
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number, bool:
+		return fmt.Sprint(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// Lock guards the resources while they are read or changed.
+func (db *Database) Lock()   { db.mu.Lock() }
+func (db *Database) Unlock() { db.mu.Unlock() }
+
+// ID returns the id of record as a string, empty when it has none.
+func (db *Database) ID(record Record) string {
+	if id, ok := record[db.IDField]; ok && id != nil {
+		return fmt.Sprint(id)
+	}
+	return ""
+}
+
+// Find returns the index of the record of collection identified by id, -1
+// when missing.
+func (db *Database) Find(collection, id string) int {
+	for i, record := range db.Collections[collection] {
+		if db.ID(record) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// NewID returns the next integer id when all the ids of collection are
+// integers, a random hexadecimal one otherwise.
+func (db *Database) NewID(collection string) interface{} {
+	max := int64(0)
+	for _, record := range db.Collections[collection] {
+		n, err := strconv.ParseInt(db.ID(record), 10, 64)
+		if err != nil {
+			b := make([]byte, 8)
+			rand.Read(b)
+			return hex.EncodeToString(b)
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return json.Number(strconv.FormatInt(max+1, 10))
+}
+
+// Save writes the resources back to the files they were loaded from, through
+// a temporary file so an error keeps the previous content.
+func (db *Database) Save() error {
+	for _, file := range db.files {
+		var data []byte
+		var err error
+		if file.CSV {
+			data, err = db.marshalCSV(file)
+		} else {
+			resources := map[string]interface{}{}
+			for _, name := range file.Resources {
+				if records, ok := db.Collections[name]; ok {
+					resources[name] = records
+				} else {
+					resources[name] = db.Singles[name]
+				}
+			}
+			data, err = json.MarshalIndent(resources, "", "  ")
+			data = append(data, '\n')
+		}
+		if err != nil {
+			return err
+		}
+		tmp := file.Path + ".tmp"
+		if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, file.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Database) marshalCSV(file dataFile) ([]byte, error) {
+	records := db.Collections[file.Resources[0]]
+	fields := append([]string{}, file.Fields...)
+	known := map[string]bool{}
+	for _, field := range fields {
+		known[field] = true
+	}
+	added := []string{}
+	for _, record := range records {
+		for field := range record {
+			if !known[field] {
+				known[field] = true
+				added = append(added, field)
+			}
+		}
+	}
+	sort.Strings(added)
+	fields = append(fields, added...)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(fields)
+	for _, record := range records {
+		row := make([]string, len(fields))
+		for i, field := range fields {
+			row[i] = formatCell(record[field])
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,176 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLimit is the page size when _page is given without _limit.
+const DefaultLimit = 10
+
+// lookup returns the value of the dotted path field in record, e.g.
+// author.name.
+func lookup(record Record, field string) (interface{}, bool) {
+	var value interface{} = map[string]interface{}(record)
+	for _, key := range strings.Split(field, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// compare orders numbers numerically and everything else as strings.
+func compare(a, b string) int {
+	x, errX := strconv.ParseFloat(a, 64)
+	y, errY := strconv.ParseFloat(b, 64)
+	if errX == nil && errY == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func contains(value interface{}, text string) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			if contains(item, text) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if contains(item, text) {
+				return true
+			}
+		}
+	case nil:
+	default:
+		return strings.Contains(strings.ToLower(fmt.Sprint(v)), text)
+	}
+	return false
+}
+
+// Matches reports whether record satisfies the filters of query, in the
+// fashion of json-server: field=value, repeated to accept several values,
+// field_ne, field_gte, field_lte, field_like for a case insensitive
+// substring and q to search every field. Parameters starting with _ are the
+// options of the list.
+func Matches(record Record, query url.Values) bool {
+	for param, values := range query {
+		if strings.HasPrefix(param, "_") {
+			continue
+		}
+		if param == "q" {
+			if !contains(map[string]interface{}(record), strings.ToLower(values[0])) {
+				return false
+			}
+			continue
+		}
+		field, operator := param, ""
+		for _, suffix := range []string{"_ne", "_gte", "_lte", "_like"} {
+			if strings.HasSuffix(param, suffix) {
+				field, operator = strings.TrimSuffix(param, suffix), suffix
+				break
+			}
+		}
+		value, ok := lookup(record, field)
+		actual := ""
+		if ok && value != nil {
+			actual = fmt.Sprint(value)
+		}
+		matched := false
+		for _, expected := range values {
+			switch operator {
+			case "":
+				matched = ok && actual == expected
+			case "_ne":
+				matched = !ok || actual != expected
+			case "_gte":
+				matched = ok && compare(actual, expected) >= 0
+			case "_lte":
+				matched = ok && compare(actual, expected) <= 0
+			case "_like":
+				matched = ok && strings.Contains(strings.ToLower(actual), strings.ToLower(expected))
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// List filters, sorts and paginates records with the query parameters
+// _sort=field[,field], _order=asc|desc[,...], _page and _limit, returning
+// the page with the number of records matched.
+func List(records []Record, query url.Values) ([]Record, int, error) {
+	matched := []Record{}
+	for _, record := range records {
+		if Matches(record, query) {
+			matched = append(matched, record)
+		}
+	}
+
+	if sortBy := query.Get("_sort"); sortBy != "" {
+		fields := strings.Split(sortBy, ",")
+		orders := strings.Split(query.Get("_order"), ",")
+		sort.SliceStable(matched, func(i, j int) bool {
+			for n, field := range fields {
+				a, _ := lookup(matched[i], field)
+				b, _ := lookup(matched[j], field)
+				c := compare(fmt.Sprint(a), fmt.Sprint(b))
+				if c == 0 {
+					continue
+				}
+				if n < len(orders) && strings.EqualFold(orders[n], "desc") {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+
+	total := len(matched)
+	if query.Get("_page") != "" || query.Get("_limit") != "" {
+		page, limit := 1, DefaultLimit
+		var err error
+		if p := query.Get("_page"); p != "" {
+			if page, err = strconv.Atoi(p); err != nil || page < 1 {
+				return nil, 0, fmt.Errorf("Invalid _page %s", p)
+			}
+		}
+		if l := query.Get("_limit"); l != "" {
+			if limit, err = strconv.Atoi(l); err != nil || limit < 0 {
+				return nil, 0, fmt.Errorf("Invalid _limit %s", l)
+			}
+		}
+		start := (page - 1) * limit
+		if start > total {
+			start = total
+		}
+		end := start + limit
+		if end > total {
+			end = total
+		}
+		matched = matched[start:end]
+	}
+	return matched, total, nil
+}
@@ -0,0 +1,235 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Server serves the resources of a database as a REST API:
+//
+//	GET    /RESOURCE        list, filtered by the query, see List
+//	POST   /RESOURCE        create, the id is generated when missing
+//	GET    /RESOURCE/ID     get
+//	PUT    /RESOURCE/ID     replace
+//	PATCH  /RESOURCE/ID     merge
+//	DELETE /RESOURCE/ID     delete
+//
+// The single objects are read with GET and changed with PUT and PATCH.
+type Server struct {
+	DB *Database
+	// Base is the path prefix of the API, e.g. /api
+	Base string
+	// Persist saves the changes to the data files
+	Persist  bool
+	ReadOnly bool
+}
+
+type apiError struct {
+	status  int
+	message string
+}
+
+func errorf(status int, format string, args ...interface{}) *apiError {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// prototypes usually run on another port than their API
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "*")
+	w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count")
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	status, body, apiErr := s.handle(w, r)
+	if apiErr != nil {
+		status, body = apiErr.status, map[string]string{"error": apiErr.message}
+	}
+	fmt.Printf("%s %s %d\n", r.Method, r.URL.RequestURI(), status)
+	data, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) (int, interface{}, *apiError) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	base := strings.TrimSuffix(s.Base, "/")
+	if !strings.HasPrefix(path+"/", base+"/") {
+		return 0, nil, errorf(http.StatusNotFound, "Not found")
+	}
+	parts := strings.Split(strings.TrimPrefix(path, base), "/")[1:]
+
+	s.DB.Lock()
+	defer s.DB.Unlock()
+	if len(parts) == 0 {
+		if r.Method != "GET" {
+			return 0, nil, errorf(http.StatusMethodNotAllowed, "Method %s not allowed", r.Method)
+		}
+		return http.StatusOK, s.index(), nil
+	}
+	if r.Method != "GET" && s.ReadOnly {
+		return 0, nil, errorf(http.StatusMethodNotAllowed, "The API is read only")
+	}
+
+	name := parts[0]
+	if single, ok := s.DB.Singles[name]; ok && len(parts) == 1 {
+		return s.handleSingle(r, name, single)
+	}
+	if _, ok := s.DB.Collections[name]; !ok || len(parts) > 2 {
+		return 0, nil, errorf(http.StatusNotFound, "No resource %s", strings.Join(parts, "/"))
+	}
+	if len(parts) == 1 {
+		return s.handleCollection(w, r, name)
+	}
+	return s.handleRecord(r, name, parts[1])
+}
+
+// index lists the resources with the number of records of the collections.
+func (s *Server) index() map[string]interface{} {
+	resources := map[string]interface{}{}
+	for name, records := range s.DB.Collections {
+		resources[name] = len(records)
+	}
+	for name := range s.DB.Singles {
+		resources[name] = "object"
+	}
+	return resources
+}
+
+func (s *Server) handleSingle(r *http.Request, name string, single Record) (int, interface{}, *apiError) {
+	switch r.Method {
+	case "GET":
+		return http.StatusOK, single, nil
+	case "PUT", "PATCH":
+		body, apiErr := readRecord(r)
+		if apiErr != nil {
+			return 0, nil, apiErr
+		}
+		if r.Method == "PATCH" {
+			for key, value := range body {
+				single[key] = value
+			}
+			body = single
+		}
+		s.DB.Singles[name] = body
+		return s.saved(http.StatusOK, body)
+	default:
+		return 0, nil, errorf(http.StatusMethodNotAllowed, "Method %s not allowed on %s", r.Method, name)
+	}
+}
+
+func (s *Server) handleCollection(w http.ResponseWriter, r *http.Request, name string) (int, interface{}, *apiError) {
+	switch r.Method {
+	case "GET":
+		records, total, err := List(s.DB.Collections[name], r.URL.Query())
+		if err != nil {
+			return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		return http.StatusOK, records, nil
+	case "POST":
+		record, apiErr := readRecord(r)
+		if apiErr != nil {
+			return 0, nil, apiErr
+		}
+		if id := s.DB.ID(record); id == "" {
+			record[s.DB.IDField] = s.DB.NewID(name)
+		} else if s.DB.Find(name, id) >= 0 {
+			return 0, nil, errorf(http.StatusConflict, "%s %s already exists", name, id)
+		}
+		s.DB.Collections[name] = append(s.DB.Collections[name], record)
+		w.Header().Set("Location", strings.TrimSuffix(s.Base, "/")+"/"+name+"/"+s.DB.ID(record))
+		return s.saved(http.StatusCreated, record)
+	default:
+		return 0, nil, errorf(http.StatusMethodNotAllowed, "Method %s not allowed on %s", r.Method, name)
+	}
+}
+
+func (s *Server) handleRecord(r *http.Request, name, id string) (int, interface{}, *apiError) {
+	i := s.DB.Find(name, id)
+	if i < 0 {
+		return 0, nil, errorf(http.StatusNotFound, "No %s %s", name, id)
+	}
+	records := s.DB.Collections[name]
+	switch r.Method {
+	case "GET":
+		return http.StatusOK, records[i], nil
+	case "PUT", "PATCH":
+		body, apiErr := readRecord(r)
+		if apiErr != nil {
+			return 0, nil, apiErr
+		}
+		// the id of the path wins over the one of the body
+		body[s.DB.IDField] = records[i][s.DB.IDField]
+		if r.Method == "PATCH" {
+			for key, value := range body {
+				records[i][key] = value
+			}
+			body = records[i]
+		}
+		records[i] = body
+		return s.saved(http.StatusOK, body)
+	case "DELETE":
+		s.DB.Collections[name] = append(records[:i:i], records[i+1:]...)
+		return s.saved(http.StatusOK, map[string]interface{}{})
+	default:
+		return 0, nil, errorf(http.StatusMethodNotAllowed, "Method %s not allowed on %s/%s", r.Method, name, id)
+	}
+}
+
+// saved persists the change when asked then returns the response.
+func (s *Server) saved(status int, body interface{}) (int, interface{}, *apiError) {
+	if s.Persist {
+		if err := s.DB.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not save the data: %v\n", err)
+			return 0, nil, errorf(http.StatusInternalServerError, "Could not save the data: %v", err)
+		}
+	}
+	return status, body, nil
+}
+
+func readRecord(r *http.Request) (Record, *apiError) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	var record Record
+	if err := decoder.Decode(&record); err != nil || record == nil {
+		return nil, errorf(http.StatusBadRequest, "Expected a json object")
+	}
+	return record, nil
+}
+
+// Routes describes the endpoints of the resources of db under base.
+func Routes(db *Database, base string) string {
+	base = strings.TrimSuffix(base, "/")
+	lines := []string{}
+	names := []string{}
+	for name := range db.Collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s/%s  %d records", base, name, len(db.Collections[name])))
+	}
+	names = names[:0]
+	for name := range db.Singles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s/%s  object", base, name))
+	}
+	return "  " + strings.Join(lines, "\n  ") + "\n"
+}
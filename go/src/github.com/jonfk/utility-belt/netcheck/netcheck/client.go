@@ -0,0 +1,225 @@
+package netcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Client measures the network between this machine and a netcheck server.
+type Client struct {
+	// Server is the URL of the server
+	Server string
+	Token  string
+	// HTTP must not time out, the transfers last as long as asked
+	HTTP *http.Client
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.Server, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	return req.WithContext(ctx), nil
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s: %s %s", resp.Request.URL, resp.Status, strings.TrimSpace(string(message)))
+}
+
+// LatencyStats are the round trip times of the pings.
+type LatencyStats struct {
+	Samples          []time.Duration
+	Min, Median, Max time.Duration
+	Average, Jitter  time.Duration
+}
+
+// NewLatencyStats computes the statistics of samples, the jitter being the
+// mean difference between consecutive samples.
+func NewLatencyStats(samples []time.Duration) LatencyStats {
+	stats := LatencyStats{Samples: samples}
+	if len(samples) == 0 {
+		return stats
+	}
+	sorted := append([]time.Duration{}, samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	stats.Min, stats.Median, stats.Max = sorted[0], sorted[len(sorted)/2], sorted[len(sorted)-1]
+	var total, jitter time.Duration
+	for i, sample := range samples {
+		total += sample
+		if i > 0 {
+			diff := sample - samples[i-1]
+			if diff < 0 {
+				diff = -diff
+			}
+			jitter += diff
+		}
+	}
+	stats.Average = total / time.Duration(len(samples))
+	if len(samples) > 1 {
+		stats.Jitter = jitter / time.Duration(len(samples)-1)
+	}
+	return stats
+}
+
+// Latency pings the server count times, interval apart, over a connection
+// opened by a first ping which isn't counted.
+func (c *Client) Latency(count int, interval time.Duration) (LatencyStats, error) {
+	samples := []time.Duration{}
+	for i := 0; i <= count; i++ {
+		req, err := c.newRequest(context.Background(), "GET", "/ping", nil)
+		if err != nil {
+			return LatencyStats{}, err
+		}
+		start := time.Now()
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return LatencyStats{}, err
+		}
+		rtt := time.Since(start)
+		err = checkStatus(resp)
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return LatencyStats{}, err
+		}
+		if i > 0 {
+			samples = append(samples, rtt)
+		}
+		if i < count {
+			time.Sleep(interval)
+		}
+	}
+	return NewLatencyStats(samples), nil
+}
+
+// Throughput is the data transferred by all the streams.
+type Throughput struct {
+	Bytes   int64
+	Elapsed time.Duration
+	Streams int
+}
+
+func (t Throughput) BitsPerSecond() float64 {
+	if t.Elapsed <= 0 {
+		return 0
+	}
+	return float64(t.Bytes) * 8 / t.Elapsed.Seconds()
+}
+
+// Estimate returns how long transferring size bytes takes at this speed.
+func (t Throughput) Estimate(size int64) time.Duration {
+	bps := t.BitsPerSecond()
+	if bps == 0 {
+		return 0
+	}
+	return time.Duration(float64(size) * 8 / bps * float64(time.Second))
+}
+
+// Download reads from the server over streams connections for duration.
+func (c *Client) Download(duration time.Duration, streams int) (Throughput, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+	var total int64
+	start := time.Now()
+	err := parallel(ctx, streams, func() error {
+		req, err := c.newRequest(ctx, "GET", "/download", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if err := checkStatus(resp); err != nil {
+			return err
+		}
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := resp.Body.Read(buf)
+			atomic.AddInt64(&total, int64(n))
+			if err != nil {
+				return err
+			}
+		}
+	})
+	return Throughput{Bytes: total, Elapsed: time.Since(start), Streams: streams}, err
+}
+
+// deadlineReader repeats the payload until its deadline.
+type deadlineReader struct {
+	deadline time.Time
+	offset   int
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	if time.Now().After(r.deadline) {
+		return 0, io.EOF
+	}
+	n := copy(p, payload()[r.offset:])
+	r.offset = (r.offset + n) % ChunkSize
+	return n, nil
+}
+
+// Upload sends to the server over streams connections for duration, the
+// bytes counted being the ones the server received.
+func (c *Client) Upload(duration time.Duration, streams int) (Throughput, error) {
+	var total int64
+	start := time.Now()
+	deadline := start.Add(duration)
+	err := parallel(nil, streams, func() error {
+		req, err := c.newRequest(context.Background(), "POST", "/upload", &deadlineReader{deadline: deadline})
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if err := checkStatus(resp); err != nil {
+			return err
+		}
+		var result UploadResult
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("Invalid response of the server: %v", err)
+		}
+		atomic.AddInt64(&total, result.Bytes)
+		return nil
+	})
+	return Throughput{Bytes: total, Elapsed: time.Since(start), Streams: streams}, err
+}
+
+// parallel runs transfer on n goroutines, returning the first error which
+// isn't caused by the end of ctx, which may be nil.
+func parallel(ctx context.Context, n int, transfer func() error) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := transfer(); err != nil && err != io.EOF && (ctx == nil || ctx.Err() == nil) {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
@@ -0,0 +1,164 @@
+package netcheck
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/inspection-server/inspectionserver"
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/jonfk/utility-belt/internal/httpx"
+	"github.com/urfave/cli"
+)
+
+const DefaultPort = "8765"
+
+var tokenFlag = cli.StringFlag{
+	Name:  "token,t",
+	Usage: "Token shared by the server and the clients, set it with `ub config set-secret netcheck.token`",
+}
+
+// Command measures the latency and throughput between two machines, run
+// standalone as netcheck or as ub netcheck.
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "netcheck",
+		Usage: "Measure the latency and throughput between this machine and one of yours",
+		Description: "Run `netcheck serve` on the remote machine then `netcheck run http://HOST:" + DefaultPort + "` here,\n" +
+			"   e.g. with --size 500GB to estimate how long moving a photo library takes. Both ends share a token.",
+		Subcommands: []cli.Command{
+			{
+				Name:  "serve",
+				Usage: "Answer the measurements of the clients with the token",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "addr,a",
+						Usage: "Address to listen on",
+						Value: ":" + DefaultPort,
+					},
+					tokenFlag,
+				},
+				Action: func(c *cli.Context) error {
+					token, err := resolveToken(c)
+					if err != nil {
+						return err
+					}
+					if token == "" {
+						if token, err = NewToken(); err != nil {
+							return err
+						}
+						fmt.Printf("No token configured, the clients need --token %s\n", token)
+					}
+					fmt.Printf("serving on %s\n", c.String("addr"))
+					return http.ListenAndServe(c.String("addr"), Handler(token))
+				},
+			},
+			{
+				Name:      "run",
+				Usage:     "Measure the latency, download and upload against a server",
+				ArgsUsage: "URL",
+				Flags: []cli.Flag{
+					tokenFlag,
+					cli.IntFlag{
+						Name:  "pings",
+						Usage: "Number of pings measuring the latency",
+						Value: 10,
+					},
+					cli.DurationFlag{
+						Name:  "duration,d",
+						Usage: "Duration of the download and of the upload",
+						Value: 10 * time.Second,
+					},
+					cli.IntFlag{
+						Name:  "streams",
+						Usage: "Number of parallel connections of the transfers",
+						Value: 4,
+					},
+					cli.StringFlag{
+						Name:  "size",
+						Usage: "Estimate how long transferring `SIZE`, e.g. 500GB, takes each way",
+					},
+					cli.BoolFlag{
+						Name:  "no-download",
+						Usage: "Skip the download",
+					},
+					cli.BoolFlag{
+						Name:  "no-upload",
+						Usage: "Skip the upload",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("Expected the URL of the server")
+					}
+					if c.Int("streams") < 1 {
+						return fmt.Errorf("Invalid --streams %d", c.Int("streams"))
+					}
+					var size int64
+					if c.String("size") != "" {
+						var err error
+						if size, err = inspectionserver.ParseSize(c.String("size")); err != nil {
+							return err
+						}
+					}
+					token, err := resolveToken(c)
+					if err != nil {
+						return err
+					}
+					if token == "" {
+						return fmt.Errorf("No token, pass the one of the server with --token")
+					}
+					// the transfers last as long as asked, no timeout nor retries
+					httpClient, err := httpx.NewClient(httpx.Options{})
+					if err != nil {
+						return err
+					}
+					client := &Client{Server: c.Args().First(), Token: token, HTTP: httpClient}
+
+					latency, err := client.Latency(c.Int("pings"), 200*time.Millisecond)
+					if err != nil {
+						return err
+					}
+					fmt.Println(FormatLatency(latency))
+					results := []struct {
+						name string
+						skip bool
+						run  func(time.Duration, int) (Throughput, error)
+					}{
+						{"Download", c.Bool("no-download"), client.Download},
+						{"Upload", c.Bool("no-upload"), client.Upload},
+					}
+					estimates := []string{}
+					for _, result := range results {
+						if result.skip {
+							continue
+						}
+						fmt.Fprintf(os.Stderr, "%s for %s...\n", strings.ToLower(result.name), c.Duration("duration"))
+						throughput, err := result.run(c.Duration("duration"), c.Int("streams"))
+						if err != nil {
+							return err
+						}
+						fmt.Printf("%-9s %s\n", result.name, FormatThroughput(throughput))
+						if size > 0 {
+							estimates = append(estimates, fmt.Sprintf("%s to %s", FormatEstimate(throughput.Estimate(size)), strings.ToLower(result.name)))
+						}
+					}
+					if len(estimates) > 0 {
+						fmt.Printf("%s takes about %s\n", formatBytes(size), strings.Join(estimates, ", "))
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func resolveToken(c *cli.Context) (string, error) {
+	cfg, err := config.Load("netcheck")
+	if err != nil {
+		return "", err
+	}
+	return cfg.Secret(c, "token")
+}
@@ -0,0 +1,53 @@
+package netcheck
+
+import (
+	"fmt"
+	"time"
+)
+
+func formatBytes(n int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value, unit := float64(n), 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+}
+
+func FormatLatency(stats LatencyStats) string {
+	return fmt.Sprintf("Latency   %s ms avg, %s min, %s median, %s max, %s jitter over %d pings",
+		formatMillis(stats.Average), formatMillis(stats.Min), formatMillis(stats.Median), formatMillis(stats.Max),
+		formatMillis(stats.Jitter), len(stats.Samples))
+}
+
+// FormatThroughput renders the speed in Mbit/s, as ISPs advertise it, and
+// in MB/s, as file transfers show it.
+func FormatThroughput(t Throughput) string {
+	bps := t.BitsPerSecond()
+	return fmt.Sprintf("%.1f Mbit/s (%.1f MB/s), %s in %s over %d streams",
+		bps/1e6, bps/8/1e6, formatBytes(t.Bytes), t.Elapsed.Round(100*time.Millisecond), t.Streams)
+}
+
+// FormatEstimate rounds d to a precision fitting its length.
+func FormatEstimate(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "forever"
+	case d >= 24*time.Hour:
+		d = d.Round(time.Hour)
+		return fmt.Sprintf("%dd%dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+	case d >= time.Hour:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%dh%02dm", d/time.Hour, d%time.Hour/time.Minute)
+	default:
+		return d.Round(time.Second).String()
+	}
+}
@@ -0,0 +1,117 @@
+package netcheck
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChunkSize is the size of the random payload repeated by the transfers,
+// random so that a compressing proxy or VPN can't inflate the results.
+const ChunkSize = 1 << 20
+
+// MaxTransfer caps a download, the client stops reading long before.
+const MaxTransfer = 1 << 40
+
+var (
+	payloadOnce sync.Once
+	payloadData []byte
+)
+
+func payload() []byte {
+	payloadOnce.Do(func() {
+		payloadData = make([]byte, ChunkSize)
+		rand.Read(payloadData)
+	})
+	return payloadData
+}
+
+// UploadResult is the response of the server to an upload.
+type UploadResult struct {
+	Bytes   int64   `json:"bytes"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Handler serves the measurements to the clients presenting token as a
+// bearer token: GET /ping answers right away, GET /download?bytes=N streams
+// N bytes, all that a client reads by default, and POST /upload discards the
+// body and reports its size.
+func Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		size := int64(MaxTransfer)
+		if n := r.URL.Query().Get("bytes"); n != "" {
+			var err error
+			if size, err = strconv.ParseInt(n, 10, 64); err != nil || size < 0 || size > MaxTransfer {
+				http.Error(w, "Invalid bytes", http.StatusBadRequest)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		start := time.Now()
+		sent := int64(0)
+		data := payload()
+		for sent < size {
+			chunk := data
+			if remaining := size - sent; remaining < int64(len(chunk)) {
+				chunk = chunk[:remaining]
+			}
+			n, err := w.Write(chunk)
+			sent += int64(n)
+			if err != nil {
+				break
+			}
+		}
+		fmt.Printf("sent %s to %s in %s\n", formatBytes(sent), r.RemoteAddr, time.Since(start).Round(time.Millisecond))
+	})
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		start := time.Now()
+		n, err := io.Copy(ioutil.Discard, r.Body)
+		elapsed := time.Since(start)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Printf("received %s from %s in %s\n", formatBytes(n), r.RemoteAddr, elapsed.Round(time.Millisecond))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(UploadResult{Bytes: n, Seconds: elapsed.Seconds()})
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// NewToken returns a random token for a server started without one.
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
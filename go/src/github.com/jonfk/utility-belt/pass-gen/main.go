@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/pass-gen/passgen"
+)
+
+func main() {
+	belt.Run(belt.NewApp("pass-gen", passgen.Command()))
+}
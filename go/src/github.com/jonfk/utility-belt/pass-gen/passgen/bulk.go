@@ -0,0 +1,107 @@
+package passgen
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Profile is a preset of the password generation options.
+type Profile struct {
+	Length        int
+	ExcludedTypes []CharType
+}
+
+var Profiles = map[string]Profile{
+	"default": {Length: DefaultLength},
+	"strong":  {Length: 32},
+	"alnum":   {Length: DefaultLength, ExcludedTypes: []CharType{SpecialCharType}},
+	"pin":     {Length: 6, ExcludedTypes: []CharType{SpecialCharType, UpperCharType, LowerCharType}},
+}
+
+func ProfileNames() []string {
+	names := []string{}
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadUsernames reads the first column of a CSV, skipping a header row
+// named username.
+func ReadUsernames(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	usernames := []string{}
+	for i, record := range records {
+		username := strings.TrimSpace(record[0])
+		if username == "" || (i == 0 && strings.EqualFold(username, "username")) {
+			continue
+		}
+		usernames = append(usernames, username)
+	}
+	return usernames, nil
+}
+
+// GenerateUsernames numbers count usernames after prefix, e.g. user001.
+func GenerateUsernames(prefix string, count int) []string {
+	width := len(fmt.Sprint(count))
+	if width < 3 {
+		width = 3
+	}
+	usernames := []string{}
+	for i := 1; i <= count; i++ {
+		usernames = append(usernames, fmt.Sprintf("%s%0*d", prefix, width, i))
+	}
+	return usernames
+}
+
+// WriteBulk writes a username,password CSV of a password per username, with
+// a bcrypt column of the password hashed with cost when positive.
+func WriteBulk(w io.Writer, usernames []string, profile Profile, cost int) error {
+	out := csv.NewWriter(w)
+	header := []string{"username", "password"}
+	if cost > 0 {
+		header = append(header, "bcrypt")
+	}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, username := range usernames {
+		randInts, err := GenerateRandomInts(profile.Length, nil, profile.ExcludedTypes)
+		if err != nil {
+			return err
+		}
+		password := IntsToString(randInts)
+		record := []string{username, password}
+		if cost > 0 {
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+			if err != nil {
+				return err
+			}
+			record = append(record, string(hash))
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func openInput(filename string) (io.ReadCloser, error) {
+	if filename == "-" {
+		return os.Stdin, nil
+	}
+	return os.Open(filename)
+}
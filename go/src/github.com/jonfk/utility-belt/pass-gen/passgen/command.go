@@ -0,0 +1,362 @@
+package passgen
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const DefaultLength = 16
+
+// Command generates passwords, run standalone as pass-gen or as ub pass gen.
+func Command() cli.Command {
+	return cli.Command{
+		Name:   "gen",
+		Usage:  "Generates a random password",
+		Action: generateAction,
+		Flags:  flags,
+		Subcommands: []cli.Command{
+			{
+				Name:      "bulk",
+				Usage:     "Generate a username,password CSV for provisioning accounts",
+				ArgsUsage: "[USERNAMES.csv|-]",
+				Description: "Usernames are read from the first column of the CSV, or numbered after\n" +
+					"   --prefix with --count.",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "profile,p",
+						Usage: "Password `PROFILE`: " + strings.Join(ProfileNames(), ", "),
+						Value: "default",
+					},
+					cli.IntFlag{
+						Name:  "length,l",
+						Usage: "Password length, overriding the profile",
+					},
+					cli.IntFlag{
+						Name:  "count,c",
+						Usage: "Generate `N` usernames instead of reading them",
+					},
+					cli.StringFlag{
+						Name:  "prefix",
+						Usage: "Prefix of the generated usernames",
+						Value: "user",
+					},
+					cli.BoolFlag{
+						Name:  "bcrypt",
+						Usage: "Add a column with the bcrypt hash of the password",
+					},
+					cli.IntFlag{
+						Name:  "cost",
+						Usage: "bcrypt cost",
+						Value: bcrypt.DefaultCost,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					profile, ok := Profiles[c.String("profile")]
+					if !ok {
+						return fmt.Errorf("Unknown profile %s, expected one of %s", c.String("profile"), strings.Join(ProfileNames(), ", "))
+					}
+					if c.IsSet("length") {
+						profile.Length = c.Int("length")
+					}
+
+					var usernames []string
+					switch {
+					case c.IsSet("count") && c.NArg() > 0:
+						return fmt.Errorf("Pass either a CSV of usernames or --count, not both")
+					case c.IsSet("count"):
+						usernames = GenerateUsernames(c.String("prefix"), c.Int("count"))
+					case c.NArg() == 1:
+						in, err := openInput(c.Args().First())
+						if err != nil {
+							return err
+						}
+						defer in.Close()
+						if usernames, err = ReadUsernames(in); err != nil {
+							return err
+						}
+					default:
+						return fmt.Errorf("Expected a CSV of usernames or --count")
+					}
+
+					cost := 0
+					if c.Bool("bcrypt") {
+						cost = c.Int("cost")
+					}
+					return WriteBulk(os.Stdout, usernames, profile, cost)
+				},
+			},
+			dueCommand(),
+			saveCommand(),
+			getCommand(),
+		},
+	}
+}
+
+func generateAction(c *cli.Context) error {
+	disableCoreDumps()
+	secret, policy, err := generatePassword(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer secret.Destroy()
+
+	if label := c.String("record"); label != "" {
+		path, err := recordsPath(c)
+		if err != nil {
+			return err
+		}
+		if err := AppendRecord(path, Record{Label: label, Date: time.Now().UTC(), Policy: policy}); err != nil {
+			return err
+		}
+	}
+	return output(c, secret.Bytes())
+}
+
+// output shows password as asked by the outputFlags, writing it directly to
+// the terminal, the clipboard or stdout so that no formatted copy lingers.
+func output(c *cli.Context, password []byte) error {
+	switch {
+	case c.Bool("copy") && c.Bool("hidden"):
+		return fmt.Errorf("Pass either --copy or --hidden, not both")
+	case c.Bool("copy"):
+		clearAfter := DefaultClearAfter
+		if c.IsSet("clear-after") {
+			clearAfter = time.Duration(c.Int("clear-after")) * time.Second
+		}
+		return CopyToClipboard(password, clearAfter)
+	case c.Bool("hidden"):
+		return RevealHidden(password, time.Duration(c.Int("reveal-for"))*time.Second)
+	}
+	if _, err := os.Stdout.Write(password); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
+
+// generatePassword generates a password following the generationFlags,
+// returning it with its Policy. The caller destroys the secret.
+func generatePassword(c *cli.Context) (*Secret, string, error) {
+	length := c.Int("length")
+	excludedTypes := []CharType{}
+	excludedChars := []int32{}
+
+	if c.Bool("special") {
+		excludedTypes = append(excludedTypes, SpecialCharType)
+	}
+	if c.Bool("number") {
+		excludedTypes = append(excludedTypes, NumberCharType)
+	}
+	if c.Bool("upper") {
+		excludedTypes = append(excludedTypes, UpperCharType)
+	}
+	if c.Bool("lower") {
+		excludedTypes = append(excludedTypes, LowerCharType)
+	}
+	for _, ch := range c.String("exclude") {
+		excludedChars = append(excludedChars, int32(ch))
+	}
+
+	if c.Bool("verbose") {
+		fmt.Printf("Characters to be excluded:")
+		for _, ch := range excludedChars {
+			fmt.Printf(" %c", rune(ch))
+		}
+		fmt.Println()
+	}
+
+	var randInts []int32
+	var err error
+	policy := ""
+	rules := c.String("rules")
+	if site := c.String("site"); site != "" {
+		if rules != "" {
+			return nil, "", fmt.Errorf("Pass either --rules or --site, not both")
+		}
+		if rules, err = FetchSiteRules(c.String("rules-list"), site); err != nil {
+			return nil, "", err
+		}
+		if c.Bool("verbose") {
+			fmt.Printf("Rules of %s: %s\n", site, rules)
+		}
+	}
+	if rules != "" && c.String("preset") != "" {
+		return nil, "", fmt.Errorf("Pass either --preset or --rules, not both")
+	}
+	if rules != "" {
+		parsed, err := ParseRules(rules)
+		if err != nil {
+			return nil, "", err
+		}
+		if length, err = parsed.Length(length, c.IsSet("length")); err != nil {
+			return nil, "", err
+		}
+		randInts, err = GenerateWithRules(parsed, length, excludedChars, excludedTypes)
+		if err != nil {
+			return nil, "", err
+		}
+		policy = fmt.Sprintf("rules %q, ", rules)
+	} else if name := c.String("preset"); name != "" {
+		preset, ok := Presets[name]
+		if !ok {
+			return nil, "", fmt.Errorf("Unknown preset %s, expected one of %s", name, strings.Join(PresetNames(), ", "))
+		}
+		if !c.IsSet("length") {
+			length = preset.Length
+		} else if length < preset.Length {
+			return nil, "", fmt.Errorf("The %s preset requires at least %d characters", name, preset.Length)
+		}
+		randInts, err = GenerateWithPreset(preset, length, excludedChars, excludedTypes)
+		policy = "preset " + name + ", "
+	} else {
+		randInts, err = GenerateRandomInts(length, excludedChars, excludedTypes)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if c.Bool("verbose") {
+		// never the characters themselves, verbose output ends up in logs
+		fmt.Printf("Generated %d characters\n", len(randInts))
+	}
+	return SecretFromInts(randInts), policy + Policy(length, excludedTypes, excludedChars), nil
+}
+
+// generationFlags are the options of the generated passwords, shared by gen
+// and save.
+var generationFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "preset",
+		Usage: presetUsage(),
+	},
+	cli.StringFlag{
+		Name:  "rules",
+		Usage: "Follow the password `RULES` of a site in Apple's passwordrules format, e.g. 'minlength: 12; required: upper, lower, digit; allowed: [-_.]'",
+	},
+	cli.StringFlag{
+		Name:  "site",
+		Usage: "Follow the password rules of `DOMAIN` from the --rules-list",
+	},
+	cli.StringFlag{
+		Name:  "rules-list",
+		Usage: "`URL` or file of the password rules by domain used by --site",
+		Value: DefaultRulesList,
+	},
+	cli.IntFlag{
+		Name:  "length,l",
+		Usage: "Password Length",
+		Value: DefaultLength,
+	},
+	cli.BoolFlag{
+		Name:  "special,s",
+		Usage: "Exclude special characters: !\"#$%&()*+,-./:;<=>?@[\\]^_`{|}~",
+	},
+	cli.BoolFlag{
+		Name:  "number,n",
+		Usage: "Exclude numbers",
+	},
+	cli.BoolFlag{
+		Name:  "upper,u",
+		Usage: "Exclude uppercase characters",
+	},
+	cli.BoolFlag{
+		Name:  "lower",
+		Usage: "Exclude lowercase characters",
+	},
+	cli.BoolFlag{
+		Name:  "verbose, v",
+		Usage: "verbose output",
+	},
+	cli.StringFlag{
+		Name:  "exclude, e",
+		Usage: "Characters to be excluded",
+		Value: "",
+	},
+}
+
+// outputFlags choose how a password is shown, shared by gen and get.
+var outputFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "hidden",
+		Usage: "Show the password on the terminal until a key is pressed then clear it, keeping it out of the scrollback",
+	},
+	cli.IntFlag{
+		Name:  "reveal-for",
+		Usage: "With --hidden, clear the password after `N` seconds instead of on a key press",
+	},
+	cli.BoolFlag{
+		Name:  "copy,c",
+		Usage: "Copy the password to the clipboard instead of printing it, then clear the clipboard",
+	},
+	cli.IntFlag{
+		Name:  "clear-after",
+		Usage: "With --copy, clear the clipboard after `N` seconds",
+		Value: int(DefaultClearAfter / time.Second),
+	},
+}
+
+var flags = append(append(append([]cli.Flag{}, generationFlags...), outputFlags...),
+	cli.StringFlag{
+		Name:  "record",
+		Usage: "Record the date and policy of the password, not the password, under `LABEL` for the due subcommand",
+	},
+	recordsFlag,
+)
+
+func IntsToString(nums []int32) string {
+	buf := bytes.Buffer{}
+
+	for _, x := range nums {
+		buf.WriteRune(rune(x))
+	}
+	return buf.String()
+}
+
+func GenerateRandomInts(length int, excluded []int32, excludedTypes []CharType) ([]int32, error) {
+	// Filter characters outside of valid ascii range (no unicode or nonvisible chars)
+	toExclude := []int32{}
+	for _, x := range excluded {
+		if x >= 32 && x < 127 {
+			toExclude = append(toExclude, x)
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("Invalid length %d", length)
+	}
+	// allocated once so growing it leaves no copies of the password behind
+	randInts := make([]int32, 0, length)
+
+	for i := 0; i < length; i++ {
+
+		bigRandNum, err := rand.Int(rand.Reader, big.NewInt(95))
+		if err != nil {
+			return randInts, fmt.Errorf("Error generating random number: %v", err)
+		}
+		randNum := int32(bigRandNum.Int64())
+		randNum += 32
+		if !containsInt32(randNum, toExclude) && !containsCharType(GetCharType(randNum), excludedTypes) {
+			randInts = append(randInts, randNum)
+		} else {
+			i -= 1
+		}
+	}
+	return randInts, nil
+}
+
+func containsInt32(a int32, ints []int32) bool {
+	for _, x := range ints {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package passgen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jonfk/utility-belt/clip/clip"
+)
+
+// DefaultClearAfter is how long a copied password stays in the clipboard.
+const DefaultClearAfter = 45 * time.Second
+
+// CopyToClipboard copies secret to the clipboard and waits for clearAfter,
+// or an interrupt, to clear it unless something else was copied since.
+func CopyToClipboard(secret []byte, clearAfter time.Duration) error {
+	if err := clip.WriteClipboard(secret); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Copied to the clipboard, clearing in %s\n", clearAfter)
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupted)
+	select {
+	case <-time.After(clearAfter):
+	case <-interrupted:
+	}
+
+	current, err := clip.ReadClipboard()
+	if err != nil {
+		return err
+	}
+	defer wipe(current)
+	if !bytes.Equal(bytes.TrimRight(current, "\r\n"), secret) {
+		return nil
+	}
+	return clip.WriteClipboard([]byte{})
+}
@@ -0,0 +1,83 @@
+package passgen
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// clearLine returns the cursor to the start of the line and erases it.
+const clearLine = "\r\033[2K"
+
+// RevealHidden shows secret on the terminal without a newline, so it never
+// reaches the scrollback, until a key is pressed or for the given duration
+// when positive, then clears the line. An interrupt clears it too.
+func RevealHidden(secret []byte, duration time.Duration) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("--hidden needs a terminal: %v", err)
+	}
+	defer tty.Close()
+
+	// stty disables the echo and line buffering so a single key press is read
+	// and isn't printed after the secret.
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return err
+	}
+	if _, err := stty(tty, "-echo", "-icanon", "min", "1"); err != nil {
+		return err
+	}
+	defer stty(tty, saved)
+
+	prompt := "(press any key to hide)"
+	if duration > 0 {
+		prompt = fmt.Sprintf("(hidden in %s)", duration)
+	}
+	// written directly rather than formatted so no copy is left in the
+	// buffers of fmt
+	if _, err := tty.Write(secret); err != nil {
+		return err
+	}
+	fmt.Fprintf(tty, "  %s", prompt)
+	defer fmt.Fprint(tty, clearLine)
+
+	// an interrupt hides the secret and restores the terminal as well,
+	// through the deferred calls
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupted)
+
+	if duration > 0 {
+		select {
+		case <-time.After(duration):
+		case <-interrupted:
+		}
+		return nil
+	}
+	pressed := make(chan error, 1)
+	go func() {
+		_, err := tty.Read(make([]byte, 1))
+		pressed <- err
+	}()
+	select {
+	case err := <-pressed:
+		return err
+	case <-interrupted:
+		return nil
+	}
+}
+
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %v", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
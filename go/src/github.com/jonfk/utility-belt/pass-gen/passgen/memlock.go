@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package passgen
+
+import "syscall"
+
+// lockMemory keeps b out of swap, it fails when the locked memory limit of
+// the user is reached in which case the secret is only zeroed.
+func lockMemory(b []byte) bool {
+	return len(b) > 0 && syscall.Mlock(b) == nil
+}
+
+func unlockMemory(b []byte) {
+	syscall.Munlock(b)
+}
+
+// disableCoreDumps keeps the secrets out of the core file of a crash.
+func disableCoreDumps() {
+	syscall.Setrlimit(syscall.RLIMIT_CORE, &syscall.Rlimit{})
+}
@@ -0,0 +1,12 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package passgen
+
+func lockMemory(b []byte) bool {
+	return false
+}
+
+func unlockMemory(b []byte) {}
+
+func disableCoreDumps() {}
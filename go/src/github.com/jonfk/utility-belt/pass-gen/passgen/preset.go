@@ -0,0 +1,150 @@
+package passgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Requirement asks for at least Min characters of one of Types.
+type Requirement struct {
+	Types []CharType
+	Min   int
+}
+
+// Preset encodes the password rules of a policy document.
+type Preset struct {
+	Description string
+	Length      int
+	// Requirements are the minimums of characters of given classes
+	Requirements []Requirement
+	// Classes is the number of the upper, lower, number and special classes
+	// the password must use, as complexity rules count them
+	Classes int
+	// Forbidden are the characters the policy rejects, excluded as --exclude
+	// does
+	Forbidden string
+}
+
+var Presets = map[string]Preset{
+	"nist": {
+		Description: "NIST SP 800-63B, 15 characters without composition rules",
+		Length:      15,
+	},
+	"pci": {
+		Description: "PCI DSS 4.0, 12 characters with letters and numbers",
+		Length:      12,
+		Requirements: []Requirement{
+			{Types: []CharType{UpperCharType, LowerCharType}, Min: 1},
+			{Types: []CharType{NumberCharType}, Min: 1},
+		},
+	},
+	"ad-complexity": {
+		Description: "Active Directory complexity, 14 characters of 3 of the 4 classes without spaces",
+		Length:      14,
+		Classes:     3,
+		// the space isn't one of the special characters the complexity
+		// rules count
+		Forbidden: " ",
+	},
+}
+
+func PresetNames() []string {
+	names := []string{}
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check reports why the preset can't be met with the excluded types in a
+// password of length.
+func (p Preset) Check(length int, excludedTypes []CharType) error {
+	total := 0
+	for _, requirement := range p.Requirements {
+		allowed := false
+		for _, t := range requirement.Types {
+			allowed = allowed || !containsCharType(t, excludedTypes)
+		}
+		if !allowed {
+			return fmt.Errorf("The preset requires characters which are excluded")
+		}
+		total += requirement.Min
+	}
+	classes := 0
+	for _, t := range []CharType{UpperCharType, LowerCharType, NumberCharType, SpecialCharType} {
+		if !containsCharType(t, excludedTypes) {
+			classes++
+		}
+	}
+	if p.Classes > classes {
+		return fmt.Errorf("The preset requires %d character classes, only %d are not excluded", p.Classes, classes)
+	}
+	if p.Classes > total {
+		total = p.Classes
+	}
+	if length < total {
+		return fmt.Errorf("The preset requires at least %d characters, got a length of %d", total, length)
+	}
+	return nil
+}
+
+// Satisfied reports whether password follows the rules of the preset.
+func (p Preset) Satisfied(password []int32) bool {
+	counts := map[CharType]int{}
+	for _, ch := range password {
+		if strings.ContainsRune(p.Forbidden, ch) {
+			return false
+		}
+		counts[GetCharType(ch)]++
+	}
+	for _, requirement := range p.Requirements {
+		n := 0
+		for _, t := range requirement.Types {
+			n += counts[t]
+		}
+		if n < requirement.Min {
+			return false
+		}
+	}
+	classes := 0
+	for _, t := range []CharType{UpperCharType, LowerCharType, NumberCharType, SpecialCharType} {
+		if counts[t] > 0 {
+			classes++
+		}
+	}
+	return classes >= p.Classes
+}
+
+// maxPresetAttempts bounds the passwords drawn until one meets the preset,
+// which Check makes possible.
+const maxPresetAttempts = 10000
+
+// GenerateWithPreset draws passwords until one satisfies preset, rejecting
+// rather than placing the required characters keeps them uniformly random.
+func GenerateWithPreset(preset Preset, length int, excluded []int32, excludedTypes []CharType) ([]int32, error) {
+	if err := preset.Check(length, excludedTypes); err != nil {
+		return nil, err
+	}
+	excluded = append(append([]int32{}, excluded...), []int32(preset.Forbidden)...)
+	for i := 0; i < maxPresetAttempts; i++ {
+		randInts, err := GenerateRandomInts(length, excluded, excludedTypes)
+		if err != nil {
+			return nil, err
+		}
+		if preset.Satisfied(randInts) {
+			return randInts, nil
+		}
+		wipeInts(randInts)
+	}
+	return nil, fmt.Errorf("Could not generate a password meeting the preset in %d attempts, exclude fewer characters", maxPresetAttempts)
+}
+
+func presetUsage() string {
+	presets := []string{}
+	for _, name := range PresetNames() {
+		presets = append(presets, fmt.Sprintf("%s (%s)", name, Presets[name].Description))
+	}
+	return "Follow the rules of `PRESET`: " + strings.Join(presets, ", ")
+}
@@ -0,0 +1,187 @@
+package passgen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/urfave/cli"
+)
+
+// DefaultRotation is the age after which a recorded password is due.
+const DefaultRotation = "90d"
+
+// Record notes when the password of Label was generated and with which
+// policy, never the password itself.
+type Record struct {
+	Label  string    `json:"label"`
+	Date   time.Time `json:"date"`
+	Policy string    `json:"policy"`
+}
+
+// Policy describes the generation options of a password, e.g.
+// "length 16, no special" for a record.
+func Policy(length int, excludedTypes []CharType, excludedChars []int32) string {
+	names := map[CharType]string{
+		NumberCharType:  "no numbers",
+		SpecialCharType: "no special",
+		UpperCharType:   "no uppercase",
+		LowerCharType:   "no lowercase",
+	}
+	parts := []string{fmt.Sprintf("length %d", length)}
+	for _, t := range excludedTypes {
+		parts = append(parts, names[t])
+	}
+	if len(excludedChars) > 0 {
+		parts = append(parts, fmt.Sprintf("excluding %q", IntsToString(excludedChars)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// recordsPath resolves the record file from --records, the environment or the
+// pass section of the config file, defaulting to the XDG data directory.
+func recordsPath(c *cli.Context) (string, error) {
+	cfg, err := config.Load("pass")
+	if err != nil {
+		return "", err
+	}
+	if path := cfg.String(c, "records"); path != "" {
+		return path, nil
+	}
+	dir, err := config.DataDir("pass")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "records.jsonl"), nil
+}
+
+// AppendRecord adds record as a json line at the end of the file at path.
+func AppendRecord(path string, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadRecords reads the record file at path, a missing file has no records.
+func ReadRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := []Record{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// Due returns the latest record of every label older than rotation at now,
+// the oldest first.
+func Due(records []Record, rotation time.Duration, now time.Time) []Record {
+	latest := map[string]Record{}
+	for _, record := range records {
+		if previous, ok := latest[record.Label]; !ok || record.Date.After(previous.Date) {
+			latest[record.Label] = record
+		}
+	}
+	due := []Record{}
+	for _, record := range latest {
+		if now.Sub(record.Date) >= rotation {
+			due = append(due, record)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Date.Before(due[j].Date) })
+	return due
+}
+
+// ParseRotation parses a rotation period in days like 90d, or as a Go
+// duration like 2160h.
+func ParseRotation(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("Invalid rotation period %q, expected days like 90d or a duration like 2160h", s)
+}
+
+var recordsFlag = cli.StringFlag{
+	Name:  "records",
+	Usage: "Record `FILE` of the generated passwords, defaults to the XDG data directory",
+}
+
+func dueCommand() cli.Command {
+	return cli.Command{
+		Name:  "due",
+		Usage: "List the labels recorded with --record whose password is due for rotation",
+		Flags: []cli.Flag{
+			recordsFlag,
+			cli.StringFlag{
+				Name:  "rotate-after",
+				Usage: "Rotation `PERIOD` as days like 90d or a duration like 2160h",
+				Value: DefaultRotation,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			path, err := recordsPath(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.Load("pass")
+			if err != nil {
+				return err
+			}
+			rotation, err := ParseRotation(cfg.String(c, "rotate-after"))
+			if err != nil {
+				return err
+			}
+			records, err := ReadRecords(path)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			due := Due(records, rotation, now)
+			if len(due) == 0 {
+				fmt.Println("Nothing due")
+				return nil
+			}
+			for _, record := range due {
+				days := int(now.Sub(record.Date).Hours() / 24)
+				fmt.Printf("%-24s %s (%d days ago, %s)\n", record.Label, record.Date.Format("2006-01-02"), days, record.Policy)
+			}
+			return nil
+		},
+	}
+}
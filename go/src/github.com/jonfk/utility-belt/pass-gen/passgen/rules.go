@@ -0,0 +1,301 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonfk/utility-belt/internal/httpx"
+)
+
+// DefaultRulesList is the list of the password rules of websites maintained
+// by Apple, keyed by domain.
+const DefaultRulesList = "https://raw.githubusercontent.com/apple/password-manager-resources/main/quirks/password-rules.json"
+
+// Rules are the password rules of a site in Apple's passwordrules format,
+// e.g. "minlength: 12; required: upper, lower, digit; allowed: [-_.]".
+type Rules struct {
+	// Source is the rules string as given
+	Source    string
+	MinLength int
+	MaxLength int
+	// MaxConsecutive bounds the runs of identical characters
+	MaxConsecutive int
+	// Required are the sets of characters the password needs at least one of
+	Required [][]int32
+	// Allowed are the characters of the password, sorted
+	Allowed []int32
+}
+
+// ruleClasses are the named character classes, special and ascii-printable
+// include the space as in the passwordrules specification. unicode is
+// generated as ascii-printable.
+var ruleClasses = map[string]func(ch int32) bool{
+	"upper":           func(ch int32) bool { return GetCharType(ch) == UpperCharType },
+	"lower":           func(ch int32) bool { return GetCharType(ch) == LowerCharType },
+	"digit":           func(ch int32) bool { return GetCharType(ch) == NumberCharType },
+	"special":         func(ch int32) bool { return GetCharType(ch) == SpecialCharType },
+	"ascii-printable": func(ch int32) bool { return true },
+	"unicode":         func(ch int32) bool { return true },
+}
+
+// ParseRules parses a passwordrules string. Unknown properties are ignored
+// as the specification asks, for the rules of the future.
+func ParseRules(source string) (Rules, error) {
+	rules := Rules{Source: source}
+	allowed := map[int32]bool{}
+	for _, property := range splitOutsideBrackets(source, ';') {
+		property = strings.TrimSpace(property)
+		if property == "" {
+			continue
+		}
+		colon := strings.Index(property, ":")
+		if colon < 0 {
+			return rules, fmt.Errorf("Invalid password rule %q, expected name: value", property)
+		}
+		name := strings.ToLower(strings.TrimSpace(property[:colon]))
+		value := strings.TrimSpace(property[colon+1:])
+		switch name {
+		case "minlength", "maxlength", "max-consecutive":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rules, fmt.Errorf("Invalid %s %q, expected a positive number", name, value)
+			}
+			switch name {
+			case "minlength":
+				rules.MinLength = n
+			case "maxlength":
+				rules.MaxLength = n
+			default:
+				rules.MaxConsecutive = n
+			}
+		case "required", "allowed":
+			chars, err := parseRuleClasses(value)
+			if err != nil {
+				return rules, err
+			}
+			if name == "required" {
+				rules.Required = append(rules.Required, chars)
+			}
+			for _, ch := range chars {
+				allowed[ch] = true
+			}
+		}
+	}
+	if rules.MaxLength > 0 && rules.MinLength > rules.MaxLength {
+		return rules, fmt.Errorf("The minlength %d is greater than the maxlength %d", rules.MinLength, rules.MaxLength)
+	}
+	// without required or allowed classes every printable character is allowed
+	if len(allowed) == 0 {
+		for ch := int32(32); ch < 127; ch++ {
+			allowed[ch] = true
+		}
+	}
+	for ch := range allowed {
+		rules.Allowed = append(rules.Allowed, ch)
+	}
+	sort.Slice(rules.Allowed, func(i, j int) bool { return rules.Allowed[i] < rules.Allowed[j] })
+	return rules, nil
+}
+
+// parseRuleClasses returns the characters of a comma separated list of named
+// classes and custom ones such as [-_.].
+func parseRuleClasses(value string) ([]int32, error) {
+	seen := map[int32]bool{}
+	chars := []int32{}
+	add := func(ch int32) {
+		if ch >= 32 && ch < 127 && !seen[ch] {
+			seen[ch] = true
+			chars = append(chars, ch)
+		}
+	}
+	for _, class := range splitOutsideBrackets(value, ',') {
+		class = strings.TrimSpace(class)
+		if strings.HasPrefix(class, "[") && strings.HasSuffix(class, "]") && len(class) >= 2 {
+			for _, ch := range class[1 : len(class)-1] {
+				add(ch)
+			}
+			continue
+		}
+		in, ok := ruleClasses[strings.ToLower(class)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown character class %q, expected upper, lower, digit, special, ascii-printable, unicode or [characters]", class)
+		}
+		for ch := int32(32); ch < 127; ch++ {
+			if in(ch) {
+				add(ch)
+			}
+		}
+	}
+	return chars, nil
+}
+
+// splitOutsideBrackets splits s on sep outside of the custom classes. A ]
+// only closes a class when followed by a separator or the end, so that ] can
+// be the last character of a class as in [-]].
+func splitOutsideBrackets(s string, sep byte) []string {
+	parts := []string{}
+	start := 0
+	inClass := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case !inClass && s[i] == '[':
+			inClass = true
+		case inClass && s[i] == ']':
+			rest := strings.TrimLeft(s[i+1:], " \t")
+			if rest == "" || rest[0] == ',' || rest[0] == ';' {
+				inClass = false
+			}
+		case !inClass && s[i] == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Length returns the length of the passwords generated for the rules:
+// length when it is set, otherwise the default raised to the minlength and
+// capped by the maxlength.
+func (r Rules) Length(length int, set bool) (int, error) {
+	if set {
+		if length < r.MinLength {
+			return 0, fmt.Errorf("The rules require at least %d characters, got a length of %d", r.MinLength, length)
+		}
+		if r.MaxLength > 0 && length > r.MaxLength {
+			return 0, fmt.Errorf("The rules allow at most %d characters, got a length of %d", r.MaxLength, length)
+		}
+		return length, nil
+	}
+	length = DefaultLength
+	if length < r.MinLength {
+		length = r.MinLength
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		length = r.MaxLength
+	}
+	return length, nil
+}
+
+// Satisfied reports whether password has a character of every required
+// class and no run longer than MaxConsecutive.
+func (r Rules) Satisfied(password []int32) bool {
+	for _, required := range r.Required {
+		found := false
+		for _, ch := range password {
+			if containsInt32(ch, required) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	run := 0
+	for i, ch := range password {
+		if i > 0 && ch == password[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if r.MaxConsecutive > 0 && run > r.MaxConsecutive {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateWithRules draws passwords of the allowed characters until one
+// satisfies the rules, as GenerateWithPreset does.
+func GenerateWithRules(rules Rules, length int, excluded []int32, excludedTypes []CharType) ([]int32, error) {
+	keep := func(ch int32) bool {
+		return !containsInt32(ch, excluded) && !containsCharType(GetCharType(ch), excludedTypes)
+	}
+	allowed := []int32{}
+	for _, ch := range rules.Allowed {
+		if keep(ch) {
+			allowed = append(allowed, ch)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("The rules allow no character which is not excluded")
+	}
+	for _, required := range rules.Required {
+		available := false
+		for _, ch := range required {
+			available = available || keep(ch)
+		}
+		if !available {
+			return nil, fmt.Errorf("The rules require characters which are excluded")
+		}
+	}
+	if length < len(rules.Required) {
+		return nil, fmt.Errorf("The rules require at least %d characters, got a length of %d", len(rules.Required), length)
+	}
+
+	max := big.NewInt(int64(len(allowed)))
+	for attempt := 0; attempt < maxPresetAttempts; attempt++ {
+		randInts := make([]int32, length)
+		for i := range randInts {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, fmt.Errorf("Error generating random number: %v", err)
+			}
+			randInts[i] = allowed[n.Int64()]
+		}
+		if rules.Satisfied(randInts) {
+			return randInts, nil
+		}
+		wipeInts(randInts)
+	}
+	return nil, fmt.Errorf("Could not generate a password meeting the rules in %d attempts, exclude fewer characters", maxPresetAttempts)
+}
+
+// FetchSiteRules looks up the rules of domain, or of its closest parent
+// domain, in a list of password rules by domain such as DefaultRulesList.
+// list is a URL or a local file.
+func FetchSiteRules(list, domain string) (string, error) {
+	var data []byte
+	if strings.HasPrefix(list, "http://") || strings.HasPrefix(list, "https://") {
+		client, err := httpx.NewClient(httpx.DefaultOptions)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Get(list)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("GET %s: %s", list, resp.Status)
+		}
+		if data, err = ioutil.ReadAll(resp.Body); err != nil {
+			return "", err
+		}
+	} else {
+		var err error
+		if data, err = ioutil.ReadFile(list); err != nil {
+			return "", err
+		}
+	}
+	var sites map[string]struct {
+		PasswordRules string `json:"password-rules"`
+	}
+	if err := json.Unmarshal(data, &sites); err != nil {
+		return "", fmt.Errorf("Could not read the password rules of %s: %v", list, err)
+	}
+	domain = strings.TrimPrefix(strings.ToLower(strings.TrimSuffix(domain, ".")), "www.")
+	for name := domain; strings.Contains(name, "."); name = name[strings.Index(name, ".")+1:] {
+		if site, ok := sites[name]; ok && site.PasswordRules != "" {
+			return site.PasswordRules, nil
+		}
+	}
+	return "", fmt.Errorf("No password rules for %s in %s", domain, list)
+}
@@ -0,0 +1,96 @@
+package passgen
+
+import (
+	"fmt"
+	"io"
+)
+
+// Secret holds a generated password in a buffer locked out of swap where the
+// system allows it and zeroed by Destroy. It prints as [redacted] so it can't
+// reach a log by accident, write its Bytes to where it is meant to go instead
+// of converting it to a string, which would leave a copy nothing can erase.
+type Secret struct {
+	b      []byte
+	locked bool
+}
+
+// SecretFromInts builds a secret of the characters of ints then zeroes ints.
+func SecretFromInts(ints []int32) *Secret {
+	s := &Secret{b: make([]byte, len(ints))}
+	s.locked = lockMemory(s.b)
+	for i, x := range ints {
+		s.b[i] = byte(x)
+	}
+	wipeInts(ints)
+	return s
+}
+
+// SecretFromBytes builds a secret of a copy of b then zeroes b.
+func SecretFromBytes(b []byte) *Secret {
+	s := &Secret{b: make([]byte, len(b))}
+	s.locked = lockMemory(s.b)
+	copy(s.b, b)
+	wipe(b)
+	return s
+}
+
+// ReadSecret reads r until EOF into a secret, failing when it holds more
+// than max bytes. The buffer is allocated and locked once at max bytes.
+func ReadSecret(r io.Reader, max int) (*Secret, error) {
+	// one more byte tells an output of max bytes from a longer one
+	s := &Secret{b: make([]byte, max+1)}
+	s.locked = lockMemory(s.b)
+	n, err := io.ReadFull(r, s.b)
+	switch err {
+	case nil:
+		s.Destroy()
+		return nil, fmt.Errorf("The secret is larger than %d bytes", max)
+	case io.EOF, io.ErrUnexpectedEOF:
+		s.b = s.b[:n]
+		return s, nil
+	default:
+		s.Destroy()
+		return nil, err
+	}
+}
+
+// Bytes returns the buffer of the secret, valid until Destroy.
+func (s *Secret) Bytes() []byte {
+	return s.b
+}
+
+func (s *Secret) Len() int {
+	return len(s.b)
+}
+
+func (s *Secret) String() string {
+	return "[redacted]"
+}
+
+func (s *Secret) GoString() string {
+	return "[redacted]"
+}
+
+// Destroy zeroes and unlocks the buffer, past the length of the secret up to
+// its capacity for those read by ReadSecret.
+func (s *Secret) Destroy() {
+	b := s.b[:cap(s.b)]
+	wipe(b)
+	if s.locked {
+		unlockMemory(b)
+		s.locked = false
+	}
+	s.b = nil
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func wipeInts(ints []int32) {
+	for i := range ints {
+		ints[i] = 0
+	}
+}
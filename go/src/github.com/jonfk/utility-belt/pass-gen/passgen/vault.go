@@ -0,0 +1,397 @@
+package passgen
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/urfave/cli"
+)
+
+// Vault stores the passwords by label. The passwords are passed in byte
+// slices and Secrets the callers zero, the output of the age and pass
+// commands is read into locked buffers zeroed once used. The vaults still
+// make copies they can't erase, such as the strings decoded from the json of
+// an AgeVault.
+type Vault interface {
+	// Save doesn't keep password, the caller zeroes it
+	Save(label string, password []byte) error
+	// Get returns the password saved under label, the caller destroys it
+	Get(label string) (*Secret, error)
+	Labels() ([]string, error)
+}
+
+// VaultEntry is a password of an AgeVault.
+type VaultEntry struct {
+	Password string    `json:"password"`
+	Date     time.Time `json:"date"`
+}
+
+// AgeVault keeps the passwords in a json file encrypted with the age
+// command line tool, it is decrypted with Identity and encrypted to
+// Recipient, the public key of Identity when empty.
+type AgeVault struct {
+	Path      string
+	Identity  string
+	Recipient string
+}
+
+// load decrypts the entries, the caller zeroes them with wipeEntries.
+func (v AgeVault) load() (map[string]VaultEntry, error) {
+	entries := map[string]VaultEntry{}
+	info, err := os.Stat(v.Path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if v.Identity == "" {
+		return nil, fmt.Errorf("No age identity to decrypt %s, set it with --age-identity", v.Path)
+	}
+	// the plaintext is smaller than the age file, armored or not
+	out, err := runSecret(nil, int(info.Size()), "age", "--decrypt", "--identity", v.Identity, v.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Destroy()
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		return nil, fmt.Errorf("Could not read %s: %v", v.Path, err)
+	}
+	return entries, nil
+}
+
+func (v AgeVault) Save(label string, password []byte) error {
+	entries, err := v.load()
+	if err != nil {
+		return err
+	}
+	entries[label] = VaultEntry{Password: string(password), Date: time.Now().UTC()}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	defer wipe(data)
+
+	recipient := v.Recipient
+	if recipient == "" {
+		if v.Identity == "" {
+			return fmt.Errorf("No age recipient to encrypt %s, set --age-recipient or --age-identity", v.Path)
+		}
+		out, err := run(nil, "age-keygen", "-y", v.Identity)
+		if err != nil {
+			return err
+		}
+		recipient = strings.TrimSpace(string(out))
+	}
+	if err := os.MkdirAll(filepath.Dir(v.Path), 0700); err != nil {
+		return err
+	}
+	// encrypt next to the vault then rename so a failure keeps the old one
+	tmp := v.Path + ".tmp"
+	if _, err := run(data, "age", "--encrypt", "--recipient", recipient, "--output", tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, v.Path)
+}
+
+func (v AgeVault) Get(label string) (*Secret, error) {
+	entries, err := v.load()
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := entries[label]
+	if !ok {
+		return nil, fmt.Errorf("No password saved under %s", label)
+	}
+	return SecretFromBytes([]byte(entry.Password)), nil
+}
+
+func (v AgeVault) Labels() ([]string, error) {
+	entries, err := v.load()
+	if err != nil {
+		return nil, err
+	}
+	labels := []string{}
+	for label := range entries {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+// MaxPassEntry bounds the size of the pass entries read, a password and a
+// few lines of notes.
+const MaxPassEntry = 16 << 10
+
+// PassVault delegates to the pass password manager, the labels being pass
+// names under Prefix.
+type PassVault struct {
+	Prefix string
+}
+
+func (v PassVault) name(label string) string {
+	if v.Prefix == "" {
+		return label
+	}
+	return strings.TrimSuffix(v.Prefix, "/") + "/" + label
+}
+
+func (v PassVault) Save(label string, password []byte) error {
+	stdin := make([]byte, len(password)+1)
+	defer wipe(stdin)
+	copy(stdin, password)
+	stdin[len(password)] = '\n'
+	_, err := run(stdin, "pass", "insert", "--multiline", "--force", v.name(label))
+	return err
+}
+
+// Get returns the first line of the entry, pass' convention for the
+// password.
+func (v PassVault) Get(label string) (*Secret, error) {
+	out, err := runSecret(nil, MaxPassEntry, "pass", "show", v.name(label))
+	if err != nil {
+		return nil, err
+	}
+	defer out.Destroy()
+	line := out.Bytes()
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return SecretFromBytes(line), nil
+}
+
+// Labels lists the entries under the prefix from the password store
+// directory, pass only printing them as a tree.
+func (v PassVault) Labels() ([]string, error) {
+	dir := os.Getenv("PASSWORD_STORE_DIR")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(home, ".password-store")
+	}
+	root := filepath.Join(dir, filepath.FromSlash(v.Prefix))
+	labels := []string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".gpg") {
+			rel, _ := filepath.Rel(root, strings.TrimSuffix(path, ".gpg"))
+			labels = append(labels, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return labels, nil
+	}
+	return labels, err
+}
+
+// run runs the command with stdin, returning its output or an error with
+// its stderr.
+func run(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, commandError(err, &stderr, name, args)
+	}
+	return out, nil
+}
+
+// runSecret runs the command as run does, reading its output of at most max
+// bytes straight into a secret rather than a growing buffer which would
+// leave copies behind. The caller destroys the secret.
+func runSecret(stdin []byte, max int, name string, args ...string) (*Secret, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, commandError(err, &stderr, name, args)
+	}
+	out, readErr := ReadSecret(stdout, max)
+	if readErr != nil {
+		cmd.Process.Kill()
+	}
+	err = cmd.Wait()
+	if readErr != nil {
+		return nil, fmt.Errorf("%s %s: %v", name, args[0], readErr)
+	}
+	if err != nil {
+		out.Destroy()
+		return nil, commandError(err, &stderr, name, args)
+	}
+	return out, nil
+}
+
+// commandError adds the stderr of the command to err.
+func commandError(err error, stderr *bytes.Buffer, name string, args []string) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		err = fmt.Errorf("%v: %s", err, msg)
+	}
+	return fmt.Errorf("%s %s: %v", name, args[0], err)
+}
+
+var vaultFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "vault",
+		Usage: "Where the passwords are kept: age, a file encrypted with age, or pass",
+		Value: "age",
+	},
+	cli.StringFlag{
+		Name:  "vault-file",
+		Usage: "age encrypted `FILE` of the passwords, defaults to the XDG data directory",
+	},
+	cli.StringFlag{
+		Name:  "age-identity",
+		Usage: "age identity `FILE` decrypting the vault",
+	},
+	cli.StringFlag{
+		Name:  "age-recipient",
+		Usage: "age `RECIPIENT` encrypting the vault, defaults to the public key of the identity",
+	},
+	cli.StringFlag{
+		Name:  "pass-prefix",
+		Usage: "Directory of the password store the labels are saved under with --vault pass",
+	},
+}
+
+// openVault resolves the vault from the flags, the environment or the pass
+// section of the config file.
+func openVault(c *cli.Context) (Vault, error) {
+	cfg, err := config.Load("pass")
+	if err != nil {
+		return nil, err
+	}
+	switch kind := cfg.String(c, "vault"); kind {
+	case "age":
+		path := cfg.String(c, "vault-file")
+		if path == "" {
+			dir, err := config.DataDir("pass")
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(dir, "vault.age")
+		}
+		return AgeVault{Path: path, Identity: cfg.String(c, "age-identity"), Recipient: cfg.String(c, "age-recipient")}, nil
+	case "pass":
+		return PassVault{Prefix: cfg.String(c, "pass-prefix")}, nil
+	default:
+		return nil, fmt.Errorf("Unknown vault %s, expected age or pass", kind)
+	}
+}
+
+func saveCommand() cli.Command {
+	return cli.Command{
+		Name:      "save",
+		Usage:     "Generate a password and save it in the vault under LABEL, recording it for the due subcommand",
+		ArgsUsage: "LABEL",
+		Flags: append(append([]cli.Flag{
+			cli.BoolFlag{
+				Name:  "stdin",
+				Usage: "Save the password read from the first line of stdin instead of generating one",
+			},
+			recordsFlag,
+		}, vaultFlags...), generationFlags...),
+		Action: func(c *cli.Context) error {
+			label := c.Args().First()
+			if c.NArg() != 1 || label == "" {
+				return fmt.Errorf("Expected the LABEL to save the password under")
+			}
+			vault, err := openVault(c)
+			if err != nil {
+				return err
+			}
+
+			disableCoreDumps()
+			var secret *Secret
+			var policy string
+			if c.Bool("stdin") {
+				scanner := bufio.NewScanner(os.Stdin)
+				if !scanner.Scan() {
+					if err := scanner.Err(); err != nil {
+						return err
+					}
+					return fmt.Errorf("No password on stdin")
+				}
+				// zeroes the line in the buffer of the scanner
+				secret, policy = SecretFromBytes(bytes.TrimRight(scanner.Bytes(), "\r")), "from stdin"
+			} else {
+				if secret, policy, err = generatePassword(c); err != nil {
+					return err
+				}
+			}
+			defer secret.Destroy()
+			if secret.Len() == 0 {
+				return fmt.Errorf("Refusing to save an empty password")
+			}
+
+			if err := vault.Save(label, secret.Bytes()); err != nil {
+				return err
+			}
+			path, err := recordsPath(c)
+			if err != nil {
+				return err
+			}
+			if err := AppendRecord(path, Record{Label: label, Date: time.Now().UTC(), Policy: policy}); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Saved %s\n", label)
+			return nil
+		},
+	}
+}
+
+func getCommand() cli.Command {
+	return cli.Command{
+		Name:      "get",
+		Usage:     "Print the password saved under LABEL, or list the labels",
+		ArgsUsage: "[LABEL]",
+		Flags:     append(append([]cli.Flag{}, outputFlags...), vaultFlags...),
+		Action: func(c *cli.Context) error {
+			vault, err := openVault(c)
+			if err != nil {
+				return err
+			}
+			if c.NArg() == 0 {
+				labels, err := vault.Labels()
+				if err != nil {
+					return err
+				}
+				for _, label := range labels {
+					fmt.Println(label)
+				}
+				return nil
+			}
+			disableCoreDumps()
+			secret, err := vault.Get(c.Args().First())
+			if err != nil {
+				return err
+			}
+			defer secret.Destroy()
+			return output(c, secret.Bytes())
+		},
+	}
+}
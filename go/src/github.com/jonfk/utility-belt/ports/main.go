@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/ports/ports"
+)
+
+func main() {
+	belt.Run(belt.NewApp("ports", ports.Command()))
+}
@@ -0,0 +1,113 @@
+package ports
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of checking a host:port.
+type Result struct {
+	Addr    string
+	Open    bool
+	Latency time.Duration
+	Err     error
+	// TLS describes the handshake when it was requested
+	TLS string
+}
+
+// ExpandTargets expands host:port targets where the port may be a range such
+// as 8000-8010 or a list such as 80,443.
+func ExpandTargets(targets []string) ([]string, error) {
+	addrs := []string{}
+	for _, target := range targets {
+		host, ports, err := net.SplitHostPort(target)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid target %s, expected host:port: %v", target, err)
+		}
+		for _, part := range strings.Split(ports, ",") {
+			bounds := strings.SplitN(part, "-", 2)
+			first, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid port %s in %s", part, target)
+			}
+			last := first
+			if len(bounds) == 2 {
+				if last, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("Invalid port %s in %s", part, target)
+				}
+			}
+			if first < 1 || last > 65535 || last < first {
+				return nil, fmt.Errorf("Invalid port range %s in %s", part, target)
+			}
+			for port := first; port <= last; port++ {
+				addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(port)))
+			}
+		}
+	}
+	return addrs, nil
+}
+
+// Check connects to addr, and completes a TLS handshake when withTLS is set.
+func Check(addr string, timeout time.Duration, withTLS bool) Result {
+	result := Result{Addr: addr}
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	result.Open = true
+	if !withTLS {
+		return result
+	}
+
+	host, _, _ := net.SplitHostPort(addr)
+	conn.SetDeadline(time.Now().Add(timeout))
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+	if err := tlsConn.Handshake(); err != nil {
+		result.Err = fmt.Errorf("tls: %v", err)
+		return result
+	}
+	state := tlsConn.ConnectionState()
+	result.TLS = tls.VersionName(state.Version)
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		result.TLS += fmt.Sprintf(" %s expires %s", cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+		if err := cert.VerifyHostname(host); err != nil {
+			result.TLS += " (hostname mismatch)"
+		}
+	}
+	return result
+}
+
+// CheckAll checks addrs over jobs workers, the results are in the order of addrs.
+func CheckAll(addrs []string, timeout time.Duration, withTLS bool, jobs int) []Result {
+	if jobs < 1 {
+		jobs = 1
+	}
+	results := make([]Result, len(addrs))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = Check(addrs[i], timeout, withTLS)
+			}
+		}()
+	}
+	for i := range addrs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,101 @@
+package ports
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// Command checks ports, run standalone as ports or as ub ports.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "ports",
+		Usage:     "Checks which host:port are reachable",
+		ArgsUsage: "[HOST:PORT...]",
+		Description: "Ports may be ranges or lists, e.g. localhost:8000-8010 or example.com:80,443.\n" +
+			"   Without arguments the targets are read one per line from stdin.",
+		Flags: []cli.Flag{
+			cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Connection timeout",
+				Value: 2 * time.Second,
+			},
+			cli.BoolFlag{
+				Name:  "tls",
+				Usage: "Complete a TLS handshake and report the protocol and certificate",
+			},
+			cli.IntFlag{
+				Name:  "jobs,j",
+				Usage: "Number of parallel checks",
+				Value: 32,
+			},
+			cli.BoolFlag{
+				Name:  "open",
+				Usage: "Only print the open ports",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			targets := []string(c.Args())
+			if len(targets) == 0 {
+				scanner := bufio.NewScanner(os.Stdin)
+				for scanner.Scan() {
+					if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+						targets = append(targets, line)
+					}
+				}
+				if err := scanner.Err(); err != nil {
+					return err
+				}
+			}
+			addrs, err := ExpandTargets(targets)
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for _, result := range CheckAll(addrs, c.Duration("timeout"), c.Bool("tls"), c.Int("jobs")) {
+				switch {
+				case result.Open && result.Err == nil:
+					fmt.Printf("%-28s open    %6s %s\n", result.Addr, result.Latency.Round(time.Millisecond), result.TLS)
+				case result.Open:
+					failed++
+					fmt.Printf("%-28s open    %6s %v\n", result.Addr, result.Latency.Round(time.Millisecond), result.Err)
+				default:
+					failed++
+					if !c.Bool("open") {
+						fmt.Printf("%-28s closed  %v\n", result.Addr, result.Err)
+					}
+				}
+			}
+			if failed > 0 {
+				return cli.NewExitError(fmt.Sprintf("%d of %d ports are unreachable", failed, len(addrs)), 1)
+			}
+			return nil
+		},
+		Subcommands: []cli.Command{
+			{
+				Name:  "listen",
+				Usage: "Lists the local listening TCP ports and their processes",
+				Action: func(c *cli.Context) error {
+					listeners, err := Listeners()
+					if err != nil {
+						return err
+					}
+					fmt.Printf("%-6s %-24s %-8s %s\n", "PORT", "ADDRESS", "PID", "PROCESS")
+					for _, l := range listeners {
+						pid := "-"
+						if l.PID != 0 {
+							pid = fmt.Sprint(l.PID)
+						}
+						fmt.Printf("%-6d %-24s %-8s %s\n", l.Port, l.Addr, pid, l.Process)
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,150 @@
+package ports
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Listener is a local TCP port in the LISTEN state and the process holding it.
+type Listener struct {
+	Addr    string
+	Port    int
+	PID     int
+	Process string
+}
+
+// Listeners lists the listening TCP ports, from /proc on Linux and lsof
+// elsewhere. Processes of other users may be unknown without root.
+func Listeners() ([]Listener, error) {
+	var listeners []Listener
+	var err error
+	if runtime.GOOS == "linux" {
+		listeners, err = procListeners()
+	} else {
+		listeners, err = lsofListeners()
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].Port != listeners[j].Port {
+			return listeners[i].Port < listeners[j].Port
+		}
+		return listeners[i].Addr < listeners[j].Addr
+	})
+	return listeners, nil
+}
+
+// tcpListen is the LISTEN state in /proc/net/tcp.
+const tcpListen = "0A"
+
+func procListeners() ([]Listener, error) {
+	inodes := map[string]Listener{}
+	for _, file := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		f, err := os.Open(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != tcpListen {
+				continue
+			}
+			ip, port, err := parseProcAddr(fields[1])
+			if err != nil {
+				continue
+			}
+			inodes[fields[9]] = Listener{Addr: ip.String(), Port: port}
+		}
+		f.Close()
+	}
+
+	procs, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range procs {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+		listener, ok := inodes[inode]
+		if !ok || listener.PID != 0 {
+			continue
+		}
+		pid, _ := strconv.Atoi(strings.Split(fd, "/")[2])
+		comm, _ := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+		listener.PID, listener.Process = pid, strings.TrimSpace(string(comm))
+		inodes[inode] = listener
+	}
+
+	listeners := []Listener{}
+	for _, listener := range inodes {
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// parseProcAddr parses the hex ip:port of /proc/net/tcp, where the ip is in
+// host byte order by 32 bit words.
+func parseProcAddr(s string) (net.IP, int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return nil, 0, fmt.Errorf("invalid address %s", s)
+	}
+	raw, err := hex.DecodeString(parts[0])
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := 0; i+4 <= len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	port, err := strconv.ParseInt(parts[1], 16, 32)
+	return net.IP(raw), int(port), err
+}
+
+func lsofListeners() ([]Listener, error) {
+	out, err := exec.Command("lsof", "-nP", "-iTCP", "-sTCP:LISTEN", "-Fpcn").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) == 0 {
+			// lsof exits 1 when nothing matches
+			return []Listener{}, nil
+		}
+		return nil, fmt.Errorf("lsof: %v", err)
+	}
+	listeners := []Listener{}
+	current := Listener{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			current.PID, _ = strconv.Atoi(line[1:])
+		case 'c':
+			current.Process = line[1:]
+		case 'n':
+			host, port, err := net.SplitHostPort(line[1:])
+			if err != nil {
+				continue
+			}
+			listener := current
+			listener.Addr = strings.Trim(host, "[]")
+			listener.Port, _ = strconv.Atoi(port)
+			listeners = append(listeners, listener)
+		}
+	}
+	return listeners, nil
+}
@@ -0,0 +1,130 @@
+package prettifyjson
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BatchResult is the outcome of formatting one file of a batch.
+type BatchResult struct {
+	File    string
+	Changed bool
+	Err     error
+}
+
+// ExpandFiles returns the files of paths, directories are walked for their
+// .json files when recursive is set, skipping the hidden ones.
+func ExpandFiles(paths []string, recursive bool) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		if !recursive {
+			return nil, fmt.Errorf("%s is a directory, pass --recursive to format its json files", path)
+		}
+		found := []string{}
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if file != path && strings.HasPrefix(info.Name(), ".") {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.IsDir() && strings.EqualFold(filepath.Ext(file), ".json") {
+				found = append(found, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// FormatFiles formats files with transform over jobs workers, writing back
+// the files which changed when write is set. The results are in the order of
+// files.
+func FormatFiles(files []string, jobs int, write bool, transform func(file string, data []byte) ([]byte, error)) []BatchResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+	results := make([]BatchResult, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = formatFile(files[i], write, transform)
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+func formatFile(file string, write bool, transform func(file string, data []byte) ([]byte, error)) BatchResult {
+	result := BatchResult{File: file}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	out, err := transform(file, data)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Changed = !bytes.Equal(data, out)
+	if result.Changed && write {
+		info, err := os.Stat(file)
+		if err == nil {
+			err = ioutil.WriteFile(file, out, info.Mode().Perm())
+		}
+		result.Err = err
+	}
+	return result
+}
+
+// FormatBatchSummary counts the changed, unchanged and failed files.
+func FormatBatchSummary(results []BatchResult, write bool) string {
+	changed, unchanged, failed := 0, 0, 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+		case result.Changed:
+			changed++
+		default:
+			unchanged++
+		}
+	}
+	verb := "to format"
+	if write {
+		verb = "formatted"
+	}
+	return fmt.Sprintf("%d files: %d %s, %d unchanged, %d failed", len(results), changed, verb, unchanged, failed)
+}
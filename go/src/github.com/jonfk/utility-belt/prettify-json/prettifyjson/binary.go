@@ -0,0 +1,197 @@
+package prettifyjson
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// DecodeBinary decodes data in format into the values of DecodeOrdered.
+// Byte strings become base64 strings, timestamps RFC 3339 strings and a
+// stream of several concatenated values an array of them.
+func DecodeBinary(format string, data []byte) (interface{}, error) {
+	var decode func(r *binaryReader) (interface{}, error)
+	switch format {
+	case "msgpack":
+		decode = decodeMsgpack
+	case "cbor":
+		decode = decodeCBOR
+	case "bson":
+		decode = decodeBSONDocument
+	default:
+		return nil, fmt.Errorf("Unknown input format %q, expected json, msgpack, cbor or bson", format)
+	}
+
+	r := &binaryReader{data: data}
+	values := []interface{}{}
+	for r.pos < len(r.data) {
+		value, err := decode(r)
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("Truncated %s data at byte %d", format, r.pos)
+		} else if err != nil {
+			return nil, fmt.Errorf("Invalid %s data at byte %d: %v", format, r.pos, err)
+		}
+		values = append(values, value)
+	}
+	switch len(values) {
+	case 0:
+		return nil, fmt.Errorf("No %s data", format)
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
+
+// EncodeBinary encodes a value of DecodeOrdered in format. BSON only holds
+// documents, an array of objects is written as concatenated documents.
+func EncodeBinary(format string, value interface{}) ([]byte, error) {
+	switch format {
+	case "msgpack":
+		return encodeMsgpack(nil, value)
+	case "cbor":
+		return encodeCBOR(nil, value)
+	case "bson":
+		if array, ok := value.([]interface{}); ok {
+			var out []byte
+			for i, item := range array {
+				object, ok := item.(*Object)
+				if !ok {
+					return nil, fmt.Errorf("BSON holds documents, element %d is not an object", i)
+				}
+				var err error
+				if out, err = encodeBSONDocument(out, object); err != nil {
+					return nil, err
+				}
+			}
+			return out, nil
+		}
+		object, ok := value.(*Object)
+		if !ok {
+			return nil, fmt.Errorf("BSON holds documents, expected an object or an array of objects")
+		}
+		return encodeBSONDocument(nil, object)
+	default:
+		return nil, fmt.Errorf("Unknown output format %q", format)
+	}
+}
+
+// binaryReader reads big and little endian values off data.
+type binaryReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *binaryReader) next(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *binaryReader) byte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// uint reads a big endian unsigned integer of size bytes.
+func (r *binaryReader) uint(size int) (uint64, error) {
+	b, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func (r *binaryReader) int32LE() (int32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+func (r *binaryReader) int64LE() (int64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(b)), nil
+}
+
+// bigEndian returns the size low bytes of n, most significant first.
+func bigEndian(n uint64, size int) []byte {
+	b := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		b[i] = byte(n)
+		n >>= 8
+	}
+	return b
+}
+
+// littleEndian returns the size low bytes of n, least significant first.
+func littleEndian(n uint64, size int) []byte {
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = byte(n)
+		n >>= 8
+	}
+	return b
+}
+
+func intNumber(n int64) json.Number   { return json.Number(strconv.FormatInt(n, 10)) }
+func uintNumber(n uint64) json.Number { return json.Number(strconv.FormatUint(n, 10)) }
+
+// floatNumber converts f, json having no NaN nor infinities they are
+// written as strings.
+func floatNumber(f float64) interface{} {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+func bytesValue(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func timeValue(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// mapKey turns the key of a msgpack or cbor map into an object key, keys
+// which aren't strings are written as json.
+func mapKey(key interface{}) (string, error) {
+	if s, ok := key.(string); ok {
+		return s, nil
+	}
+	out, err := MarshalOrdered(key)
+	return string(out), err
+}
+
+// parseNumber returns n as an int64 when it is an integer in range, as a
+// float64 otherwise.
+func parseNumber(n json.Number) (interface{}, error) {
+	if i, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+		return i, nil
+	}
+	f, err := strconv.ParseFloat(n.String(), 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid number %s", n)
+	}
+	return f, nil
+}
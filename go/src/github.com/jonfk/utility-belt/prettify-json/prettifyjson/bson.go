@@ -0,0 +1,203 @@
+package prettifyjson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// decodeBSONDocument decodes a BSON document, like a mongodump file holds
+// one after the other. ObjectIds become hex strings, datetimes RFC 3339
+// strings and regular expressions /pattern/options strings.
+func decodeBSONDocument(r *binaryReader) (interface{}, error) {
+	start := r.pos
+	size, err := r.int32LE()
+	if err != nil {
+		return nil, err
+	}
+	if size < 5 || int(size) > len(r.data)-start {
+		return nil, fmt.Errorf("invalid document size %d", size)
+	}
+	end := start + int(size) - 1
+
+	object := NewObject()
+	for r.pos < end {
+		typ, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		name, err := r.cstring()
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeBSONValue(r, typ)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		object.Set(name, value)
+	}
+	if r.pos != end || r.data[end] != 0 {
+		return nil, fmt.Errorf("document not terminated by a null byte")
+	}
+	r.pos++
+	return object, nil
+}
+
+func decodeBSONValue(r *binaryReader, typ byte) (interface{}, error) {
+	switch typ {
+	case 0x01:
+		b, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return floatNumber(math.Float64frombits(binary.LittleEndian.Uint64(b))), nil
+	case 0x02, 0x0d, 0x0e:
+		return r.bsonString()
+	case 0x03:
+		return decodeBSONDocument(r)
+	case 0x04:
+		document, err := decodeBSONDocument(r)
+		if err != nil {
+			return nil, err
+		}
+		return document.(*Object).valuesInOrder(), nil
+	case 0x05:
+		size, err := r.int32LE()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.byte(); err != nil {
+			return nil, err
+		}
+		data, err := r.next(int(size))
+		return bytesValue(data), err
+	case 0x06, 0x0a:
+		return nil, nil
+	case 0x07:
+		id, err := r.next(12)
+		return hex.EncodeToString(id), err
+	case 0x08:
+		b, err := r.byte()
+		return b != 0, err
+	case 0x09:
+		ms, err := r.int64LE()
+		return timeValue(time.Unix(0, 0).Add(time.Duration(ms) * time.Millisecond)), err
+	case 0x0b:
+		pattern, err := r.cstring()
+		if err != nil {
+			return nil, err
+		}
+		options, err := r.cstring()
+		return "/" + pattern + "/" + options, err
+	case 0x10:
+		n, err := r.int32LE()
+		return intNumber(int64(n)), err
+	case 0x11:
+		n, err := r.int64LE()
+		return uintNumber(uint64(n)), err
+	case 0x12:
+		n, err := r.int64LE()
+		return intNumber(n), err
+	}
+	return nil, fmt.Errorf("unsupported element type 0x%02x", typ)
+}
+
+func (r *binaryReader) cstring() (string, error) {
+	i := bytes.IndexByte(r.data[r.pos:], 0)
+	if i < 0 {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := string(r.data[r.pos : r.pos+i])
+	r.pos += i + 1
+	return s, nil
+}
+
+func (r *binaryReader) bsonString() (string, error) {
+	size, err := r.int32LE()
+	if err != nil {
+		return "", err
+	}
+	if size < 1 {
+		return "", fmt.Errorf("invalid string size %d", size)
+	}
+	s, err := r.next(int(size))
+	if err != nil {
+		return "", err
+	}
+	return string(s[:size-1]), nil
+}
+
+// valuesInOrder returns the values of a BSON array document, whose keys are
+// the indexes.
+func (o *Object) valuesInOrder() []interface{} {
+	values := make([]interface{}, 0, len(o.Keys))
+	for _, key := range o.Keys {
+		values = append(values, o.Values[key])
+	}
+	return values
+}
+
+func encodeBSONDocument(out []byte, object *Object) ([]byte, error) {
+	start := len(out)
+	out = append(out, 0, 0, 0, 0)
+	for _, key := range object.Keys {
+		var err error
+		if out, err = encodeBSONElement(out, key, object.Values[key]); err != nil {
+			return nil, err
+		}
+	}
+	out = append(out, 0)
+	binary.LittleEndian.PutUint32(out[start:], uint32(len(out)-start))
+	return out, nil
+}
+
+// encodeBSONElement appends the element name, integers being stored as
+// int32 when they fit and int64 otherwise.
+func encodeBSONElement(out []byte, name string, value interface{}) ([]byte, error) {
+	if bytes.IndexByte([]byte(name), 0) >= 0 {
+		return nil, fmt.Errorf("BSON keys can't contain null bytes, %q", name)
+	}
+	element := func(typ byte) []byte {
+		return append(append(append(out, typ), name...), 0)
+	}
+	switch v := value.(type) {
+	case nil:
+		return element(0x0a), nil
+	case bool:
+		if v {
+			return append(element(0x08), 1), nil
+		}
+		return append(element(0x08), 0), nil
+	case json.Number:
+		n, err := parseNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		switch n := n.(type) {
+		case int64:
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return append(element(0x10), littleEndian(uint64(n), 4)...), nil
+			}
+			return append(element(0x12), littleEndian(uint64(n), 8)...), nil
+		case float64:
+			return append(element(0x01), littleEndian(math.Float64bits(n), 8)...), nil
+		}
+	case string:
+		out = append(element(0x02), littleEndian(uint64(len(v)+1), 4)...)
+		return append(append(out, v...), 0), nil
+	case []interface{}:
+		array := NewObject()
+		for i, item := range v {
+			array.Set(strconv.Itoa(i), item)
+		}
+		return encodeBSONDocument(element(0x04), array)
+	case *Object:
+		return encodeBSONDocument(element(0x03), v)
+	}
+	return nil, fmt.Errorf("Unexpected json value %T", value)
+}
@@ -0,0 +1,287 @@
+package prettifyjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// errCBORBreak is returned when the break ending an indefinite length item
+// is read.
+var errCBORBreak = errors.New("unexpected break")
+
+// decodeCBOR decodes an RFC 8949 CBOR item. Epoch dates become RFC 3339
+// strings, bignums numbers and the other tags are dropped for their value.
+func decodeCBOR(r *binaryReader) (interface{}, error) {
+	b, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	major, info := b>>5, b&0x1f
+	if b == 0xff {
+		return nil, errCBORBreak
+	}
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		case 25:
+			n, err := r.uint(2)
+			return floatNumber(halfFloat(uint16(n))), err
+		case 26:
+			n, err := r.uint(4)
+			return floatNumber(float64(math.Float32frombits(uint32(n)))), err
+		case 27:
+			n, err := r.uint(8)
+			return floatNumber(math.Float64frombits(n)), err
+		case 24:
+			n, err := r.byte()
+			return intNumber(int64(n)), err
+		default:
+			if info < 20 {
+				return intNumber(int64(info)), nil
+			}
+			return nil, fmt.Errorf("invalid simple value %d", info)
+		}
+	}
+
+	indefinite := info == 31 && major >= 2 && major <= 5
+	var n uint64
+	if !indefinite {
+		if n, err = cborArgument(r, info); err != nil {
+			return nil, err
+		}
+	}
+
+	switch major {
+	case 0:
+		return uintNumber(n), nil
+	case 1:
+		if n > math.MaxInt64 {
+			return json.Number(new(big.Int).Sub(big.NewInt(-1), new(big.Int).SetUint64(n)).String()), nil
+		}
+		return intNumber(-1 - int64(n)), nil
+	case 2, 3:
+		data, err := decodeCBORString(r, major, n, indefinite)
+		if major == 2 {
+			return bytesValue(data), err
+		}
+		return string(data), err
+	case 4:
+		array := []interface{}{}
+		for i := uint64(0); indefinite || i < n; i++ {
+			value, err := decodeCBOR(r)
+			if err == errCBORBreak && indefinite {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		return array, nil
+	case 5:
+		object := NewObject()
+		for i := uint64(0); indefinite || i < n; i++ {
+			key, err := decodeCBOR(r)
+			if err == errCBORBreak && indefinite {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			value, err := decodeCBOR(r)
+			if err != nil {
+				return nil, err
+			}
+			name, err := mapKey(key)
+			if err != nil {
+				return nil, err
+			}
+			object.Set(name, value)
+		}
+		return object, nil
+	default:
+		return decodeCBORTag(r, n)
+	}
+}
+
+// decodeCBORString reads a byte or text string, indefinite ones being the
+// concatenation of their chunks.
+func decodeCBORString(r *binaryReader, major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		if n > math.MaxInt32 {
+			return nil, fmt.Errorf("string of %d bytes too long", n)
+		}
+		return r.next(int(n))
+	}
+	var data []byte
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 0xff {
+			return data, nil
+		}
+		if b>>5 != major || b&0x1f == 31 {
+			return nil, fmt.Errorf("invalid chunk in an indefinite length string")
+		}
+		n, err := cborArgument(r, b&0x1f)
+		if err != nil {
+			return nil, err
+		}
+		chunk, err := decodeCBORString(r, major, n, false)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+}
+
+// cborArgument reads the argument of an item head following its additional
+// information.
+func cborArgument(r *binaryReader, info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return r.uint(1 << (info - 24))
+	default:
+		return 0, fmt.Errorf("invalid additional information %d", info)
+	}
+}
+
+func decodeCBORTag(r *binaryReader, tag uint64) (interface{}, error) {
+	if tag == 2 || tag == 3 {
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if b>>5 != 2 {
+			return nil, fmt.Errorf("bignum tag on a major type %d item", b>>5)
+		}
+		n, err := cborArgument(r, b&0x1f)
+		if err != nil {
+			return nil, err
+		}
+		data, err := decodeCBORString(r, 2, n, false)
+		if err != nil {
+			return nil, err
+		}
+		bignum := new(big.Int).SetBytes(data)
+		if tag == 3 {
+			bignum.Sub(big.NewInt(-1), bignum)
+		}
+		return json.Number(bignum.String()), nil
+	}
+	value, err := decodeCBOR(r)
+	if err != nil {
+		return nil, err
+	}
+	if tag == 1 {
+		if n, ok := value.(json.Number); ok {
+			if f, err := n.Float64(); err == nil {
+				sec, frac := math.Modf(f)
+				return timeValue(time.Unix(int64(sec), int64(frac*1e9))), nil
+			}
+		}
+	}
+	return value, nil
+}
+
+// halfFloat converts an IEEE 754 half precision float.
+func halfFloat(h uint16) float64 {
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
+
+// encodeCBOR appends value to out with the shortest argument encodings and
+// floats as doubles.
+func encodeCBOR(out []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(out, 0xf6), nil
+	case bool:
+		if v {
+			return append(out, 0xf5), nil
+		}
+		return append(out, 0xf4), nil
+	case json.Number:
+		n, err := parseNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		switch n := n.(type) {
+		case int64:
+			if n < 0 {
+				return encodeCBORHead(out, 1, uint64(-1-n)), nil
+			}
+			return encodeCBORHead(out, 0, uint64(n)), nil
+		case float64:
+			return append(append(out, 0xfb), bigEndian(math.Float64bits(n), 8)...), nil
+		}
+	case string:
+		return append(encodeCBORHead(out, 3, uint64(len(v))), v...), nil
+	case []interface{}:
+		out = encodeCBORHead(out, 4, uint64(len(v)))
+		for _, item := range v {
+			var err error
+			if out, err = encodeCBOR(out, item); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case *Object:
+		out = encodeCBORHead(out, 5, uint64(len(v.Keys)))
+		for _, key := range v.Keys {
+			var err error
+			if out, err = encodeCBOR(out, key); err != nil {
+				return nil, err
+			}
+			if out, err = encodeCBOR(out, v.Values[key]); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("Unexpected json value %T", value)
+}
+
+func encodeCBORHead(out []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(out, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(out, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(append(out, major|25), bigEndian(n, 2)...)
+	case n <= math.MaxUint32:
+		return append(append(out, major|26), bigEndian(n, 4)...)
+	default:
+		return append(append(out, major|27), bigEndian(n, 8)...)
+	}
+}
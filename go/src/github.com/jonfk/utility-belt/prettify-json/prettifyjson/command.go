@@ -0,0 +1,363 @@
+package prettifyjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"runtime"
+
+	"github.com/jonfk/utility-belt/internal/httpx"
+	"github.com/urfave/cli"
+)
+
+// Command prettifies json files, run standalone as prettify-json or as ub json fmt.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "fmt",
+		Usage:     "Prettifies json",
+		ArgsUsage: "file|url | file|dir...",
+		Flags: append([]cli.Flag{
+			cli.BoolFlag{
+				Name:  "write,w",
+				Usage: "overwrite to file",
+			},
+			cli.BoolFlag{
+				Name:  "list,l",
+				Usage: "Only print the files whose formatting differs, exit 1 when there are some",
+			},
+			cli.BoolFlag{
+				Name:  "recursive,r",
+				Usage: "Format the .json files of the directories given as arguments",
+			},
+			cli.IntFlag{
+				Name:  "jobs,j",
+				Usage: "Number of files formatted in parallel when given several",
+				Value: runtime.NumCPU(),
+			},
+			cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Fail on duplicate keys within an object",
+			},
+			cli.StringFlag{
+				Name:  "from",
+				Usage: "Input format: json, msgpack, cbor or bson, concatenated binary values are read as an array",
+				Value: "json",
+			},
+			cli.StringFlag{
+				Name:  "to",
+				Usage: "Output format: json, csv, tsv, msgpack, cbor or bson, csv and tsv expect an array of objects",
+				Value: "json",
+			},
+			cli.StringFlag{
+				Name:  "bom",
+				Usage: "UTF-8 byte order mark of the json output: keep the one of the input, strip or add",
+				Value: "keep",
+			},
+			cli.StringFlag{
+				Name:  "eol",
+				Usage: "Line endings of the json output: keep the ones of the input, lf or crlf",
+				Value: "keep",
+			},
+			cli.BoolFlag{
+				Name:  "final-newline",
+				Usage: "End the json output with exactly one line ending",
+			},
+			cli.StringFlag{
+				Name:  "redact",
+				Usage: "Replace the values of the keys matching the case insensitive `REGEXP` with \"***\", e.g. 'password|token|secret'",
+			},
+			cli.BoolFlag{
+				Name:  "canonical",
+				Usage: "Write RFC 8785 canonical json: sorted keys, no whitespace",
+			},
+			cli.BoolFlag{
+				Name:  "flatten",
+				Usage: "Flatten nested objects into dotted columns for csv and tsv",
+			},
+			cli.BoolFlag{
+				Name:  "stats",
+				Usage: "Print a summary of the size and structure of the document instead of the document",
+			},
+			cli.StringFlag{
+				Name:  "patch",
+				Usage: "Apply the RFC 6902 JSON Patch `FILE` before formatting",
+			},
+			cli.StringFlag{
+				Name:  "merge-patch",
+				Usage: "Apply the RFC 7386 JSON Merge Patch `FILE` before formatting",
+			},
+			cli.StringFlag{
+				Name:  "diff-against",
+				Usage: "Print the JSON Patch turning the input into `FILE` instead of the document",
+			},
+			cli.StringSliceFlag{
+				Name:  "header,H",
+				Usage: "Request header as 'Name: value' when fetching an url, can be repeated",
+				Value: &cli.StringSlice{},
+			},
+		}, httpx.Flags...),
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("prettify-json takes a file or an url")
+			}
+			if c.NArg() > 1 || c.Bool("recursive") || c.Bool("list") {
+				return formatBatch(c)
+			}
+			filename := c.Args().First()
+
+			unformattedJson, err := readInput(c, filename)
+			if err != nil {
+				return err
+			}
+			layout, unformattedJson, err := readLayout(c, unformattedJson)
+			if err != nil {
+				return err
+			}
+			if unformattedJson, err = transform(c, filename, unformattedJson); err != nil {
+				return err
+			}
+
+			if c.Bool("stats") {
+				stats, err := ComputeStats(unformattedJson)
+				if err != nil {
+					return err
+				}
+				fmt.Print(FormatStats(stats))
+				return nil
+			}
+
+			out, err := format(c, unformattedJson)
+			if err != nil {
+				return err
+			}
+			out = writeLayout(c, layout, out)
+
+			if c.Bool("write") {
+				if c.String("to") != "json" || c.String("from") != "json" {
+					return fmt.Errorf("--write only works with json input and output")
+				}
+				if IsURL(filename) {
+					return fmt.Errorf("--write can't overwrite an url")
+				}
+				return ioutil.WriteFile(filename, out, 0777)
+			}
+			_, err = os.Stdout.Write(out)
+			return err
+		},
+	}
+}
+
+// transform converts data read from filename to json, checks its duplicate
+// keys and applies the patches and the redaction.
+func transform(c *cli.Context, filename string, data []byte) ([]byte, error) {
+	if from := c.String("from"); from != "json" && from != "" {
+		doc, err := DecodeBinary(from, data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = MarshalOrdered(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Bool("strict") {
+		duplicates, err := FindDuplicateKeys(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, duplicate := range duplicates {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", filename, duplicate)
+		}
+		if len(duplicates) > 0 {
+			return nil, cli.NewExitError(fmt.Sprintf("%d duplicate keys found", len(duplicates)), 1)
+		}
+	}
+
+	data, err := patch(c, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.String("redact") != "" {
+		pattern, err := regexp.Compile("(?i)" + c.String("redact"))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = Redact(data, pattern); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// formatBatch formats the files and directories given as arguments in
+// parallel, like gofmt -l or -w.
+func formatBatch(c *cli.Context) error {
+	if !c.Bool("write") && !c.Bool("list") {
+		return fmt.Errorf("Formatting several files requires --write or --list")
+	}
+	if c.Bool("stats") || c.String("diff-against") != "" || c.String("to") != "json" || c.String("from") != "json" {
+		return fmt.Errorf("Several files can only be formatted from json to json")
+	}
+	for _, arg := range c.Args() {
+		if IsURL(arg) {
+			return fmt.Errorf("Several files can't include the url %s", arg)
+		}
+	}
+	files, err := ExpandFiles(c.Args(), c.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+	results := FormatFiles(files, c.Int("jobs"), c.Bool("write"), func(file string, data []byte) ([]byte, error) {
+		layout, data, err := readLayout(c, data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = transform(c, file, data); err != nil {
+			return nil, err
+		}
+		out, err := format(c, data)
+		if err != nil {
+			return nil, err
+		}
+		return writeLayout(c, layout, out), nil
+	})
+
+	changed, failed := false, false
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			fmt.Fprintf(os.Stderr, "%s: %v\n", result.File, result.Err)
+			failed = true
+		case result.Changed:
+			changed = true
+			if c.Bool("list") {
+				fmt.Println(result.File)
+			}
+		}
+	}
+	fmt.Fprintln(os.Stderr, FormatBatchSummary(results, c.Bool("write")))
+	if failed || (changed && c.Bool("list") && !c.Bool("write")) {
+		return cli.NewExitError("", 1)
+	}
+	return nil
+}
+
+// readLayout strips the BOM of json input and returns the layout of the
+// output resolved from the flags and the input.
+func readLayout(c *cli.Context, data []byte) (TextLayout, []byte, error) {
+	input := TextLayout{}
+	if c.String("from") == "json" || c.String("from") == "" {
+		input, data = DetectLayout(data)
+	}
+	layout, err := ResolveLayout(input, c.String("bom"), c.String("eol"), c.Bool("final-newline"))
+	return layout, data, err
+}
+
+// writeLayout lays out out when it is indented json.
+func writeLayout(c *cli.Context, layout TextLayout, out []byte) []byte {
+	if c.Bool("canonical") || (c.String("to") != "json" && c.String("to") != "") {
+		return out
+	}
+	return layout.Apply(out)
+}
+
+// readInput reads the file or fetches the url given as input.
+func readInput(c *cli.Context, input string) ([]byte, error) {
+	if !IsURL(input) {
+		return ioutil.ReadFile(input)
+	}
+	headers, err := ParseHeaders(c.StringSlice("header"))
+	if err != nil {
+		return nil, err
+	}
+	client, err := httpx.FromContext(c)
+	if err != nil {
+		return nil, err
+	}
+	return Fetch(client, input, headers)
+}
+
+// patch applies the --patch and --merge-patch documents to data, or replaces
+// it by the patch to the --diff-against document.
+func patch(c *cli.Context, data []byte) ([]byte, error) {
+	if c.String("patch") == "" && c.String("merge-patch") == "" && c.String("diff-against") == "" {
+		return data, nil
+	}
+	doc, err := DecodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+	if filename := c.String("patch"); filename != "" {
+		patch, err := readOrdered(filename)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = ApplyPatch(doc, patch); err != nil {
+			return nil, cli.NewExitError(fmt.Sprintf("%s: %v", filename, err), 1)
+		}
+	}
+	if filename := c.String("merge-patch"); filename != "" {
+		patch, err := readOrdered(filename)
+		if err != nil {
+			return nil, err
+		}
+		doc = MergePatch(doc, patch)
+	}
+	if filename := c.String("diff-against"); filename != "" {
+		other, err := readOrdered(filename)
+		if err != nil {
+			return nil, err
+		}
+		operations := DiffPatch(doc, other)
+		if operations == nil {
+			operations = []interface{}{}
+		}
+		doc = operations
+	}
+	return MarshalOrdered(doc)
+}
+
+func readOrdered(filename string) (interface{}, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := DecodeOrdered(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return doc, nil
+}
+
+// Indent returns data indented by two spaces, the way fmt prints json.
+func Indent(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	err := json.Indent(&out, data, "", "  ")
+	return out.Bytes(), err
+}
+
+func format(c *cli.Context, data []byte) ([]byte, error) {
+	if c.Bool("canonical") {
+		return Canonicalize(data)
+	}
+	switch c.String("to") {
+	case "json", "":
+		return Indent(data)
+	case "csv":
+		return ToCSV(data, ',', c.Bool("flatten"))
+	case "tsv":
+		return ToCSV(data, '\t', c.Bool("flatten"))
+	case "msgpack", "cbor", "bson":
+		doc, err := DecodeOrdered(data)
+		if err != nil {
+			return nil, err
+		}
+		return EncodeBinary(c.String("to"), doc)
+	default:
+		return nil, fmt.Errorf("Unknown output format %q, expected json, csv, tsv, msgpack, cbor or bson", c.String("to"))
+	}
+}
@@ -0,0 +1,105 @@
+package prettifyjson
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ToCSV converts an array of objects into rows with a header made of the union
+// of their keys, new keys are added in alphabetical order as they first appear
+// in the array. Nested values are written as
+// json unless flatten is set, in which case objects become dotted columns.
+func ToCSV(data []byte, comma rune, flatten bool) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var items []interface{}
+	if err := decoder.Decode(&items); err != nil {
+		return nil, fmt.Errorf("Expected an array of objects: %v", err)
+	}
+
+	columns := []string{}
+	seen := map[string]bool{}
+	rows := []map[string]string{}
+	for i, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Element %d is not an object", i)
+		}
+		row := map[string]string{}
+		if err := flattenInto(row, "", object, flatten); err != nil {
+			return nil, err
+		}
+		// Map iteration order is random, keep the columns of an element stable
+		for _, key := range sortedKeys(row) {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = row[column]
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func flattenInto(row map[string]string, prefix string, object map[string]interface{}, flatten bool) error {
+	for key, value := range object {
+		column := prefix + key
+		if nested, ok := value.(map[string]interface{}); ok && flatten {
+			if err := flattenInto(row, column+".", nested, flatten); err != nil {
+				return err
+			}
+			continue
+		}
+		cell, err := csvCell(value)
+		if err != nil {
+			return err
+		}
+		row[column] = cell
+	}
+	return nil
+}
+
+func csvCell(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	default:
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
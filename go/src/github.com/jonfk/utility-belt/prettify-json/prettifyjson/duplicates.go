@@ -0,0 +1,100 @@
+package prettifyjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type DuplicateKey struct {
+	Path string
+	Key  string
+	// Line of the duplicate occurrence, FirstLine of the first one
+	Line      int
+	FirstLine int
+}
+
+func (d DuplicateKey) String() string {
+	return fmt.Sprintf("line %d: duplicate key %q in %s, first defined on line %d", d.Line, d.Key, d.Path, d.FirstLine)
+}
+
+// FindDuplicateKeys walks data and reports every key defined more than once
+// in the same object, which json.Unmarshal and json.Indent silently accept.
+func FindDuplicateKeys(data []byte) ([]DuplicateKey, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	duplicates := []DuplicateKey{}
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	type frame struct {
+		path   string
+		object bool
+		index  int
+		keys   map[string]int
+		// expectKey is set when the next token of an object is a key
+		expectKey bool
+		key       string
+	}
+	stack := []*frame{}
+	childPath := func() string {
+		if len(stack) == 0 {
+			return "$"
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			return top.path + "." + top.key
+		}
+		return top.path + "[" + strconv.Itoa(top.index) + "]"
+	}
+	// valueDone moves the parent past the value that just ended
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.expectKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return duplicates, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineAt(decoder.InputOffset()), err)
+		}
+
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if key, ok := token.(string); ok && top.object && top.expectKey {
+				line := lineAt(decoder.InputOffset())
+				if first, ok := top.keys[key]; ok {
+					duplicates = append(duplicates, DuplicateKey{Path: top.path, Key: key, Line: line, FirstLine: first})
+				} else {
+					top.keys[key] = line
+				}
+				top.key = key
+				top.expectKey = false
+				continue
+			}
+		}
+
+		switch token {
+		case json.Delim('{'):
+			stack = append(stack, &frame{path: childPath(), object: true, keys: map[string]int{}, expectKey: true})
+		case json.Delim('['):
+			stack = append(stack, &frame{path: childPath()})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			valueDone()
+		default:
+			valueDone()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package prettifyjson
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// IsURL reports whether the input argument is an http url to fetch rather than a file.
+func IsURL(arg string) bool {
+	return strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://")
+}
+
+// ParseHeaders parses headers given as 'Name: value'.
+func ParseHeaders(headers []string) (http.Header, error) {
+	parsed := http.Header{}
+	for _, header := range headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid header %q, expected 'Name: value'", header)
+		}
+		parsed.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+	return parsed, nil
+}
+
+// Fetch GETs url with headers and returns the body, a response outside of 2xx
+// is an error including the start of the body.
+func Fetch(client *http.Client, url string, headers http.Header) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		excerpt := strings.TrimSpace(string(body))
+		if len(excerpt) > 200 {
+			excerpt = excerpt[:200] + "..."
+		}
+		return nil, fmt.Errorf("GET %s: %s: %s", url, resp.Status, excerpt)
+	}
+	return body, nil
+}
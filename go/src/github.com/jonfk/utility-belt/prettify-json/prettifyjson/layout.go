@@ -0,0 +1,66 @@
+package prettifyjson
+
+import (
+	"bytes"
+	"fmt"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// TextLayout is the byte order mark and the line endings of a json file.
+type TextLayout struct {
+	BOM  bool
+	CRLF bool
+	// FinalNewline ends the file with exactly one line ending
+	FinalNewline bool
+}
+
+// DetectLayout returns the layout of data and data without its UTF-8 BOM,
+// which json parsers reject.
+func DetectLayout(data []byte) (TextLayout, []byte) {
+	layout := TextLayout{
+		BOM:  bytes.HasPrefix(data, utf8BOM),
+		CRLF: bytes.Contains(data, []byte("\r\n")),
+	}
+	return layout, bytes.TrimPrefix(data, utf8BOM)
+}
+
+// ResolveLayout applies the keep, strip or add bom and the keep, lf or crlf
+// eol options to the layout of the input.
+func ResolveLayout(input TextLayout, bom, eol string, finalNewline bool) (TextLayout, error) {
+	layout := TextLayout{FinalNewline: finalNewline}
+	switch bom {
+	case "keep", "":
+		layout.BOM = input.BOM
+	case "strip":
+	case "add":
+		layout.BOM = true
+	default:
+		return layout, fmt.Errorf("Invalid --bom %q, expected keep, strip or add", bom)
+	}
+	switch eol {
+	case "keep", "":
+		layout.CRLF = input.CRLF
+	case "lf":
+	case "crlf":
+		layout.CRLF = true
+	default:
+		return layout, fmt.Errorf("Invalid --eol %q, expected keep, lf or crlf", eol)
+	}
+	return layout, nil
+}
+
+// Apply lays out formatted json, whose strings can't hold raw line endings.
+func (l TextLayout) Apply(data []byte) []byte {
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	if l.FinalNewline {
+		data = append(bytes.TrimRight(data, " \t\r\n"), '\n')
+	}
+	if l.CRLF {
+		data = bytes.Replace(data, []byte("\n"), []byte("\r\n"), -1)
+	}
+	if l.BOM {
+		data = append(append([]byte{}, utf8BOM...), data...)
+	}
+	return data
+}
@@ -0,0 +1,243 @@
+package prettifyjson
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// decodeMsgpack decodes a MessagePack value, the timestamp extension
+// becoming an RFC 3339 string and other extensions {"type": n, "data": base64}.
+func decodeMsgpack(r *binaryReader) (interface{}, error) {
+	b, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case b <= 0x7f:
+		return intNumber(int64(b)), nil
+	case b >= 0xe0:
+		return intNumber(int64(int8(b))), nil
+	case b&0xf0 == 0x80:
+		return decodeMsgpackMap(r, int(b&0x0f))
+	case b&0xf0 == 0x90:
+		return decodeMsgpackArray(r, int(b&0x0f))
+	case b&0xe0 == 0xa0:
+		s, err := r.next(int(b & 0x1f))
+		return string(s), err
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.next(int(n))
+		return bytesValue(data), err
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.uint(1 << (b - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackExt(r, int(n))
+	case 0xca:
+		n, err := r.uint(4)
+		return floatNumber(float64(math.Float32frombits(uint32(n)))), err
+	case 0xcb:
+		n, err := r.uint(8)
+		return floatNumber(math.Float64frombits(n)), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := r.uint(1 << (b - 0xcc))
+		return uintNumber(n), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		n, err := r.uint(size)
+		// sign extend from size bytes
+		shift := uint(64 - 8*size)
+		return intNumber(int64(n<<shift) >> shift), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return decodeMsgpackExt(r, 1<<(b-0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		s, err := r.next(int(n))
+		return string(s), err
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackArray(r, int(n))
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackMap(r, int(n))
+	}
+	return nil, fmt.Errorf("unused type byte 0x%02x", b)
+}
+
+func decodeMsgpackArray(r *binaryReader, n int) (interface{}, error) {
+	array := []interface{}{}
+	for i := 0; i < n; i++ {
+		value, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+	}
+	return array, nil
+}
+
+func decodeMsgpackMap(r *binaryReader, n int) (interface{}, error) {
+	object := NewObject()
+	for i := 0; i < n; i++ {
+		key, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		name, err := mapKey(key)
+		if err != nil {
+			return nil, err
+		}
+		object.Set(name, value)
+	}
+	return object, nil
+}
+
+func decodeMsgpackExt(r *binaryReader, n int) (interface{}, error) {
+	typ, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ) == -1 {
+		switch n {
+		case 4:
+			return timeValue(time.Unix(int64(binary.BigEndian.Uint32(data)), 0)), nil
+		case 8:
+			n := binary.BigEndian.Uint64(data)
+			return timeValue(time.Unix(int64(n&(1<<34-1)), int64(n>>34))), nil
+		case 12:
+			nsec := binary.BigEndian.Uint32(data)
+			return timeValue(time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(nsec))), nil
+		}
+	}
+	ext := NewObject()
+	ext.Set("type", intNumber(int64(int8(typ))))
+	ext.Set("data", bytesValue(data))
+	return ext, nil
+}
+
+// encodeMsgpack appends value to out using the smallest representation.
+func encodeMsgpack(out []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(out, 0xc0), nil
+	case bool:
+		if v {
+			return append(out, 0xc3), nil
+		}
+		return append(out, 0xc2), nil
+	case json.Number:
+		n, err := parseNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		switch n := n.(type) {
+		case int64:
+			return encodeMsgpackInt(out, n), nil
+		case float64:
+			return append(append(out, 0xcb), bigEndian(math.Float64bits(n), 8)...), nil
+		}
+	case string:
+		switch n := len(v); {
+		case n < 32:
+			out = append(out, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			out = append(out, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			out = append(append(out, 0xda), bigEndian(uint64(n), 2)...)
+		default:
+			out = append(append(out, 0xdb), bigEndian(uint64(n), 4)...)
+		}
+		return append(out, v...), nil
+	case []interface{}:
+		out = encodeMsgpackLength(out, len(v), 0x90, 0xdc)
+		for _, item := range v {
+			var err error
+			if out, err = encodeMsgpack(out, item); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case *Object:
+		out = encodeMsgpackLength(out, len(v.Keys), 0x80, 0xde)
+		for _, key := range v.Keys {
+			var err error
+			if out, err = encodeMsgpack(out, key); err != nil {
+				return nil, err
+			}
+			if out, err = encodeMsgpack(out, v.Values[key]); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("Unexpected json value %T", value)
+}
+
+func encodeMsgpackInt(out []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n <= 0x7f, n < 0 && n >= -32:
+		return append(out, byte(n))
+	case n >= 0 && n <= math.MaxUint8:
+		return append(out, 0xcc, byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		return append(append(out, 0xcd), bigEndian(uint64(n), 2)...)
+	case n >= 0 && n <= math.MaxUint32:
+		return append(append(out, 0xce), bigEndian(uint64(n), 4)...)
+	case n >= 0:
+		return append(append(out, 0xcf), bigEndian(uint64(n), 8)...)
+	case n >= math.MinInt8:
+		return append(out, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return append(append(out, 0xd1), bigEndian(uint64(n), 2)...)
+	case n >= math.MinInt32:
+		return append(append(out, 0xd2), bigEndian(uint64(n), 4)...)
+	default:
+		return append(append(out, 0xd3), bigEndian(uint64(n), 8)...)
+	}
+}
+
+// encodeMsgpackLength appends the header of an array or a map of n items,
+// fix being the type byte of the short form and long the 16 bits one.
+func encodeMsgpackLength(out []byte, n int, fix, long byte) []byte {
+	switch {
+	case n < 16:
+		return append(out, fix|byte(n))
+	case n <= math.MaxUint16:
+		return append(append(out, long), bigEndian(uint64(n), 2)...)
+	default:
+		return append(append(out, long+1), bigEndian(uint64(n), 4)...)
+	}
+}
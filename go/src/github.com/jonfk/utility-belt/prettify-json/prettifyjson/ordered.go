@@ -0,0 +1,198 @@
+package prettifyjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Object is a json object keeping the order of its keys, so documents
+// modified by the patches are written back in their original order.
+type Object struct {
+	Keys   []string
+	Values map[string]interface{}
+}
+
+func NewObject() *Object {
+	return &Object{Values: map[string]interface{}{}}
+}
+
+func (o *Object) Get(key string) (interface{}, bool) {
+	value, ok := o.Values[key]
+	return value, ok
+}
+
+// Set replaces the value of key, new keys are added last.
+func (o *Object) Set(key string, value interface{}) {
+	if _, ok := o.Values[key]; !ok {
+		o.Keys = append(o.Keys, key)
+	}
+	o.Values[key] = value
+}
+
+func (o *Object) Delete(key string) {
+	if _, ok := o.Values[key]; !ok {
+		return
+	}
+	delete(o.Values, key)
+	for i, k := range o.Keys {
+		if k == key {
+			o.Keys = append(o.Keys[:i:i], o.Keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeJSON(&buf, key); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := encodeJSON(&buf, o.Values[key]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalOrdered encodes a value of DecodeOrdered compactly without escaping
+// HTML characters.
+func MarshalOrdered(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := encodeJSON(&buf, value)
+	return buf.Bytes(), err
+}
+
+func encodeJSON(buf *bytes.Buffer, value interface{}) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// DecodeOrdered decodes data with objects as *Object, arrays as
+// []interface{} and numbers as json.Number.
+func DecodeOrdered(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeOrderedValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("Unexpected data after the json value")
+	}
+	return value, nil
+}
+
+func decodeOrderedValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := NewObject()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.Set(key.(string), value)
+		}
+		_, err := decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err
+	default:
+		return token, nil
+	}
+}
+
+// deepCopy copies a value of DecodeOrdered.
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *Object:
+		object := NewObject()
+		for _, key := range v.Keys {
+			object.Set(key, deepCopy(v.Values[key]))
+		}
+		return object
+	case []interface{}:
+		array := make([]interface{}, len(v))
+		for i, item := range v {
+			array[i] = deepCopy(item)
+		}
+		return array
+	default:
+		return value
+	}
+}
+
+// jsonEqual compares values of DecodeOrdered, numbers by value and objects
+// regardless of the order of their keys.
+func jsonEqual(a, b interface{}) bool {
+	switch va := a.(type) {
+	case *Object:
+		vb, ok := b.(*Object)
+		if !ok || len(va.Keys) != len(vb.Keys) {
+			return false
+		}
+		for _, key := range va.Keys {
+			other, ok := vb.Values[key]
+			if !ok || !jsonEqual(va.Values[key], other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !jsonEqual(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		vb, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if va == vb {
+			return true
+		}
+		fa, errA := va.Float64()
+		fb, errB := vb.Float64()
+		return errA == nil && errB == nil && fa == fb
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,353 @@
+package prettifyjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplyPatch applies the RFC 6902 JSON Patch document patch to doc, both
+// decoded by DecodeOrdered. The operations apply in order and the first
+// failing one, including a failed test, aborts the patch.
+func ApplyPatch(doc, patch interface{}) (interface{}, error) {
+	operations, ok := patch.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("A JSON Patch is an array of operations")
+	}
+	for i, item := range operations {
+		operation, ok := item.(*Object)
+		if !ok {
+			return nil, fmt.Errorf("Patch operation %d is not an object", i+1)
+		}
+		var err error
+		if doc, err = applyOperation(doc, operation); err != nil {
+			op, _ := operation.Get("op")
+			path, _ := operation.Get("path")
+			return nil, fmt.Errorf("Patch operation %d (%v %v): %v", i+1, op, path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, operation *Object) (interface{}, error) {
+	str := func(name string) (string, error) {
+		value, ok := operation.Get(name)
+		s, isString := value.(string)
+		if !ok || !isString {
+			return "", fmt.Errorf("missing %s", name)
+		}
+		return s, nil
+	}
+	op, err := str("op")
+	if err != nil {
+		return nil, err
+	}
+	path, err := str("path")
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := operation.Get("value")
+
+	switch op {
+	case "add", "replace", "test":
+		if !hasValue {
+			return nil, fmt.Errorf("missing value")
+		}
+	}
+	switch op {
+	case "add":
+		return addAt(doc, tokens, deepCopy(value))
+	case "remove":
+		doc, _, err := removeAt(doc, tokens)
+		return doc, err
+	case "replace":
+		if _, err := getAt(doc, tokens); err != nil {
+			return nil, err
+		}
+		return setAt(doc, tokens, deepCopy(value))
+	case "test":
+		current, err := getAt(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(current, value) {
+			return nil, fmt.Errorf("test failed, the value is %s", marshalForError(current))
+		}
+		return doc, nil
+	case "move", "copy":
+		from, err := str("from")
+		if err != nil {
+			return nil, err
+		}
+		fromTokens, err := parsePointer(from)
+		if err != nil {
+			return nil, err
+		}
+		if op == "copy" {
+			value, err := getAt(doc, fromTokens)
+			if err != nil {
+				return nil, err
+			}
+			return addAt(doc, tokens, deepCopy(value))
+		}
+		if strings.HasPrefix(path, from+"/") {
+			return nil, fmt.Errorf("can't move %s into one of its children", from)
+		}
+		doc, value, err := removeAt(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+		return addAt(doc, tokens, value)
+	default:
+		return nil, fmt.Errorf("unknown op %q", op)
+	}
+}
+
+// MergePatch applies the RFC 7386 JSON Merge Patch patch to target: objects
+// are merged recursively, null removes a key and other values replace.
+func MergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(*Object)
+	if !ok {
+		return deepCopy(patch)
+	}
+	t, ok := target.(*Object)
+	if !ok {
+		t = NewObject()
+	}
+	for _, key := range p.Keys {
+		if p.Values[key] == nil {
+			t.Delete(key)
+			continue
+		}
+		current, _ := t.Get(key)
+		t.Set(key, MergePatch(current, p.Values[key]))
+	}
+	return t
+}
+
+// DiffPatch returns the JSON Patch turning a into b. Objects are compared
+// key by key and arrays index by index, the extra elements of the longer
+// array being added or removed at the end.
+func DiffPatch(a, b interface{}) []interface{} {
+	return diffAt(nil, "", a, b)
+}
+
+func diffAt(patch []interface{}, path string, a, b interface{}) []interface{} {
+	switch va := a.(type) {
+	case *Object:
+		vb, ok := b.(*Object)
+		if !ok {
+			break
+		}
+		for _, key := range va.Keys {
+			if _, ok := vb.Get(key); !ok {
+				patch = append(patch, operation("remove", path+"/"+escapePointer(key), nil))
+			}
+		}
+		for _, key := range vb.Keys {
+			if old, ok := va.Get(key); ok {
+				patch = diffAt(patch, path+"/"+escapePointer(key), old, vb.Values[key])
+			} else {
+				patch = append(patch, operation("add", path+"/"+escapePointer(key), vb.Values[key]))
+			}
+		}
+		return patch
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(va) && i < len(vb); i++ {
+			patch = diffAt(patch, path+"/"+strconv.Itoa(i), va[i], vb[i])
+		}
+		for i := len(va) - 1; i >= len(vb); i-- {
+			patch = append(patch, operation("remove", path+"/"+strconv.Itoa(i), nil))
+		}
+		for i := len(va); i < len(vb); i++ {
+			patch = append(patch, operation("add", path+"/-", vb[i]))
+		}
+		return patch
+	}
+	if jsonEqual(a, b) {
+		return patch
+	}
+	return append(patch, operation("replace", path, b))
+}
+
+func operation(op, path string, value interface{}) *Object {
+	o := NewObject()
+	o.Set("op", op)
+	o.Set("path", path)
+	if op != "remove" {
+		o.Set("value", value)
+	}
+	return o
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens,
+// the empty pointer being the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q, expected it to start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// arrayIndex parses the token indexing array, "-" or length are only valid
+// when end is set, to add at the end.
+func arrayIndex(token string, length int, end bool) (int, error) {
+	if token == "-" && end {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') || strings.HasPrefix(token, "+") {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > length || (i == length && !end) {
+		return 0, fmt.Errorf("array index %d out of bounds", i)
+	}
+	return i, nil
+}
+
+func getAt(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch v := doc.(type) {
+		case *Object:
+			value, ok := v.Get(token)
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("can't index a scalar with %q", token)
+		}
+	}
+	return doc, nil
+}
+
+// updateParent calls update with the container of the last token and
+// stores the container it returns, arrays being reallocated by inserts.
+func updateParent(doc interface{}, tokens []string, update func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return update(doc, tokens[0])
+	}
+	child, err := getAt(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = updateParent(child, tokens[1:], update); err != nil {
+		return nil, err
+	}
+	switch v := doc.(type) {
+	case *Object:
+		v.Set(tokens[0], child)
+	case []interface{}:
+		i, _ := arrayIndex(tokens[0], len(v), false)
+		v[i] = child
+	}
+	return doc, nil
+}
+
+func addAt(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch v := parent.(type) {
+		case *Object:
+			v.Set(token, value)
+			return v, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(v), true)
+			if err != nil {
+				return nil, err
+			}
+			v = append(v, nil)
+			copy(v[i+1:], v[i:])
+			v[i] = value
+			return v, nil
+		default:
+			return nil, fmt.Errorf("can't add %q to a scalar", token)
+		}
+	})
+}
+
+func setAt(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch v := parent.(type) {
+		case *Object:
+			v.Set(token, value)
+		case []interface{}:
+			i, err := arrayIndex(token, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = value
+		}
+		return parent, nil
+	})
+}
+
+func removeAt(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("can't remove the whole document")
+	}
+	var removed interface{}
+	doc, err := updateParent(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch v := parent.(type) {
+		case *Object:
+			value, ok := v.Get(token)
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			removed = value
+			v.Delete(token)
+			return v, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = v[i]
+			return append(v[:i:i], v[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("can't remove %q from a scalar", token)
+		}
+	})
+	return doc, removed, err
+}
+
+func marshalForError(value interface{}) string {
+	out, err := MarshalOrdered(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	if len(out) > 80 {
+		return string(out[:80]) + "..."
+	}
+	return string(out)
+}
@@ -0,0 +1,120 @@
+package prettifyjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+)
+
+// Redacted replaces the values of the redacted keys.
+const Redacted = "***"
+
+// Redact replaces the values of the keys matching pattern with Redacted at any
+// depth. The document is rewritten token by token so the key order is kept,
+// the output is compact.
+func Redact(data []byte, pattern *regexp.Regexp) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var buf bytes.Buffer
+
+	type frame struct {
+		object    bool
+		count     int
+		expectKey bool
+	}
+	stack := []*frame{}
+	// separator writes the comma or colon expected before the next token
+	separator := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		switch {
+		case top.object && !top.expectKey:
+			buf.WriteByte(':')
+		case top.count > 0:
+			buf.WriteByte(',')
+		}
+	}
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		top.count++
+		if top.object {
+			top.expectKey = true
+		}
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if key, ok := token.(string); ok && top.object && top.expectKey {
+				separator()
+				writeJSON(&buf, key)
+				top.expectKey = false
+				if pattern.MatchString(key) {
+					if err := skipValue(decoder); err != nil {
+						return nil, err
+					}
+					buf.WriteByte(':')
+					writeJSON(&buf, Redacted)
+					valueDone()
+				}
+				continue
+			}
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			separator()
+			buf.WriteString(token.(json.Delim).String())
+			stack = append(stack, &frame{object: token == json.Delim('{'), expectKey: true})
+		case json.Delim('}'), json.Delim(']'):
+			buf.WriteString(token.(json.Delim).String())
+			stack = stack[:len(stack)-1]
+			valueDone()
+		default:
+			separator()
+			writeJSON(&buf, token)
+			valueDone()
+		}
+	}
+}
+
+// skipValue consumes the next value of decoder, nested or not.
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(v)
+	// Encode terminates the value with a newline
+	buf.Truncate(buf.Len() - 1)
+}
@@ -0,0 +1,169 @@
+package prettifyjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StatsTop is the number of entries of the rankings of Stats.
+const StatsTop = 10
+
+// Stats summarizes the size and structure of a json document.
+type Stats struct {
+	Size     int
+	MaxDepth int
+	Objects  int
+	Arrays   int
+	Keys     int
+	Strings  int
+	Numbers  int
+	Bools    int
+	Nulls    int
+	// KeyNames counts the occurrences of every key name
+	KeyNames map[string]int
+	// Longest arrays and Largest subtrees by compact serialized size, largest first
+	Longest []PathSize
+	Largest []PathSize
+}
+
+// PathSize is the length or size of the value at Path.
+type PathSize struct {
+	Path string
+	Size int
+}
+
+// ComputeStats walks data and sums up its structure, the sizes are those of
+// the compact serialization rather than of data.
+func ComputeStats(data []byte) (*Stats, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	stats := &Stats{KeyNames: map[string]int{}}
+	stats.Size = stats.walk(value, "$", 1)
+	sortPathSizes(stats.Longest)
+	sortPathSizes(stats.Largest)
+	return stats, nil
+}
+
+// walk records value and returns its compact serialized size.
+func (s *Stats) walk(value interface{}, path string, depth int) int {
+	if depth > s.MaxDepth {
+		s.MaxDepth = depth
+	}
+	size := 0
+	switch v := value.(type) {
+	case nil:
+		s.Nulls++
+		return len("null")
+	case bool:
+		s.Bools++
+		return len(strconv.FormatBool(v))
+	case json.Number:
+		s.Numbers++
+		return len(v.String())
+	case string:
+		s.Strings++
+		return jsonStringSize(v)
+	case []interface{}:
+		s.Arrays++
+		size = 2 + max(len(v)-1, 0)
+		for i, item := range v {
+			size += s.walk(item, path+"["+strconv.Itoa(i)+"]", depth+1)
+		}
+		s.Longest = addRanked(s.Longest, PathSize{Path: path, Size: len(v)})
+	case map[string]interface{}:
+		s.Objects++
+		size = 2 + max(len(v)-1, 0)
+		for key, item := range v {
+			s.Keys++
+			s.KeyNames[key]++
+			size += jsonStringSize(key) + 1 + s.walk(item, path+"."+key, depth+1)
+		}
+	}
+	if path != "$" {
+		s.Largest = addRanked(s.Largest, PathSize{Path: path, Size: size})
+	}
+	return size
+}
+
+func jsonStringSize(s string) int {
+	encoded, _ := json.Marshal(s)
+	return len(encoded)
+}
+
+// addRanked keeps the StatsTop largest entries, sorting only once they overflow.
+func addRanked(ranked []PathSize, entry PathSize) []PathSize {
+	ranked = append(ranked, entry)
+	if len(ranked) > 2*StatsTop {
+		sortPathSizes(ranked)
+		ranked = ranked[:StatsTop]
+	}
+	return ranked
+}
+
+func sortPathSizes(ranked []PathSize) {
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Size > ranked[j].Size })
+}
+
+// FormatStats renders stats as a plain text report.
+func FormatStats(stats *Stats) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Size:      %s\n", formatSize(stats.Size))
+	fmt.Fprintf(&b, "Max depth: %d\n", stats.MaxDepth)
+	fmt.Fprintf(&b, "Objects:   %d (%d keys, %d distinct)\n", stats.Objects, stats.Keys, len(stats.KeyNames))
+	fmt.Fprintf(&b, "Arrays:    %d\n", stats.Arrays)
+	fmt.Fprintf(&b, "Strings:   %d\n", stats.Strings)
+	fmt.Fprintf(&b, "Numbers:   %d\n", stats.Numbers)
+	fmt.Fprintf(&b, "Booleans:  %d\n", stats.Bools)
+	fmt.Fprintf(&b, "Nulls:     %d\n", stats.Nulls)
+
+	names := []string{}
+	for name := range stats.KeyNames {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if stats.KeyNames[names[i]] != stats.KeyNames[names[j]] {
+			return stats.KeyNames[names[i]] > stats.KeyNames[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > 0 {
+		b.WriteString("\nMost common keys:\n")
+		for _, name := range names[:min(len(names), StatsTop)] {
+			fmt.Fprintf(&b, "  %8d  %s\n", stats.KeyNames[name], name)
+		}
+	}
+	if len(stats.Longest) > 0 {
+		b.WriteString("\nLongest arrays:\n")
+		for _, array := range stats.Longest[:min(len(stats.Longest), StatsTop)] {
+			fmt.Fprintf(&b, "  %8d  %s\n", array.Size, array.Path)
+		}
+	}
+	if len(stats.Largest) > 0 {
+		b.WriteString("\nLargest subtrees:\n")
+		for _, subtree := range stats.Largest[:min(len(stats.Largest), StatsTop)] {
+			fmt.Fprintf(&b, "  %8s  %s\n", formatSize(subtree.Size), subtree.Path)
+		}
+	}
+	return b.String()
+}
+
+func formatSize(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
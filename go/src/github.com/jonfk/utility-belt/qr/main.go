@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/qr/qrcode"
+)
+
+func main() {
+	belt.Run(belt.NewApp("qr", qrcode.Command()))
+}
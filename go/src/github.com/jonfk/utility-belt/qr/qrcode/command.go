@@ -0,0 +1,173 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/jonfk/utility-belt/internal/qr"
+	"github.com/urfave/cli"
+)
+
+// Command renders texts as QR codes and decodes them from images, run
+// standalone as qr or as ub qr.
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "qr",
+		Usage: "Renders texts and URLs as QR codes and decodes QR codes from images",
+		Subcommands: []cli.Command{
+			{
+				Name:      "encode",
+				Usage:     "Print the QR code of the arguments, or stdin, on the terminal or write it as a PNG",
+				ArgsUsage: "[TEXT...]",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "png,o",
+						Usage: "Write the code as a PNG to `FILE` instead, - for stdout",
+					},
+					cli.IntFlag{
+						Name:  "scale",
+						Usage: "Pixels per module of the PNG",
+						Value: 8,
+					},
+					cli.StringFlag{
+						Name:  "totp",
+						Usage: "Encode the otpauth URI of the base32 TOTP `SECRET`, e.g. from pass-gen get, for authenticator apps",
+					},
+					cli.StringFlag{
+						Name:  "label",
+						Usage: "`ISSUER:ACCOUNT` shown by authenticator apps for --totp",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					text, err := encodedText(c)
+					if err != nil {
+						return err
+					}
+					code, err := qr.Encode(text)
+					if err != nil {
+						return err
+					}
+					if c.String("png") == "" {
+						fmt.Print(code.Terminal())
+						return nil
+					}
+					if c.Int("scale") < 1 {
+						return fmt.Errorf("Invalid --scale %d", c.Int("scale"))
+					}
+					if c.String("png") == "-" {
+						return png.Encode(os.Stdout, code.Image(c.Int("scale")))
+					}
+					f, err := os.Create(c.String("png"))
+					if err != nil {
+						return err
+					}
+					if err := png.Encode(f, code.Image(c.Int("scale"))); err != nil {
+						f.Close()
+						return err
+					}
+					return f.Close()
+				},
+			},
+			{
+				Name:      "decode",
+				Usage:     "Print the text of the QR code in PNG, JPEG or GIF images",
+				ArgsUsage: "[IMAGE|-]...",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "secret",
+						Usage: "Only print the secret of otpauth URIs, to pipe into pass-gen save --stdin",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					filenames := []string(c.Args())
+					if len(filenames) == 0 {
+						filenames = []string{"-"}
+					}
+					failed := false
+					for _, filename := range filenames {
+						text, err := decodeFile(filename)
+						if err == nil && c.Bool("secret") {
+							text, err = TOTPSecret(text)
+						}
+						if err != nil {
+							fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+							failed = true
+							continue
+						}
+						fmt.Println(text)
+					}
+					if failed {
+						return cli.NewExitError("", 1)
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// encodedText returns the otpauth URI of --totp or the text to encode.
+func encodedText(c *cli.Context) (string, error) {
+	if c.String("totp") != "" {
+		return TOTPURI(c.String("totp"), c.String("label"))
+	}
+	if c.NArg() > 0 {
+		return strings.Join(c.Args(), " "), nil
+	}
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// TOTPURI returns the otpauth URI of secret, the issuer of label being its
+// part before a colon.
+func TOTPURI(secret, label string) (string, error) {
+	secret = strings.ToUpper(strings.Replace(secret, " ", "", -1))
+	if strings.Trim(secret, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567=") != "" {
+		return "", fmt.Errorf("Invalid TOTP secret, expected base32")
+	}
+	if label == "" {
+		return "", fmt.Errorf("--totp requires a --label")
+	}
+	query := url.Values{"secret": {strings.TrimRight(secret, "=")}}
+	if i := strings.Index(label, ":"); i > 0 {
+		query.Set("issuer", label[:i])
+	}
+	return (&url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: query.Encode()}).String(), nil
+}
+
+// TOTPSecret returns the secret of an otpauth URI.
+func TOTPSecret(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "otpauth" || u.Query().Get("secret") == "" {
+		return "", fmt.Errorf("Not an otpauth URI: %s", uri)
+	}
+	return u.Query().Get("secret"), nil
+}
+
+func decodeFile(filename string) (string, error) {
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return "", err
+	}
+	return qr.Decode(img)
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/serve-dir/servedir"
+)
+
+func main() {
+	belt.Run(belt.NewApp("serve-dir", servedir.Command()))
+}
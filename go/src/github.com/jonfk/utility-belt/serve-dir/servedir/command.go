@@ -0,0 +1,80 @@
+package servedir
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/jonfk/utility-belt/internal/qr"
+	"github.com/urfave/cli"
+)
+
+// Command serves a directory, run standalone as serve-dir or as ub serve dir.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "dir",
+		Usage:     "Serves a directory over HTTP with listings and optional uploads",
+		ArgsUsage: "[DIR]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "addr,a",
+				Usage: "Address to listen on",
+				Value: ":8000",
+			},
+			cli.BoolFlag{
+				Name:  "upload,u",
+				Usage: "Accept multipart POST uploads to directories and PUT to files",
+			},
+			cli.Int64Flag{
+				Name:  "max-upload",
+				Usage: "Largest accepted upload in `BYTES`, 0 is unlimited",
+			},
+			cli.StringFlag{
+				Name:  "auth",
+				Usage: "Require basic auth as `USER:PASS`",
+			},
+			cli.BoolFlag{
+				Name:  "no-qr",
+				Usage: "Don't print the QR code of the LAN url",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			root := c.Args().First()
+			if root == "" {
+				root = "."
+			}
+			cfg, err := config.Load("serve-dir")
+			if err != nil {
+				return err
+			}
+			auth, err := cfg.Secret(c, "auth")
+			if err != nil {
+				return err
+			}
+
+			var handler http.Handler = NewServer(root, c.Bool("upload"), c.Int64("max-upload"))
+			if auth != "" {
+				parts := strings.SplitN(auth, ":", 2)
+				if len(parts) != 2 || parts[0] == "" {
+					return fmt.Errorf("Invalid --auth, expected user:pass")
+				}
+				handler = BasicAuth(parts[0], parts[1], handler)
+			}
+
+			url, err := LANURL(c.String("addr"))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("serving %s on %s\n", root, url)
+			if !c.Bool("no-qr") {
+				code, err := qr.Encode(url)
+				if err != nil {
+					return err
+				}
+				fmt.Print(code.Terminal())
+			}
+			return http.ListenAndServe(c.String("addr"), handler)
+		},
+	}
+}
@@ -0,0 +1,255 @@
+package servedir
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Server serves the files under Root, accepting uploads when Upload is set.
+type Server struct {
+	Root   string
+	Upload bool
+	// MaxUpload bounds the size of a request body in bytes, 0 is unlimited
+	MaxUpload int64
+
+	files http.Handler
+}
+
+func NewServer(root string, upload bool, maxUpload int64) *Server {
+	return &Server{Root: root, Upload: upload, MaxUpload: maxUpload, files: http.FileServer(http.Dir(root))}
+}
+
+// localPath maps the url path to a file under Root, never outside of it.
+func (s *Server) localPath(urlPath string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(path.Clean("/"+urlPath)))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+		info, err := os.Stat(s.localPath(r.URL.Path))
+		if err == nil && info.IsDir() && strings.HasSuffix(r.URL.Path, "/") {
+			s.listing(w, r)
+			return
+		}
+		s.files.ServeHTTP(w, r)
+	case "POST", "PUT":
+		if !s.Upload {
+			http.Error(w, "Uploads are disabled, restart with --upload", http.StatusMethodNotAllowed)
+			return
+		}
+		if s.MaxUpload > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.MaxUpload)
+		}
+		if r.Method == "PUT" {
+			s.put(w, r)
+		} else {
+			s.post(w, r)
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+var listingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head><meta name="viewport" content="width=device-width, initial-scale=1"><title>{{.Path}}</title></head>
+<body>
+<h1>{{.Path}}</h1>
+{{if .Upload}}<form method="post" enctype="multipart/form-data">
+<input type="file" name="file" multiple> <input type="submit" value="Upload">
+</form>{{end}}
+<ul>
+{{if ne .Path "/"}}<li><a href="../">../</a></li>{{end}}
+{{range .Entries}}<li><a href="{{.Href}}">{{.Name}}</a> {{.Size}}</li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+type entry struct {
+	Name string
+	Href string
+	Size string
+}
+
+func (s *Server) listing(w http.ResponseWriter, r *http.Request) {
+	infos, err := ioutil.ReadDir(s.localPath(r.URL.Path))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].IsDir() != infos[j].IsDir() {
+			return infos[i].IsDir()
+		}
+		return infos[i].Name() < infos[j].Name()
+	})
+	entries := []entry{}
+	for _, info := range infos {
+		e := entry{Name: info.Name(), Size: formatSize(info.Size())}
+		if info.IsDir() {
+			e.Name, e.Size = e.Name+"/", ""
+		}
+		// a relative path so names with a colon aren't read as a scheme
+		e.Href = (&url.URL{Path: "./" + e.Name}).String()
+		entries = append(entries, e)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	listingTemplate.Execute(w, struct {
+		Path    string
+		Upload  bool
+		Entries []entry
+	}{r.URL.Path, s.Upload, entries})
+}
+
+// post saves the files of a multipart form in the directory of the url,
+// refusing to overwrite existing files.
+func (s *Server) post(w http.ResponseWriter, r *http.Request) {
+	dir := s.localPath(r.URL.Path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		http.Error(w, "Upload to a directory", http.StatusNotFound)
+		return
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved := []string{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		name := filepath.Base(part.FileName())
+		if part.FileName() == "" || name == "." || name == string(filepath.Separator) {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			http.Error(w, fmt.Sprintf("%s already exists", name), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := writeFile(f, part); err != nil {
+			os.Remove(f.Name())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		saved = append(saved, name)
+		fmt.Printf("uploaded %s\n", f.Name())
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+		return
+	}
+	fmt.Fprintf(w, "uploaded %s\n", strings.Join(saved, ", "))
+}
+
+// put writes the body to the file of the url, replacing it.
+func (s *Server) put(w http.ResponseWriter, r *http.Request) {
+	name := s.localPath(r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		http.Error(w, "PUT to a file path", http.StatusBadRequest)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".upload-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := writeFile(tmp, r.Body); err != nil {
+		os.Remove(tmp.Name())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Printf("uploaded %s\n", name)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func writeFile(f *os.File, r io.Reader) error {
+	_, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// BasicAuth rejects the requests to next without the user and password.
+func BasicAuth(user, password string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		userOk := subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1
+		passwordOk := subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+		if !ok || !userOk || !passwordOk {
+			w.Header().Set("WWW-Authenticate", `Basic realm="serve-dir"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LANURL returns the url of addr on the first private IPv4 address of the
+// machine, for phones on the same network.
+func LANURL(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return "", err
+		}
+		host = "localhost"
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.To4() != nil && !ipnet.IP.IsLoopback() {
+				host = ipnet.IP.String()
+				if ipnet.IP.IsPrivate() {
+					break
+				}
+			}
+		}
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/", nil
+}
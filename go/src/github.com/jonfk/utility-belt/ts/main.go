@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/ts/ts"
+)
+
+func main() {
+	belt.Run(belt.NewApp("ts", ts.Command()))
+}
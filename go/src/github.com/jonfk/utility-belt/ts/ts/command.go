@@ -0,0 +1,124 @@
+package ts
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// Command converts timestamps, run standalone as ts or as ub ts.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "ts",
+		Usage:     "Converts between unix timestamps, dates and relative times",
+		ArgsUsage: "[TIME...]",
+		Description: "Without arguments the times are read one per line from stdin. Numbers are\n" +
+			"   converted to dates and dates to unix seconds unless --to is given. The times\n" +
+			"   that can't be converted are reported on stderr and the others still printed.",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "to,t",
+				Usage: "Output `FORMAT`: " + strings.Join(Formats, ", "),
+			},
+			cli.StringFlag{
+				Name:  "tz",
+				Usage: "Time zone of the dates printed and of the ones read without a zone, e.g. America/Toronto",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			loc := time.Local
+			if c.String("tz") != "" {
+				var err error
+				if loc, err = time.LoadLocation(c.String("tz")); err != nil {
+					return err
+				}
+			}
+			now := time.Now()
+
+			inputs := c.Args()
+			if len(inputs) == 1 && inputs[0] != "-" {
+				t, _, err := Parse(inputs[0], now, loc)
+				if err != nil {
+					return err
+				}
+				if c.String("to") != "" {
+					out, err := Format(t, c.String("to"), now, loc)
+					if err != nil {
+						return err
+					}
+					fmt.Println(out)
+					return nil
+				}
+				for _, format := range Formats {
+					out, _ := Format(t, format, now, loc)
+					fmt.Printf("%-9s %s\n", format, out)
+				}
+				return nil
+			}
+
+			convert := func(input string) error {
+				t, kind, err := Parse(input, now, loc)
+				if err != nil {
+					return err
+				}
+				to := c.String("to")
+				if to == "" {
+					to = UnixFormat
+					if kind == UnixFormat || kind == MillisFormat {
+						to = RFC3339Format
+					}
+				}
+				out, err := Format(t, to, now, loc)
+				if err != nil {
+					return err
+				}
+				fmt.Println(out)
+				return nil
+			}
+			// an unknown --to fails every line, report it once
+			if c.String("to") != "" {
+				if _, err := Format(now, c.String("to"), now, loc); err != nil {
+					return err
+				}
+			}
+			// the times that can't be converted are reported on stderr and
+			// printed as empty lines, keeping the output aligned with the input
+			failed, total := 0, 0
+			if len(inputs) > 1 {
+				for _, input := range inputs {
+					total++
+					if err := convert(input); err != nil {
+						fmt.Fprintf(os.Stderr, "%s: %v\n", input, err)
+						fmt.Println()
+						failed++
+					}
+				}
+			} else {
+				scanner := bufio.NewScanner(os.Stdin)
+				for line := 1; scanner.Scan(); line++ {
+					if strings.TrimSpace(scanner.Text()) == "" {
+						fmt.Println()
+						continue
+					}
+					total++
+					if err := convert(scanner.Text()); err != nil {
+						fmt.Fprintf(os.Stderr, "line %d: %v\n", line, err)
+						fmt.Println()
+						failed++
+					}
+				}
+				if err := scanner.Err(); err != nil {
+					return err
+				}
+			}
+			if failed > 0 {
+				return cli.NewExitError(fmt.Sprintf("%d of %d times could not be converted", failed, total), 1)
+			}
+			return nil
+		},
+	}
+}
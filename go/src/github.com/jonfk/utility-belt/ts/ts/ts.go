@@ -0,0 +1,210 @@
+package ts
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats of the conversions.
+const (
+	UnixFormat     = "unix"
+	MillisFormat   = "millis"
+	RFC3339Format  = "rfc3339"
+	RelativeFormat = "relative"
+)
+
+var Formats = []string{UnixFormat, MillisFormat, RFC3339Format, RelativeFormat}
+
+// layouts are tried in order for inputs which aren't numbers or relative,
+// the ones without a zone are read in the location passed to Parse.
+var layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+}
+
+// unitPattern lists the units of the relative times with their plurals, so
+// that a unit such as ms isn't read as m followed by a plural s.
+const unitPattern = `(ms|msecs?|milliseconds?|s|secs?|seconds?|m|mins?|minutes?|h|hrs?|hours?|d|days?|w|weeks?|mo|mos|months?|y|yrs?|years?)`
+
+var (
+	agoPattern = regexp.MustCompile(`^(\d+)\s*` + unitPattern + `\s+ago$`)
+	inPattern  = regexp.MustCompile(`^in\s+(\d+)\s*` + unitPattern + `$`)
+)
+
+var units = map[string]time.Duration{
+	"ms": time.Millisecond, "msec": time.Millisecond, "millisecond": time.Millisecond,
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hour": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour,
+}
+
+// Parse detects the format of input: unix seconds, millis, micros or nanos
+// by their number of digits, dates such as RFC3339 or relative times such as
+// "3 hours ago", "in 2 days", "-90m" or "yesterday". It returns the time and
+// the detected format.
+func Parse(input string, now time.Time, loc *time.Location) (time.Time, string, error) {
+	input = strings.TrimSpace(input)
+	lower := strings.ToLower(input)
+
+	if t, ok := parseUnix(input); ok {
+		return t, unixKind(input), nil
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, input, loc); err == nil {
+			return t, RFC3339Format, nil
+		}
+	}
+
+	switch lower {
+	case "now":
+		return now, RelativeFormat, nil
+	case "today":
+		y, m, d := now.In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc), RelativeFormat, nil
+	case "yesterday":
+		y, m, d := now.In(loc).Date()
+		return time.Date(y, m, d-1, 0, 0, 0, 0, loc), RelativeFormat, nil
+	case "tomorrow":
+		y, m, d := now.In(loc).Date()
+		return time.Date(y, m, d+1, 0, 0, 0, 0, loc), RelativeFormat, nil
+	}
+	if match := agoPattern.FindStringSubmatch(lower); match != nil {
+		if t, ok := relative(now, match[1], match[2], -1); ok {
+			return t, RelativeFormat, nil
+		}
+	}
+	if match := inPattern.FindStringSubmatch(lower); match != nil {
+		if t, ok := relative(now, match[1], match[2], 1); ok {
+			return t, RelativeFormat, nil
+		}
+	}
+	if strings.HasPrefix(input, "+") || strings.HasPrefix(input, "-") {
+		if d, err := time.ParseDuration(input); err == nil {
+			return now.Add(d), RelativeFormat, nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("Unrecognized time %q, expected unix seconds or millis, a date such as 2024-01-31T09:00:00Z or a relative time such as \"3 hours ago\"", input)
+}
+
+func relative(now time.Time, amount, unit string, sign int) (time.Time, bool) {
+	n, err := strconv.Atoi(amount)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if len(unit) > 2 || unit == "mos" {
+		// the plurals of the units other than ms and s
+		unit = strings.TrimSuffix(unit, "s")
+	}
+	switch unit {
+	case "mo", "month":
+		return now.AddDate(0, sign*n, 0), true
+	case "y", "yr", "year":
+		return now.AddDate(sign*n, 0, 0), true
+	}
+	d, ok := units[unit]
+	return now.Add(time.Duration(sign*n) * d), ok
+}
+
+func unixDigits(input string) int {
+	digits := strings.TrimPrefix(input, "-")
+	if i := strings.Index(digits, "."); i >= 0 {
+		digits = digits[:i]
+	}
+	return len(digits)
+}
+
+func unixKind(input string) string {
+	if unixDigits(input) >= 12 {
+		return MillisFormat
+	}
+	return UnixFormat
+}
+
+// parseUnix reads numbers as seconds up to 11 digits, which reaches the year
+// 5138, then millis, micros and nanos.
+func parseUnix(input string) (time.Time, bool) {
+	f, err := strconv.ParseFloat(input, 64)
+	if err != nil || strings.ContainsAny(input, "eEnN") {
+		return time.Time{}, false
+	}
+	unit := time.Nanosecond
+	switch digits := unixDigits(input); {
+	case digits <= 11:
+		unit = time.Second
+	case digits <= 14:
+		unit = time.Millisecond
+	case digits <= 17:
+		unit = time.Microsecond
+	}
+	// integers are converted exactly, floats lose precision past micros
+	if n, err := strconv.ParseInt(input, 10, 64); err == nil {
+		perSecond := int64(time.Second / unit)
+		return time.Unix(n/perSecond, n%perSecond*int64(unit)), true
+	}
+	sec, frac := math.Modf(f * float64(unit) / float64(time.Second))
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
+
+// Format formats t as one of Formats in loc.
+func Format(t time.Time, format string, now time.Time, loc *time.Location) (string, error) {
+	switch format {
+	case UnixFormat:
+		return strconv.FormatInt(t.Unix(), 10), nil
+	case MillisFormat:
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10), nil
+	case RFC3339Format:
+		return t.In(loc).Format(time.RFC3339Nano), nil
+	case RelativeFormat:
+		return Relative(t, now), nil
+	default:
+		return "", fmt.Errorf("Unknown format %s, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Relative describes t from now in its largest unit, e.g. "3 hours ago" or
+// "in 2 days".
+func Relative(t, now time.Time) string {
+	d := t.Sub(now)
+	future := d > 0
+	if d < 0 {
+		d = -d
+	}
+	var n int
+	var unit string
+	switch {
+	case d < time.Second:
+		return "now"
+	case d < time.Minute:
+		n, unit = int(d/time.Second), "second"
+	case d < time.Hour:
+		n, unit = int(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int(d/(365*24*time.Hour)), "year"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	if future {
+		return fmt.Sprintf("in %d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %s ago", n, unit)
+}
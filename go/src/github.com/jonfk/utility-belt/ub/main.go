@@ -0,0 +1,82 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/basic-auth/basicauth"
+	"github.com/jonfk/utility-belt/certinfo/certinfo"
+	"github.com/jonfk/utility-belt/clip/clip"
+	"github.com/jonfk/utility-belt/cronwhen/cronwhen"
+	"github.com/jonfk/utility-belt/day-of-year/dayofyear"
+	"github.com/jonfk/utility-belt/dupes/dupes"
+	"github.com/jonfk/utility-belt/dur/dur"
+	"github.com/jonfk/utility-belt/encode/encode"
+	"github.com/jonfk/utility-belt/envtool/envtool"
+	"github.com/jonfk/utility-belt/github-analytics/githubanalytics"
+	"github.com/jonfk/utility-belt/hash/hash"
+	"github.com/jonfk/utility-belt/hexd/hexd"
+	"github.com/jonfk/utility-belt/httpprobe/httpprobe"
+	"github.com/jonfk/utility-belt/idgen/idgen"
+	"github.com/jonfk/utility-belt/inspection-server/inspectionserver"
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/internal/config"
+	"github.com/jonfk/utility-belt/mdtoc/mdtoc"
+	"github.com/jonfk/utility-belt/mockapi/mockapi"
+	"github.com/jonfk/utility-belt/netcheck/netcheck"
+	"github.com/jonfk/utility-belt/pass-gen/passgen"
+	"github.com/jonfk/utility-belt/ports/ports"
+	"github.com/jonfk/utility-belt/prettify-json/prettifyjson"
+	"github.com/jonfk/utility-belt/qr/qrcode"
+	"github.com/jonfk/utility-belt/serve-dir/servedir"
+	"github.com/jonfk/utility-belt/ts/ts"
+	"github.com/jonfk/utility-belt/watchdo/watchdo"
+	"github.com/urfave/cli"
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "ub"
+	app.Usage = "Utility belt of little programs to ease life"
+	app.Version = belt.BuildInfo()
+	app.EnableBashCompletion = true
+	app.Commands = []cli.Command{
+		{
+			Name:        "json",
+			Usage:       "Work with json documents",
+			Subcommands: []cli.Command{prettifyjson.Command()},
+		},
+		{
+			Name:        "pass",
+			Usage:       "Work with passwords",
+			Subcommands: []cli.Command{passgen.Command()},
+		},
+		{
+			Name:        "serve",
+			Usage:       "Run local servers",
+			Subcommands: []cli.Command{inspectionserver.Command(), servedir.Command(), mockapi.Command()},
+		},
+		basicauth.Command(),
+		dayofyear.Command(),
+		githubanalytics.Command(),
+		idgen.Command(),
+		encode.Command(),
+		httpprobe.Command(),
+		hash.Command(),
+		certinfo.Command(),
+		envtool.Command(),
+		cronwhen.Command(),
+		dupes.Command(),
+		ts.Command(),
+		ports.Command(),
+		mdtoc.Command(),
+		hexd.Command(),
+		clip.Command(),
+		qrcode.Command(),
+		watchdo.Command(),
+		netcheck.Command(),
+		dur.Command(),
+		config.Command(),
+		belt.CompletionCommand(app.Name),
+		belt.SelfUpdateCommand(app.Name),
+	}
+
+	belt.Run(app)
+}
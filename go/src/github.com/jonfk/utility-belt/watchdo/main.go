@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/jonfk/utility-belt/internal/belt"
+	"github.com/jonfk/utility-belt/watchdo/watchdo"
+)
+
+func main() {
+	belt.Run(belt.NewApp("watchdo", watchdo.Command()))
+}
@@ -0,0 +1,152 @@
+package watchdo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// ChangedEnv holds the files changed since the last run, one per line.
+const ChangedEnv = "WATCHDO_CHANGED"
+
+// StopGrace is the delay between interrupting and killing the command when
+// --restart restarts it.
+const StopGrace = 2 * time.Second
+
+// Command runs a command whenever the watched files change, run standalone as
+// watchdo or as ub watchdo.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "watchdo",
+		Usage:     "Runs a command when files change, like entr or watchexec",
+		ArgsUsage: "[--] COMMAND [ARGS...]",
+		Description: "A single argument runs in sh -c, e.g. watchdo -i '*.json' 'ub prettify-json in.json > out.json'.\n" +
+			"   The changed files are passed in " + ChangedEnv + ", one per line.",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "path,p",
+				Usage: "Watch `PATH`, recursively for directories, the working directory by default",
+			},
+			cli.StringSliceFlag{
+				Name:  "ignore,i",
+				Usage: "Ignore the files and directories whose name or relative path matches `GLOB`, in addition to " + strings.Join(DefaultIgnore, " "),
+			},
+			cli.DurationFlag{
+				Name:  "debounce,d",
+				Usage: "Wait for the changes to settle for `DURATION` before running",
+				Value: 200 * time.Millisecond,
+			},
+			cli.BoolFlag{
+				Name:  "clear,c",
+				Usage: "Clear the screen before running",
+			},
+			cli.BoolFlag{
+				Name:  "restart,r",
+				Usage: "Stop the command still running on change instead of waiting for it, for servers",
+			},
+			cli.BoolFlag{
+				Name:  "postpone",
+				Usage: "Wait for a change before the first run",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("No command to run")
+			}
+			args := []string(c.Args())
+			if len(args) == 1 {
+				args = []string{"sh", "-c", args[0]}
+			}
+			paths := c.StringSlice("path")
+			if len(paths) == 0 {
+				paths = []string{"."}
+			}
+			watcher, err := NewWatcher(paths, c.StringSlice("ignore"), c.Duration("debounce"))
+			if err != nil {
+				return err
+			}
+			defer watcher.Close()
+
+			runner := &Runner{Args: args, Clear: c.Bool("clear")}
+			if !c.Bool("postpone") {
+				runner.Start(nil)
+			}
+			for changed := range watcher.Changes() {
+				if c.Bool("restart") {
+					runner.Stop(StopGrace)
+				} else {
+					runner.Wait()
+				}
+				runner.Start(changed)
+			}
+			return nil
+		},
+	}
+}
+
+// Runner runs a command at most once at a time.
+type Runner struct {
+	Args  []string
+	Clear bool
+
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+// Start runs the command in the background with the changed files.
+func (r *Runner) Start(changed []string) {
+	if r.Clear {
+		fmt.Print("\033[H\033[2J\033[3J")
+	}
+	cmd := exec.Command(r.Args[0], r.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), ChangedEnv+"="+strings.Join(changed, "\n"))
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "[watchdo] %v\n", err)
+		return
+	}
+	r.cmd, r.done = cmd, make(chan struct{})
+	go func(done chan struct{}) {
+		err := cmd.Wait()
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[watchdo] %v after %s\n", err, elapsed)
+		} else {
+			fmt.Fprintf(os.Stderr, "[watchdo] done in %s\n", elapsed)
+		}
+		close(done)
+	}(r.done)
+}
+
+// Wait waits for the running command.
+func (r *Runner) Wait() {
+	if r.done != nil {
+		<-r.done
+	}
+}
+
+// Stop interrupts the running command and kills it after grace.
+func (r *Runner) Stop(grace time.Duration) {
+	if r.done == nil {
+		return
+	}
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+	if err := r.cmd.Process.Signal(os.Interrupt); err != nil {
+		r.cmd.Process.Kill()
+	}
+	select {
+	case <-r.done:
+	case <-time.After(grace):
+		r.cmd.Process.Kill()
+		<-r.done
+	}
+}
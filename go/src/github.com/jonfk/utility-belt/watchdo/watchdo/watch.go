@@ -0,0 +1,175 @@
+package watchdo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultIgnore are the version control directories and the temporary files
+// of editors, always ignored.
+var DefaultIgnore = []string{".git", ".hg", ".svn", "*.swp", "*.swx", "*~", ".#*", "4913"}
+
+// Watcher watches directories recursively and files, batching their
+// changes until no change happened for Debounce.
+type Watcher struct {
+	Ignore   []string
+	Debounce time.Duration
+
+	fs *fsnotify.Watcher
+	// dirs are watched recursively, files alone among their directory, both
+	// are absolute
+	dirs  []string
+	files map[string]bool
+	wd    string
+}
+
+// NewWatcher watches paths, skipping those matching the ignore globs.
+func NewWatcher(paths, ignore []string, debounce time.Duration) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		fs.Close()
+		return nil, err
+	}
+	w := &Watcher{Ignore: append(DefaultIgnore, ignore...), Debounce: debounce, fs: fs, files: map[string]bool{}, wd: wd}
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(wd, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			fs.Close()
+			return nil, err
+		}
+		if info.IsDir() {
+			w.dirs = append(w.dirs, path)
+			err = w.addTree(path)
+		} else {
+			w.files[path] = true
+			err = fs.Add(filepath.Dir(path))
+		}
+		if err != nil {
+			fs.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Ignored reports whether a glob matches the name of file or its path
+// relative to the working directory.
+func (w *Watcher) Ignored(file string) bool {
+	for _, glob := range w.Ignore {
+		if ok, _ := filepath.Match(glob, filepath.Base(file)); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(filepath.Clean(glob), w.relative(file)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// relative returns file relative to the working directory when under it.
+func (w *Watcher) relative(file string) string {
+	if rel, err := filepath.Rel(w.wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return file
+}
+
+// addTree watches dir and its directories which aren't ignored.
+func (w *Watcher) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// the directory may be gone already
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != dir && w.Ignored(path) {
+			return filepath.SkipDir
+		}
+		return w.fs.Add(path)
+	})
+}
+
+// watched reports whether file is under a watched directory or a watched
+// file itself rather than one of its siblings.
+func (w *Watcher) watched(file string) bool {
+	if w.files[file] {
+		return true
+	}
+	for _, dir := range w.dirs {
+		if file == dir || strings.HasPrefix(file, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Changes returns the batches of changed files, sorted and relative to the
+// working directory when under it. A batch is held
+// until the previous one was received, merging the changes in between.
+func (w *Watcher) Changes() <-chan []string {
+	out := make(chan []string)
+	go func() {
+		defer close(out)
+		pending := map[string]bool{}
+		var quiet <-chan time.Time
+		var send chan<- []string
+		for {
+			select {
+			case event, ok := <-w.fs.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod || w.Ignored(event.Name) || !w.watched(event.Name) {
+					continue
+				}
+				if event.Op&fsnotify.Create != 0 {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := w.addTree(event.Name); err != nil {
+							fmt.Fprintf(os.Stderr, "Could not watch %s: %v\n", event.Name, err)
+						}
+					}
+				}
+				pending[w.relative(event.Name)] = true
+				quiet, send = time.After(w.Debounce), nil
+			case err, ok := <-w.fs.Errors:
+				if !ok {
+					return
+				}
+				fmt.Fprintln(os.Stderr, err)
+			case <-quiet:
+				quiet, send = nil, out
+			case send <- sortedKeys(pending):
+				pending, send = map[string]bool{}, nil
+			}
+		}
+	}()
+	return out
+}
+
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}